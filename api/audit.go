@@ -0,0 +1,252 @@
+package api
+
+import (
+	"catalyst/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// changedByHeader identifies who made a config change
+const changedByHeader = "X-Changed-By"
+
+// defaultChangedBy is recorded when the request has no X-Changed-By header
+const defaultChangedBy = "api"
+
+// defaultAuditLimit is the number of audit entries returned when no limit is given
+const defaultAuditLimit = 20
+
+// ConfigAuditEntry is a config change recorded in the config_audit table
+type ConfigAuditEntry struct {
+	ID            int64     `json:"id"`
+	ServerName    string    `json:"server_name"`
+	ChangedBy     string    `json:"changed_by"`
+	ChangeSummary string    `json:"change_summary"`
+	PreviousYAML  string    `json:"previous_yaml"`
+	NewYAML       string    `json:"new_yaml"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// GetConfigAudit handles GET /api/mock/config/audit - lists the latest config changes of a server
+func (h *APIHandler) GetConfigAudit(c *gin.Context) {
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	if serverName == "" {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidServer, http.StatusBadRequest, "server_name parameter is required"))
+		return
+	}
+
+	limit := defaultAuditLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid limit %q", raw), http.StatusBadRequest, "limit must be a positive integer"))
+			return
+		}
+		limit = parsed
+	}
+
+	if h.batchManager == nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	entries, err := NewDatabaseService(h.batchManager).GetConfigAudit(serverName, limit)
+	if err != nil {
+		log.Printf("ERROR: Failed to retrieve config audit for server %s: %v", serverName, err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving config audit"))
+		return
+	}
+
+	log.Printf("SUCCESS: Retrieved %d config audit entries for server: %s", len(entries), serverName)
+	c.JSON(http.StatusOK, NewSuccessResponse(entries))
+}
+
+// DeleteConfigAudit handles DELETE /api/mock/config/audit - removes entries older than older_than_days
+func (h *APIHandler) DeleteConfigAudit(c *gin.Context) {
+	days, err := strconv.Atoi(c.Query("older_than_days"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid older_than_days %q", c.Query("older_than_days")), http.StatusBadRequest, "older_than_days must be a non-negative integer"))
+		return
+	}
+
+	if h.batchManager == nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	deleted, err := NewDatabaseService(h.batchManager).DeleteConfigAuditOlderThan(days)
+	if err != nil {
+		log.Printf("ERROR: Failed to delete config audit entries: %v", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error deleting config audit"))
+		return
+	}
+
+	log.Printf("SUCCESS: Deleted %d config audit entries older than %d days", deleted, days)
+	c.JSON(http.StatusOK, NewSuccessResponse(map[string]interface{}{"deleted": deleted}))
+}
+
+// recordConfigAudit stores a config change. Failures are logged and never fail the update.
+func (h *APIHandler) recordConfigAudit(c *gin.Context, serverName string, previousYAML, newYAML []byte) {
+	if h.batchManager == nil {
+		return
+	}
+
+	changedBy := strings.TrimSpace(c.GetHeader(changedByHeader))
+	if changedBy == "" {
+		changedBy = defaultChangedBy
+	}
+
+	entry := ConfigAuditEntry{
+		ServerName:    serverName,
+		ChangedBy:     changedBy,
+		ChangeSummary: summarizeLocationChanges(previousYAML, newYAML),
+		PreviousYAML:  string(previousYAML),
+		NewYAML:       string(newYAML),
+	}
+	if err := NewDatabaseService(h.batchManager).InsertConfigAudit(entry); err != nil {
+		log.Printf("WARNING: Failed to record config audit for server %s: %v", serverName, err)
+	}
+}
+
+// ReadConfigYAML returns the raw YAML on disk for a server
+func (cs *ConfigService) ReadConfigYAML(serverName string) ([]byte, error) {
+	configFile, found := cs.findConfigFile(serverName)
+	if !found {
+		return nil, ErrConfigNotFound
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return data, nil
+}
+
+// summarizeLocationChanges lists the location paths added, removed and modified between two
+// YAML configs, e.g. "added: POST /api/pay; modified: GET /api/users"
+func summarizeLocationChanges(previousYAML, newYAML []byte) string {
+	previous := locationsByKey(previousYAML)
+	next := locationsByKey(newYAML)
+
+	var added, removed, modified []string
+	for key, location := range next {
+		old, ok := previous[key]
+		if !ok {
+			added = append(added, key)
+		} else if !reflect.DeepEqual(old, location) {
+			modified = append(modified, key)
+		}
+	}
+	for key := range previous {
+		if _, ok := next[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	var parts []string
+	for _, group := range []struct {
+		label string
+		keys  []string
+	}{{"added", added}, {"removed", removed}, {"modified", modified}} {
+		if len(group.keys) == 0 {
+			continue
+		}
+		sort.Strings(group.keys)
+		parts = append(parts, group.label+": "+strings.Join(group.keys, ", "))
+	}
+
+	if len(parts) == 0 {
+		return "no location changes"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// locationsByKey indexes the locations of every HTTP server by "METHOD path"
+func locationsByKey(data []byte) map[string]models.Location {
+	locations := make(map[string]models.Location)
+
+	var config models.MockServer
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return locations
+	}
+
+	for _, server := range config.Http.Servers {
+		for _, location := range server.Location {
+			locations[strings.ToUpper(location.Method)+" "+location.Path] = location
+		}
+	}
+	return locations
+}
+
+// InsertConfigAudit stores a config audit entry
+func (ds *DatabaseService) InsertConfigAudit(entry ConfigAuditEntry) error {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	_, err := ds.batchManager.GetDB().Exec(
+		`INSERT INTO config_audit (server_name, changed_by, change_summary, previous_yaml, new_yaml) VALUES (?, ?, ?, ?, ?)`,
+		entry.ServerName, entry.ChangedBy, entry.ChangeSummary, entry.PreviousYAML, entry.NewYAML,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert config audit: %w", err)
+	}
+	return nil
+}
+
+// GetConfigAudit retrieves the latest audit entries of a server, newest first
+func (ds *DatabaseService) GetConfigAudit(serverName string, limit int) ([]ConfigAuditEntry, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	rows, err := ds.batchManager.GetDB().Query(
+		`SELECT id, server_name, changed_by, change_summary, previous_yaml, new_yaml, timestamp
+		 FROM config_audit WHERE server_name = ? ORDER BY timestamp DESC, id DESC LIMIT ?`,
+		serverName, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config audit: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ConfigAuditEntry{}
+	for rows.Next() {
+		var entry ConfigAuditEntry
+		if err := rows.Scan(&entry.ID, &entry.ServerName, &entry.ChangedBy, &entry.ChangeSummary, &entry.PreviousYAML, &entry.NewYAML, &entry.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan config audit row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteConfigAuditOlderThan removes the audit entries older than the given number of days
+func (ds *DatabaseService) DeleteConfigAuditOlderThan(days int) (int64, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+
+	result, err := ds.batchManager.GetDB().Exec(
+		`DELETE FROM config_audit WHERE timestamp < datetime('now', ?)`,
+		fmt.Sprintf("-%d days", days),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete config audit: %w", err)
+	}
+	return result.RowsAffected()
+}
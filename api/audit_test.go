@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"catalyst/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConfigAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configDir := t.TempDir()
+	initial := `http:
+  servers:
+    - listen: 9200
+      name: foo
+      version: 1.0.0
+      location:
+        - path: /api/a
+          method: GET
+          response: '{"a":1}'
+          status_code: 200
+        - path: /api/b
+          method: GET
+          response: '{"b":1}'
+          status_code: 200
+`
+	if err := os.WriteFile(filepath.Join(configDir, "foo.yaml"), []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "audit.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: configDir, RestartChan: make(chan string, 10)})
+
+	update := func(changedBy string) {
+		body := `{"http":{"servers":[{"listen":9200,"name":"foo","version":"1.0.0","location":[
+			{"path":"/api/a","method":"GET","response":"{\"a\":2}","status_code":200},
+			{"path":"/api/c","method":"POST","response":"{}","status_code":201}]}]}}`
+		req := httptest.NewRequest("PUT", "/api/mock/config?server_name=foo", bytes.NewBufferString(body))
+		if changedBy != "" {
+			req.Header.Set("X-Changed-By", changedBy)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected config update to return 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	audit := func() []ConfigAuditEntry {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/config/audit?server_name=foo&limit=20", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected audit to return 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []ConfigAuditEntry `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode audit response: %v", err)
+		}
+		return response.Data
+	}
+
+	update("alice")
+	entries := audit()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ChangedBy != "alice" {
+		t.Errorf("Expected changed_by alice, got %q", entry.ChangedBy)
+	}
+	if expected := "added: POST /api/c; removed: GET /api/b; modified: GET /api/a"; entry.ChangeSummary != expected {
+		t.Errorf("Expected change summary %q, got %q", expected, entry.ChangeSummary)
+	}
+	if entry.PreviousYAML != initial {
+		t.Errorf("Expected the previous YAML to be recorded, got %q", entry.PreviousYAML)
+	}
+	if entry.NewYAML == "" || entry.NewYAML == initial {
+		t.Error("Expected the new YAML to be recorded")
+	}
+
+	update("")
+	entries = audit()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+	if entries[0].ChangedBy != defaultChangedBy {
+		t.Errorf("Expected changed_by %q without header, got %q", defaultChangedBy, entries[0].ChangedBy)
+	}
+	if entries[0].ChangeSummary != "no location changes" {
+		t.Errorf("Expected no location changes, got %q", entries[0].ChangeSummary)
+	}
+
+	// Age the first entry past the retention window
+	if _, err := bm.GetDB().Exec(`UPDATE config_audit SET timestamp = datetime('now', '-40 days') WHERE id = ?`, entry.ID); err != nil {
+		t.Fatalf("Failed to age audit entry: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/mock/config/audit?older_than_days=30", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected delete to return 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if entries = audit(); len(entries) != 1 {
+		t.Errorf("Expected 1 audit entry after cleanup, got %d", len(entries))
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/mock/config/audit", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without older_than_days, got %d", w.Code)
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authentication modes supported by the management API
+const (
+	AuthModeAPIKey = "api_key"
+	AuthModeJWT    = "jwt"
+)
+
+// AuthConfig configures authentication for the management API
+type AuthConfig struct {
+	Mode      string // api_key (default) or jwt
+	APIKey    string // Expected key when Mode is api_key
+	JWTSecret string // HMAC secret used to verify HS256 tokens when Mode is jwt
+}
+
+// AuthMiddleware returns the authentication middleware for the configured mode.
+// When no key or secret is configured authentication is disabled and a warning is logged.
+func AuthMiddleware(cfg AuthConfig) gin.HandlerFunc {
+	if strings.EqualFold(cfg.Mode, AuthModeJWT) {
+		if cfg.JWTSecret == "" {
+			log.Printf("WARNING: API auth mode is jwt but no jwt_secret is configured, management API is unauthenticated")
+			return passThrough()
+		}
+		return JWTMiddleware(cfg.JWTSecret)
+	}
+
+	if cfg.APIKey == "" {
+		log.Printf("WARNING: No API key configured, management API is unauthenticated")
+		return passThrough()
+	}
+	return APIKeyMiddleware(cfg.APIKey)
+}
+
+// APIKeyMiddleware checks the `Authorization: Bearer <key>` or `X-API-Key: <key>` headers
+func APIKeyMiddleware(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-API-Key")
+		if provided == "" {
+			provided = bearerToken(c)
+		}
+
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			abortUnauthorized(c, "Invalid or missing API key")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// BearerTokenMiddleware checks the `Authorization: Bearer <token>` header against a static token
+func BearerTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := bearerToken(c)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			abortUnauthorized(c, "Invalid or missing bearer token")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// JWTMiddleware checks for a valid HS256 token in the `Authorization: Bearer <token>` header
+func JWTMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			abortUnauthorized(c, "Missing bearer token")
+			return
+		}
+
+		if err := validateJWT(token, secret, time.Now()); err != nil {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token from the Authorization header
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// validateJWT verifies the signature and the exp/nbf claims of an HS256 token
+func validateJWT(token, secret string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidToken
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return ErrInvalidToken
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	var claims struct {
+		Exp *int64 `json:"exp"`
+		Nbf *int64 `json:"nbf"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return ErrInvalidToken
+	}
+
+	if claims.Exp != nil && now.Unix() >= *claims.Exp {
+		return ErrTokenExpired
+	}
+	if claims.Nbf != nil && now.Unix() < *claims.Nbf {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// abortUnauthorized aborts the request with a 401 response
+func abortUnauthorized(c *gin.Context, message string) {
+	c.JSON(http.StatusUnauthorized, NewErrorResponse(ErrUnauthorized, http.StatusUnauthorized, message))
+	c.Abort()
+}
+
+// passThrough is used when authentication is disabled
+func passThrough() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "s3cret"
+
+// signJWT builds a token with the given header and claims signed with HMAC-SHA256
+func signJWT(header, claims, secret string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidateJWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`)) + "."
+
+	tests := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{"valid", signJWT(hs256, `{"sub":"admin"}`, testSecret), nil},
+		{"valid with exp and nbf", signJWT(hs256, `{"exp":1700000060,"nbf":1699999940}`, testSecret), nil},
+		{"bad signature", signJWT(hs256, `{"sub":"admin"}`, "other"), ErrInvalidToken},
+		{"alg none", none, ErrInvalidToken},
+		{"alg HS512", signJWT(`{"alg":"HS512"}`, `{"sub":"admin"}`, testSecret), ErrInvalidToken},
+		{"expired", signJWT(hs256, `{"exp":1699999999}`, testSecret), ErrTokenExpired},
+		{"not yet valid", signJWT(hs256, `{"nbf":1700000060}`, testSecret), ErrInvalidToken},
+		{"malformed", "not.a-token", ErrInvalidToken},
+		{"invalid claims", signJWT(hs256, `not json`, testSecret), ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJWT(tt.token, testSecret, now)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// serveWithAuth runs a request with the given headers through the middleware
+func serveWithAuth(middleware gin.HandlerFunc, headers map[string]string) int {
+	router := gin.New()
+	router.GET("/api/mock/data", middleware, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/api/mock/data", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{"x-api-key", map[string]string{"X-API-Key": "key"}, http.StatusOK},
+		{"bearer", map[string]string{"Authorization": "Bearer key"}, http.StatusOK},
+		{"both headers", map[string]string{"X-API-Key": "key", "Authorization": "Bearer wrong"}, http.StatusOK},
+		{"x-api-key takes precedence", map[string]string{"X-API-Key": "wrong", "Authorization": "Bearer key"}, http.StatusUnauthorized},
+		{"missing", nil, http.StatusUnauthorized},
+		{"wrong key", map[string]string{"X-API-Key": "wrong"}, http.StatusUnauthorized},
+		{"not a bearer", map[string]string{"Authorization": "Basic key"}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := serveWithAuth(APIKeyMiddleware("key"), tt.headers); code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{"valid", signJWT(hs256, `{"sub":"admin"}`, testSecret), http.StatusOK},
+		{"bad signature", signJWT(hs256, `{"sub":"admin"}`, "other"), http.StatusUnauthorized},
+		{"alg HS384", signJWT(`{"alg":"HS384"}`, `{"sub":"admin"}`, testSecret), http.StatusUnauthorized},
+		{"expired", signJWT(hs256, `{"exp":`+strconv.FormatInt(past, 10)+`}`, testSecret), http.StatusUnauthorized},
+		{"future nbf", signJWT(hs256, `{"nbf":`+strconv.FormatInt(future, 10)+`}`, testSecret), http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.token != "" {
+				headers["Authorization"] = "Bearer " + tt.token
+			}
+			if code := serveWithAuth(JWTMiddleware(testSecret), headers); code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareModes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token := signJWT(`{"alg":"HS256"}`, `{"sub":"admin"}`, testSecret)
+	tests := []struct {
+		name     string
+		config   AuthConfig
+		headers  map[string]string
+		expected int
+	}{
+		{"disabled", AuthConfig{}, nil, http.StatusOK},
+		{"jwt without secret", AuthConfig{Mode: AuthModeJWT}, nil, http.StatusOK},
+		{"api key required", AuthConfig{APIKey: "key"}, nil, http.StatusUnauthorized},
+		{"api key", AuthConfig{Mode: AuthModeAPIKey, APIKey: "key"}, map[string]string{"X-API-Key": "key"}, http.StatusOK},
+		{"jwt required", AuthConfig{Mode: AuthModeJWT, JWTSecret: testSecret}, map[string]string{"X-API-Key": "key"}, http.StatusUnauthorized},
+		{"jwt", AuthConfig{Mode: AuthModeJWT, JWTSecret: testSecret}, map[string]string{"Authorization": "Bearer " + token}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := serveWithAuth(AuthMiddleware(tt.config), tt.headers); code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
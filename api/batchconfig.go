@@ -0,0 +1,74 @@
+package api
+
+import (
+	"catalyst/database"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchConfigRequest is the body of PUT /api/mock/batch-config; omitted or zero fields keep their value
+type BatchConfigRequest struct {
+	BatchSize       int `json:"batch_size"`
+	FlushIntervalMs int `json:"flush_interval_ms"`
+	MaxWorkers      int `json:"max_workers"`
+}
+
+// BatchConfigResponse reports the batch settings of the transaction database
+type BatchConfigResponse struct {
+	BatchSize       int   `json:"batch_size"`
+	FlushIntervalMs int64 `json:"flush_interval_ms"`
+	MaxWorkers      int   `json:"max_workers"`
+}
+
+// newBatchConfigResponse reports the batch settings with the flush interval in milliseconds
+func newBatchConfigResponse(cfg database.BatchConfig) BatchConfigResponse {
+	return BatchConfigResponse{
+		BatchSize:       cfg.BatchSize,
+		FlushIntervalMs: cfg.FlushInterval.Milliseconds(),
+		MaxWorkers:      cfg.MaxWorkers,
+	}
+}
+
+// GetBatchConfig handles GET /api/mock/batch-config - returns the batch settings in use
+func (h *APIHandler) GetBatchConfig(c *gin.Context) {
+	if h.batchManager == nil {
+		log.Printf("ERROR: Database not available for GET /api/mock/batch-config")
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewSuccessResponse(newBatchConfigResponse(h.batchManager.CurrentConfig())))
+}
+
+// UpdateBatchConfig handles PUT /api/mock/batch-config - changes the batch size, flush interval and
+// number of workers without a restart
+func (h *APIHandler) UpdateBatchConfig(c *gin.Context) {
+	if h.batchManager == nil {
+		log.Printf("ERROR: Database not available for PUT /api/mock/batch-config")
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	var req BatchConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	err := h.batchManager.Reconfigure(database.BatchConfig{
+		BatchSize:     req.BatchSize,
+		FlushInterval: time.Duration(req.FlushIntervalMs) * time.Millisecond,
+		MaxWorkers:    req.MaxWorkers,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid batch config"))
+		return
+	}
+
+	cfg := h.batchManager.CurrentConfig()
+	log.Printf("SUCCESS: Batch config updated: batch_size=%d flush_interval=%s max_workers=%d", cfg.BatchSize, cfg.FlushInterval, cfg.MaxWorkers)
+	c.JSON(http.StatusOK, NewSuccessResponse(newBatchConfigResponse(cfg), "Batch config updated"))
+}
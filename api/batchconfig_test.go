@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"catalyst/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBatchConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "batch.db"), database.BatchConfig{
+		BatchSize:     20,
+		FlushInterval: 2 * time.Second,
+		MaxWorkers:    3,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer bm.Stop()
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expected       BatchConfigResponse
+	}{
+		{"more workers and a shorter interval", `{"max_workers":5,"flush_interval_ms":500}`, 200, BatchConfigResponse{BatchSize: 20, FlushIntervalMs: 500, MaxWorkers: 5}},
+		{"batch size only", `{"batch_size":50}`, 200, BatchConfigResponse{BatchSize: 50, FlushIntervalMs: 500, MaxWorkers: 5}},
+		{"negative workers", `{"max_workers":-1}`, 400, BatchConfigResponse{}},
+		{"invalid body", `{"batch_size":"big"}`, 400, BatchConfigResponse{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/mock/batch-config", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data BatchConfigResponse `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Data != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, resp.Data)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/batch-config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data BatchConfigResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Data.MaxWorkers != 5 || resp.Data.BatchSize != 50 {
+		t.Errorf("Expected the updated config, got %+v", resp.Data)
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"catalyst/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheStatsProvider exposes the location response caches of the mock servers
+type CacheStatsProvider interface {
+	// CacheStats returns the cache statistics keyed by server port
+	CacheStats() map[int]models.CacheStats
+}
+
+// GetCacheStats handles GET /api/mock/cache-stats - lists the cached location responses
+func (h *APIHandler) GetCacheStats(c *gin.Context) {
+	if h.cacheStats == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("cache stats not available"), http.StatusServiceUnavailable, "Cache stats not available"))
+		return
+	}
+
+	stats := h.cacheStats.CacheStats()
+	log.Printf("SUCCESS: Retrieved cache stats for %d servers", len(stats))
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"catalyst/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultChaosHistoryLimit = 100
+	maxChaosHistoryLimit     = 10000
+)
+
+// ChaosHistoryProvider exposes the chaos events recorded by the mock servers
+type ChaosHistoryProvider interface {
+	// ChaosHistory returns the events matching path and method, newest first
+	ChaosHistory(path, method string, limit int) []models.ChaosEvent
+}
+
+// GetChaosHistory handles GET /api/mock/chaos-history - lists the chaos applied to requests
+func (h *APIHandler) GetChaosHistory(c *gin.Context) {
+	if h.chaosHistory == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("chaos history not available"), http.StatusServiceUnavailable, "Chaos history not available"))
+		return
+	}
+
+	limit := defaultChaosHistoryLimit
+	if value := strings.TrimSpace(c.Query("limit")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxChaosHistoryLimit {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid limit %q", value), http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxChaosHistoryLimit)))
+			return
+		}
+		limit = parsed
+	}
+
+	path := strings.TrimSpace(c.Query("path"))
+	method := strings.ToUpper(strings.TrimSpace(c.Query("method")))
+
+	events := h.chaosHistory.ChaosHistory(path, method, limit)
+	log.Printf("SUCCESS: Retrieved %d chaos events", len(events))
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeChaosHistory struct {
+	path   string
+	method string
+	limit  int
+}
+
+func (f *fakeChaosHistory) ChaosHistory(path, method string, limit int) []models.ChaosEvent {
+	f.path, f.method, f.limit = path, method, limit
+	return []models.ChaosEvent{
+		{Timestamp: time.Now(), Path: "/foo", Method: "GET", Type: "abort", Applied: "503"},
+	}
+}
+
+func TestGetChaosHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	history := &fakeChaosHistory{}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1), ChaosHistory: history})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/chaos-history"+query, nil))
+		return w
+	}
+
+	w := get("?path=/foo&method=get&limit=5")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if history.path != "/foo" || history.method != "GET" || history.limit != 5 {
+		t.Errorf("Unexpected filters path=%q method=%q limit=%d", history.path, history.method, history.limit)
+	}
+
+	var events []models.ChaosEvent
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != "abort" {
+		t.Errorf("Unexpected events %v", events)
+	}
+
+	// Default limit
+	get("")
+	if history.limit != defaultChaosHistoryLimit {
+		t.Errorf("Expected default limit %d, got %d", defaultChaosHistoryLimit, history.limit)
+	}
+
+	if w := get("?limit=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for limit=0, got %d", w.Code)
+	}
+	if w := get("?limit=abc"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a non numeric limit, got %d", w.Code)
+	}
+}
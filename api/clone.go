@@ -0,0 +1,218 @@
+package api
+
+import (
+	"catalyst/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// ServerLauncher creates and starts mock servers at runtime
+type ServerLauncher interface {
+	// LaunchServer creates the server and starts listening on its port
+	LaunchServer(config models.Server) error
+}
+
+// CloneServerRequest is the body of POST /api/mock/servers/clone
+type CloneServerRequest struct {
+	SourceServerName string `json:"source_server_name" binding:"required"`
+	TargetPort       int    `json:"target_port" binding:"required"`
+	TargetName       string `json:"target_name" binding:"required"`
+	// Locations replace the source location with the same method and path, or are added
+	Locations []models.Location `json:"locations"`
+	// Persist writes the clone to <target_name>.yml in the config directory
+	Persist bool `json:"persist"`
+}
+
+// ErrConfigExists is returned when a persisted clone would overwrite a config file
+var ErrConfigExists = errors.New("configuration file already exists")
+
+// CloneServer handles POST /api/mock/servers/clone - starts a copy of a running server on another port
+func (h *APIHandler) CloneServer(c *gin.Context) {
+	if h.configs == nil || h.launcher == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("server cloning not available"), http.StatusServiceUnavailable, "Server cloning not available"))
+		return
+	}
+
+	var req CloneServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	if req.TargetPort <= 0 || req.TargetPort > 65535 {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid target port %d", req.TargetPort), http.StatusBadRequest, "target_port must be between 1 and 65535"))
+		return
+	}
+	if !isValidServerName(req.TargetName) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidServer, http.StatusBadRequest, "target_name must be a plain name without path separators"))
+		return
+	}
+	if strings.EqualFold(req.SourceServerName, req.TargetName) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidServer, http.StatusBadRequest, "target_name must differ from source_server_name"))
+		return
+	}
+	if _, exists := h.configs.RunningConfig(req.TargetName); exists {
+		c.JSON(http.StatusConflict, NewErrorResponse(ErrInvalidServer, http.StatusConflict, fmt.Sprintf("Server already running: %s", req.TargetName)))
+		return
+	}
+
+	running, ok := h.configs.RunningConfig(req.SourceServerName)
+	if !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running: %s", req.SourceServerName)))
+		return
+	}
+
+	clone, err := cloneServerConfig(running, req.SourceServerName)
+	if err != nil {
+		log.Printf("ERROR: Failed to copy config of server %s: %v", req.SourceServerName, err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error copying server configuration"))
+		return
+	}
+
+	clone.Listen = req.TargetPort
+	clone.Name = &req.TargetName
+	clone.Location = patchLocations(clone.Location, req.Locations)
+
+	if !isPortAvailable(req.TargetPort) {
+		c.JSON(http.StatusConflict, NewErrorResponse(fmt.Errorf("port %d is already in use", req.TargetPort), http.StatusConflict, fmt.Sprintf("Port %d is already in use", req.TargetPort)))
+		return
+	}
+
+	var configFile string
+	if req.Persist {
+		configService := NewConfigService(h.configDir)
+		configFile, err = configService.SaveServer(clone)
+		if err != nil {
+			log.Printf("ERROR: Failed to persist clone %s: %v", req.TargetName, err)
+			if errors.Is(err, ErrConfigExists) {
+				c.JSON(http.StatusConflict, NewErrorResponse(err, http.StatusConflict, fmt.Sprintf("Configuration file already exists: %s", req.TargetName)))
+			} else {
+				c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error saving configuration"))
+			}
+			return
+		}
+	}
+
+	if err := h.launcher.LaunchServer(clone); err != nil {
+		log.Printf("ERROR: Failed to start clone %s on port %d: %v", req.TargetName, req.TargetPort, err)
+		if configFile != "" {
+			os.Remove(configFile)
+		}
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error starting cloned server"))
+		return
+	}
+
+	log.Printf("SUCCESS: Cloned server %s as %s on port %d", req.SourceServerName, req.TargetName, req.TargetPort)
+	c.JSON(http.StatusCreated, NewSuccessResponse(clone, fmt.Sprintf("Server %s cloned as %s", req.SourceServerName, req.TargetName)))
+}
+
+// cloneServerConfig returns a deep copy of the named server from its running config
+func cloneServerConfig(running *models.MockServer, serverName string) (models.Server, error) {
+	for _, server := range running.Http.Servers {
+		if server.Name == nil || !strings.EqualFold(*server.Name, serverName) {
+			continue
+		}
+
+		data, err := yaml.Marshal(server)
+		if err != nil {
+			return models.Server{}, fmt.Errorf("failed to marshal server config: %w", err)
+		}
+
+		var clone models.Server
+		if err := yaml.Unmarshal(data, &clone); err != nil {
+			return models.Server{}, fmt.Errorf("failed to unmarshal server config: %w", err)
+		}
+		return clone, nil
+	}
+
+	return models.Server{}, fmt.Errorf("server %s not found in running config", serverName)
+}
+
+// patchLocations replaces the locations with the same method and path as a patch and appends the rest
+func patchLocations(locations []models.Location, patches []models.Location) []models.Location {
+	for _, patch := range patches {
+		replaced := false
+		for i, location := range locations {
+			if location.Path == patch.Path && strings.EqualFold(location.Method, patch.Method) {
+				locations[i] = patch
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			locations = append(locations, patch)
+		}
+	}
+	return locations
+}
+
+// SaveServer writes a single-server config named after the server; existing files are not overwritten
+func (cs *ConfigService) SaveServer(server models.Server) (string, error) {
+	if server.Name == nil || !isValidServerName(*server.Name) {
+		return "", ErrInvalidServer
+	}
+	if _, found := cs.findConfigFile(*server.Name); found {
+		return "", ErrConfigExists
+	}
+
+	data, err := yaml.Marshal(models.MockServer{Http: models.Http{Servers: []models.Server{server}}})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Drop unset fields so the file only holds what the server actually configures
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("failed to parse config: %w", err)
+	}
+	removeEmptyStrings(config)
+	removeNullValues(config)
+
+	data, err = yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	configFile := filepath.Join(cs.configDir, *server.Name+".yml")
+	if err := os.WriteFile(configFile, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return configFile, nil
+}
+
+// isValidServerName reports whether name can be used as a config file name inside the config directory
+func isValidServerName(name string) bool {
+	if strings.TrimSpace(name) == "" || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) {
+		return false
+	}
+	return filepath.Base(name) == name
+}
+
+// removeEmptyStrings recursively removes empty string values, e.g. an unset path_prefix
+func removeEmptyStrings(m map[string]interface{}) {
+	for key, value := range m {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				delete(m, key)
+			}
+		case map[string]interface{}:
+			removeEmptyStrings(v)
+		case []interface{}:
+			for _, item := range v {
+				if itemMap, ok := item.(map[string]interface{}); ok {
+					removeEmptyStrings(itemMap)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"catalyst/internal/config"
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeLauncher struct {
+	launched []models.Server
+}
+
+func (l *fakeLauncher) LaunchServer(config models.Server) error {
+	l.launched = append(l.launched, config)
+	return nil
+}
+
+func TestCloneServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configDir := t.TempDir()
+	name := "foo"
+	version := "1.0.0"
+	logger := false
+	loggerPath := "./logs"
+	source := models.Server{
+		Listen:     9100,
+		Name:       &name,
+		Version:    &version,
+		Logger:     &logger,
+		LoggerPath: &loggerPath,
+		Location: []models.Location{
+			{Path: "/api/price", Method: "GET", Response: `{"price":9.99}`, StatusCode: 200},
+			{Path: "/api/stock", Method: "GET", Response: `{"stock":3}`, StatusCode: 200, Headers: &models.Headers{"X-Version": "1"}},
+		},
+	}
+
+	provider := &fakeConfigProvider{configs: map[string]*models.MockServer{
+		"foo": {Http: models.Http{Servers: []models.Server{source}}},
+	}}
+	launcher := &fakeLauncher{}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{
+		ConfigDir:   configDir,
+		RestartChan: make(chan string, 1),
+		Configs:     provider,
+		Launcher:    launcher,
+	})
+
+	clone := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/mock/servers/clone", bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := clone(`{"source_server_name":"foo","target_port":19090,"target_name":"foo-clone","persist":true,
+		"locations":[{"path":"/api/price","method":"GET","response":"{\"price\":10.49}","statusCode":200}]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(launcher.launched) != 1 {
+		t.Fatalf("Expected 1 launched server, got %d", len(launcher.launched))
+	}
+
+	launched := launcher.launched[0]
+	if launched.Listen != 19090 || *launched.Name != "foo-clone" {
+		t.Errorf("Expected foo-clone on 19090, got %s on %d", *launched.Name, launched.Listen)
+	}
+	if len(launched.Location) != 2 || launched.Location[0].Response != `{"price":10.49}` {
+		t.Errorf("Expected the price location to be patched, got %+v", launched.Location)
+	}
+
+	// The copy is deep, changing it leaves the source untouched
+	(*launched.Location[1].Headers)["X-Version"] = "2"
+	*launched.Version = "2.0.0"
+	if (*source.Location[1].Headers)["X-Version"] != "1" || *source.Version != "1.0.0" || source.Location[0].Response != `{"price":9.99}` {
+		t.Error("Expected the source config to be unchanged")
+	}
+
+	// The persisted file is a valid config on its own
+	persisted, err := config.LoadConfig(filepath.Join(configDir, "foo-clone.yml"))
+	if err != nil {
+		t.Fatalf("Failed to load persisted clone: %v", err)
+	}
+	if server := persisted.Http.Servers[0]; server.Listen != 19090 || *server.Name != "foo-clone" || *server.Logger {
+		t.Errorf("Unexpected persisted server %+v", server)
+	}
+
+	if w := clone(`{"source_server_name":"missing","target_port":19091,"target_name":"other"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown source, got %d", w.Code)
+	}
+	if w := clone(`{"source_server_name":"foo","target_port":70000,"target_name":"other"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid port, got %d", w.Code)
+	}
+	if w := clone(`{"source_server_name":"foo","target_port":19092,"target_name":"foo-clone","persist":true}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when the config file exists, got %d", w.Code)
+	}
+}
+
+func TestCloneServerRejectsPathTraversal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configDir := filepath.Join(t.TempDir(), "config")
+	if err := os.Mkdir(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	name := "foo"
+	source := models.Server{Listen: 9100, Name: &name}
+	provider := &fakeConfigProvider{configs: map[string]*models.MockServer{
+		"foo": {Http: models.Http{Servers: []models.Server{source}}},
+	}}
+	launcher := &fakeLauncher{}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{
+		ConfigDir:   configDir,
+		RestartChan: make(chan string, 1),
+		Configs:     provider,
+		Launcher:    launcher,
+	})
+
+	for _, target := range []string{"../escape", "nested/clone", `..\\escape`, "..", "/etc/clone", "   "} {
+		body, _ := json.Marshal(map[string]interface{}{
+			"source_server_name": "foo",
+			"target_port":        19093,
+			"target_name":        target,
+			"persist":            true,
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/mock/servers/clone", bytes.NewBuffer(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("target_name %q: expected 400, got %d", target, w.Code)
+		}
+	}
+
+	if len(launcher.launched) != 0 {
+		t.Errorf("Expected no launched server, got %d", len(launcher.launched))
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(configDir), "escape.yml")); !os.IsNotExist(err) {
+		t.Errorf("Expected no config written outside the config dir, got %v", err)
+	}
+}
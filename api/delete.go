@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDeleteUUIDs bounds the number of UUIDs deleted by a single request
+const maxDeleteUUIDs = 1000
+
+// dataAuditServerName is the server_name of the audit entries that record transaction deletions
+const dataAuditServerName = "mock_transactions"
+
+// DeleteDataRequest is the body of POST /api/mock/data/delete: either uuids, or filters combined with AND
+type DeleteDataRequest struct {
+	UUIDs          []string `json:"uuids"`
+	OlderThanHours int      `json:"older_than_hours"`
+	Endpoint       string   `json:"endpoint"`
+	Method         string   `json:"method"`
+}
+
+// DeleteData handles POST /api/mock/data/delete - deletes the transactions with the given UUIDs, or
+// those matching older_than_hours, endpoint and method. The deletion is recorded in the config audit.
+func (h *APIHandler) DeleteData(c *gin.Context) {
+	var req DeleteDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	hasFilters := req.OlderThanHours != 0 || req.Endpoint != "" || req.Method != ""
+	switch {
+	case len(req.UUIDs) > 0 && hasFilters:
+		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("uuids combined with filters"), http.StatusBadRequest, "Use either uuids or older_than_hours/endpoint/method"))
+		return
+	case len(req.UUIDs) == 0 && !hasFilters:
+		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("missing uuids or filters"), http.StatusBadRequest, "uuids or at least one of older_than_hours, endpoint and method is required"))
+		return
+	case len(req.UUIDs) > maxDeleteUUIDs:
+		c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("%d uuids", len(req.UUIDs)), http.StatusBadRequest, fmt.Sprintf("At most %d uuids can be deleted per request", maxDeleteUUIDs)))
+		return
+	case req.OlderThanHours < 0:
+		c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid older_than_hours %d", req.OlderThanHours), http.StatusBadRequest, "older_than_hours must be a positive integer"))
+		return
+	}
+
+	if h.batchManager == nil {
+		log.Printf("ERROR: Database not available for POST /api/mock/data/delete")
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	ds := NewDatabaseService(h.batchManager)
+	var (
+		deleted int64
+		summary string
+		err     error
+	)
+	if len(req.UUIDs) > 0 {
+		deleted, err = ds.DeleteRecordsByUUIDs(req.UUIDs)
+		summary = fmt.Sprintf("deleted %d transactions by uuid", deleted)
+	} else {
+		var olderThan time.Time
+		if req.OlderThanHours > 0 {
+			olderThan = time.Now().Add(-time.Duration(req.OlderThanHours) * time.Hour)
+		}
+		deleted, err = ds.DeleteRecordsByFilter(olderThan, req.Endpoint, req.Method)
+		summary = fmt.Sprintf("deleted %d transactions matching %s", deleted, describeDeleteFilters(req))
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to delete transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error deleting data"))
+		return
+	}
+
+	h.recordDataAudit(c, summary)
+
+	log.Printf("SUCCESS: %s", summary)
+	c.JSON(http.StatusOK, NewSuccessResponse(map[string]interface{}{"deleted_count": deleted}))
+}
+
+// describeDeleteFilters lists the filters of a deletion for the audit, e.g. "older_than_hours=24 AND method=POST"
+func describeDeleteFilters(req DeleteDataRequest) string {
+	var filters []string
+	if req.OlderThanHours > 0 {
+		filters = append(filters, fmt.Sprintf("older_than_hours=%d", req.OlderThanHours))
+	}
+	if req.Endpoint != "" {
+		filters = append(filters, "endpoint="+req.Endpoint)
+	}
+	if req.Method != "" {
+		filters = append(filters, "method="+strings.ToUpper(req.Method))
+	}
+	return strings.Join(filters, " AND ")
+}
+
+// recordDataAudit stores a transaction deletion in the config audit. Failures are logged and never
+// fail the deletion.
+func (h *APIHandler) recordDataAudit(c *gin.Context, summary string) {
+	changedBy := strings.TrimSpace(c.GetHeader(changedByHeader))
+	if changedBy == "" {
+		changedBy = defaultChangedBy
+	}
+
+	entry := ConfigAuditEntry{
+		ServerName:    dataAuditServerName,
+		ChangedBy:     changedBy,
+		ChangeSummary: summary,
+	}
+	if err := NewDatabaseService(h.batchManager).InsertConfigAudit(entry); err != nil {
+		log.Printf("WARNING: Failed to record data deletion audit: %v", err)
+	}
+}
+
+// DeleteRecordsByUUIDs deletes the transactions with the given UUIDs and drops them from the body search index
+func (ds *DatabaseService) DeleteRecordsByUUIDs(uuids []string) (int64, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+
+	args := make([]interface{}, len(uuids))
+	for i, uuid := range uuids {
+		args[i] = uuid
+	}
+	query := `DELETE FROM mock_transactions WHERE uuid IN (?` + strings.Repeat(",?", len(uuids)-1) + `)`
+
+	result, err := ds.batchManager.GetDB().Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete transactions: %w", err)
+	}
+	for _, uuid := range uuids {
+		ds.batchManager.SearchIndex().Remove(uuid)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteRecordsByFilter deletes the transactions older than olderThan, received on endpoint with
+// method, and drops them from the body search index. Zero values are not filtered on.
+func (ds *DatabaseService) DeleteRecordsByFilter(olderThan time.Time, endpoint, method string) (int64, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if !olderThan.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, olderThan)
+	}
+	if endpoint != "" {
+		conditions = append(conditions, "request_endpoint = ?")
+		args = append(args, endpoint)
+	}
+	if method != "" {
+		conditions = append(conditions, "request_method = ?")
+		args = append(args, strings.ToUpper(method))
+	}
+	if len(conditions) == 0 {
+		return 0, fmt.Errorf("at least one filter is required")
+	}
+
+	// RETURNING gives the deleted UUIDs, which are not known before the filter runs
+	rows, err := ds.batchManager.GetDB().Query(`DELETE FROM mock_transactions WHERE `+strings.Join(conditions, " AND ")+` RETURNING uuid`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var deleted int64
+	for rows.Next() {
+		var uuid string
+		if err := rows.Scan(&uuid); err != nil {
+			return deleted, fmt.Errorf("failed to scan deleted transaction: %w", err)
+		}
+		ds.batchManager.SearchIndex().Remove(uuid)
+		deleted++
+	}
+	if err := rows.Err(); err != nil {
+		return deleted, fmt.Errorf("failed to delete transactions: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"catalyst/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeleteData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "delete.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	// 10 recent records, plus old ones to delete by filter
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := database.InsertOperation(bm.GetDB(), &database.Mockdata{
+			UUID: fmt.Sprintf("record-%d", i), RequestMethod: "GET", RequestEndpoint: "/api/users", ResponseStatusCode: 200, Timestamp: now,
+		}); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+	}
+	for i, operation := range []*database.Mockdata{
+		{RequestMethod: "POST", RequestEndpoint: "/api/pay", Timestamp: now.Add(-48 * time.Hour)},
+		{RequestMethod: "POST", RequestEndpoint: "/api/pay", Timestamp: now.Add(-72 * time.Hour)},
+		{RequestMethod: "GET", RequestEndpoint: "/api/pay", Timestamp: now.Add(-48 * time.Hour)},
+		{RequestMethod: "POST", RequestEndpoint: "/api/pay", Timestamp: now},
+	} {
+		operation.UUID = fmt.Sprintf("pay-%d", i)
+		if err := database.InsertOperation(bm.GetDB(), operation); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	remove := func(body string) (int, int64) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/mock/data/delete", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		var resp struct {
+			Data struct {
+				DeletedCount int64 `json:"deleted_count"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data.DeletedCount
+	}
+	count := func(endpoint string) int {
+		t.Helper()
+		var n int
+		if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions WHERE request_endpoint = ?", endpoint).Scan(&n); err != nil {
+			t.Fatalf("Failed to count rows: %v", err)
+		}
+		return n
+	}
+
+	code, deleted := remove(`{"uuids":["record-0","record-2","record-4","record-6","record-8","missing"]}`)
+	if code != http.StatusOK || deleted != 5 {
+		t.Fatalf("Expected 5 records deleted by uuid, got %d (status %d)", deleted, code)
+	}
+	if n := count("/api/users"); n != 5 {
+		t.Errorf("Expected 5 records left, got %d", n)
+	}
+
+	code, deleted = remove(`{"older_than_hours":24,"endpoint":"/api/pay","method":"post"}`)
+	if code != http.StatusOK || deleted != 2 {
+		t.Fatalf("Expected 2 old POST /api/pay records deleted, got %d (status %d)", deleted, code)
+	}
+	if n := count("/api/pay"); n != 2 {
+		t.Errorf("Expected the GET and the recent POST /api/pay records left, got %d", n)
+	}
+
+	uuids := make([]string, maxDeleteUUIDs+1)
+	for i := range uuids {
+		uuids[i] = fmt.Sprintf("%q", fmt.Sprintf("uuid-%d", i))
+	}
+	for _, body := range []string{
+		`{}`,
+		`{"uuids":["record-1"],"endpoint":"/api/users"}`,
+		`{"older_than_hours":-1}`,
+		`{"uuids":[` + strings.Join(uuids, ",") + `]}`,
+	} {
+		if code, _ := remove(body); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %.60s, got %d", body, code)
+		}
+	}
+
+	// Every deletion is in the audit
+	entries, err := NewDatabaseService(bm).GetConfigAudit(dataAuditServerName, 10)
+	if err != nil {
+		t.Fatalf("GetConfigAudit failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+	summaries := entries[0].ChangeSummary + "; " + entries[1].ChangeSummary
+	for _, want := range []string{"deleted 5 transactions by uuid", "deleted 2 transactions matching older_than_hours=24 AND endpoint=/api/pay AND method=POST"} {
+		if !strings.Contains(summaries, want) {
+			t.Errorf("Expected audit summary %q, got %q", want, summaries)
+		}
+	}
+}
+
+func TestDeleteDataRemovesFromSearch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "delete-search.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	now := time.Now()
+	for _, operation := range []*database.Mockdata{
+		{UUID: "by-uuid", RequestMethod: "POST", RequestEndpoint: "/api/pay", ResponseBody: `{"status":"declined"}`, Timestamp: now},
+		{UUID: "by-filter", RequestMethod: "POST", RequestEndpoint: "/api/refund", ResponseBody: `{"status":"declined"}`, Timestamp: now.Add(-48 * time.Hour)},
+		{UUID: "kept", RequestMethod: "POST", RequestEndpoint: "/api/pay", ResponseBody: `{"status":"declined"}`, Timestamp: now},
+	} {
+		if err := database.InsertOperation(bm.GetDB(), operation); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+		bm.SearchIndex().Add(operation.UUID, operation.ResponseBody)
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	for _, body := range []string{`{"uuids":["by-uuid"]}`, `{"older_than_hours":24,"endpoint":"/api/refund"}`} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/mock/data/delete", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 deleting %s, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	if got := bm.SearchIndex().Search("declined"); len(got) != 1 || got[0] != "kept" {
+		t.Errorf("Expected only the kept transaction in the index, got %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data/search?contains_body=declined", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(records) != 1 || records[0]["uuid"] != "kept" {
+		t.Errorf("Expected search to return only the kept transaction, got %v", records)
+	}
+}
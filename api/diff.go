@@ -0,0 +1,221 @@
+package api
+
+import (
+	"catalyst/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProvider exposes the configuration the mock servers are currently running with
+type ConfigProvider interface {
+	// RunningConfig returns the in-memory config containing the named server
+	RunningConfig(serverName string) (*models.MockServer, bool)
+}
+
+// ConfigDiff describes the differences between the running config and the YAML on disk.
+// Added fields exist only on disk, removed fields exist only in the running config.
+type ConfigDiff struct {
+	InSync   bool                     `json:"in_sync"`
+	Added    map[string]interface{}   `json:"added,omitempty"`
+	Removed  map[string]interface{}   `json:"removed,omitempty"`
+	Modified map[string]ModifiedField `json:"modified,omitempty"`
+}
+
+// ModifiedField holds both values of a field that differs
+type ModifiedField struct {
+	Running interface{} `json:"running"`
+	OnDisk  interface{} `json:"on_disk"`
+}
+
+// GetConfigDiff handles GET /api/mock/config/diff - compares the running config against the YAML on disk
+func (h *APIHandler) GetConfigDiff(c *gin.Context) {
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	if serverName == "" {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidServer, http.StatusBadRequest, "server_name parameter is required"))
+		return
+	}
+
+	if h.configs == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(ErrConfigNotFound, http.StatusServiceUnavailable, "Running configuration not available"))
+		return
+	}
+
+	running, ok := h.configs.RunningConfig(serverName)
+	if !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running: %s", serverName)))
+		return
+	}
+
+	configService := NewConfigService(h.configDir)
+	onDisk, err := configService.LoadMockServer(serverName)
+	if err != nil {
+		log.Printf("ERROR: Failed to load config for server %s: %v", serverName, err)
+		if err == ErrConfigNotFound {
+			c.JSON(http.StatusNotFound, NewErrorResponse(err, http.StatusNotFound, fmt.Sprintf("Configuration file not found: %s", serverName)))
+		} else {
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving configuration"))
+		}
+		return
+	}
+
+	// A file without a name runs with its file name as the config name, that is not drift
+	if onDisk.Name == "" {
+		onDisk.Name = running.Name
+	}
+
+	diff, err := DiffConfigs(running, onDisk)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error comparing configurations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// LoadMockServer parses the on-disk YAML of a server into the models used by the mock servers
+func (cs *ConfigService) LoadMockServer(serverName string) (*models.MockServer, error) {
+	if strings.TrimSpace(serverName) == "" {
+		return nil, ErrInvalidServer
+	}
+
+	configFile, found := cs.findConfigFile(serverName)
+	if !found {
+		return nil, ErrConfigNotFound
+	}
+
+	configData, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config models.MockServer
+	if err := yaml.Unmarshal(configData, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return &config, nil
+}
+
+// DiffConfigs compares two configs through their canonical JSON representation
+func DiffConfigs(running, onDisk *models.MockServer) (*ConfigDiff, error) {
+	runningMap, err := canonicalConfig(running)
+	if err != nil {
+		return nil, err
+	}
+	onDiskMap, err := canonicalConfig(onDisk)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ConfigDiff{
+		Added:    make(map[string]interface{}),
+		Removed:  make(map[string]interface{}),
+		Modified: make(map[string]ModifiedField),
+	}
+	diffValues("", runningMap, onDiskMap, diff)
+	diff.InSync = len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Modified) == 0
+
+	return diff, nil
+}
+
+// canonicalConfig marshals a config to JSON and back into generic maps.
+// Runtime-only state such as postgres containers is left out.
+func canonicalConfig(config *models.MockServer) (map[string]interface{}, error) {
+	if config == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	clean := *config
+	clean.PostgresServers.Postgres = make([]models.PostgresServer, len(config.PostgresServers.Postgres))
+	for i, server := range config.PostgresServers.Postgres {
+		server.PostgresContainer = nil
+		clean.PostgresServers.Postgres[i] = server
+	}
+
+	data, err := json.Marshal(clean)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var canonical map[string]interface{}
+	if err := json.Unmarshal(data, &canonical); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	removeNullValues(canonical)
+
+	return canonical, nil
+}
+
+// diffValues recursively records the differences between two JSON values
+func diffValues(path string, running, onDisk interface{}, diff *ConfigDiff) {
+	runningMap, runningIsMap := running.(map[string]interface{})
+	onDiskMap, onDiskIsMap := onDisk.(map[string]interface{})
+	if runningIsMap && onDiskIsMap {
+		keys := make(map[string]struct{})
+		for key := range runningMap {
+			keys[key] = struct{}{}
+		}
+		for key := range onDiskMap {
+			keys[key] = struct{}{}
+		}
+
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		for _, key := range sorted {
+			fieldPath := joinDiffPath(path, key)
+			runningValue, inRunning := runningMap[key]
+			onDiskValue, inOnDisk := onDiskMap[key]
+			switch {
+			case !inRunning:
+				diff.Added[fieldPath] = onDiskValue
+			case !inOnDisk:
+				diff.Removed[fieldPath] = runningValue
+			default:
+				diffValues(fieldPath, runningValue, onDiskValue, diff)
+			}
+		}
+		return
+	}
+
+	runningSlice, runningIsSlice := running.([]interface{})
+	onDiskSlice, onDiskIsSlice := onDisk.([]interface{})
+	if runningIsSlice && onDiskIsSlice {
+		for i := 0; i < len(runningSlice) || i < len(onDiskSlice); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(runningSlice):
+				diff.Added[itemPath] = onDiskSlice[i]
+			case i >= len(onDiskSlice):
+				diff.Removed[itemPath] = runningSlice[i]
+			default:
+				diffValues(itemPath, runningSlice[i], onDiskSlice[i], diff)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(running, onDisk) {
+		diff.Modified[path] = ModifiedField{Running: running, OnDisk: onDisk}
+	}
+}
+
+// joinDiffPath builds dotted field paths such as http.servers[0].listen
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
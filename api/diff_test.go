@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+type fakeConfigProvider struct {
+	configs map[string]*models.MockServer
+}
+
+func (p *fakeConfigProvider) RunningConfig(serverName string) (*models.MockServer, bool) {
+	config, ok := p.configs[serverName]
+	return config, ok
+}
+
+func TestGetConfigDiff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configDir := t.TempDir()
+	name := "foo"
+	onDisk := &models.MockServer{
+		Http: models.Http{
+			Servers: []models.Server{
+				{
+					Listen: 9100,
+					Name:   &name,
+					Location: []models.Location{
+						{Path: "/api/test", Method: "GET", Response: `{}`, StatusCode: 200},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := yaml.Marshal(onDisk)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "foo.yaml"), data, 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	provider := &fakeConfigProvider{configs: map[string]*models.MockServer{}}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: configDir, RestartChan: make(chan string, 1), Configs: provider})
+
+	getDiff := func() (int, ConfigDiff) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/mock/config/diff?server_name=foo", nil)
+		router.ServeHTTP(w, req)
+
+		var diff ConfigDiff
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &diff); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+		}
+		return w.Code, diff
+	}
+
+	// Unknown running server
+	if code, _ := getDiff(); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a server that is not running, got %d", code)
+	}
+
+	// Identical configs are in sync
+	running := *onDisk
+	provider.configs["foo"] = &running
+	code, diff := getDiff()
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if !diff.InSync {
+		t.Errorf("Expected in_sync for identical configs, got %+v", diff)
+	}
+
+	// Drift: the running server changed port and gained a location the file doesn't have
+	drifted := models.Server{
+		Listen: 9200,
+		Name:   &name,
+		Location: []models.Location{
+			{Path: "/api/test", Method: "GET", Response: `{}`, StatusCode: 200},
+			{Path: "/api/extra", Method: "POST", Response: `{}`, StatusCode: 201},
+		},
+	}
+	provider.configs["foo"] = &models.MockServer{Http: models.Http{Servers: []models.Server{drifted}}}
+
+	code, diff = getDiff()
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if diff.InSync {
+		t.Fatal("Expected drift to be reported")
+	}
+
+	listen, ok := diff.Modified["http.servers[0].listen"]
+	if !ok {
+		t.Fatalf("Expected listen to be modified, got %+v", diff.Modified)
+	}
+	if listen.Running != float64(9200) || listen.OnDisk != float64(9100) {
+		t.Errorf("Unexpected listen values: %+v", listen)
+	}
+
+	if _, ok := diff.Removed["http.servers[0].location[1]"]; !ok {
+		t.Errorf("Expected the extra running location to be reported as removed, got %+v", diff.Removed)
+	}
+	if len(diff.Added) != 0 {
+		t.Errorf("Expected no added fields, got %+v", diff.Added)
+	}
+}
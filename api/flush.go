@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flushTimeout bounds how long FlushData waits for the flushed operations to be persisted
+const flushTimeout = 5 * time.Second
+
+// FlushData handles POST /api/mock/data/flush - sends the queued transactions to the database
+// without waiting for the flush interval, e.g. before a backup. Answers 202 when they were not
+// all persisted within flushTimeout.
+func (h *APIHandler) FlushData(c *gin.Context) {
+	if h.batchManager == nil {
+		log.Printf("ERROR: Database not available for POST /api/mock/data/flush")
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), flushTimeout)
+	defer cancel()
+
+	start := time.Now()
+	flushed, err := h.batchManager.Flush(ctx)
+	result := map[string]interface{}{
+		"flushed_count": flushed,
+		"duration_ms":   time.Since(start).Milliseconds(),
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("ERROR: Flush of %d queued transactions not confirmed after %s", flushed, flushTimeout)
+		c.JSON(http.StatusAccepted, NewSuccessResponse(result, "Flush started but not confirmed"))
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to flush queued transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error flushing data"))
+		return
+	}
+
+	log.Printf("SUCCESS: Flushed %d queued transactions", flushed)
+	c.JSON(http.StatusOK, NewSuccessResponse(result))
+}
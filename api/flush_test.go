@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"catalyst/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFlushData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The flush interval is long enough that only the flush persists the last batch
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "flush.db"), database.BatchConfig{
+		BatchSize:     20,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer bm.Stop()
+
+	const operations = 50
+	for i := 0; i < operations; i++ {
+		if err := bm.AddOperation(&database.Mockdata{
+			UUID:            fmt.Sprintf("flush-%d", i),
+			RequestMethod:   "POST",
+			RequestEndpoint: "/flush",
+			Timestamp:       time.Now(),
+		}); err != nil {
+			t.Fatalf("AddOperation %d failed: %v", i, err)
+		}
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/mock/data/flush", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			FlushedCount int   `json:"flushed_count"`
+			DurationMs   int64 `json:"duration_ms"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Data.FlushedCount > operations {
+		t.Errorf("Expected at most %d flushed operations, got %d", operations, resp.Data.FlushedCount)
+	}
+	if resp.Data.DurationMs < 0 || resp.Data.DurationMs > flushTimeout.Milliseconds() {
+		t.Errorf("Unexpected duration_ms %d", resp.Data.DurationMs)
+	}
+
+	var count int
+	if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions WHERE request_endpoint = '/flush'").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != operations {
+		t.Errorf("Expected %d persisted operations after flush, got %d", operations, count)
+	}
+}
+
+func TestFlushDataWithoutDatabase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/mock/data/flush", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+}
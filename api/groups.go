@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Group statuses reported by GET /api/mock/groups
+const (
+	GroupStatusRunning = "running"
+	GroupStatusStopped = "stopped"
+	GroupStatusPartial = "partial"
+)
+
+// ServerGroup is a set of mock servers started and stopped together
+type ServerGroup struct {
+	Name        string   `json:"name"`
+	Servers     []string `json:"servers"`
+	ServerCount int      `json:"server_count"`
+	Running     int      `json:"running"`
+	Status      string   `json:"status"`
+}
+
+// GroupManager starts and stops the mock servers that share a group
+type GroupManager interface {
+	// Groups returns every configured group with the state of its servers
+	Groups() []ServerGroup
+	// StartGroup starts the stopped servers of the group, returns ErrGroupNotFound if it doesn't exist
+	StartGroup(name string) error
+	// StopGroup gracefully stops the running servers of the group, returns ErrGroupNotFound if it doesn't exist
+	StopGroup(name string) error
+}
+
+// GroupStatus summarizes how many of the servers of a group are running
+func GroupStatus(running, total int) string {
+	switch {
+	case running == 0:
+		return GroupStatusStopped
+	case running == total:
+		return GroupStatusRunning
+	default:
+		return GroupStatusPartial
+	}
+}
+
+// ListGroups handles GET /api/mock/groups - lists the server groups and their status
+func (h *APIHandler) ListGroups(c *gin.Context) {
+	if h.groups == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("server groups not available"), http.StatusServiceUnavailable, "Server groups not available"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewSuccessResponse(h.groups.Groups()))
+}
+
+// StartGroup handles POST /api/mock/groups/:name/start - starts every server of the group
+func (h *APIHandler) StartGroup(c *gin.Context) {
+	h.changeGroupState(c, true)
+}
+
+// StopGroup handles POST /api/mock/groups/:name/stop - gracefully stops every server of the group
+func (h *APIHandler) StopGroup(c *gin.Context) {
+	h.changeGroupState(c, false)
+}
+
+// changeGroupState starts or stops the group named in the path
+func (h *APIHandler) changeGroupState(c *gin.Context, start bool) {
+	if h.groups == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("server groups not available"), http.StatusServiceUnavailable, "Server groups not available"))
+		return
+	}
+
+	name := c.Param("name")
+	action, apply := "stopped", h.groups.StopGroup
+	if start {
+		action, apply = "started", h.groups.StartGroup
+	}
+
+	if err := apply(name); err != nil {
+		if errors.Is(err, ErrGroupNotFound) {
+			c.JSON(http.StatusNotFound, NewErrorResponse(err, http.StatusNotFound, fmt.Sprintf("Group not found: %s", name)))
+			return
+		}
+		log.Printf("ERROR: Group %s could not be %s: %v", name, action, err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, fmt.Sprintf("Group could not be %s", action)))
+		return
+	}
+
+	log.Printf("SUCCESS: Group %s %s", name, action)
+	c.JSON(http.StatusOK, NewSuccessResponse(nil, fmt.Sprintf("Group %s %s", name, action)))
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeGroupManager struct {
+	stopped []string
+}
+
+func (g *fakeGroupManager) Groups() []ServerGroup {
+	return []ServerGroup{{Name: "payment-services", Servers: []string{"payments", "refunds"}, ServerCount: 2, Running: 1, Status: GroupStatus(1, 2)}}
+}
+
+func (g *fakeGroupManager) StartGroup(name string) error {
+	if name != "payment-services" {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+func (g *fakeGroupManager) StopGroup(name string) error {
+	if name != "payment-services" {
+		return ErrGroupNotFound
+	}
+	g.stopped = append(g.stopped, name)
+	return nil
+}
+
+func TestGroupRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	groups := &fakeGroupManager{}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1), Groups: groups})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/groups", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data []ServerGroup `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode groups: %v", err)
+	}
+	if len(response.Data) != 1 || response.Data[0].Status != GroupStatusPartial {
+		t.Errorf("Expected one partial group, got %+v", response.Data)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/mock/groups/payment-services/stop", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 stopping the group, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(groups.stopped) != 1 {
+		t.Errorf("Expected the group to be stopped once, got %v", groups.stopped)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/mock/groups/unknown/start", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown group, got %d", w.Code)
+	}
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"catalyst/database"
+	"catalyst/internal/config"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -18,10 +20,23 @@ import (
 
 // APIHandler handles REST API endpoints with improved structure and error handling
 type APIHandler struct {
-	batchManager *database.BatchManager
-	configDir    string
-	restartChan  chan string
-	timeout      time.Duration
+	batchManager   *database.BatchManager
+	configDir      string
+	restartChan    chan string
+	configs        ConfigProvider
+	chaosHistory   ChaosHistoryProvider
+	cacheStats     CacheStatsProvider
+	logLevels      LogLevelProvider
+	realtimeStats  RealtimeStatsProvider
+	launcher       ServerLauncher
+	groups         GroupManager
+	states         StateProvider
+	servers        ServerLister
+	schemas        SchemaProvider
+	restartManager *RestartManager
+	postgres       PostgresConnectionProvider
+	buildInfo      BuildInfo
+	timeout        time.Duration
 }
 
 // ConfigService handles configuration operations
@@ -36,13 +51,50 @@ type DatabaseService struct {
 	timeout      time.Duration
 }
 
+// APIDependencies holds what the API handlers work with. Fields left unset disable the
+// endpoints that need them, so callers only set what they have.
+type APIDependencies struct {
+	BatchManager   *database.BatchManager
+	ConfigDir      string
+	RestartChan    chan string
+	Configs        ConfigProvider
+	ChaosHistory   ChaosHistoryProvider
+	CacheStats     CacheStatsProvider
+	LogLevels      LogLevelProvider
+	RealtimeStats  RealtimeStatsProvider
+	Launcher       ServerLauncher
+	Groups         GroupManager
+	States         StateProvider
+	Servers        ServerLister
+	Schemas        SchemaProvider
+	RestartManager *RestartManager
+	Postgres       PostgresConnectionProvider
+	// BuildInfo is reported by GET /api/mock/version
+	BuildInfo BuildInfo
+	// Auth protects every route of the management API
+	Auth AuthConfig
+}
+
 // NewAPIHandler creates a new APIHandler instance
-func NewAPIHandler(batchManager *database.BatchManager, configDir string, restartChan chan string) *APIHandler {
+func NewAPIHandler(deps APIDependencies) *APIHandler {
 	return &APIHandler{
-		batchManager: batchManager,
-		configDir:    configDir,
-		restartChan:  restartChan,
-		timeout:      30 * time.Second,
+		batchManager:   deps.BatchManager,
+		configDir:      deps.ConfigDir,
+		restartChan:    deps.RestartChan,
+		configs:        deps.Configs,
+		chaosHistory:   deps.ChaosHistory,
+		cacheStats:     deps.CacheStats,
+		logLevels:      deps.LogLevels,
+		realtimeStats:  deps.RealtimeStats,
+		launcher:       deps.Launcher,
+		groups:         deps.Groups,
+		states:         deps.States,
+		servers:        deps.Servers,
+		schemas:        deps.Schemas,
+		restartManager: deps.RestartManager,
+		postgres:       deps.Postgres,
+		buildInfo:      deps.BuildInfo,
+		timeout:        30 * time.Second,
 	}
 }
 
@@ -62,7 +114,9 @@ func NewDatabaseService(batchManager *database.BatchManager) *DatabaseService {
 	}
 }
 
-// GetData handles GET /api/mock/data - retrieves all records from database
+// GetData handles GET /api/mock/data - retrieves all records from database.
+// The optional ?type=sync|async query param filters by transaction type and ?trace_id= by the
+// request_trace_id shared by a request and its async calls.
 func (h *APIHandler) GetData(c *gin.Context) {
 	log.Printf("GET /api/mock/data - Retrieving all records from database")
 
@@ -73,7 +127,14 @@ func (h *APIHandler) GetData(c *gin.Context) {
 	}
 
 	dbService := NewDatabaseService(h.batchManager)
-	records, err := dbService.GetAllRecords()
+
+	transactionType := strings.TrimSpace(c.Query("type"))
+	if transactionType != "" && transactionType != database.TransactionTypeSync && transactionType != database.TransactionTypeAsync {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidTransactionType, http.StatusBadRequest, "type must be 'sync' or 'async'"))
+		return
+	}
+
+	records, err := dbService.GetRecordsByFilter(transactionType, strings.TrimSpace(c.Query("trace_id")))
 	if err != nil {
 		log.Printf("ERROR: Failed to retrieve data from database: %v", err)
 		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving data"))
@@ -175,6 +236,9 @@ func (h *APIHandler) UpdateConfig(c *gin.Context) {
 	// Remove null values from the map before writing to file
 	removeNullValues(configMap)
 
+	// Keep the current YAML for the audit log; a missing file is reported by UpdateConfig
+	previousYAML, _ := configService.ReadConfigYAML(serverName)
+
 	// Update configuration using service
 	updatedConfig, err := configService.UpdateConfig(serverName, configMap)
 	if err != nil {
@@ -188,6 +252,9 @@ func (h *APIHandler) UpdateConfig(c *gin.Context) {
 	}
 
 	log.Printf("SUCCESS: Updated configuration for server: %s", serverName)
+	if newYAML, err := configService.ReadConfigYAML(serverName); err == nil {
+		h.recordConfigAudit(c, serverName, previousYAML, newYAML)
+	}
 	c.JSON(http.StatusOK, updatedConfig)
 
 	// Notify restart after successful config update
@@ -198,46 +265,91 @@ func (h *APIHandler) UpdateConfig(c *gin.Context) {
 
 // GetAllRecords retrieves all records from the database
 func (ds *DatabaseService) GetAllRecords() ([]DatabaseRecord, error) {
-	if ds.batchManager == nil || ds.batchManager.DB == nil {
+	return ds.queryRecords("", "")
+}
+
+// GetRecordsByType retrieves the records with the given transaction type (sync or async)
+func (ds *DatabaseService) GetRecordsByType(transactionType string) ([]DatabaseRecord, error) {
+	return ds.queryRecords(transactionType, "")
+}
+
+// GetRecordsByFilter retrieves the records matching the transaction type and trace ID; empty values don't filter
+func (ds *DatabaseService) GetRecordsByFilter(transactionType, traceID string) ([]DatabaseRecord, error) {
+	return ds.queryRecords(transactionType, traceID)
+}
+
+// queryRecords retrieves records, optionally filtered by transaction type and trace ID
+func (ds *DatabaseService) queryRecords(transactionType, traceID string) ([]DatabaseRecord, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
 		return nil, fmt.Errorf("database not available")
 	}
 
-	db := ds.batchManager.DB
+	db := ds.batchManager.GetDB()
 	query := `SELECT uuid, recepcion_id, sender_id, request_headers, request_method, 
 			  request_endpoint, request_body, response_headers, response_body, 
-			  response_status_code, timestamp FROM mock_transactions ORDER BY timestamp DESC`
+			  response_status_code, transaction_type, trace_id, timestamp, tags FROM mock_transactions`
 
-	rows, err := db.Query(query)
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if transactionType != "" {
+		conditions = append(conditions, "transaction_type = ?")
+		args = append(args, transactionType)
+	}
+	if traceID != "" {
+		conditions = append(conditions, "trace_id = ?")
+		args = append(args, traceID)
+	}
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY timestamp DESC`
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query database: %w", err)
 	}
 	defer rows.Close()
 
+	return scanRecords(rows)
+}
+
+// scanRecords reads the mock_transactions rows selected by queryRecords
+func scanRecords(rows *sql.Rows) ([]DatabaseRecord, error) {
 	var records []DatabaseRecord
 	for rows.Next() {
-		var record DatabaseRecord
-		var requestHeaders, responseHeaders string
+		var (
+			record DatabaseRecord
+			tags   string
+		)
 
 		err := rows.Scan(
 			&record.UUID,
 			&record.RecepcionID,
 			&record.SenderID,
-			&requestHeaders,
+			&record.RequestHeaders,
 			&record.RequestMethod,
 			&record.RequestEndpoint,
 			&record.RequestBody,
-			&responseHeaders,
+			&record.ResponseHeaders,
 			&record.ResponseBody,
 			&record.ResponseStatusCode,
+			&record.TransactionType,
+			&record.TraceID,
 			&record.Timestamp,
+			&tags,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan database row: %w", err)
 		}
+		if record.Tags, err = database.DecodeTags(tags); err != nil {
+			return nil, err
+		}
 		records = append(records, record)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
@@ -293,21 +405,20 @@ func (cs *ConfigService) UpdateConfig(serverName string, config map[string]inter
 	return config, nil
 }
 
-// findConfigFile finds the configuration file for a server, trying different extensions
+// findConfigFile finds the configuration file for a server by its top-level name, or else by file name
 func (cs *ConfigService) findConfigFile(serverName string) (string, bool) {
-	extensions := []string{".yml", ".yaml"}
-	for _, ext := range extensions {
-		configFile := filepath.Join(cs.configDir, serverName+ext)
-		if _, err := os.Stat(configFile); err == nil {
-			return configFile, true
-		}
+	configFile, err := config.FindConfigFile(cs.configDir, serverName)
+	if err != nil {
+		return "", false
 	}
-	return "", false
+	return configFile, true
 }
 
-// GetAllUsedPorts retrieves all ports in use by other servers, excluding the specified server
+// GetAllUsedPorts retrieves all ports in use by other servers, excluding the config file of the
+// specified server as resolved by findConfigFile
 func (cs *ConfigService) GetAllUsedPorts(excludeServerName string) (map[int]string, error) {
 	portMap := make(map[int]string)
+	excludeFile, _ := cs.findConfigFile(excludeServerName)
 
 	// Get all YAML files in the directory
 	extensions := []string{".yml", ".yaml"}
@@ -325,12 +436,8 @@ func (cs *ConfigService) GetAllUsedPorts(excludeServerName string) (map[int]stri
 
 	// Process each config file
 	for _, file := range files {
-		// Extract server name from filename (without extension)
-		baseName := filepath.Base(file)
-		serverName := strings.TrimSuffix(strings.TrimSuffix(baseName, ".yml"), ".yaml")
-
-		// Skip the server being updated
-		if serverName == excludeServerName {
+		// Skip the config file being updated, whether it was found by name or by file name
+		if excludeFile != "" && filepath.Clean(file) == filepath.Clean(excludeFile) {
 			continue
 		}
 
@@ -347,6 +454,13 @@ func (cs *ConfigService) GetAllUsedPorts(excludeServerName string) (map[int]stri
 			continue
 		}
 
+		// The server name is the config name, or the filename (without extension) when it has none
+		serverName := config.Name
+		if serverName == "" {
+			baseName := filepath.Base(file)
+			serverName = strings.TrimSuffix(strings.TrimSuffix(baseName, ".yml"), ".yaml")
+		}
+
 		// Extract ports from all servers in this config
 		for _, server := range config.HTTP.Servers {
 			if server.Listen > 0 {
@@ -441,6 +555,8 @@ func (h *APIHandler) UpdateConfigYaml(c *gin.Context) {
 		return
 	}
 
+	previousYAML, _ := configService.ReadConfigYAML(req.ServerName)
+
 	// Update configuration with new values
 	if req.Config.TestSetting != "" {
 		currentConfig["test_setting"] = req.Config.TestSetting
@@ -461,6 +577,9 @@ func (h *APIHandler) UpdateConfigYaml(c *gin.Context) {
 	}
 
 	log.Printf("SUCCESS: Updated YAML configuration for server: %s", req.ServerName)
+	if newYAML, err := configService.ReadConfigYAML(req.ServerName); err == nil {
+		h.recordConfigAudit(c, req.ServerName, previousYAML, newYAML)
+	}
 	c.JSON(http.StatusOK, updatedConfig)
 
 	// Notify restart after successful config update
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"catalyst/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConfigLookupByName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configDir := t.TempDir()
+	files := map[string]string{
+		"legacy.yml":       "http:\n  servers:\n    - listen: 9300\n      name: legacy\n",
+		"payments-v2.yaml": "name: payments\nversion: 2.1.0\nhttp:\n  servers:\n    - listen: 9301\n      name: payments-api\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	restartChan := make(chan string, 1)
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: configDir, RestartChan: restartChan})
+
+	getConfig := func(serverName string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/config?server_name="+serverName, nil))
+		var config map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &config)
+		return w.Code, config
+	}
+
+	// A file without a name is found by its file name, a named one by its name
+	if code, _ := getConfig("legacy"); code != http.StatusOK {
+		t.Errorf("Expected 200 for the legacy file name, got %d", code)
+	}
+	code, config := getConfig("payments")
+	if code != http.StatusOK || config["version"] != "2.1.0" {
+		t.Fatalf("Expected the payments config, got %d %v", code, config)
+	}
+
+	// The update keeps the name and notifies the restart with it
+	body := `{"name":"payments","version":"2.2.0","http":{"servers":[{"listen":9301,"name":"payments-api","location":[{"path":"/api/pay","method":"POST","status_code":200}]}]}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/api/mock/config?server_name=payments", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 updating by name, got %d: %s", w.Code, w.Body.String())
+	}
+	if name := <-restartChan; name != "payments" {
+		t.Errorf("Expected restart signal for payments, got %s", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "payments-v2.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read updated config: %v", err)
+	}
+	if !strings.Contains(string(data), "name: payments") || !strings.Contains(string(data), "version: 2.2.0") {
+		t.Errorf("Expected the name and version to be written, got:\n%s", data)
+	}
+}
+
+func TestGetAllUsedPortsExcludesResolvedFile(t *testing.T) {
+	configDir := t.TempDir()
+	files := map[string]string{
+		"legacy.yml":       "http:\n  servers:\n    - listen: 9300\n      name: legacy\n",
+		"payments-v2.yaml": "name: payments\nhttp:\n  servers:\n    - listen: 9301\n      name: payments-api\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cs := NewConfigService(configDir)
+	tests := []struct {
+		exclude  string
+		expected map[int]string
+	}{
+		{"payments", map[int]string{9300: "legacy"}},
+		// The file name finds the named config too, so it is excluded as well
+		{"payments-v2", map[int]string{9300: "legacy"}},
+		{"legacy", map[int]string{9301: "payments"}},
+		{"unknown", map[int]string{9300: "legacy", 9301: "payments"}},
+	}
+	for _, tt := range tests {
+		ports, err := cs.GetAllUsedPorts(tt.exclude)
+		if err != nil {
+			t.Fatalf("GetAllUsedPorts(%s) failed: %v", tt.exclude, err)
+		}
+		if len(ports) != len(tt.expected) {
+			t.Errorf("GetAllUsedPorts(%s): expected %v, got %v", tt.exclude, tt.expected, ports)
+			continue
+		}
+		for port, name := range tt.expected {
+			if ports[port] != name {
+				t.Errorf("GetAllUsedPorts(%s): expected %v, got %v", tt.exclude, tt.expected, ports)
+			}
+		}
+	}
+}
+
+func TestGetDataTraceFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "trace.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	now := time.Now()
+	operations := []*database.Mockdata{
+		{UUID: "request", RequestMethod: "POST", RequestEndpoint: "/api/orders", TraceID: "trace-1", Timestamp: now},
+		{UUID: "callback", RequestMethod: "POST", RequestEndpoint: "http://callback", TraceID: "trace-1",
+			TransactionType: database.TransactionTypeAsync, Timestamp: now},
+		{UUID: "other", RequestMethod: "GET", RequestEndpoint: "/api/orders", TraceID: "trace-2", Timestamp: now},
+	}
+	for _, operation := range operations {
+		if err := database.InsertOperation(bm.GetDB(), operation); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"?trace_id=trace-1", []string{"callback", "request"}},
+		{"?trace_id=trace-1&type=async", []string{"callback"}},
+		{"?trace_id=missing", nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.query, w.Code, w.Body.String())
+		}
+
+		var records []map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", tt.query, err)
+		}
+		var uuids []string
+		for _, record := range records {
+			uuids = append(uuids, record["uuid"].(string))
+		}
+		sort.Strings(uuids)
+		if strings.Join(uuids, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.expected, uuids)
+		}
+	}
+}
+
+func TestGetDataTypeFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "type.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	now := time.Now()
+	operations := []*database.Mockdata{
+		{UUID: "request", RequestMethod: "POST", RequestEndpoint: "/api/orders",
+			TransactionType: database.TransactionTypeSync, Timestamp: now},
+		{UUID: "callback", RequestMethod: "POST", RequestEndpoint: "http://callback",
+			TransactionType: database.TransactionTypeAsync, Timestamp: now},
+		{UUID: "untyped", RequestMethod: "GET", RequestEndpoint: "/api/orders", Timestamp: now},
+	}
+	for _, operation := range operations {
+		if err := database.InsertOperation(bm.GetDB(), operation); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"callback", "request", "untyped"}},
+		{"?type=sync", []string{"request", "untyped"}},
+		{"?type=async", []string{"callback"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d: %s", tt.query, w.Code, w.Body.String())
+		}
+
+		var records []map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+			t.Fatalf("%q: failed to parse response: %v", tt.query, err)
+		}
+		var uuids []string
+		for _, record := range records {
+			uuids = append(uuids, record["uuid"].(string))
+		}
+		sort.Strings(uuids)
+		if strings.Join(uuids, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.expected, uuids)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data?type=batch", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid type: expected 400, got %d", w.Code)
+	}
+}
+
+func TestSearchDataTagFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "tags.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	now := time.Now()
+	operations := []*database.Mockdata{
+		{UUID: "payment", RequestMethod: "POST", RequestEndpoint: "/api/pay", RequestBody: `{"status":"error"}`, Timestamp: now,
+			Tags: map[string]string{"X-Test-Name": "PaymentFlowTest", "X-Test-Suite": "checkout"}},
+		{UUID: "refund", RequestMethod: "POST", RequestEndpoint: "/api/refund", Timestamp: now,
+			Tags: map[string]string{"X-Test-Name": "RefundFlowTest", "X-Test-Suite": "checkout"}},
+		{UUID: "untagged", RequestMethod: "GET", RequestEndpoint: "/api/pay", Timestamp: now},
+	}
+	for _, operation := range operations {
+		if err := database.InsertOperation(bm.GetDB(), operation); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+		bm.SearchIndex().Add(operation.UUID, operation.RequestBody)
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{BatchManager: bm, ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"?tag_key=X-Test-Name&tag_value=PaymentFlowTest", []string{"payment"}},
+		{"?tag_key=X-Test-Suite&tag_value=checkout", []string{"payment", "refund"}},
+		{"?tag_key=X-Test-Suite&tag_value=checkout&contains_body=error", []string{"payment"}},
+		{"?tag_key=X-Test-Name&tag_value=missing", nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data/search"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.query, w.Code, w.Body.String())
+		}
+
+		var records []map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+			t.Fatalf("%s: failed to parse response: %v", tt.query, err)
+		}
+		var uuids []string
+		for _, record := range records {
+			uuids = append(uuids, record["uuid"].(string))
+		}
+		sort.Strings(uuids)
+		if strings.Join(uuids, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.expected, uuids)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data/search?tag_key=X-Test-Name", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without tag_value, got %d", w.Code)
+	}
+}
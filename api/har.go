@@ -0,0 +1,323 @@
+package api
+
+import (
+	"catalyst/database"
+	"catalyst/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HAR 1.2 document, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of the exported data
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that created the log
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a recorded request/response pair
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest describes the recorded request
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse describes the recorded response
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARCookie is a request or response cookie. Cookies are not recorded, the lists are always empty.
+type HARCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARNameValue is a header or query string parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the body of the request
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the body of the response
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARTimings are the phases of the request. The database only keeps when it was received,
+// so the phases are zero.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harVersion is the HAR spec version of the export
+const harVersion = "1.2"
+
+// harCreatorName identifies the mock in the creator of the exported log; its version is the server version
+const harCreatorName = "catalyst"
+
+// ExportHAR handles GET /api/mock/export/har?server_name=foo&from=...&to=... - exports the requests
+// received by a server, optionally between two RFC 3339 times, as a HAR 1.2 document
+func (h *APIHandler) ExportHAR(c *gin.Context) {
+	if h.configs == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("HAR export not available"), http.StatusServiceUnavailable, "HAR export not available"))
+		return
+	}
+
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	from, err := parseTimeParam(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "from must be an RFC 3339 time"))
+		return
+	}
+	to, err := parseTimeParam(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "to must be an RFC 3339 time"))
+		return
+	}
+
+	server, ok := runningServer(h.configs, serverName)
+	if !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running: %s", serverName)))
+		return
+	}
+
+	if h.batchManager == nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	records, err := NewDatabaseService(h.batchManager).GetRecordsByType(database.TransactionTypeSync)
+	if err != nil {
+		log.Printf("ERROR: Failed to retrieve records for HAR export of server %s: %v", serverName, err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving data"))
+		return
+	}
+
+	har := buildHAR(server, records, from, to)
+	log.Printf("SUCCESS: Exported %d HAR entries for server: %s", len(har.Log.Entries), serverName)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.har"`, serverName))
+	c.JSON(http.StatusOK, har)
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter; a missing one is the zero time
+func parseTimeParam(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return parsed, nil
+}
+
+// runningServer finds the config of the named server within the running configs
+func runningServer(configs ConfigProvider, serverName string) (models.Server, bool) {
+	running, ok := configs.RunningConfig(serverName)
+	if !ok {
+		return models.Server{}, false
+	}
+	for _, server := range running.Http.Servers {
+		if server.Name != nil && strings.EqualFold(*server.Name, serverName) {
+			return server, true
+		}
+	}
+	return models.Server{}, false
+}
+
+// buildHAR converts the records served by the server locations within [from, to] into HAR
+// entries, oldest first. Mock servers share the database, so records are matched by location.
+func buildHAR(server models.Server, records []DatabaseRecord, from, to time.Time) HAR {
+	baseURL := serverBaseURL(server)
+
+	creator := HARCreator{Name: harCreatorName}
+	if server.Version != nil {
+		creator.Version = *server.Version
+	}
+
+	var served []DatabaseRecord
+	for _, record := range records {
+		if (!from.IsZero() && record.Timestamp.Before(from)) || (!to.IsZero() && record.Timestamp.After(to)) {
+			continue
+		}
+		if servesRecord(server.Location, record) {
+			served = append(served, record)
+		}
+	}
+	sort.SliceStable(served, func(i, j int) bool {
+		return served[i].Timestamp.Before(served[j].Timestamp)
+	})
+
+	entries := make([]HAREntry, 0, len(served))
+	for _, record := range served {
+		entries = append(entries, harEntry(baseURL, record))
+	}
+
+	return HAR{Log: HARLog{
+		Version: harVersion,
+		Creator: creator,
+		Entries: entries,
+	}}
+}
+
+// serverBaseURL is the local URL the recorded endpoints of the server are relative to
+func serverBaseURL(server models.Server) string {
+	scheme := "http"
+	if server.TLSCertFile != "" || server.TLSAuto {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d%s", scheme, server.Listen, strings.TrimRight(server.PathPrefix, "/"))
+}
+
+// servesRecord reports whether one of the locations matches the method and path of the record
+func servesRecord(locations []models.Location, record DatabaseRecord) bool {
+	for _, location := range locations {
+		if strings.EqualFold(location.Method, record.RequestMethod) && matchRoutePath(location.Path, record.RequestEndpoint) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoutePath matches a request path against a gin route, e.g. /users/:id or /static/*file
+func matchRoutePath(route, path string) bool {
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range routeSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if !strings.HasPrefix(segment, ":") && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(routeSegments) == len(pathSegments)
+}
+
+// harEntry converts a database record into a HAR entry
+func harEntry(baseURL string, record DatabaseRecord) HAREntry {
+	requestHeaders := harHeaders(record.RequestHeaders)
+	responseHeaders := harHeaders(record.ResponseHeaders)
+
+	request := HARRequest{
+		Method:      record.RequestMethod,
+		URL:         baseURL + record.RequestEndpoint,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARCookie{},
+		Headers:     requestHeaders,
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(record.RequestBody),
+	}
+	if record.RequestBody != "" {
+		request.PostData = &HARPostData{
+			MimeType: headerValue(requestHeaders, "Content-Type"),
+			Text:     record.RequestBody,
+		}
+	}
+
+	return HAREntry{
+		StartedDateTime: record.Timestamp.Format(time.RFC3339Nano),
+		Request:         request,
+		Response: HARResponse{
+			Status:      record.ResponseStatusCode,
+			StatusText:  http.StatusText(record.ResponseStatusCode),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARCookie{},
+			Headers:     responseHeaders,
+			Content: HARContent{
+				Size:     len(record.ResponseBody),
+				MimeType: headerValue(responseHeaders, "Content-Type"),
+				Text:     record.ResponseBody,
+			},
+			HeadersSize: -1,
+			BodySize:    len(record.ResponseBody),
+		},
+	}
+}
+
+// harHeaders converts headers stored as JSON (http.Header) into HAR name/value pairs, sorted by name
+func harHeaders(stored string) []HARNameValue {
+	headers := []HARNameValue{}
+	var parsed map[string][]string
+	if err := json.Unmarshal([]byte(stored), &parsed); err != nil {
+		return headers
+	}
+
+	for name, values := range parsed {
+		for _, value := range values {
+			headers = append(headers, HARNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool {
+		return headers[i].Name < headers[j].Name
+	})
+	return headers
+}
+
+// headerValue returns the first value of a header, case-insensitively
+func headerValue(headers []HARNameValue, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"catalyst/database"
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// harSchema is the HAR 1.2 spec written as a JSON schema, limited to the fields the export emits
+const harSchema = `{
+  "type": "object",
+  "required": ["log"],
+  "properties": {
+    "log": {
+      "type": "object",
+      "required": ["version", "creator", "entries"],
+      "properties": {
+        "version": { "type": "string" },
+        "creator": {
+          "type": "object",
+          "required": ["name", "version"],
+          "properties": { "name": { "type": "string" }, "version": { "type": "string" } }
+        },
+        "entries": { "type": "array", "items": { "$ref": "#/$defs/entry" } }
+      }
+    }
+  },
+  "$defs": {
+    "nameValue": {
+      "type": "object",
+      "required": ["name", "value"],
+      "properties": { "name": { "type": "string" }, "value": { "type": "string" } }
+    },
+    "nameValues": { "type": "array", "items": { "$ref": "#/$defs/nameValue" } },
+    "entry": {
+      "type": "object",
+      "required": ["startedDateTime", "time", "request", "response", "cache", "timings"],
+      "properties": {
+        "startedDateTime": { "type": "string", "format": "date-time" },
+        "time": { "type": "number", "minimum": 0 },
+        "request": {
+          "type": "object",
+          "required": ["method", "url", "httpVersion", "cookies", "headers", "queryString", "headersSize", "bodySize"],
+          "properties": {
+            "method": { "type": "string" },
+            "url": { "type": "string", "format": "uri" },
+            "httpVersion": { "type": "string" },
+            "cookies": { "$ref": "#/$defs/nameValues" },
+            "headers": { "$ref": "#/$defs/nameValues" },
+            "queryString": { "$ref": "#/$defs/nameValues" },
+            "postData": {
+              "type": "object",
+              "required": ["mimeType", "text"],
+              "properties": { "mimeType": { "type": "string" }, "text": { "type": "string" } }
+            },
+            "headersSize": { "type": "integer" },
+            "bodySize": { "type": "integer" }
+          }
+        },
+        "response": {
+          "type": "object",
+          "required": ["status", "statusText", "httpVersion", "cookies", "headers", "content", "redirectURL", "headersSize", "bodySize"],
+          "properties": {
+            "status": { "type": "integer" },
+            "statusText": { "type": "string" },
+            "httpVersion": { "type": "string" },
+            "cookies": { "$ref": "#/$defs/nameValues" },
+            "headers": { "$ref": "#/$defs/nameValues" },
+            "content": {
+              "type": "object",
+              "required": ["size", "mimeType"],
+              "properties": {
+                "size": { "type": "integer" },
+                "mimeType": { "type": "string" },
+                "text": { "type": "string" }
+              }
+            },
+            "redirectURL": { "type": "string" },
+            "headersSize": { "type": "integer" },
+            "bodySize": { "type": "integer" }
+          }
+        },
+        "cache": { "type": "object" },
+        "timings": {
+          "type": "object",
+          "required": ["send", "wait", "receive"],
+          "properties": {
+            "send": { "type": "number", "minimum": 0 },
+            "wait": { "type": "number", "minimum": 0 },
+            "receive": { "type": "number", "minimum": 0 }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestExportHAR(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "har.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	now := time.Now()
+	operations := []*database.Mockdata{
+		{UUID: "user", RequestMethod: "GET", RequestEndpoint: "/api/users/42", ResponseStatusCode: 200,
+			ResponseHeaders: `{"Content-Type":["application/json"]}`, ResponseBody: `{"id":42}`, Timestamp: now.Add(-time.Minute)},
+		{UUID: "order", RequestMethod: "POST", RequestEndpoint: "/api/orders", RequestHeaders: `{"Content-Type":["application/json"],"X-Tenant":["acme"]}`,
+			RequestBody: `{"sku":"A1"}`, ResponseStatusCode: 201, ResponseBody: `{}`, Timestamp: now},
+		{UUID: "other-server", RequestMethod: "GET", RequestEndpoint: "/api/other", ResponseStatusCode: 200, Timestamp: now},
+		{UUID: "async", RequestMethod: "POST", RequestEndpoint: "/api/orders", ResponseStatusCode: 200,
+			TransactionType: database.TransactionTypeAsync, Timestamp: now},
+		{UUID: "old", RequestMethod: "GET", RequestEndpoint: "/api/users/1", ResponseStatusCode: 404, Timestamp: now.Add(-48 * time.Hour)},
+	}
+	for _, operation := range operations {
+		if err := database.InsertOperation(bm.GetDB(), operation); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+	}
+
+	name := "foo"
+	version := "2.1.0"
+	provider := &fakeConfigProvider{configs: map[string]*models.MockServer{
+		"foo": {Http: models.Http{Servers: []models.Server{{
+			Listen:  9300,
+			Name:    &name,
+			Version: &version,
+			Location: []models.Location{
+				{Path: "/api/users/:id", Method: "GET", StatusCode: 200},
+				{Path: "/api/orders", Method: "POST", StatusCode: 201},
+			},
+		}}}},
+	}}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{
+		BatchManager: bm,
+		ConfigDir:    t.TempDir(),
+		RestartChan:  make(chan string, 1),
+		Configs:      provider,
+	})
+
+	from := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/export/har?server_name=foo&from="+from, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The document must validate against the HAR 1.2 schema
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	schemaDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader([]byte(harSchema)))
+	if err != nil {
+		t.Fatalf("Failed to parse HAR schema: %v", err)
+	}
+	if err := compiler.AddResource("har.json", schemaDoc); err != nil {
+		t.Fatalf("Failed to add HAR schema: %v", err)
+	}
+	schema, err := compiler.Compile("har.json")
+	if err != nil {
+		t.Fatalf("Failed to compile HAR schema: %v", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to parse HAR export: %v", err)
+	}
+	if err := schema.Validate(doc); err != nil {
+		t.Fatalf("HAR export does not validate against the HAR 1.2 schema: %v", err)
+	}
+
+	var har HAR
+	if err := json.Unmarshal(w.Body.Bytes(), &har); err != nil {
+		t.Fatalf("Failed to decode HAR export: %v", err)
+	}
+	if har.Log.Version != "1.2" || har.Log.Creator.Version != version {
+		t.Errorf("Unexpected log header: %+v", har.Log)
+	}
+	if len(har.Log.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(har.Log.Entries))
+	}
+
+	user, order := har.Log.Entries[0], har.Log.Entries[1]
+	if user.Request.URL != "http://localhost:9300/api/users/42" || user.Request.PostData != nil {
+		t.Errorf("Unexpected first entry request: %+v", user.Request)
+	}
+	if user.Response.Content.MimeType != "application/json" || user.Response.Content.Text != `{"id":42}` {
+		t.Errorf("Unexpected first entry content: %+v", user.Response.Content)
+	}
+	if order.Request.PostData == nil || order.Request.PostData.Text != `{"sku":"A1"}` || order.Request.PostData.MimeType != "application/json" {
+		t.Errorf("Unexpected second entry post data: %+v", order.Request.PostData)
+	}
+	if order.Response.Status != http.StatusCreated || order.Response.StatusText != "Created" {
+		t.Errorf("Unexpected second entry response: %+v", order.Response)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/export/har?server_name=bar", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a server that is not running, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/export/har?server_name=foo&to=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid time, got %d", w.Code)
+	}
+}
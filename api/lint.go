@@ -0,0 +1,36 @@
+package api
+
+import (
+	"catalyst/internal/config"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetConfigLint handles GET /api/mock/config/lint - reports non-fatal mistakes in the YAML on disk
+func (h *APIHandler) GetConfigLint(c *gin.Context) {
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	if serverName == "" {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidServer, http.StatusBadRequest, "server_name parameter is required"))
+		return
+	}
+
+	configService := NewConfigService(h.configDir)
+	mockServer, err := configService.LoadMockServer(serverName)
+	if err != nil {
+		log.Printf("ERROR: Failed to load config for server %s: %v", serverName, err)
+		if err == ErrConfigNotFound {
+			c.JSON(http.StatusNotFound, NewErrorResponse(err, http.StatusNotFound, fmt.Sprintf("Configuration file not found: %s", serverName)))
+		} else {
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving configuration"))
+		}
+		return
+	}
+
+	warnings := config.LintConfig(mockServer)
+	log.Printf("SUCCESS: Linted configuration for server %s, %d warnings", serverName, len(warnings))
+	c.JSON(http.StatusOK, warnings)
+}
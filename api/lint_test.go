@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"catalyst/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetConfigLint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configDir := t.TempDir()
+	yamlData := `http:
+  servers:
+    - listen: 9100
+      name: foo
+      location:
+        - path: /api/test
+          method: GET
+          response: '{"message":'
+          status_code: 200
+        - path: /api/test
+          method: GET
+          status_code: 200
+`
+	if err := os.WriteFile(filepath.Join(configDir, "foo.yaml"), []byte(yamlData), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: configDir, RestartChan: make(chan string, 1)})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/config/lint?server_name=foo", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var warnings []config.LintWarning
+	if err := json.Unmarshal(w.Body.Bytes(), &warnings); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Expected invalid JSON and duplicate route warnings, got %v", warnings)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/config/lint?server_name=missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown server, got %d", w.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"catalyst/internal/logger"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevelProvider reads and changes the log level of the running mock servers
+type LogLevelProvider interface {
+	// LogLevel returns the current level of the named server and whether it is running
+	LogLevel(serverName string) (string, bool)
+	// SetLogLevel changes the level of the named server from the next request on
+	SetLogLevel(serverName, level string) error
+}
+
+// LogLevelRequest is the body of PUT /api/mock/log-level
+type LogLevelRequest struct {
+	ServerName string `json:"server_name" binding:"required"`
+	Level      string `json:"level" binding:"required"`
+}
+
+// LogLevelResponse reports the log level of a server
+type LogLevelResponse struct {
+	ServerName string `json:"server_name"`
+	Level      string `json:"level"`
+}
+
+// GetLogLevel handles GET /api/mock/log-level - returns the current log level of a server
+func (h *APIHandler) GetLogLevel(c *gin.Context) {
+	if h.logLevels == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("log level control not available"), http.StatusServiceUnavailable, "Log level control not available"))
+		return
+	}
+
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	level, ok := h.logLevels.LogLevel(serverName)
+	if !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running: %s", serverName)))
+		return
+	}
+
+	c.JSON(http.StatusOK, LogLevelResponse{ServerName: serverName, Level: level})
+}
+
+// SetLogLevel handles PUT /api/mock/log-level - changes the log level of a server at runtime
+func (h *APIHandler) SetLogLevel(c *gin.Context) {
+	if h.logLevels == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("log level control not available"), http.StatusServiceUnavailable, "Log level control not available"))
+		return
+	}
+
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	level := strings.ToLower(strings.TrimSpace(req.Level))
+	if err := logger.ValidateLevel(level); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid log level"))
+		return
+	}
+
+	if _, ok := h.logLevels.LogLevel(req.ServerName); !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running: %s", req.ServerName)))
+		return
+	}
+
+	if err := h.logLevels.SetLogLevel(req.ServerName, level); err != nil {
+		log.Printf("ERROR: Failed to set log level for server %s: %v", req.ServerName, err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error changing log level"))
+		return
+	}
+
+	log.Printf("SUCCESS: Log level for server %s set to %s", req.ServerName, level)
+	c.JSON(http.StatusOK, LogLevelResponse{ServerName: req.ServerName, Level: level})
+}
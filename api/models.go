@@ -1,6 +1,7 @@
 package api
 
 import (
+	"catalyst/internal/models"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -139,7 +140,7 @@ type Error struct {
 // ServerLocation represents a server location configuration
 type ServerLocation struct {
 	Path            string           `yaml:"path" json:"path" validate:"required"`
-	Method          string           `yaml:"method" json:"method" validate:"required,oneof=GET POST PUT DELETE PATCH"`
+	Method          string           `yaml:"method" json:"method" validate:"required"`
 	Response        string           `yaml:"response" json:"response"`
 	StatusCode      int              `yaml:"status_code" json:"status_code" validate:"min=100,max=599"`
 	Headers         *Headers         `yaml:"headers" json:"headers"`
@@ -166,6 +167,8 @@ type HTTPConfig struct {
 
 // YamlConfig represents the complete YAML configuration structure
 type YamlConfig struct {
+	Name        string     `yaml:"name,omitempty" json:"name,omitempty"`
+	Version     string     `yaml:"version,omitempty" json:"version,omitempty"`
 	HTTP        HTTPConfig `yaml:"http" json:"http" validate:"required"`
 	TestSetting string     `yaml:"test_setting,omitempty" json:"test_setting,omitempty"`
 	RestartTest string     `yaml:"restart_test,omitempty" json:"restart_test,omitempty"`
@@ -225,7 +228,13 @@ type DatabaseRecord struct {
 	RequestBody        string    `json:"request_body"`
 	ResponseBody       string    `json:"response_body"`
 	ResponseStatusCode int       `json:"response_status_code" validate:"min=100,max=599"`
+	RequestHeaders     string    `json:"-"`
+	ResponseHeaders    string    `json:"-"`
+	TransactionType    string    `json:"transaction_type"`
+	TraceID            string    `json:"trace_id"`
 	Timestamp          time.Time `json:"timestamp" validate:"required"`
+
+	Tags map[string]string `json:"tags"`
 }
 
 // ToAPIFormat converts DatabaseRecord to API format with string timestamp
@@ -239,38 +248,32 @@ func (dr *DatabaseRecord) ToAPIFormat() map[string]interface{} {
 		"request_body":         dr.RequestBody,
 		"response_body":        dr.ResponseBody,
 		"response_status_code": dr.ResponseStatusCode,
+		"transaction_type":     dr.TransactionType,
+		"trace_id":             dr.TraceID,
+		"tags":                 dr.Tags,
 		"timestamp":            dr.Timestamp.Format("2006-01-02 15:04:05"),
 	}
 }
 
 // Error definitions for better error handling
 var (
-	ErrControllerClosed      = errors.New("controller is closed")
-	ErrChannelFull           = errors.New("restart channel is full")
-	ErrInvalidServer         = errors.New("invalid server name")
-	ErrConfigNotFound        = errors.New("configuration file not found")
-	ErrConfigInvalid         = errors.New("invalid configuration")
-	ErrManagerAlreadyRunning = errors.New("restart manager is already running")
+	ErrControllerClosed       = errors.New("controller is closed")
+	ErrChannelFull            = errors.New("restart channel is full")
+	ErrInvalidServer          = errors.New("invalid server name")
+	ErrConfigNotFound         = errors.New("configuration file not found")
+	ErrConfigInvalid          = errors.New("invalid configuration")
+	ErrManagerAlreadyRunning  = errors.New("restart manager is already running")
+	ErrInvalidTransactionType = errors.New("invalid transaction type")
+	ErrRecordNotFound         = errors.New("record not found")
+	ErrUnauthorized           = errors.New("unauthorized")
+	ErrInvalidToken           = errors.New("invalid token")
+	ErrTokenExpired           = errors.New("token expired")
+	ErrGroupNotFound          = errors.New("server group not found")
+	ErrUnknownState           = errors.New("unknown state")
 )
 
 // ValidationError represents a validation error with field details
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-	Value   string `json:"value,omitempty"`
-}
-
-func (ve ValidationError) Error() string {
-	return fmt.Sprintf("validation error for field '%s': %s", ve.Field, ve.Message)
-}
+type ValidationError = models.ValidationError
 
 // ValidationErrors represents multiple validation errors
-type ValidationErrors []ValidationError
-
-func (ve ValidationErrors) Error() string {
-	var messages []string
-	for _, err := range ve {
-		messages = append(messages, err.Error())
-	}
-	return strings.Join(messages, "; ")
-}
+type ValidationErrors = models.ValidationErrors
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PostgresConnectionProvider returns how to reach the started postgres servers
+type PostgresConnectionProvider interface {
+	// ConnectionStrings returns the connection string of each started server by configured port
+	ConnectionStrings() map[int]string
+}
+
+// GetPostgresConnectionStrings handles GET /api/mock/postgres/connection-strings - returns the
+// connection string of each started postgres server by configured port
+func (h *APIHandler) GetPostgresConnectionStrings(c *gin.Context) {
+	if h.postgres == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("postgres servers not available"), http.StatusServiceUnavailable, "Postgres servers not available"))
+		return
+	}
+
+	connections := h.postgres.ConnectionStrings()
+
+	// JSON object keys are strings, the configured port is kept as its decimal form
+	response := make(map[string]string, len(connections))
+	for port, connection := range connections {
+		response[strconv.Itoa(port)] = connection
+	}
+
+	log.Printf("SUCCESS: Retrieved %d postgres connection strings", len(response))
+	c.JSON(http.StatusOK, response)
+}
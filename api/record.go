@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReplayResult is the outcome of replaying a recorded transaction against its mock server
+type ReplayResult struct {
+	UUID               string `json:"uuid"`
+	URL                string `json:"url"`
+	Method             string `json:"method"`
+	StatusCode         int    `json:"status_code"`
+	OriginalStatusCode int    `json:"original_status_code"`
+	ResponseBody       string `json:"response_body"`
+}
+
+// GetRecord handles GET /api/mock/data/:uuid - retrieves a single transaction
+func (h *APIHandler) GetRecord(c *gin.Context) {
+	record, ok := h.lookupRecord(c)
+	if !ok {
+		return
+	}
+
+	log.Printf("SUCCESS: Retrieved record %s", record.UUID)
+	c.JSON(http.StatusOK, record.ToAPIFormat())
+}
+
+// ReplayRecord handles GET /api/mock/data/:uuid/replay?server_name=foo - sends the recorded request
+// again to the named running server and returns its response
+func (h *APIHandler) ReplayRecord(c *gin.Context) {
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	if serverName == "" {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidServer, http.StatusBadRequest, "server_name parameter is required"))
+		return
+	}
+	if h.configs == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("replay not available"), http.StatusServiceUnavailable, "Replay not available"))
+		return
+	}
+	server, ok := runningServer(h.configs, serverName)
+	if !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running: %s", serverName)))
+		return
+	}
+
+	record, ok := h.lookupRecord(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.replay(serverBaseURL(server), record)
+	if err != nil {
+		log.Printf("ERROR: Failed to replay record %s on server %s: %v", record.UUID, serverName, err)
+		c.JSON(http.StatusBadGateway, NewErrorResponse(err, http.StatusBadGateway, "Error replaying record"))
+		return
+	}
+
+	log.Printf("SUCCESS: Replayed record %s on server %s with status %d", record.UUID, serverName, result.StatusCode)
+	c.JSON(http.StatusOK, result)
+}
+
+// lookupRecord reads the record of the :uuid parameter, writing the error response when it fails
+func (h *APIHandler) lookupRecord(c *gin.Context) (*DatabaseRecord, bool) {
+	id := c.Param("uuid")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, fmt.Sprintf("Invalid UUID: %s", id)))
+		return nil, false
+	}
+
+	if h.batchManager == nil {
+		log.Printf("ERROR: Database not available for GET /api/mock/data/%s", id)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return nil, false
+	}
+
+	record, err := NewDatabaseService(h.batchManager).GetRecordByUUID(id)
+	if err == ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, NewErrorResponse(err, http.StatusNotFound, fmt.Sprintf("Record not found: %s", id)))
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to retrieve record %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving data"))
+		return nil, false
+	}
+	return record, true
+}
+
+// replay sends the recorded method, endpoint, headers and body to baseURL
+func (h *APIHandler) replay(baseURL string, record *DatabaseRecord) (*ReplayResult, error) {
+	url := baseURL + record.RequestEndpoint
+
+	var body io.Reader
+	if record.RequestBody != "" {
+		body = strings.NewReader(record.RequestBody)
+	}
+	req, err := http.NewRequest(record.RequestMethod, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating replay request: %w", err)
+	}
+
+	var headers map[string][]string
+	if err := json.Unmarshal([]byte(record.RequestHeaders), &headers); err == nil {
+		for name, values := range headers {
+			// The length is set from the replayed body
+			if strings.EqualFold(name, "Content-Length") {
+				continue
+			}
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+	}
+
+	client := &http.Client{Timeout: h.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending replay request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading replay response: %w", err)
+	}
+
+	return &ReplayResult{
+		UUID:               record.UUID,
+		URL:                url,
+		Method:             record.RequestMethod,
+		StatusCode:         resp.StatusCode,
+		OriginalStatusCode: record.ResponseStatusCode,
+		ResponseBody:       string(responseBody),
+	}, nil
+}
+
+// GetRecordByUUID retrieves the transaction with the given UUID, or ErrRecordNotFound
+func (ds *DatabaseService) GetRecordByUUID(id string) (*DatabaseRecord, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	rows, err := ds.batchManager.GetDB().Query(`SELECT uuid, recepcion_id, sender_id, request_headers, request_method,
+			  request_endpoint, request_body, response_headers, response_body,
+			  response_status_code, transaction_type, trace_id, timestamp, tags FROM mock_transactions WHERE uuid = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query database: %w", err)
+	}
+	defer rows.Close()
+
+	records, err := scanRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return &records[0], nil
+}
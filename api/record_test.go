@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"catalyst/database"
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const recordUUID = "3f2b8c1e-7a4d-4e2b-9c6f-1d2e3f4a5b6c"
+
+// newRecordRouter stores a recorded POST /api/orders and sets up the routes with the provider
+func newRecordRouter(t *testing.T, provider ConfigProvider) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "record.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	t.Cleanup(func() { bm.GetDB().Close() })
+
+	operation := &database.Mockdata{
+		UUID:               recordUUID,
+		RequestMethod:      "POST",
+		RequestEndpoint:    "/api/orders",
+		RequestHeaders:     `{"Content-Type":["application/json"],"X-Tenant":["acme"],"Content-Length":["12"]}`,
+		RequestBody:        `{"sku":"A1"}`,
+		ResponseStatusCode: 201,
+		ResponseBody:       `{"id":1}`,
+		Timestamp:          time.Now(),
+	}
+	if err := database.InsertOperation(bm.GetDB(), operation); err != nil {
+		t.Fatalf("InsertOperation failed: %v", err)
+	}
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{
+		BatchManager: bm,
+		ConfigDir:    t.TempDir(),
+		RestartChan:  make(chan string, 1),
+		Configs:      provider,
+	})
+	return router
+}
+
+func TestGetRecord(t *testing.T) {
+	router := newRecordRouter(t, nil)
+
+	tests := []struct {
+		name     string
+		uuid     string
+		expected int
+	}{
+		{"found", recordUUID, http.StatusOK},
+		{"not found", "00000000-0000-4000-8000-000000000000", http.StatusNotFound},
+		{"invalid uuid", "not-a-uuid", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data/"+tt.uuid, nil))
+			if w.Code != tt.expected {
+				t.Fatalf("Expected %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if tt.expected != http.StatusOK {
+				return
+			}
+
+			var record map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &record); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if record["uuid"] != recordUUID || record["request_endpoint"] != "/api/orders" {
+				t.Errorf("Expected the stored record, got %v", record)
+			}
+		})
+	}
+}
+
+func TestReplayRecord(t *testing.T) {
+	var received *http.Request
+	var receivedBody string
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received, receivedBody = r, string(body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":2}`))
+	}))
+	defer mock.Close()
+
+	_, port, _ := net.SplitHostPort(mock.Listener.Addr().String())
+	listen, _ := strconv.Atoi(port)
+	name := "orders"
+	provider := &fakeConfigProvider{configs: map[string]*models.MockServer{
+		"orders": {Http: models.Http{Servers: []models.Server{{Listen: listen, Name: &name}}}},
+	}}
+	router := newRecordRouter(t, provider)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/data/"+recordUUID+"/replay?server_name=orders", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result ReplayResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.StatusCode != http.StatusCreated || result.OriginalStatusCode != 201 || result.ResponseBody != `{"id":2}` {
+		t.Errorf("Unexpected replay result: %+v", result)
+	}
+	if received == nil || received.Method != "POST" || received.URL.Path != "/api/orders" {
+		t.Fatalf("Expected the recorded POST /api/orders to be replayed, got %v", received)
+	}
+	if receivedBody != `{"sku":"A1"}` || received.Header.Get("X-Tenant") != "acme" {
+		t.Errorf("Expected the recorded body and headers, got %s %v", receivedBody, received.Header)
+	}
+
+	// Unknown servers and records are rejected before anything is sent
+	for path, expected := range map[string]int{
+		"/api/mock/data/" + recordUUID + "/replay?server_name=missing":                  http.StatusNotFound,
+		"/api/mock/data/" + recordUUID + "/replay":                                      http.StatusBadRequest,
+		"/api/mock/data/00000000-0000-4000-8000-000000000000/replay?server_name=orders": http.StatusNotFound,
+		"/api/mock/data/not-a-uuid/replay?server_name=orders":                           http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != expected {
+			t.Errorf("%s: expected %d, got %d", path, expected, w.Code)
+		}
+	}
+}
@@ -2,15 +2,31 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	prom "catalyst/prometheus"
+
+	"github.com/gin-gonic/gin"
+)
+
+// restartHistorySize is the number of restart events kept by RestartManager
+const restartHistorySize = 50
+
+// Restart results, used as the result label of server_restart_total
+const (
+	RestartResultSuccess = "success"
+	RestartResultFailed  = "failed"
+	RestartResultTimeout = "timeout"
 )
 
 // RestartManager manages server restart operations with improved error handling and context support
 type RestartManager struct {
 	restartChan chan string
-	restartFunc func(string) error
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
@@ -19,6 +35,40 @@ type RestartManager struct {
 	timeout     time.Duration
 	retryCount  int
 	retryDelay  time.Duration
+	paused      atomic.Bool
+	queueMu     sync.Mutex
+	queued      []string
+	resumeChan  chan struct{}
+	historyMu   sync.Mutex
+	history     [restartHistorySize]RestartEvent
+	historyNext int
+	historyLen  int
+
+	// subscribers are called on every restart signal, the restartFunc of the constructor first
+	subscribersMu sync.RWMutex
+	subscribers   []restartSubscriber
+	nextID        uint64
+}
+
+// restartSubscriber is a handler of restart signals, identified to unsubscribe it
+type restartSubscriber struct {
+	id      uint64
+	handler func(string) error
+}
+
+// RestartEvent records the outcome of a processed restart signal
+type RestartEvent struct {
+	ServerName string    `json:"server_name"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Result     string    `json:"result"`
+	Attempt    int       `json:"attempt"` // Attempts made, retries included
+}
+
+// RestartStatus reports whether restarts are paused and which ones are waiting
+type RestartStatus struct {
+	Paused         bool     `json:"paused"`
+	QueuedRestarts []string `json:"queued_restarts"`
 }
 
 // RestartOptions configures the RestartManager behavior
@@ -46,16 +96,62 @@ func NewRestartManager(restartChan chan string, restartFunc func(string) error,
 		options = opts[0]
 	}
 
-	return &RestartManager{
+	rm := &RestartManager{
 		restartChan: restartChan,
-		restartFunc: restartFunc,
 		ctx:         ctx,
 		cancel:      cancel,
 		timeout:     options.Timeout,
 		retryCount:  options.RetryCount,
 		retryDelay:  options.RetryDelay,
 		running:     false,
+		resumeChan:  make(chan struct{}, 1),
+	}
+	if restartFunc != nil {
+		rm.subscribe(restartFunc)
+	}
+	return rm
+}
+
+// Subscribe registers an additional handler of restart signals, run concurrently with the
+// others on every restart. The returned function unsubscribes it.
+func (rm *RestartManager) Subscribe(handler func(serverName string)) func() {
+	return rm.subscribe(func(serverName string) error {
+		handler(serverName)
+		return nil
+	})
+}
+
+// subscribe adds a handler that can fail, and so be retried, and returns its unsubscribe function
+func (rm *RestartManager) subscribe(handler func(string) error) func() {
+	rm.subscribersMu.Lock()
+	defer rm.subscribersMu.Unlock()
+
+	id := rm.nextID
+	rm.nextID++
+	rm.subscribers = append(rm.subscribers, restartSubscriber{id: id, handler: handler})
+
+	return func() {
+		rm.subscribersMu.Lock()
+		defer rm.subscribersMu.Unlock()
+		for i, subscriber := range rm.subscribers {
+			if subscriber.id == id {
+				rm.subscribers = append(rm.subscribers[:i:i], rm.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// handlers returns the current subscribers, so they can change while a restart runs
+func (rm *RestartManager) handlers() []func(string) error {
+	rm.subscribersMu.RLock()
+	defer rm.subscribersMu.RUnlock()
+
+	handlers := make([]func(string) error, len(rm.subscribers))
+	for i, subscriber := range rm.subscribers {
+		handlers[i] = subscriber.handler
 	}
+	return handlers
 }
 
 // Start begins the restart manager
@@ -97,6 +193,59 @@ func (rm *RestartManager) IsRunning() bool {
 	return rm.running
 }
 
+// Pause buffers incoming restart signals instead of processing them, e.g. during a batch of
+// config updates. Signals for the same server are only kept once.
+func (rm *RestartManager) Pause() {
+	rm.paused.Store(true)
+	log.Printf("RestartManager: Paused")
+}
+
+// Resume processes the buffered restart signals and goes back to handling them as they arrive
+func (rm *RestartManager) Resume() {
+	rm.paused.Store(false)
+	log.Printf("RestartManager: Resumed")
+
+	// Wake up run() to drain the queue; a pending wake-up is enough
+	select {
+	case rm.resumeChan <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns the pause state and the restarts waiting for Resume
+func (rm *RestartManager) Status() RestartStatus {
+	rm.queueMu.Lock()
+	defer rm.queueMu.Unlock()
+
+	return RestartStatus{
+		Paused:         rm.paused.Load(),
+		QueuedRestarts: append([]string{}, rm.queued...),
+	}
+}
+
+// enqueue buffers a restart signal while paused, once per server
+func (rm *RestartManager) enqueue(serverName string) {
+	rm.queueMu.Lock()
+	defer rm.queueMu.Unlock()
+
+	for _, queued := range rm.queued {
+		if queued == serverName {
+			return
+		}
+	}
+	rm.queued = append(rm.queued, serverName)
+}
+
+// drainQueue returns the buffered restart signals and empties the queue
+func (rm *RestartManager) drainQueue() []string {
+	rm.queueMu.Lock()
+	defer rm.queueMu.Unlock()
+
+	queued := rm.queued
+	rm.queued = nil
+	return queued
+}
+
 // run is the main loop for processing restart signals
 func (rm *RestartManager) run() {
 	defer rm.wg.Done()
@@ -113,9 +262,21 @@ func (rm *RestartManager) run() {
 
 			log.Printf("RestartManager: Restart signal received for server: %s", serverName)
 
+			if rm.paused.Load() {
+				log.Printf("RestartManager: Paused, queuing restart for server: %s", serverName)
+				rm.enqueue(serverName)
+				continue
+			}
+
 			// Process restart with retry logic
 			rm.processRestart(serverName)
 
+		case <-rm.resumeChan:
+			for _, serverName := range rm.drainQueue() {
+				log.Printf("RestartManager: Processing queued restart for server: %s", serverName)
+				rm.processRestart(serverName)
+			}
+
 		case <-rm.ctx.Done():
 			log.Printf("RestartManager: Context cancelled, stopping")
 			return
@@ -123,25 +284,71 @@ func (rm *RestartManager) run() {
 	}
 }
 
-// processRestart handles a single restart request with retry logic
+// processRestart runs every subscriber concurrently for a single restart request, each with
+// its own retries and timeout, and records the result in the restart metrics and history. The
+// event fails or times out when any subscriber does.
 func (rm *RestartManager) processRestart(serverName string) {
+	event := RestartEvent{ServerName: serverName, StartedAt: time.Now(), Result: RestartResultSuccess}
+	defer func() { rm.recordRestart(event) }()
+
+	handlers := rm.handlers()
+	results := make([]RestartEvent, len(handlers))
+	var wg sync.WaitGroup
+	for i, handler := range handlers {
+		wg.Add(1)
+		go func(i int, handler func(string) error) {
+			defer wg.Done()
+			results[i] = rm.runHandler(serverName, handler)
+		}(i, handler)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Attempt > event.Attempt {
+			event.Attempt = result.Attempt
+		}
+		if result.Result == RestartResultTimeout || (result.Result == RestartResultFailed && event.Result == RestartResultSuccess) {
+			event.Result = result.Result
+		}
+	}
+}
+
+// runHandler runs a subscriber with retry logic. A subscriber still running when the timeout
+// expires is no longer waited for.
+func (rm *RestartManager) runHandler(serverName string, handler func(string) error) RestartEvent {
 	ctx, cancel := context.WithTimeout(rm.ctx, rm.timeout)
 	defer cancel()
 
+	var attempts atomic.Int32
+	done := make(chan string, 1)
+	go func() { done <- rm.retryHandler(ctx, serverName, handler, &attempts) }()
+
+	select {
+	case result := <-done:
+		return RestartEvent{Result: result, Attempt: int(attempts.Load())}
+	case <-ctx.Done():
+		log.Printf("RestartManager: Timeout waiting for restart of server: %s", serverName)
+		return RestartEvent{Result: RestartResultTimeout, Attempt: int(attempts.Load())}
+	}
+}
+
+// retryHandler calls the subscriber until it succeeds, the retries run out or ctx expires,
+// counting the attempts made
+func (rm *RestartManager) retryHandler(ctx context.Context, serverName string, handler func(string) error, attempts *atomic.Int32) string {
 	var lastErr error
 	for attempt := 1; attempt <= rm.retryCount; attempt++ {
 		select {
 		case <-ctx.Done():
-			log.Printf("RestartManager: Timeout waiting for restart of server: %s", serverName)
-			return
+			return RestartResultTimeout
 		default:
 		}
+		attempts.Store(int32(attempt))
 
 		// Add small delay before restart (as in original code)
 		time.Sleep(100 * time.Millisecond)
 
 		// Execute restart function
-		if err := rm.restartFunc(serverName); err != nil {
+		if err := handler(serverName); err != nil {
 			lastErr = err
 			log.Printf("RestartManager: Restart attempt %d failed for server %s: %v", attempt, serverName, err)
 
@@ -151,11 +358,42 @@ func (rm *RestartManager) processRestart(serverName string) {
 			}
 		} else {
 			log.Printf("RestartManager: Successfully restarted server: %s", serverName)
-			return
+			return RestartResultSuccess
 		}
 	}
 
 	log.Printf("RestartManager: All restart attempts failed for server %s: %v", serverName, lastErr)
+	return RestartResultFailed
+}
+
+// recordRestart emits the restart metrics and adds the event to the history, replacing the oldest one when full
+func (rm *RestartManager) recordRestart(event RestartEvent) {
+	duration := time.Since(event.StartedAt)
+	event.DurationMs = duration.Milliseconds()
+
+	prom.ServerRestartTotal.WithLabelValues(event.ServerName, event.Result).Inc()
+	prom.ServerRestartDuration.Observe(duration.Seconds())
+
+	rm.historyMu.Lock()
+	defer rm.historyMu.Unlock()
+
+	rm.history[rm.historyNext] = event
+	rm.historyNext = (rm.historyNext + 1) % restartHistorySize
+	if rm.historyLen < restartHistorySize {
+		rm.historyLen++
+	}
+}
+
+// History returns the last restart events, newest first
+func (rm *RestartManager) History() []RestartEvent {
+	rm.historyMu.Lock()
+	defer rm.historyMu.Unlock()
+
+	events := make([]RestartEvent, 0, rm.historyLen)
+	for i := 1; i <= rm.historyLen; i++ {
+		events = append(events, rm.history[(rm.historyNext-i+restartHistorySize)%restartHistorySize])
+	}
+	return events
 }
 
 // UpdateOptions updates the restart manager options
@@ -186,3 +424,48 @@ func (rm *RestartManager) GetStats() map[string]interface{} {
 		"retry_delay": rm.retryDelay.String(),
 	}
 }
+
+// GetRestartManagerStatus handles GET /api/mock/restart-manager/status
+func (h *APIHandler) GetRestartManagerStatus(c *gin.Context) {
+	if h.restartManager == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("restart manager not available"), http.StatusServiceUnavailable, "Restart manager not available"))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.restartManager.Status())
+}
+
+// GetRestartHistory handles GET /api/mock/restart-history - the last restarts, newest first
+func (h *APIHandler) GetRestartHistory(c *gin.Context) {
+	if h.restartManager == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("restart manager not available"), http.StatusServiceUnavailable, "Restart manager not available"))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.restartManager.History())
+}
+
+// PauseRestartManager handles POST /api/mock/restart-manager/pause - buffers restarts until resumed
+func (h *APIHandler) PauseRestartManager(c *gin.Context) {
+	if h.restartManager == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("restart manager not available"), http.StatusServiceUnavailable, "Restart manager not available"))
+		return
+	}
+
+	h.restartManager.Pause()
+	log.Printf("SUCCESS: Restart manager paused")
+	c.JSON(http.StatusOK, h.restartManager.Status())
+}
+
+// ResumeRestartManager handles POST /api/mock/restart-manager/resume - processes the buffered restarts
+func (h *APIHandler) ResumeRestartManager(c *gin.Context) {
+	if h.restartManager == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("restart manager not available"), http.StatusServiceUnavailable, "Restart manager not available"))
+		return
+	}
+
+	status := h.restartManager.Status()
+	h.restartManager.Resume()
+	log.Printf("SUCCESS: Restart manager resumed with %d queued restarts", len(status.QueuedRestarts))
+	c.JSON(http.StatusOK, RestartStatus{Paused: false, QueuedRestarts: status.QueuedRestarts})
+}
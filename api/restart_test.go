@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	prom "catalyst/prometheus"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestRestartManagerPauseResume(t *testing.T) {
+	restartChan := make(chan string, 10)
+	var restarts atomic.Int32
+	rm := NewRestartManager(restartChan, func(serverName string) error {
+		restarts.Add(1)
+		return nil
+	})
+
+	if err := rm.Start(); err != nil {
+		t.Fatalf("Failed to start restart manager: %v", err)
+	}
+	defer rm.Stop()
+
+	rm.Pause()
+	for i := 0; i < 5; i++ {
+		restartChan <- "foo"
+	}
+
+	// Wait until run() consumed every signal
+	deadline := time.Now().Add(2 * time.Second)
+	for len(restartChan) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	status := rm.Status()
+	if !status.Paused || len(status.QueuedRestarts) != 1 || status.QueuedRestarts[0] != "foo" {
+		t.Fatalf("Expected one queued restart for foo while paused, got %+v", status)
+	}
+	if restarts.Load() != 0 {
+		t.Fatalf("Expected no restarts while paused, got %d", restarts.Load())
+	}
+
+	rm.Resume()
+	deadline = time.Now().Add(2 * time.Second)
+	for restarts.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	if got := restarts.Load(); got != 1 {
+		t.Errorf("Expected exactly 1 restart after resume, got %d", got)
+	}
+	if status := rm.Status(); status.Paused || len(status.QueuedRestarts) != 0 {
+		t.Errorf("Expected an empty queue after resume, got %+v", status)
+	}
+}
+
+func TestRestartMetrics(t *testing.T) {
+	rm := NewRestartManager(make(chan string), func(serverName string) error {
+		if serverName == "metrics-ok" {
+			return nil
+		}
+		return errors.New("port in use")
+	}, &RestartOptions{Timeout: time.Second, RetryCount: 2, RetryDelay: 10 * time.Millisecond})
+
+	durationsBefore := restartDurationSamples(t)
+
+	rm.processRestart("metrics-ok")
+	rm.processRestart("metrics-ok")
+	rm.processRestart("metrics-broken")
+
+	// The first attempt already takes longer than the timeout, it is no longer waited for
+	rm.UpdateOptions(&RestartOptions{Timeout: 50 * time.Millisecond})
+	rm.processRestart("metrics-slow")
+
+	tests := []struct {
+		server, result string
+		expected       float64
+	}{
+		{"metrics-ok", RestartResultSuccess, 2},
+		{"metrics-ok", RestartResultFailed, 0},
+		{"metrics-broken", RestartResultFailed, 1},
+		{"metrics-slow", RestartResultTimeout, 1},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(prom.ServerRestartTotal.WithLabelValues(tt.server, tt.result)); got != tt.expected {
+			t.Errorf("server_restart_total{%s,%s} = %v, expected %v", tt.server, tt.result, got, tt.expected)
+		}
+	}
+	if durations := restartDurationSamples(t); durations-durationsBefore != 4 {
+		t.Errorf("Expected 4 restart durations observed, got %d", durations-durationsBefore)
+	}
+
+	history := rm.History()
+	if len(history) != 4 {
+		t.Fatalf("Expected 4 restart events, got %d", len(history))
+	}
+	expected := []RestartEvent{
+		{ServerName: "metrics-slow", Result: RestartResultTimeout, Attempt: 1},
+		{ServerName: "metrics-broken", Result: RestartResultFailed, Attempt: 2},
+		{ServerName: "metrics-ok", Result: RestartResultSuccess, Attempt: 1},
+		{ServerName: "metrics-ok", Result: RestartResultSuccess, Attempt: 1},
+	}
+	for i, event := range history {
+		if event.ServerName != expected[i].ServerName || event.Result != expected[i].Result || event.Attempt != expected[i].Attempt {
+			t.Errorf("Event %d: expected %+v, got %+v", i, expected[i], event)
+		}
+		// A timed out restart takes the 50ms timeout, the others at least the 100ms restart delay
+		minDurationMs := int64(100)
+		if event.Result == RestartResultTimeout {
+			minDurationMs = 50
+		}
+		if event.StartedAt.IsZero() || event.DurationMs < minDurationMs {
+			t.Errorf("Event %d: expected a start time and at least %dms, got %+v", i, minDurationMs, event)
+		}
+	}
+}
+
+func TestRestartSubscribers(t *testing.T) {
+	var restarts, notified, unsubscribed atomic.Int32
+	rm := NewRestartManager(make(chan string), func(string) error {
+		restarts.Add(1)
+		return nil
+	}, &RestartOptions{Timeout: time.Second, RetryCount: 1, RetryDelay: 10 * time.Millisecond})
+
+	received := make(chan string, 1)
+	rm.Subscribe(func(serverName string) {
+		notified.Add(1)
+		received <- serverName
+	})
+	unsubscribe := rm.Subscribe(func(string) { unsubscribed.Add(1) })
+	unsubscribe()
+
+	rm.processRestart("subscribers")
+
+	if restarts.Load() != 1 || notified.Load() != 1 {
+		t.Errorf("Expected the restart function and the subscriber called once, got %d and %d", restarts.Load(), notified.Load())
+	}
+	if serverName := <-received; serverName != "subscribers" {
+		t.Errorf("Expected the subscriber to receive subscribers, got %s", serverName)
+	}
+	if unsubscribed.Load() != 0 {
+		t.Errorf("Expected no call after unsubscribing, got %d", unsubscribed.Load())
+	}
+}
+
+func TestRestartSubscriberTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	rm := NewRestartManager(make(chan string), func(string) error { return nil },
+		&RestartOptions{Timeout: 300 * time.Millisecond, RetryCount: 1, RetryDelay: 10 * time.Millisecond})
+	rm.Subscribe(func(string) { <-release })
+
+	start := time.Now()
+	rm.processRestart("subscriber-hung")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected a hung subscriber to be abandoned after the timeout, took %v", elapsed)
+	}
+
+	if event := rm.History()[0]; event.ServerName != "subscriber-hung" || event.Result != RestartResultTimeout {
+		t.Errorf("Expected a timed out restart, got %+v", event)
+	}
+}
+
+func TestRestartHistoryRingBuffer(t *testing.T) {
+	rm := NewRestartManager(make(chan string), func(string) error { return nil })
+
+	for i := 0; i < restartHistorySize+5; i++ {
+		rm.recordRestart(RestartEvent{ServerName: fmt.Sprintf("history-%d", i), StartedAt: time.Now(), Result: RestartResultSuccess})
+	}
+
+	history := rm.History()
+	if len(history) != restartHistorySize {
+		t.Fatalf("Expected %d events, got %d", restartHistorySize, len(history))
+	}
+	if history[0].ServerName != "history-54" || history[len(history)-1].ServerName != "history-5" {
+		t.Errorf("Expected events history-54 to history-5, got %s to %s", history[0].ServerName, history[len(history)-1].ServerName)
+	}
+}
+
+func TestGetRestartHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rm := NewRestartManager(make(chan string), func(string) error { return nil })
+	rm.recordRestart(RestartEvent{ServerName: "foo", StartedAt: time.Now(), Result: RestartResultSuccess, Attempt: 1})
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartManager: rm})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/restart-history", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var events []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(events) != 1 || events[0]["server_name"] != "foo" || events[0]["result"] != "success" {
+		t.Fatalf("Unexpected history: %s", w.Body.String())
+	}
+	for _, field := range []string{"started_at", "duration_ms", "attempt"} {
+		if _, ok := events[0][field]; !ok {
+			t.Errorf("Expected field %s in %s", field, w.Body.String())
+		}
+	}
+}
+
+// restartDurationSamples returns the number of observations of server_restart_duration_seconds
+func restartDurationSamples(t *testing.T) uint64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := prom.ServerRestartDuration.Write(metric); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -29,7 +29,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -101,27 +101,40 @@ func NewRouteGroup(handler *APIHandler) *RouteGroup {
 	}
 }
 
-// SetupDataRoutes sets up data-related routes
-func (rg *RouteGroup) SetupDataRoutes(router *gin.RouterGroup) {
+// SetupDataRoutes sets up data-related routes; middleware runs before each of their handlers
+func (rg *RouteGroup) SetupDataRoutes(router *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	router = router.Group("", middleware...)
 	data := router.Group("/data")
 	{
 		data.GET("", rg.handler.GetData)
+		data.GET("/search", rg.handler.SearchData)
+		data.POST("/flush", rg.handler.FlushData)
+		data.POST("/delete", rg.handler.DeleteData)
+		data.GET("/:uuid", rg.handler.GetRecord)
+		data.GET("/:uuid/replay", rg.handler.ReplayRecord)
 	}
+	router.GET("/export/har", ValidateServerName(), rg.handler.ExportHAR)
+	router.GET("/batch-config", rg.handler.GetBatchConfig)
+	router.PUT("/batch-config", rg.handler.UpdateBatchConfig)
 }
 
-// SetupConfigRoutes sets up configuration-related routes
-func (rg *RouteGroup) SetupConfigRoutes(router *gin.RouterGroup) {
-	config := router.Group("/config")
+// SetupConfigRoutes sets up configuration-related routes; middleware runs before each of their handlers
+func (rg *RouteGroup) SetupConfigRoutes(router *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	config := router.Group("/config", middleware...)
 	{
 		config.GET("", ValidateServerName(), rg.handler.GetConfig)
 		config.PUT("", ValidateServerName(), rg.handler.UpdateConfig)
 		config.PUT("/yaml", rg.handler.UpdateConfigYaml)
+		config.GET("/diff", ValidateServerName(), rg.handler.GetConfigDiff)
+		config.GET("/lint", ValidateServerName(), rg.handler.GetConfigLint)
+		config.GET("/audit", ValidateServerName(), rg.handler.GetConfigAudit)
+		config.DELETE("/audit", rg.handler.DeleteConfigAudit)
 	}
 }
 
-// SetupHealthRoutes sets up health check routes
-func (rg *RouteGroup) SetupHealthRoutes(router *gin.RouterGroup) {
-	health := router.Group("/health")
+// SetupHealthRoutes sets up health check routes; middleware runs before each of their handlers
+func (rg *RouteGroup) SetupHealthRoutes(router *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	health := router.Group("/health", middleware...)
 	{
 		health.GET("", func(c *gin.Context) {
 			c.JSON(http.StatusOK, NewSuccessResponse(map[string]interface{}{
@@ -132,50 +145,221 @@ func (rg *RouteGroup) SetupHealthRoutes(router *gin.RouterGroup) {
 	}
 }
 
+// SetupChaosRoutes sets up chaos injection routes
+func (rg *RouteGroup) SetupChaosRoutes(router *gin.RouterGroup) {
+	router.GET("/chaos-history", rg.handler.GetChaosHistory)
+}
+
+// SetupCacheRoutes sets up response cache routes
+func (rg *RouteGroup) SetupCacheRoutes(router *gin.RouterGroup) {
+	router.GET("/cache-stats", rg.handler.GetCacheStats)
+}
+
+// SetupLogRoutes sets up runtime log level routes
+func (rg *RouteGroup) SetupLogRoutes(router *gin.RouterGroup) {
+	router.GET("/log-level", ValidateServerName(), rg.handler.GetLogLevel)
+	router.PUT("/log-level", rg.handler.SetLogLevel)
+}
+
+// SetupStateRoutes sets up the state machine routes
+func (rg *RouteGroup) SetupStateRoutes(router *gin.RouterGroup) {
+	router.GET("/state", ValidateServerName(), rg.handler.GetState)
+	router.PUT("/state", ValidateServerName(), rg.handler.SetState)
+}
+
+// SetupStatsRoutes sets up the in-memory request stats routes
+func (rg *RouteGroup) SetupStatsRoutes(router *gin.RouterGroup) {
+	stats := router.Group("/stats")
+	{
+		stats.GET("/realtime", rg.handler.GetRealtimeStats)
+		stats.DELETE("/realtime", rg.handler.ResetRealtimeStats)
+	}
+}
+
+// SetupServerRoutes sets up runtime server management routes
+func (rg *RouteGroup) SetupServerRoutes(router *gin.RouterGroup) {
+	servers := router.Group("/servers")
+	{
+		servers.GET("", rg.handler.ListServers)
+		servers.GET("/:port", rg.handler.GetServer)
+		servers.POST("/clone", rg.handler.CloneServer)
+	}
+}
+
+// SetupGroupRoutes sets up the server group routes
+func (rg *RouteGroup) SetupGroupRoutes(router *gin.RouterGroup) {
+	groups := router.Group("/groups")
+	{
+		groups.GET("", rg.handler.ListGroups)
+		groups.POST("/:name/start", rg.handler.StartGroup)
+		groups.POST("/:name/stop", rg.handler.StopGroup)
+	}
+}
+
+// SetupRestartRoutes sets up the restart manager routes
+func (rg *RouteGroup) SetupRestartRoutes(router *gin.RouterGroup) {
+	restart := router.Group("/restart-manager")
+	{
+		restart.GET("/status", rg.handler.GetRestartManagerStatus)
+		restart.POST("/pause", rg.handler.PauseRestartManager)
+		restart.POST("/resume", rg.handler.ResumeRestartManager)
+	}
+	router.GET("/restart-history", rg.handler.GetRestartHistory)
+}
+
+// SetupPostgresRoutes sets up the postgres seed and connection routes
+func (rg *RouteGroup) SetupPostgresRoutes(router *gin.RouterGroup) {
+	postgres := router.Group("/postgres")
+	{
+		postgres.POST("/seed/dry-run", rg.handler.SeedDryRun)
+		postgres.GET("/connection-strings", rg.handler.GetPostgresConnectionStrings)
+	}
+}
+
+// SetupSchemaRoutes sets up the JSON schema registry routes
+func (rg *RouteGroup) SetupSchemaRoutes(router *gin.RouterGroup) {
+	router.GET("/schemas", rg.handler.GetSchemas)
+}
+
+// SetupVersionRoutes sets up the build information route
+func (rg *RouteGroup) SetupVersionRoutes(router *gin.RouterGroup) {
+	router.GET("/version", rg.handler.GetVersion)
+}
+
+// ProbeProvider reports the server state used by the Kubernetes probes
+type ProbeProvider interface {
+	// NotReadyPorts returns the mock server ports that do not accept connections
+	NotReadyPorts() []int
+	// Started reports whether all servers completed their initial Start()
+	Started() bool
+}
+
+// SetupProbeRoutes sets up the Kubernetes probe endpoints. They are registered outside
+// /api/mock so they never require authentication.
+func SetupProbeRoutes(router *gin.Engine, batchManager *database.BatchManager, probes ProbeProvider) {
+	healthz := router.Group("/healthz")
+	{
+		// Liveness: the process is running
+		healthz.GET("/live", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "alive"})
+		})
+
+		// Readiness: every mock server is listening and the batch manager is running
+		healthz.GET("/ready", func(c *gin.Context) {
+			notReady := probes.NotReadyPorts()
+			batchRunning := batchManager != nil && batchManager.IsRunning()
+
+			if len(notReady) > 0 || !batchRunning {
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"not_ready":             notReady,
+					"batch_manager_running": batchRunning,
+				})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"status": "ready"})
+		})
+
+		// Startup: all servers completed their initial Start()
+		healthz.GET("/startup", func(c *gin.Context) {
+			if !probes.Started() {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"status": "starting"})
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"status": "started"})
+		})
+	}
+}
+
 // SetupRoutes sets up all API routes with middleware and proper organization
-func SetupRoutes(router *gin.Engine, batchManager *database.BatchManager, configDir string, restartChan chan string) {
+func SetupRoutes(router *gin.Engine, deps APIDependencies) {
 	// Add global middleware
 	router.Use(RequestLogger())
 	router.Use(CORSMiddleware())
 	router.Use(ErrorRecovery())
 
 	// Create API handler
-	apiHandler := NewAPIHandler(batchManager, configDir, restartChan)
+	apiHandler := NewAPIHandler(deps)
 	routeGroup := NewRouteGroup(apiHandler)
 
-	// Setup API routes
-	api := router.Group("/api/mock")
+	// Setup API routes, all of them behind authentication
+	api := router.Group("/api/mock", AuthMiddleware(deps.Auth))
 	{
 		routeGroup.SetupDataRoutes(api)
 		routeGroup.SetupConfigRoutes(api)
 		routeGroup.SetupHealthRoutes(api)
+		routeGroup.SetupChaosRoutes(api)
+		routeGroup.SetupCacheRoutes(api)
+		routeGroup.SetupLogRoutes(api)
+		routeGroup.SetupStatsRoutes(api)
+		routeGroup.SetupServerRoutes(api)
+		routeGroup.SetupGroupRoutes(api)
+		routeGroup.SetupStateRoutes(api)
+		routeGroup.SetupRestartRoutes(api)
+		routeGroup.SetupPostgresRoutes(api)
+		routeGroup.SetupSchemaRoutes(api)
+		routeGroup.SetupVersionRoutes(api)
 	}
 
 	log.Printf("API routes configured successfully")
 }
 
 // SetupRoutesWithOptions sets up routes with custom options
-func SetupRoutesWithOptions(router *gin.Engine, batchManager *database.BatchManager, configDir string, restartChan chan string, options *RouteOptions) {
+func SetupRoutesWithOptions(router *gin.Engine, deps APIDependencies, options *RouteOptions) {
 	// Add global middleware
 	router.Use(RequestLogger())
 	router.Use(CORSMiddleware())
 	router.Use(ErrorRecovery())
 
 	// Create API handler
-	apiHandler := NewAPIHandler(batchManager, configDir, restartChan)
+	apiHandler := NewAPIHandler(deps)
 	routeGroup := NewRouteGroup(apiHandler)
 
-	// Setup API routes
-	api := router.Group("/api/mock")
+	// Setup API routes, all of them behind authentication
+	api := router.Group("/api/mock", AuthMiddleware(deps.Auth))
 	{
 		if options.EnableDataRoutes {
-			routeGroup.SetupDataRoutes(api)
+			routeGroup.SetupDataRoutes(api, options.DataMiddleware...)
 		}
 		if options.EnableConfigRoutes {
-			routeGroup.SetupConfigRoutes(api)
+			routeGroup.SetupConfigRoutes(api, options.ConfigMiddleware...)
 		}
 		if options.EnableHealthRoutes {
-			routeGroup.SetupHealthRoutes(api)
+			routeGroup.SetupHealthRoutes(api, options.HealthMiddleware...)
+		}
+		if options.EnableChaosRoutes {
+			routeGroup.SetupChaosRoutes(api)
+		}
+		if options.EnableCacheRoutes {
+			routeGroup.SetupCacheRoutes(api)
+		}
+		if options.EnableLogRoutes {
+			routeGroup.SetupLogRoutes(api)
+		}
+		if options.EnableStatsRoutes {
+			routeGroup.SetupStatsRoutes(api)
+		}
+		if options.EnableServerRoutes {
+			routeGroup.SetupServerRoutes(api)
+		}
+		if options.EnableGroupRoutes {
+			routeGroup.SetupGroupRoutes(api)
+		}
+		if options.EnableStateRoutes {
+			routeGroup.SetupStateRoutes(api)
+		}
+		if options.EnableRestartRoutes {
+			routeGroup.SetupRestartRoutes(api)
+		}
+		if options.EnablePostgresRoutes {
+			routeGroup.SetupPostgresRoutes(api)
+		}
+		if options.EnableSchemaRoutes {
+			routeGroup.SetupSchemaRoutes(api)
+		}
+		if options.EnableVersionRoutes {
+			routeGroup.SetupVersionRoutes(api)
 		}
 	}
 
@@ -184,16 +368,44 @@ func SetupRoutesWithOptions(router *gin.Engine, batchManager *database.BatchMana
 
 // RouteOptions configures which route groups to enable
 type RouteOptions struct {
-	EnableDataRoutes   bool
-	EnableConfigRoutes bool
-	EnableHealthRoutes bool
+	EnableDataRoutes     bool
+	EnableConfigRoutes   bool
+	EnableHealthRoutes   bool
+	EnableChaosRoutes    bool
+	EnableCacheRoutes    bool
+	EnableLogRoutes      bool
+	EnableStatsRoutes    bool
+	EnableServerRoutes   bool
+	EnableGroupRoutes    bool
+	EnableStateRoutes    bool
+	EnableRestartRoutes  bool
+	EnablePostgresRoutes bool
+	EnableSchemaRoutes   bool
+	EnableVersionRoutes  bool
+
+	// Extra middleware run, after authentication, before the handlers of a route group,
+	// e.g. to add logging or metrics when embedding the API
+	DataMiddleware   []gin.HandlerFunc
+	ConfigMiddleware []gin.HandlerFunc
+	HealthMiddleware []gin.HandlerFunc
 }
 
 // DefaultRouteOptions returns default route options
 func DefaultRouteOptions() *RouteOptions {
 	return &RouteOptions{
-		EnableDataRoutes:   true,
-		EnableConfigRoutes: true,
-		EnableHealthRoutes: true,
+		EnableDataRoutes:     true,
+		EnableConfigRoutes:   true,
+		EnableHealthRoutes:   true,
+		EnableChaosRoutes:    true,
+		EnableCacheRoutes:    true,
+		EnableLogRoutes:      true,
+		EnableStatsRoutes:    true,
+		EnableServerRoutes:   true,
+		EnableGroupRoutes:    true,
+		EnableStateRoutes:    true,
+		EnableRestartRoutes:  true,
+		EnablePostgresRoutes: true,
+		EnableSchemaRoutes:   true,
+		EnableVersionRoutes:  true,
 	}
 }
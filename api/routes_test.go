@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRouteOptionsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The last middleware runs right before the handler and records what it will see
+	received := make(map[string]string)
+	record := func(group string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			received[group+" "+c.FullPath()] = c.GetString("tenant")
+			c.Next()
+		}
+	}
+	setTenant := func(tenant string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("tenant", tenant)
+			c.Next()
+		}
+	}
+
+	options := DefaultRouteOptions()
+	options.DataMiddleware = []gin.HandlerFunc{setTenant("data-tenant"), record("data")}
+	options.HealthMiddleware = []gin.HandlerFunc{setTenant("health-tenant"), record("health")}
+
+	router := gin.New()
+	SetupRoutesWithOptions(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)}, options)
+
+	for _, path := range []string{"/api/mock/health", "/api/mock/data", "/api/mock/batch-config", "/api/mock/chaos-history"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code == http.StatusNotFound {
+			t.Fatalf("Expected %s to be routed, got 404", path)
+		}
+	}
+
+	expected := map[string]string{
+		"health /api/mock/health":     "health-tenant",
+		"data /api/mock/data":         "data-tenant",
+		"data /api/mock/batch-config": "data-tenant",
+	}
+	if len(received) != len(expected) {
+		t.Errorf("Expected middleware to run for %v, ran for %v", expected, received)
+	}
+	for route, tenant := range expected {
+		if received[route] != tenant {
+			t.Errorf("Expected %s to receive tenant %q, got %q", route, tenant, received[route])
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocationSchema is the JSON schema a location validates its requests against
+type LocationSchema struct {
+	Server string
+	Path   string
+	Method string
+	Schema string
+}
+
+// SchemaProvider returns the JSON schemas of the running mock servers
+type SchemaProvider interface {
+	// Schemas returns the schema of every location that has one, sorted by server, path and method
+	Schemas() []LocationSchema
+}
+
+// SchemaSummary is an entry of GET /api/mock/schemas
+type SchemaSummary struct {
+	Server     string `json:"server"`
+	Path       string `json:"path"`
+	Method     string `json:"method"`
+	SchemaHash string `json:"schema_hash"`
+}
+
+// SchemaDetail is the response of GET /api/mock/schemas?path=/foo&method=POST
+type SchemaDetail struct {
+	SchemaSummary
+	Schema json.RawMessage `json:"schema"`
+}
+
+// GetSchemas handles GET /api/mock/schemas - lists the location schemas with their hash, or returns
+// the full schema of the location given by the path and method parameters. server_name narrows
+// the lookup when several servers define the location.
+func (h *APIHandler) GetSchemas(c *gin.Context) {
+	if h.schemas == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("schema registry not available"), http.StatusServiceUnavailable, "Schema registry not available"))
+		return
+	}
+
+	path := c.Query("path")
+	method := strings.ToUpper(c.Query("method"))
+	serverName := c.Query("server_name")
+
+	if path == "" && method == "" {
+		schemas := h.schemas.Schemas()
+		summaries := make([]SchemaSummary, 0, len(schemas))
+		for _, schema := range schemas {
+			if serverName != "" && schema.Server != serverName {
+				continue
+			}
+			summaries = append(summaries, schemaSummary(schema))
+		}
+		log.Printf("SUCCESS: Retrieved %d schemas", len(summaries))
+		c.JSON(http.StatusOK, NewSuccessResponse(summaries))
+		return
+	}
+	if path == "" || method == "" {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("path and method are required together"), http.StatusBadRequest, "Both path and method parameters are required"))
+		return
+	}
+
+	var matches []LocationSchema
+	for _, schema := range h.schemas.Schemas() {
+		if schema.Path == path && schema.Method == method && (serverName == "" || schema.Server == serverName) {
+			matches = append(matches, schema)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		c.JSON(http.StatusNotFound, NewErrorResponse(fmt.Errorf("schema not found"), http.StatusNotFound, fmt.Sprintf("No schema for %s %s", method, path)))
+		return
+	case len(matches) > 1:
+		c.JSON(http.StatusBadRequest, NewErrorResponse(ErrInvalidServer, http.StatusBadRequest, fmt.Sprintf("%s %s has a schema on several servers, server_name parameter is required", method, path)))
+		return
+	}
+
+	log.Printf("SUCCESS: Retrieved schema of %s %s on server %s", method, path, matches[0].Server)
+	c.JSON(http.StatusOK, NewSuccessResponse(SchemaDetail{
+		SchemaSummary: schemaSummary(matches[0]),
+		Schema:        json.RawMessage(matches[0].Schema),
+	}))
+}
+
+// schemaSummary describes the schema by the SHA-256 of its JSON
+func schemaSummary(schema LocationSchema) SchemaSummary {
+	sum := sha256.Sum256([]byte(schema.Schema))
+	return SchemaSummary{
+		Server:     schema.Server,
+		Path:       schema.Path,
+		Method:     schema.Method,
+		SchemaHash: hex.EncodeToString(sum[:]),
+	}
+}
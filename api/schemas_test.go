@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeSchemaProvider struct {
+	schemas []LocationSchema
+}
+
+func (p *fakeSchemaProvider) Schemas() []LocationSchema {
+	return p.schemas
+}
+
+func TestGetSchemas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	schemas := &fakeSchemaProvider{schemas: []LocationSchema{
+		{Server: "payments", Path: "/pay", Method: "POST", Schema: `{"type":"object"}`},
+		{Server: "refunds", Path: "/pay", Method: "POST", Schema: `{"type":"array"}`},
+		{Server: "refunds", Path: "/refund", Method: "POST", Schema: `{"type":"object"}`},
+	}}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1), Schemas: schemas})
+
+	tests := []struct {
+		name     string
+		url      string
+		expected int
+	}{
+		{"list", "/api/mock/schemas", http.StatusOK},
+		{"list of a server", "/api/mock/schemas?server_name=refunds", http.StatusOK},
+		{"single schema", "/api/mock/schemas?path=/refund&method=post", http.StatusOK},
+		{"schema on several servers", "/api/mock/schemas?path=/pay&method=POST", http.StatusBadRequest},
+		{"schema of a server", "/api/mock/schemas?path=/pay&method=POST&server_name=refunds", http.StatusOK},
+		{"missing method", "/api/mock/schemas?path=/pay", http.StatusBadRequest},
+		{"unknown location", "/api/mock/schemas?path=/unknown&method=GET", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"catalyst/database"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uuidQueryChunk bounds the number of bound parameters per IN query
+const uuidQueryChunk = 500
+
+// SearchData handles GET /api/mock/data/search?contains_body=error - finds the transactions whose
+// request or response body contains every word of the query, using the in-memory body index.
+// body_field_name and body_field_value filter by a field listed in indexed_body_fields instead, or as well,
+// and tag_key and tag_value by a tag recorded from the tag_headers of the location.
+func (h *APIHandler) SearchData(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("contains_body"))
+	fieldName := strings.TrimSpace(c.Query("body_field_name"))
+	fieldValue, hasFieldValue := c.GetQuery("body_field_value")
+	tagKey := strings.TrimSpace(c.Query("tag_key"))
+	tagValue, hasTagValue := c.GetQuery("tag_value")
+
+	if query == "" && fieldName == "" && tagKey == "" {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("missing contains_body"), http.StatusBadRequest, "contains_body, body_field_name or tag_key parameter is required"))
+		return
+	}
+	if fieldName != "" && !hasFieldValue {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("missing body_field_value"), http.StatusBadRequest, "body_field_value parameter is required with body_field_name"))
+		return
+	}
+	if tagKey != "" && !hasTagValue {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("missing tag_value"), http.StatusBadRequest, "tag_value parameter is required with tag_key"))
+		return
+	}
+	// The key is quoted in the json_extract path
+	if strings.ContainsAny(tagKey, `"\`) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid tag_key %q", tagKey), http.StatusBadRequest, "Invalid tag_key"))
+		return
+	}
+
+	if h.batchManager == nil {
+		log.Printf("ERROR: Database not available for GET /api/mock/data/search")
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(ErrConfigNotFound, http.StatusInternalServerError, "Database not available"))
+		return
+	}
+
+	ds := NewDatabaseService(h.batchManager)
+	var (
+		records []DatabaseRecord
+		err     error
+	)
+	if fieldName != "" {
+		if _, err := database.BodyFieldColumn(fieldName); err != nil {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid body_field_name"))
+			return
+		}
+		var indexed bool
+		if indexed, err = h.batchManager.HasIndexedBodyField(fieldName); err != nil {
+			log.Printf("ERROR: Failed to check indexed body field %s: %v", fieldName, err)
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving data"))
+			return
+		}
+		if !indexed {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Errorf("body field %s is not indexed", fieldName), http.StatusBadRequest, "body_field_name must be listed in indexed_body_fields"))
+			return
+		}
+
+		records, err = ds.GetRecordsByBodyField(fieldName, fieldValue)
+		if err == nil && query != "" {
+			records = filterRecordsByUUID(records, h.batchManager.SearchIndex().Search(query))
+		}
+	} else if query != "" {
+		records, err = ds.GetRecordsByUUIDs(h.batchManager.SearchIndex().Search(query))
+	} else {
+		records, err = ds.GetRecordsByTag(tagKey, tagValue)
+	}
+	if err == nil && tagKey != "" && (fieldName != "" || query != "") {
+		records = filterRecordsByTag(records, tagKey, tagValue)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to retrieve search results from database: %v", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error retrieving data"))
+		return
+	}
+
+	apiRecords := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		apiRecords = append(apiRecords, record.ToAPIFormat())
+	}
+
+	log.Printf("SUCCESS: Found %d records for search contains_body=%q body_field_name=%q tag_key=%q", len(apiRecords), query, fieldName, tagKey)
+	c.JSON(http.StatusOK, apiRecords)
+}
+
+// filterRecordsByUUID keeps the records whose UUID is in uuids
+func filterRecordsByUUID(records []DatabaseRecord, uuids []string) []DatabaseRecord {
+	keep := make(map[string]struct{}, len(uuids))
+	for _, uuid := range uuids {
+		keep[uuid] = struct{}{}
+	}
+
+	filtered := make([]DatabaseRecord, 0, len(records))
+	for _, record := range records {
+		if _, ok := keep[record.UUID]; ok {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// filterRecordsByTag keeps the records tagged with key set to value
+func filterRecordsByTag(records []DatabaseRecord, key, value string) []DatabaseRecord {
+	filtered := make([]DatabaseRecord, 0, len(records))
+	for _, record := range records {
+		if tag, ok := record.Tags[key]; ok && tag == value {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// GetRecordsByTag retrieves the records whose tag key equals value, newest first
+func (ds *DatabaseService) GetRecordsByTag(key, value string) ([]DatabaseRecord, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	// The key is bound inside a quoted JSON path, so keys with - or . match as a whole
+	query := `SELECT uuid, recepcion_id, sender_id, request_headers, request_method,
+		  request_endpoint, request_body, response_headers, response_body,
+		  response_status_code, transaction_type, trace_id, timestamp, tags FROM mock_transactions
+		  WHERE json_extract(tags, ?) = ? ORDER BY timestamp DESC`
+
+	rows, err := ds.batchManager.GetDB().Query(query, `$."`+key+`"`, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query database: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRecords(rows)
+}
+
+// GetRecordsByBodyField retrieves the records whose indexed request body field equals value, newest first
+func (ds *DatabaseService) GetRecordsByBodyField(field, value string) ([]DatabaseRecord, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	// The column name is derived from a validated identifier, the value is bound
+	column, err := database.BodyFieldColumn(field)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT uuid, recepcion_id, sender_id, request_headers, request_method,
+		  request_endpoint, request_body, response_headers, response_body,
+		  response_status_code, transaction_type, trace_id, timestamp, tags FROM mock_transactions
+		  WHERE ` + column + ` = ? ORDER BY timestamp DESC`
+
+	rows, err := ds.batchManager.GetDB().Query(query, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query database: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRecords(rows)
+}
+
+// GetRecordsByUUIDs retrieves the records with the given UUIDs, newest first
+func (ds *DatabaseService) GetRecordsByUUIDs(uuids []string) ([]DatabaseRecord, error) {
+	if ds.batchManager == nil || ds.batchManager.GetDB() == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	db := ds.batchManager.GetDB()
+	var records []DatabaseRecord
+	for start := 0; start < len(uuids); start += uuidQueryChunk {
+		end := start + uuidQueryChunk
+		if end > len(uuids) {
+			end = len(uuids)
+		}
+		chunk := uuids[start:end]
+
+		args := make([]interface{}, len(chunk))
+		for i, uuid := range chunk {
+			args[i] = uuid
+		}
+		query := `SELECT uuid, recepcion_id, sender_id, request_headers, request_method,
+			  request_endpoint, request_body, response_headers, response_body,
+			  response_status_code, transaction_type, trace_id, timestamp, tags FROM mock_transactions
+			  WHERE uuid IN (?` + strings.Repeat(",?", len(chunk)-1) + `)`
+
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query database: %w", err)
+		}
+		chunkRecords, err := scanRecords(rows)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, chunkRecords...)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Timestamp.After(records[j].Timestamp)
+	})
+	return records, nil
+}
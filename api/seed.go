@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"catalyst/internal/models"
+	"catalyst/internal/postgres/seeder"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDryRunRows limits the statements generated by a seed dry run
+const maxDryRunRows = 10000
+
+// SeedDryRun handles POST /api/mock/postgres/seed/dry-run - returns the INSERT statements a seed
+// would run, without a database. The columns come from the seed columns, or the predefined ones.
+func (h *APIHandler) SeedDryRun(c *gin.Context) {
+	var seed models.Seed
+	if err := c.ShouldBindJSON(&seed); err != nil {
+		log.Printf("ERROR: Invalid seed dry run request: %v", err)
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid seed"))
+		return
+	}
+
+	if seed.Table == "" || seed.Schema == "" {
+		err := fmt.Errorf("table and schema are required")
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, err.Error()))
+		return
+	}
+	if seed.Rows <= 0 || seed.Rows > maxDryRunRows {
+		err := fmt.Errorf("rows must be between 1 and %d", maxDryRunRows)
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	statements, err := (&seeder.MigrationService{}).DryRun(c.Request.Context(), seed)
+	if err != nil {
+		log.Printf("ERROR: Seed dry run for %s.%s failed: %v", seed.Schema, seed.Table, err)
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Failed to generate seed statements"))
+		return
+	}
+
+	log.Printf("SUCCESS: Generated %d seed statements for %s.%s", len(statements), seed.Schema, seed.Table)
+	c.JSON(http.StatusOK, NewSuccessResponse(gin.H{
+		"statements": statements,
+		"count":      len(statements),
+	}))
+}
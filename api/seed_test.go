@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSeedDryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1)})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/mock/postgres/seed/dry-run", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"schema":"public","table":"users","rows":3,"overrides":[{"column":"username","value":"admin"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			Statements []string `json:"statements"`
+			Count      int      `json:"count"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Data.Count != 3 || len(resp.Data.Statements) != 3 {
+		t.Fatalf("Expected 3 statements, got %d", len(resp.Data.Statements))
+	}
+	for _, statement := range resp.Data.Statements {
+		if !strings.HasPrefix(statement, "INSERT INTO public.users (username, email, password, created_at, updated_at) VALUES ('admin', ") {
+			t.Errorf("Unexpected statement: %s", statement)
+		}
+	}
+
+	for _, body := range []string{
+		`{"table":"users","rows":3}`,
+		`{"schema":"public","table":"users","rows":0}`,
+		`{"schema":"public","table":"users","rows":10001}`,
+		`{"schema":"public","table":"users","rows":1,"csv_file":"/nonexistent/users.csv"}`,
+		`not json`,
+	} {
+		if w := post(body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server statuses reported by GET /api/mock/servers
+const (
+	ServerStatusRunning = "running"
+	ServerStatusStopped = "stopped"
+)
+
+// ServerInfo is the status of a mock server
+type ServerInfo struct {
+	Port          int        `json:"port"`
+	Name          string     `json:"name"`
+	Status        string     `json:"status"`
+	LocationCount int        `json:"location_count"`
+	RequestTotal  int64      `json:"request_total"`
+	ErrorTotal    int64      `json:"error_total"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+}
+
+// ServerLister lists the mock servers with their status
+type ServerLister interface {
+	// Servers returns every mock server, sorted by port
+	Servers() []ServerInfo
+	// Server returns the mock server listening on port
+	Server(port int) (ServerInfo, bool)
+}
+
+// ListServers handles GET /api/mock/servers - lists the mock servers and their status
+func (h *APIHandler) ListServers(c *gin.Context) {
+	if h.servers == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("server list not available"), http.StatusServiceUnavailable, "Server list not available"))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewSuccessResponse(h.servers.Servers()))
+}
+
+// GetServer handles GET /api/mock/servers/:port - the status of a single mock server
+func (h *APIHandler) GetServer(c *gin.Context) {
+	if h.servers == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("server list not available"), http.StatusServiceUnavailable, "Server list not available"))
+		return
+	}
+
+	port, err := strconv.Atoi(c.Param("port"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "port must be a number"))
+		return
+	}
+
+	server, ok := h.servers.Server(port)
+	if !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("No server on port %d", port)))
+		return
+	}
+	c.JSON(http.StatusOK, NewSuccessResponse(server))
+}
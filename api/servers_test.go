@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeServerLister struct {
+	servers []ServerInfo
+}
+
+func (l *fakeServerLister) Servers() []ServerInfo {
+	return l.servers
+}
+
+func (l *fakeServerLister) Server(port int) (ServerInfo, bool) {
+	for _, server := range l.servers {
+		if server.Port == port {
+			return server, true
+		}
+	}
+	return ServerInfo{}, false
+}
+
+func TestServerRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	startedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	lister := &fakeServerLister{servers: []ServerInfo{
+		{Port: 8080, Name: "payments", Status: ServerStatusRunning, LocationCount: 3, RequestTotal: 42, ErrorTotal: 2, StartedAt: &startedAt},
+		{Port: 8081, Name: "refunds", Status: ServerStatusStopped, LocationCount: 1},
+	}}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1), Servers: lister})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/servers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode servers: %v", err)
+	}
+	if len(list.Data) != 2 {
+		t.Fatalf("Expected 2 servers, got %d", len(list.Data))
+	}
+	for _, field := range []string{"port", "name", "status", "location_count", "request_total", "error_total", "started_at"} {
+		if _, ok := list.Data[0][field]; !ok {
+			t.Errorf("Expected field %s in %v", field, list.Data[0])
+		}
+	}
+	if _, ok := list.Data[1]["started_at"]; ok {
+		t.Errorf("Expected no started_at for a stopped server, got %v", list.Data[1])
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/servers/8080", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a single server, got %d: %s", w.Code, w.Body.String())
+	}
+	var single struct {
+		Data ServerInfo `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &single); err != nil {
+		t.Fatalf("Failed to decode server: %v", err)
+	}
+	if single.Data.Name != "payments" || single.Data.RequestTotal != 42 || single.Data.ErrorTotal != 2 {
+		t.Errorf("Unexpected server %+v", single.Data)
+	}
+
+	for path, code := range map[string]int{
+		"/api/mock/servers/9999": http.StatusNotFound,
+		"/api/mock/servers/abc":  http.StatusBadRequest,
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != code {
+			t.Errorf("Expected %d for %s, got %d", code, path, w.Code)
+		}
+	}
+}
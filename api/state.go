@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StateProvider reads and changes the state machine of the running mock servers
+type StateProvider interface {
+	// State returns the state machine of the named server and whether the server is running with one
+	State(serverName string) (ServerState, bool)
+	// SetState moves the named server to state; an empty state resets it to the initial state.
+	// Returns ErrUnknownState when the state is not configured.
+	SetState(serverName, state string) error
+}
+
+// ServerState reports the state machine of a server
+type ServerState struct {
+	ServerName   string   `json:"server_name"`
+	State        string   `json:"state"`
+	InitialState string   `json:"initial_state"`
+	States       []string `json:"states"`
+}
+
+// GetState handles GET /api/mock/state - returns the current state of a server
+func (h *APIHandler) GetState(c *gin.Context) {
+	if h.states == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("state machine control not available"), http.StatusServiceUnavailable, "State machine control not available"))
+		return
+	}
+
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	state, ok := h.states.State(serverName)
+	if !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running with a state machine: %s", serverName)))
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// SetState handles PUT /api/mock/state?server_name=foo&state=pending - moves a server to a state,
+// or back to its initial state when state is omitted
+func (h *APIHandler) SetState(c *gin.Context) {
+	if h.states == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("state machine control not available"), http.StatusServiceUnavailable, "State machine control not available"))
+		return
+	}
+
+	serverName := strings.TrimSpace(c.Query("server_name"))
+	if _, ok := h.states.State(serverName); !ok {
+		c.JSON(http.StatusNotFound, NewErrorResponse(ErrInvalidServer, http.StatusNotFound, fmt.Sprintf("Server not running with a state machine: %s", serverName)))
+		return
+	}
+
+	if err := h.states.SetState(serverName, strings.TrimSpace(c.Query("state"))); err != nil {
+		if errors.Is(err, ErrUnknownState) {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(err, http.StatusBadRequest, "Invalid state"))
+			return
+		}
+		log.Printf("ERROR: Failed to set state for server %s: %v", serverName, err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(err, http.StatusInternalServerError, "Error changing state"))
+		return
+	}
+
+	state, _ := h.states.State(serverName)
+	log.Printf("SUCCESS: State for server %s set to %s", serverName, state.State)
+	c.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeStateProvider struct {
+	state ServerState
+}
+
+func (p *fakeStateProvider) State(serverName string) (ServerState, bool) {
+	return p.state, serverName == p.state.ServerName
+}
+
+func (p *fakeStateProvider) SetState(serverName, state string) error {
+	if state == "" {
+		state = p.state.InitialState
+	}
+	for _, configured := range p.state.States {
+		if configured == state {
+			p.state.State = state
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownState, state)
+}
+
+func TestStateRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	states := &fakeStateProvider{state: ServerState{ServerName: "foo", State: "failed", InitialState: "pending", States: []string{"pending", "failed"}}}
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1), States: states})
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		expected int
+		state    string
+	}{
+		{"get current state", "GET", "/api/mock/state?server_name=foo", http.StatusOK, "failed"},
+		{"reset to the initial state", "PUT", "/api/mock/state?server_name=foo", http.StatusOK, "pending"},
+		{"move to a state", "PUT", "/api/mock/state?server_name=foo&state=failed", http.StatusOK, "failed"},
+		{"unknown state", "PUT", "/api/mock/state?server_name=foo&state=approved", http.StatusBadRequest, ""},
+		{"unknown server", "PUT", "/api/mock/state?server_name=bar", http.StatusNotFound, ""},
+		{"missing server_name", "GET", "/api/mock/state", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+			if w.Code != tt.expected {
+				t.Fatalf("Expected %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if tt.state == "" {
+				return
+			}
+
+			var state ServerState
+			if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+				t.Fatalf("Failed to decode state: %v", err)
+			}
+			if state.State != tt.state {
+				t.Errorf("Expected state %s, got %s", tt.state, state.State)
+			}
+		})
+	}
+}
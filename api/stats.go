@@ -0,0 +1,45 @@
+package api
+
+import (
+	"catalyst/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RealtimeStatsProvider exposes the in-memory request counters of the mock servers
+type RealtimeStatsProvider interface {
+	// RealtimeStats returns the request counters per method and path
+	RealtimeStats() []models.RequestCounter
+	// ResetRealtimeStats sets every counter back to zero
+	ResetRealtimeStats()
+}
+
+// RealtimeStatsResponse is the body of GET /api/mock/stats/realtime
+type RealtimeStatsResponse struct {
+	Counters []models.RequestCounter `json:"counters"`
+}
+
+// GetRealtimeStats handles GET /api/mock/stats/realtime - request counters without querying the database
+func (h *APIHandler) GetRealtimeStats(c *gin.Context) {
+	if h.realtimeStats == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("realtime stats not available"), http.StatusServiceUnavailable, "Realtime stats not available"))
+		return
+	}
+
+	c.JSON(http.StatusOK, RealtimeStatsResponse{Counters: h.realtimeStats.RealtimeStats()})
+}
+
+// ResetRealtimeStats handles DELETE /api/mock/stats/realtime - resets the request counters
+func (h *APIHandler) ResetRealtimeStats(c *gin.Context) {
+	if h.realtimeStats == nil {
+		c.JSON(http.StatusServiceUnavailable, NewErrorResponse(fmt.Errorf("realtime stats not available"), http.StatusServiceUnavailable, "Realtime stats not available"))
+		return
+	}
+
+	h.realtimeStats.ResetRealtimeStats()
+	log.Printf("SUCCESS: Realtime stats reset")
+	c.JSON(http.StatusOK, NewSuccessResponse(nil, "Realtime stats reset"))
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildInfo describes the running binary; Version and BuildTime are injected with -ldflags at build time
+type BuildInfo struct {
+	Version   string `json:"version"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// GetVersion handles GET /api/mock/version - returns the build information of the running binary
+func (h *APIHandler) GetVersion(c *gin.Context) {
+	info := h.buildInfo
+	info.GoVersion = runtime.Version()
+	c.JSON(http.StatusOK, info)
+}
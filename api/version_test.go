@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVersionRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	SetupRoutes(router, APIDependencies{
+		Auth:      AuthConfig{APIKey: "secret"},
+		BuildInfo: BuildInfo{Version: "1.2.3", BuildTime: "2024-05-01T10:00:00Z"},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/version", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without API key, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/mock/version", nil)
+	req.Header.Set("X-API-Key", "secret")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var info BuildInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	expected := BuildInfo{Version: "1.2.3", BuildTime: "2024-05-01T10:00:00Z", GoVersion: runtime.Version()}
+	if info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, info)
+	}
+}
@@ -17,6 +17,7 @@ func main() {
 	// Parse command line flags
 	configDir := flag.String("config", "", "Directory containing YAML configuration files")
 	configFile := flag.String("file", "", "Path to a specific YAML configuration file")
+	configGlob := flag.String("glob", "", "Glob pattern matching YAML configuration files (e.g. ./configs/*/server.yaml)")
 	flag.Parse()
 
 	// Determine configuration source
@@ -32,6 +33,12 @@ func main() {
 			log.Fatalf("Error loading configuration file: %v", err)
 		}
 		configs = []*models.MockServer{cfg}
+	} else if *configGlob != "" {
+		// Load all configuration files matching the glob pattern
+		configs, err = config.LoadConfigFromGlob(*configGlob)
+		if err != nil {
+			log.Fatalf("Error loading configuration files: %v", err)
+		}
 	} else {
 		// Load all configuration files from directory
 		dir := *configDir
@@ -16,6 +16,7 @@ type ServerManager struct {
 	manager    *server.Manager
 	configDir  string
 	configFile string
+	configGlob string
 }
 
 // Multiport creates a new server manager
@@ -35,6 +36,11 @@ func (sm *ServerManager) SetConfigFile(file string) {
 	sm.configFile = file
 }
 
+// SetConfigGlob sets a glob pattern matching configuration files
+func (sm *ServerManager) SetConfigGlob(pattern string) {
+	sm.configGlob = pattern
+}
+
 // StartAll starts all servers
 func (sm *ServerManager) StartAll() error {
 	// Determine configuration source
@@ -50,6 +56,12 @@ func (sm *ServerManager) StartAll() error {
 			return err
 		}
 		configs = []*models.MockServer{cfg}
+	} else if sm.configGlob != "" {
+		// Load all configuration files matching the glob pattern
+		configs, err = config.LoadConfigFromGlob(sm.configGlob)
+		if err != nil {
+			return err
+		}
 	} else {
 		// Load all configuration files from directory
 		dir := sm.configDir
@@ -7,6 +7,8 @@ import (
 	"log"
 	"sync/atomic"
 	"time"
+
+	prom "catalyst/prometheus"
 )
 
 func NewBatchManager(db *sql.DB, config BatchConfig) *BatchManager {
@@ -32,6 +34,9 @@ func NewBatchManager(db *sql.DB, config BatchConfig) *BatchManager {
 	if config.RetryAttempts <= 0 {
 		config.RetryAttempts = 3
 	}
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = 30 * time.Second
+	}
 
 	return &BatchManager{
 		DB:       db,
@@ -43,7 +48,10 @@ func NewBatchManager(db *sql.DB, config BatchConfig) *BatchManager {
 			Operations: make([]*Mockdata, 0, config.BatchSize),
 			CreatedAt:  time.Now(),
 		},
-		LastFlush: time.Now(),
+		LastFlush:   time.Now(),
+		healthy:     true,
+		batchSize:   config.BatchSize,
+		searchIndex: NewBodySearchIndex(),
 	}
 }
 
@@ -64,12 +72,13 @@ func (bm *BatchManager) Start() error {
 	}
 
 	// Iniciar workers para procesar batches
+	bm.workerCancels = nil
 	for i := 0; i < bm.Config.MaxWorkers; i++ {
-		bm.WaitGroup.Add(1)
-		go bm.batchWorker(i)
+		bm.startWorker()
 	}
 
 	// Iniciar worker para agrupar peticiones en batches
+	bm.aggregatorDone = make(chan struct{})
 	bm.WaitGroup.Add(1)
 	go bm.batchAggregator()
 
@@ -80,12 +89,17 @@ func (bm *BatchManager) Start() error {
 		go bm.autoFlush()
 	}
 
+	// Iniciar health check de la base de datos
+	bm.WaitGroup.Add(1)
+	go bm.healthCheck()
+
 	log.Printf("BatchManager started with %d workers, batch size: %d",
 		bm.Config.MaxWorkers, bm.Config.BatchSize)
 	return nil
 }
 
-// Stop detiene el batch manager
+// Stop detiene el batch manager. Las colas se cierran solo cuando los workers, el agregador y el
+// flush automático terminaron, para que ninguno envíe a una cola cerrada.
 func (bm *BatchManager) Stop() {
 	bm.Mutex.Lock()
 	defer bm.Mutex.Unlock()
@@ -94,21 +108,62 @@ func (bm *BatchManager) Stop() {
 		return
 	}
 
-	bm.QueueMgr.Stop()
-
 	if bm.FlushTicker != nil {
 		bm.FlushTicker.Stop()
 	}
 
-	// Flush del batch actual si tiene datos
-	bm.flushCurrentBatch()
-
+	// Los contextos de los workers derivan del de QueueMgr, así que se cancelan todos
+	bm.QueueMgr.Cancel()
 	bm.WaitGroup.Wait()
+	bm.workerCancels = nil
+
+	bm.QueueMgr.Stop()
+
+	// Lo que quedó en las colas o en el batch actual se inserta directamente
+	bm.persistRemaining()
 	bm.Running = false
 
 	log.Println("BatchManager stopped")
 }
 
+// persistRemaining inserta el batch actual y lo que quedó en las colas. Solo se llama con los
+// workers detenidos y las colas cerradas.
+func (bm *BatchManager) persistRemaining() {
+	bm.BatchMutex.Lock()
+	defer bm.BatchMutex.Unlock()
+
+	remaining := bm.CurrentBatch
+	for operation := range bm.QueueMgr.InputQueue {
+		remaining.Operations = append(remaining.Operations, operation)
+		remaining.Size++
+	}
+
+	batches := []*Batch{remaining}
+	for batch := range bm.QueueMgr.BatchQueue {
+		batches = append(batches, batch)
+	}
+
+	for _, batch := range batches {
+		if batch.Size == 0 {
+			continue
+		}
+		if err := bm.insertBatchTransaction(batch); err != nil {
+			log.Printf("Error persisting batch %s on stop: %v", batch.ID, err)
+			atomic.AddInt64(&bm.TotalErrors, 1)
+		} else {
+			atomic.AddInt64(&bm.TotalProcessed, int64(batch.Size))
+			prom.DatabaseTotalProcessed.Add(float64(batch.Size))
+		}
+		atomic.AddInt64(&bm.pending, -int64(batch.Size))
+	}
+
+	bm.CurrentBatch = &Batch{
+		ID:         generateBatchID(),
+		Operations: make([]*Mockdata, 0, bm.Config.BatchSize),
+		CreatedAt:  time.Now(),
+	}
+}
+
 // AddOperation agrega una operación al batch
 func (bm *BatchManager) AddOperation(operation *Mockdata) error {
 	bm.Mutex.RLock()
@@ -119,18 +174,82 @@ func (bm *BatchManager) AddOperation(operation *Mockdata) error {
 	bm.Mutex.RUnlock()
 
 	if err := bm.QueueMgr.AddRequest(operation); err != nil {
-		if err == ErrQueueFull {
-			// Si la cola está llena, insertar directamente
+		if err == ErrQueueFull || err == ErrQueueClosed {
+			// Si la cola está llena o drenándose, insertar directamente
 			return bm.insertSync(operation)
 		}
 		return err
 	}
+	atomic.AddInt64(&bm.pending, 1)
 	return nil
 }
 
+// DrainWithTimeout cierra la cola de entrada y espera a que todas las operaciones encoladas se
+// persistan. Devuelve un error con las operaciones pendientes si vence el timeout.
+func (bm *BatchManager) DrainWithTimeout(timeout time.Duration) error {
+	bm.Mutex.RLock()
+	running := bm.Running
+	bm.Mutex.RUnlock()
+	if !running {
+		return nil
+	}
+
+	deadline := time.After(timeout)
+
+	// (1) batchAggregator termina al cerrarse la cola, enviando el batch en curso
+	bm.QueueMgr.CloseInput()
+	select {
+	case <-bm.aggregatorDone:
+	case <-deadline:
+		return fmt.Errorf("drain timeout expired with %d operations not processed", atomic.LoadInt64(&bm.pending))
+	}
+
+	// (2) flush por si quedó algo en el batch actual
+	bm.flushCurrentBatch()
+
+	// (3) esperar a que los workers procesen todos los batches
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&bm.pending) > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return fmt.Errorf("drain timeout expired with %d operations not processed", atomic.LoadInt64(&bm.pending))
+		}
+	}
+
+	return nil
+}
+
+// Flush envía el batch actual sin esperar al flush por tiempo y espera, hasta que venza ctx, a que
+// se persistan las operaciones pendientes. Devuelve cuántas operaciones había pendientes.
+func (bm *BatchManager) Flush(ctx context.Context) (int, error) {
+	if !bm.IsRunning() {
+		return 0, fmt.Errorf("batch manager not running")
+	}
+
+	flushed := int(atomic.LoadInt64(&bm.pending))
+	bm.flushCurrentBatch()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&bm.pending) > 0 || len(bm.QueueMgr.BatchQueue) > 0 {
+		select {
+		case <-ticker.C:
+			// Las operaciones que seguían en la cola de entrada llegan al batch actual después del primer flush
+			bm.flushCurrentBatch()
+		case <-ctx.Done():
+			return flushed, ctx.Err()
+		}
+	}
+
+	return flushed, nil
+}
+
 // batchAggregator agrupa peticiones en batches
 func (bm *BatchManager) batchAggregator() {
 	defer bm.WaitGroup.Done()
+	defer close(bm.aggregatorDone)
 
 	for {
 		select {
@@ -147,24 +266,126 @@ func (bm *BatchManager) batchAggregator() {
 			bm.CurrentBatch.Operations = append(bm.CurrentBatch.Operations, operation)
 			bm.CurrentBatch.Size++
 
-			// Si el batch está completo, enviarlo
-			if bm.CurrentBatch.Size >= bm.Config.BatchSize {
+			// Si el batch está completo, enviarlo; con la cola casi llena se envían batches más pequeños
+			bm.adaptBatchSize()
+			if bm.CurrentBatch.Size >= bm.batchSize {
 				bm.sendBatch()
 			}
+			bm.updateBatchGauges()
 			bm.BatchMutex.Unlock()
 		}
 	}
 }
 
-// batchWorker procesa batches completos
-func (bm *BatchManager) batchWorker(id int) {
+// adaptBatchSize reduce el tamaño efectivo del batch cuando InputQueue supera el 80% de su capacidad
+// y restaura BatchSize cuando baja del 20%; entre ambos umbrales mantiene el actual.
+// El llamador debe tener BatchMutex.
+func (bm *BatchManager) adaptBatchSize() {
+	queueLen, queueCap := len(bm.QueueMgr.InputQueue), cap(bm.QueueMgr.InputQueue)
+	switch {
+	case queueLen*5 > queueCap*4:
+		bm.batchSize = adaptiveBatchSize(queueLen, queueCap, bm.Config.BatchSize)
+	case queueLen*5 < queueCap:
+		bm.batchSize = bm.Config.BatchSize
+	}
+	prom.DatabaseAdaptiveBatchSize.Set(float64(bm.batchSize))
+}
+
+// adaptiveBatchSize devuelve el tamaño de batch para una cola con queueLen de queueCap operaciones:
+// originalBatchSize hasta el 80% de ocupación y, por encima, proporcional al espacio libre hasta 1
+// con la cola llena
+func adaptiveBatchSize(queueLen, queueCap, originalBatchSize int) int {
+	if queueCap <= 0 || queueLen*5 <= queueCap*4 {
+		return originalBatchSize
+	}
+
+	// El 20% restante de la cola se reparte entre originalBatchSize y 1
+	size := originalBatchSize * (queueCap - queueLen) * 5 / queueCap
+	if size < 1 {
+		return 1
+	}
+	if size > originalBatchSize {
+		return originalBatchSize
+	}
+	return size
+}
+
+// startWorker inicia un batchWorker con su propio contexto, para poder detenerlo por separado.
+// El llamador debe tener Mutex.
+func (bm *BatchManager) startWorker() {
+	ctx, cancel := context.WithCancel(bm.QueueMgr.Ctx)
+	id := len(bm.workerCancels)
+	bm.workerCancels = append(bm.workerCancels, cancel)
+
+	bm.WaitGroup.Add(1)
+	go bm.batchWorker(ctx, id)
+}
+
+// Reconfigure aplica un nuevo BatchSize, FlushInterval y MaxWorkers sin reiniciar. Los valores en
+// cero conservan el actual; el resto de la configuración no cambia porque dimensiona las colas.
+func (bm *BatchManager) Reconfigure(cfg BatchConfig) error {
+	if cfg.BatchSize < 0 || cfg.FlushInterval < 0 || cfg.MaxWorkers < 0 {
+		return fmt.Errorf("batch_size, flush_interval and max_workers cannot be negative")
+	}
+
+	bm.Mutex.Lock()
+	defer bm.Mutex.Unlock()
+
+	if cfg.BatchSize > 0 {
+		bm.BatchMutex.Lock()
+		bm.Config.BatchSize = cfg.BatchSize
+		bm.batchSize = cfg.BatchSize
+		// El batch en curso se envía si ya alcanza el nuevo tamaño
+		if bm.Running && bm.CurrentBatch.Size >= cfg.BatchSize {
+			bm.sendBatch()
+		}
+		bm.BatchMutex.Unlock()
+	}
+
+	if cfg.FlushInterval > 0 && cfg.FlushInterval != bm.Config.FlushInterval {
+		bm.Config.FlushInterval = cfg.FlushInterval
+		// autoFlush lee el ticker en cada iteración, así que se reinicia en lugar de reemplazarlo
+		if bm.Running && bm.FlushTicker != nil {
+			bm.FlushTicker.Reset(cfg.FlushInterval)
+		}
+	}
+
+	if cfg.MaxWorkers > 0 {
+		bm.Config.MaxWorkers = cfg.MaxWorkers
+		if bm.Running {
+			for len(bm.workerCancels) < cfg.MaxWorkers {
+				bm.startWorker()
+			}
+			// Los workers sobrantes terminan el batch que estén procesando antes de salir
+			for len(bm.workerCancels) > cfg.MaxWorkers {
+				last := len(bm.workerCancels) - 1
+				bm.workerCancels[last]()
+				bm.workerCancels = bm.workerCancels[:last]
+			}
+		}
+	}
+
+	log.Printf("BatchManager reconfigured with %d workers, batch size: %d, flush interval: %s",
+		bm.Config.MaxWorkers, bm.Config.BatchSize, bm.Config.FlushInterval)
+	return nil
+}
+
+// CurrentConfig devuelve la configuración en uso, incluidos los cambios de Reconfigure
+func (bm *BatchManager) CurrentConfig() BatchConfig {
+	bm.Mutex.RLock()
+	defer bm.Mutex.RUnlock()
+	return bm.Config
+}
+
+// batchWorker procesa batches completos hasta que se cancela ctx
+func (bm *BatchManager) batchWorker(ctx context.Context, id int) {
 	defer bm.WaitGroup.Done()
 
 	log.Printf("Batch worker %d started", id)
 
 	for {
 		select {
-		case <-bm.QueueMgr.Ctx.Done():
+		case <-ctx.Done():
 			log.Printf("Batch worker %d stopping", id)
 			return
 		case batch, ok := <-bm.QueueMgr.BatchQueue:
@@ -180,12 +401,16 @@ func (bm *BatchManager) batchWorker(id int) {
 				atomic.AddInt64(&bm.TotalErrors, 1)
 			} else {
 				atomic.AddInt64(&bm.TotalProcessed, int64(batch.Size))
+				prom.DatabaseTotalProcessed.Add(float64(batch.Size))
 			}
 
 			// Enviar resultado
 			if sendErr := bm.QueueMgr.SendResult(err); sendErr != nil {
 				log.Printf("Batch worker %d: error sending result: %v", id, sendErr)
 			}
+
+			// Al final, para que un drain completo vea los contadores y el resultado ya enviados
+			atomic.AddInt64(&bm.pending, -int64(batch.Size))
 		}
 	}
 }
@@ -195,17 +420,23 @@ func (bm *BatchManager) processBatch(batch *Batch) error {
 	var lastErr error
 
 	for attempt := 1; attempt <= bm.Config.RetryAttempts; attempt++ {
-		ctx, cancel := context.WithTimeout(bm.QueueMgr.Ctx, bm.Config.Timeout)
+		// El batch en curso se termina aunque el manager se esté deteniendo
+		ctx, cancel := context.WithTimeout(context.Background(), bm.Config.Timeout)
 
 		err := bm.insertBatchWithContext(ctx, batch)
 		cancel()
 
 		if err == nil {
 			atomic.AddInt64(&bm.TotalBatches, 1)
+			prom.DatabaseTotalBatches.Inc()
 			return nil
 		}
 
 		lastErr = err
+		// Al detenerse no se reintenta, Stop espera a los workers
+		if bm.QueueMgr.Ctx.Err() != nil {
+			break
+		}
 		if attempt < bm.Config.RetryAttempts {
 			log.Printf("Retry attempt %d for batch %s: %v", attempt, batch.ID, err)
 			time.Sleep(time.Duration(attempt) * time.Second)
@@ -233,7 +464,8 @@ func (bm *BatchManager) insertBatchWithContext(ctx context.Context, batch *Batch
 
 // insertBatchTransaction ejecuta la inserción del batch en una transacción
 func (bm *BatchManager) insertBatchTransaction(batch *Batch) error {
-	tx, err := bm.DB.Begin()
+	start := time.Now()
+	tx, err := bm.GetDB().Begin()
 	if err != nil {
 		return err
 	}
@@ -244,8 +476,8 @@ func (bm *BatchManager) insertBatchTransaction(batch *Batch) error {
 		INSERT INTO mock_transactions (
 			uuid, recepcion_id, sender_id, request_headers, request_method, 
 			request_endpoint, request_body, response_headers, response_body, 
-			response_status_code, timestamp
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			response_status_code, transaction_type, trace_id, timestamp, tags
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return err
@@ -265,19 +497,29 @@ func (bm *BatchManager) insertBatchTransaction(batch *Batch) error {
 			operation.ResponseHeaders,
 			operation.ResponseBody,
 			operation.ResponseStatusCode,
+			transactionTypeOrDefault(operation.TransactionType),
+			operation.TraceID,
 			operation.Timestamp,
+			EncodeTags(operation.Tags),
 		)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Solo se observan las transacciones confirmadas
+	prom.DatabaseInsertDuration.Observe(time.Since(start).Seconds())
+	prom.DatabaseBatchSizeRecords.Observe(float64(batch.Size))
+	return nil
 }
 
 // insertSync inserción síncrona directa (fallback)
 func (bm *BatchManager) insertSync(operation *Mockdata) error {
-	return InsertOperation(bm.DB, operation)
+	return InsertOperation(bm.GetDB(), operation)
 }
 
 // sendBatch envía el batch actual a la cola de procesamiento
@@ -289,6 +531,7 @@ func (bm *BatchManager) sendBatch() {
 				if processErr := bm.processBatch(bm.CurrentBatch); processErr != nil {
 					log.Printf("Error processing batch directly: %v", processErr)
 				}
+				atomic.AddInt64(&bm.pending, -int64(bm.CurrentBatch.Size))
 			} else {
 				log.Printf("Error adding batch to queue: %v", err)
 				return
@@ -323,11 +566,31 @@ func (bm *BatchManager) autoFlush() {
 		case <-bm.QueueMgr.Ctx.Done():
 			return
 		case <-bm.FlushTicker.C:
+			bm.sampleQueueDepth()
 			bm.flushCurrentBatch()
+
+			bm.BatchMutex.Lock()
+			bm.updateBatchGauges()
+			bm.BatchMutex.Unlock()
 		}
 	}
 }
 
+// updateBatchGauges actualiza la utilización de la cola de entrada y el tamaño del batch actual.
+// El llamador debe tener BatchMutex.
+func (bm *BatchManager) updateBatchGauges() {
+	if bm.Config.MaxQueueSize > 0 {
+		prom.DatabaseInputQueueUtilization.Set(float64(len(bm.QueueMgr.InputQueue)) / float64(bm.Config.MaxQueueSize))
+	}
+	prom.DatabaseCurrentBatchSize.Set(float64(bm.CurrentBatch.Size))
+}
+
+// sampleQueueDepth registra la profundidad actual de las colas en los histogramas
+func (bm *BatchManager) sampleQueueDepth() {
+	prom.DatabaseInputQueueDepth.Observe(float64(len(bm.QueueMgr.InputQueue)))
+	prom.DatabaseBatchQueueDepth.Observe(float64(len(bm.QueueMgr.BatchQueue)))
+}
+
 // GetStats retorna estadísticas del batch manager
 func (bm *BatchManager) GetStats() map[string]interface{} {
 	bm.Mutex.RLock()
@@ -335,19 +598,21 @@ func (bm *BatchManager) GetStats() map[string]interface{} {
 
 	bm.BatchMutex.Lock()
 	currentBatchSize := bm.CurrentBatch.Size
+	effectiveBatchSize := bm.batchSize
 	bm.BatchMutex.Unlock()
 
 	return map[string]interface{}{
-		"is_running":         bm.Running,
-		"input_queue_size":   len(bm.QueueMgr.InputQueue),
-		"batch_queue_size":   len(bm.QueueMgr.BatchQueue),
-		"current_batch_size": currentBatchSize,
-		"total_processed":    atomic.LoadInt64(&bm.TotalProcessed),
-		"total_batches":      atomic.LoadInt64(&bm.TotalBatches),
-		"total_errors":       atomic.LoadInt64(&bm.TotalErrors),
-		"batch_size":         bm.Config.BatchSize,
-		"max_workers":        bm.Config.MaxWorkers,
-		"flush_interval":     bm.Config.FlushInterval,
+		"is_running":          bm.Running,
+		"input_queue_size":    len(bm.QueueMgr.InputQueue),
+		"batch_queue_size":    len(bm.QueueMgr.BatchQueue),
+		"current_batch_size":  currentBatchSize,
+		"total_processed":     atomic.LoadInt64(&bm.TotalProcessed),
+		"total_batches":       atomic.LoadInt64(&bm.TotalBatches),
+		"total_errors":        atomic.LoadInt64(&bm.TotalErrors),
+		"batch_size":          bm.Config.BatchSize,
+		"adaptive_batch_size": effectiveBatchSize,
+		"max_workers":         bm.Config.MaxWorkers,
+		"flush_interval":      bm.Config.FlushInterval,
 	}
 }
 
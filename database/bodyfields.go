@@ -0,0 +1,67 @@
+package database
+
+import (
+	"catalyst/database/internal"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// bodyFieldNamePattern limita los nombres de campo a identificadores válidos como nombre de columna
+var bodyFieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// bodyFieldPathPattern acepta rutas JSON simples como $.customer.id o $.items[0].sku
+var bodyFieldPathPattern = regexp.MustCompile(`^\$(\.[A-Za-z0-9_]+|\[[0-9]+\])+$`)
+
+// BodyFieldColumn retorna la columna virtual de un campo del request_body, e.g. customerId → request_customer_id
+func BodyFieldColumn(field string) (string, error) {
+	if !bodyFieldNamePattern.MatchString(field) {
+		return "", fmt.Errorf("invalid indexed body field name %q", field)
+	}
+
+	var column strings.Builder
+	column.WriteString("request_")
+	runes := []rune(field)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			column.WriteRune('_')
+		}
+		column.WriteRune(unicode.ToLower(r))
+	}
+	return column.String(), nil
+}
+
+// AddIndexedBodyField crea, si no existe, la columna virtual con el valor jsonPath del request_body
+// y un índice sobre ella. Los bodies que no son JSON dejan la columna en NULL.
+func (bm *BatchManager) AddIndexedBodyField(field, jsonPath string) error {
+	column, err := BodyFieldColumn(field)
+	if err != nil {
+		return err
+	}
+	if !bodyFieldPathPattern.MatchString(jsonPath) {
+		return fmt.Errorf("invalid jsonpath %q for indexed body field %s", jsonPath, field)
+	}
+
+	// json_extract falla con JSON inválido, json_valid evita que el INSERT falle por el índice
+	expression := fmt.Sprintf("CASE WHEN json_valid(request_body) THEN json_extract(request_body, '%s') END", jsonPath)
+	if err := internal.AddGeneratedColumnIfNotExists(bm.GetDB(), "mock_transactions", column, expression); err != nil {
+		return fmt.Errorf("error indexing body field %s: %w", field, err)
+	}
+	return nil
+}
+
+// HasIndexedBodyField indica si el campo ya tiene su columna virtual en mock_transactions
+func (bm *BatchManager) HasIndexedBodyField(field string) (bool, error) {
+	column, err := BodyFieldColumn(field)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = bm.GetDB().QueryRow("SELECT COUNT(*) FROM pragma_table_xinfo('mock_transactions') WHERE name = ? AND hidden > 0", column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("error reading mock_transactions columns: %w", err)
+	}
+	return count > 0, nil
+}
@@ -17,6 +17,7 @@ type QueueManager struct {
 	WaitGroup   sync.WaitGroup
 	Running     bool
 	Mutex       sync.RWMutex
+	inputClosed bool
 }
 
 // NewQueueManager crea un nuevo manager de colas
@@ -57,7 +58,10 @@ func (qm *QueueManager) Stop() {
 	}
 
 	qm.Cancel()
-	close(qm.InputQueue)
+	if !qm.inputClosed {
+		close(qm.InputQueue)
+		qm.inputClosed = true
+	}
 	close(qm.BatchQueue)
 	close(qm.ResultQueue)
 	qm.Running = false
@@ -67,12 +71,16 @@ func (qm *QueueManager) Stop() {
 
 // AddRequest agrega una petición a la cola de entrada
 func (qm *QueueManager) AddRequest(operation *Mockdata) error {
+	// El lock se mantiene durante el envío (que nunca bloquea) para no escribir en una cola cerrada
 	qm.Mutex.RLock()
+	defer qm.Mutex.RUnlock()
+
 	if !qm.Running {
-		qm.Mutex.RUnlock()
 		return ErrQueueNotRunning
 	}
-	qm.Mutex.RUnlock()
+	if qm.inputClosed {
+		return ErrQueueClosed
+	}
 
 	select {
 	case qm.InputQueue <- operation:
@@ -84,6 +92,17 @@ func (qm *QueueManager) AddRequest(operation *Mockdata) error {
 	}
 }
 
+// CloseInput cierra la cola de entrada; las nuevas peticiones devuelven ErrQueueClosed
+func (qm *QueueManager) CloseInput() {
+	qm.Mutex.Lock()
+	defer qm.Mutex.Unlock()
+
+	if !qm.inputClosed {
+		close(qm.InputQueue)
+		qm.inputClosed = true
+	}
+}
+
 // AddBatch agrega un batch a la cola de procesamiento
 func (qm *QueueManager) AddBatch(batch *Batch) error {
 	select {
@@ -142,4 +161,5 @@ func (qm *QueueManager) IsRunning() bool {
 var (
 	ErrQueueNotRunning = fmt.Errorf("queue manager not running")
 	ErrQueueFull       = fmt.Errorf("queue is full")
+	ErrQueueClosed     = fmt.Errorf("input queue is closed")
 )
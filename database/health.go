@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+)
+
+// OpenBatchManager abre la base de datos en dbPath y crea un BatchManager que puede reconectarse a ella
+func OpenBatchManager(dbPath string, config BatchConfig) (*BatchManager, error) {
+	return OpenBatchManagerFromConfig(dbPath, config, models.DatabaseConfig{})
+}
+
+// OpenBatchManagerFromConfig es OpenBatchManager con el pool de conexiones de dbConfig
+func OpenBatchManagerFromConfig(dbPath string, config BatchConfig, dbConfig models.DatabaseConfig) (*BatchManager, error) {
+	db, err := InitDBFromConfig(dbPath, dbConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	bm := NewBatchManager(db, config)
+	bm.DBPath = dbPath
+	bm.DBConfig = dbConfig
+	bm.dbFileInfo, _ = os.Stat(dbPath)
+
+	return bm, nil
+}
+
+// GetDB retorna la conexión actual; puede cambiar tras una reconexión
+func (bm *BatchManager) GetDB() *sql.DB {
+	bm.dbMutex.RLock()
+	defer bm.dbMutex.RUnlock()
+	return bm.DB
+}
+
+// IsHealthy retorna el resultado del último health check
+func (bm *BatchManager) IsHealthy() bool {
+	bm.dbMutex.RLock()
+	defer bm.dbMutex.RUnlock()
+	return bm.healthy
+}
+
+// healthCheck verifica periódicamente la conexión y reconecta si es necesario
+func (bm *BatchManager) healthCheck() {
+	defer bm.WaitGroup.Done()
+
+	ticker := time.NewTicker(bm.Config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-bm.QueueMgr.Ctx.Done():
+			return
+		case <-ticker.C:
+			bm.checkHealth()
+		}
+	}
+}
+
+// checkHealth hace ping a la base de datos y verifica que el archivo siga siendo el mismo.
+// Si falla, reabre la base de datos usando DBPath y reemplaza la conexión.
+func (bm *BatchManager) checkHealth() bool {
+	err := bm.ping()
+	if err == nil {
+		bm.setHealthy(true)
+		return true
+	}
+
+	log.Printf("Database health check failed: %v", err)
+	bm.setHealthy(false)
+
+	if bm.DBPath == "" {
+		return false
+	}
+
+	if err := bm.reconnect(); err != nil {
+		log.Printf("Database reconnect failed: %v", err)
+		return false
+	}
+
+	log.Printf("Database reconnected: %s", bm.DBPath)
+	return true
+}
+
+// ping ejecuta PingContext y detecta si el archivo de SQLite fue borrado o reemplazado
+func (bm *BatchManager) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), bm.Config.Timeout)
+	defer cancel()
+
+	if err := bm.GetDB().PingContext(ctx); err != nil {
+		return err
+	}
+
+	if bm.DBPath == "" {
+		return nil
+	}
+
+	info, err := os.Stat(bm.DBPath)
+	if err != nil {
+		return fmt.Errorf("database file not accessible: %w", err)
+	}
+
+	bm.dbMutex.RLock()
+	original := bm.dbFileInfo
+	bm.dbMutex.RUnlock()
+
+	if original != nil && !os.SameFile(original, info) {
+		return fmt.Errorf("database file %s was replaced", bm.DBPath)
+	}
+
+	return nil
+}
+
+// reconnect abre de nuevo la base de datos, reemplaza bm.DB y marca la base como sana. Ambos
+// cambios se hacen bajo el mismo lock para que nadie vea la conexión nueva como no sana.
+func (bm *BatchManager) reconnect() error {
+	db, err := InitDBFromConfig(bm.DBPath, bm.DBConfig)
+	if err != nil {
+		return err
+	}
+	info, _ := os.Stat(bm.DBPath)
+
+	bm.dbMutex.Lock()
+	old := bm.DB
+	bm.DB = db
+	bm.dbFileInfo = info
+	bm.healthy = true
+	bm.dbMutex.Unlock()
+	prom.DatabaseHealthStatus.Set(1)
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// setHealthy actualiza el estado de salud y la métrica database_health_status
+func (bm *BatchManager) setHealthy(healthy bool) {
+	bm.dbMutex.Lock()
+	bm.healthy = healthy
+	bm.dbMutex.Unlock()
+
+	if healthy {
+		prom.DatabaseHealthStatus.Set(1)
+	} else {
+		prom.DatabaseHealthStatus.Set(0)
+	}
+}
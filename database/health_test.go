@@ -0,0 +1,108 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"catalyst/internal/models"
+)
+
+func TestBatchManagerReconnectsAfterDatabaseFileIsRecreated(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "health.db")
+
+	bm, err := OpenBatchManager(dbPath, BatchConfig{
+		BatchSize:           1,
+		FlushInterval:       50 * time.Millisecond,
+		HealthCheckInterval: 50 * time.Millisecond,
+		Timeout:             time.Second,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer bm.Stop()
+
+	originalDB := bm.GetDB()
+
+	// Delete the SQLite file (and its WAL files) and recreate it empty
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(dbPath + suffix)
+	}
+	if err := os.WriteFile(dbPath, nil, 0644); err != nil {
+		t.Fatalf("Failed to recreate database file: %v", err)
+	}
+
+	// The connection and the health state change together, so both are read under the same lock
+	reconnected := func() bool {
+		bm.dbMutex.RLock()
+		defer bm.dbMutex.RUnlock()
+		return bm.DB != originalDB && bm.healthy
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !reconnected() && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if !reconnected() {
+		t.Fatal("Expected the batch manager to reconnect to the recreated database and be healthy")
+	}
+
+	// The new connection must have the schema and accept inserts
+	operation := &Mockdata{
+		UUID:            "health-check",
+		RequestMethod:   "GET",
+		RequestEndpoint: "/health",
+		Timestamp:       time.Now(),
+	}
+	if err := bm.insertSync(operation); err != nil {
+		t.Fatalf("Insert after reconnect failed: %v", err)
+	}
+
+	var count int
+	if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions").Scan(&count); err != nil {
+		t.Fatalf("Query after reconnect failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row in the recreated database, got %d", count)
+	}
+}
+
+func TestInitDBFromConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := InitDBFromConfig(filepath.Join(dir, "default.db"), models.DatabaseConfig{})
+	if err != nil {
+		t.Fatalf("InitDBFromConfig failed: %v", err)
+	}
+	defer db.Close()
+	if got := db.Stats().MaxOpenConnections; got != 1 {
+		t.Errorf("Expected the default of 1 open connection, got %d", got)
+	}
+
+	bm, err := OpenBatchManagerFromConfig(filepath.Join(dir, "pool.db"), BatchConfig{}, models.DatabaseConfig{
+		MaxOpenConns:           8,
+		MaxIdleConns:           4,
+		ConnMaxLifetimeSeconds: 300,
+		ConnMaxIdleSeconds:     60,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManagerFromConfig failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+	if got := bm.GetDB().Stats().MaxOpenConnections; got != 8 {
+		t.Errorf("Expected 8 open connections, got %d", got)
+	}
+
+	// Reconnecting keeps the configured pool
+	if err := bm.reconnect(); err != nil {
+		t.Fatalf("reconnect failed: %v", err)
+	}
+	if got := bm.GetDB().Stats().MaxOpenConnections; got != 8 {
+		t.Errorf("Expected 8 open connections after reconnecting, got %d", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -17,13 +18,18 @@ type DBConfig struct {
 	ConnMaxIdleTime time.Duration // Tiempo máximo inactivo
 }
 
-func InitDB(dbPath string) (*sql.DB, error) {
-	return InitDBWithConfig(dbPath, DBConfig{
+// DefaultDBConfig retorna la configuración por defecto: una sola conexión, sin expiración
+func DefaultDBConfig() DBConfig {
+	return DBConfig{
 		MaxOpenConns:    1,
 		MinConn:         1,
 		ConnMaxLifetime: 0,
 		ConnMaxIdleTime: 0,
-	})
+	}
+}
+
+func InitDB(dbPath string) (*sql.DB, error) {
+	return InitDBWithConfig(dbPath, DefaultDBConfig())
 }
 
 func InitDBWithConfig(dbPath string, config DBConfig) (*sql.DB, error) {
@@ -79,6 +85,145 @@ func InitDBWithConfig(dbPath string, config DBConfig) (*sql.DB, error) {
 		return nil, fmt.Errorf("error creating indexes: %v", err)
 	}
 
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
 	log.Println("Database initialized successfully")
 	return db, nil
 }
+
+// migrate aplica los cambios de esquema sobre bases de datos ya existentes
+func migrate(db *sql.DB) error {
+	// transaction_type distingue peticiones recibidas (sync) de respuestas de llamadas asíncronas (async)
+	if err := addColumnIfNotExists(db, "mock_transactions", "transaction_type", "TEXT NOT NULL DEFAULT 'sync'"); err != nil {
+		return fmt.Errorf("error adding transaction_type column: %v", err)
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_transactions_type ON mock_transactions(transaction_type)"); err != nil {
+		return fmt.Errorf("error creating transaction_type index: %v", err)
+	}
+
+	// trace_id agrupa la petición recibida con las llamadas asíncronas que disparó
+	if err := addColumnIfNotExists(db, "mock_transactions", "trace_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return fmt.Errorf("error adding trace_id column: %v", err)
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_transactions_trace_id ON mock_transactions(trace_id)"); err != nil {
+		return fmt.Errorf("error creating trace_id index: %v", err)
+	}
+
+	// tags guarda como JSON las cabeceras tag_headers de la petición, p. ej. el nombre del test
+	if err := addColumnIfNotExists(db, "mock_transactions", "tags", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return fmt.Errorf("error adding tags column: %v", err)
+	}
+
+	// config_audit registra quién cambió la configuración de cada servidor y cuándo
+	createConfigAudit := `
+	CREATE TABLE IF NOT EXISTS config_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_name TEXT NOT NULL,
+		changed_by TEXT NOT NULL,
+		change_summary TEXT,
+		previous_yaml TEXT,
+		new_yaml TEXT,
+		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_config_audit_server_name ON config_audit(server_name, timestamp);
+	`
+	if _, err := db.Exec(createConfigAudit); err != nil {
+		return fmt.Errorf("error creating config_audit table: %v", err)
+	}
+
+	return nil
+}
+
+// addColumnIfNotExists ejecuta ALTER TABLE ADD COLUMN solo si la columna no existe
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// AddGeneratedColumnIfNotExists agrega una columna generada VIRTUAL con la expresión dada y un índice
+// sobre ella. Si la columna ya existe con otra expresión retorna error, ya que SQLite no permite modificarla.
+func AddGeneratedColumnIfNotExists(db *sql.DB, table, column, expression string) error {
+	var tableSQL string
+	if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&tableSQL); err != nil {
+		return fmt.Errorf("error reading schema of %s: %w", table, err)
+	}
+
+	// table_xinfo, a diferencia de table_info, incluye las columnas generadas (hidden 2 y 3)
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_xinfo(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	exists := false
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+			hidden    int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk, &hidden); err != nil {
+			return err
+		}
+		if name != column {
+			continue
+		}
+		if hidden == 0 {
+			return fmt.Errorf("column %s already exists and is not a generated column", column)
+		}
+		if !strings.Contains(tableSQL, expression) {
+			return fmt.Errorf("column %s already exists with a different expression", column)
+		}
+		exists = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// Con una sola conexión abierta hay que liberar las filas antes del ALTER TABLE
+	rows.Close()
+
+	if !exists {
+		definition := fmt.Sprintf("TEXT GENERATED ALWAYS AS (%s) VIRTUAL", expression)
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+			return fmt.Errorf("error adding generated column %s: %w", column, err)
+		}
+	}
+	if _, err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s(%s)", table, column, table, column)); err != nil {
+		return fmt.Errorf("error creating index on %s: %w", column, err)
+	}
+	return nil
+}
@@ -17,7 +17,10 @@ type Mockdata struct {
 	ResponseHeaders    string    `json:"response_headers" db:"response_headers"`
 	ResponseBody       string    `json:"response_body" db:"response_body"`
 	ResponseStatusCode int       `json:"response_status_code" db:"response_status_code"`
+	TransactionType    string    `json:"transaction_type" db:"transaction_type"`
+	TraceID            string    `json:"trace_id" db:"trace_id"`
 	Timestamp          time.Time `json:"timestamp" db:"timestamp"`
+	Tags               string    `json:"tags" db:"tags"` // JSON de los tags de la transacción
 }
 
 // InsertOperation inserta una nueva operación en la base de datos
@@ -26,8 +29,20 @@ func InsertOperation(db *sql.DB, operation *Mockdata) error {
 	INSERT INTO mock_transactions (
 		uuid, recepcion_id, sender_id, request_headers, request_method, 
 		request_endpoint, request_body, response_headers, response_body, 
-		response_status_code, timestamp
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		response_status_code, transaction_type, trace_id, timestamp, tags
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// La columna tags siempre guarda JSON válido para json_extract
+	tags := operation.Tags
+	if tags == "" {
+		tags = "{}"
+	}
+
+	// Las inserciones directas sin tipo se registran como transacciones síncronas
+	transactionType := operation.TransactionType
+	if transactionType == "" {
+		transactionType = "sync"
+	}
 
 	_, err := db.Exec(query,
 		operation.UUID,
@@ -40,7 +55,10 @@ func InsertOperation(db *sql.DB, operation *Mockdata) error {
 		operation.ResponseHeaders,
 		operation.ResponseBody,
 		operation.ResponseStatusCode,
+		transactionType,
+		operation.TraceID,
 		operation.Timestamp,
+		tags,
 	)
 
 	return err
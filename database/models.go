@@ -2,8 +2,12 @@ package database
 
 import (
 	"catalyst/database/internal"
+	"catalyst/internal/models"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -41,6 +45,8 @@ type BatchConfig struct {
 	Timeout       time.Duration `json:"timeout"`         // Timeout para operaciones
 	RetryAttempts int           `json:"retry_attempts"`  // Número de reintentos
 	EnableMetrics bool          `json:"enable_metrics"`  // Habilitar métricas
+
+	HealthCheckInterval time.Duration `json:"health_check_interval"` // Intervalo del health check de la base de datos
 }
 
 // Batch representa un lote de operaciones
@@ -63,12 +69,24 @@ type Mockdata struct {
 	ResponseHeaders    string    `json:"response_headers" db:"response_headers"`
 	ResponseBody       string    `json:"response_body" db:"response_body"`
 	ResponseStatusCode int       `json:"response_status_code" db:"response_status_code"`
+	TransactionType    string    `json:"transaction_type" db:"transaction_type"`
+	TraceID            string    `json:"trace_id" db:"trace_id"` // request_trace_id de la petición que originó la transacción
 	Timestamp          time.Time `json:"timestamp" db:"timestamp"`
+
+	Tags map[string]string `json:"tags" db:"tags"` // Cabeceras tag_headers de la petición, guardadas como JSON
 }
 
+// Tipos de transacción registrados en mock_transactions
+const (
+	TransactionTypeSync  = "sync"  // Petición recibida por el mock server
+	TransactionTypeAsync = "async" // Respuesta de una llamada asíncrona saliente
+)
+
 // BatchManager maneja el sistema de batch con alta concurrencia
 type BatchManager struct {
 	DB        *sql.DB
+	DBPath    string                // Ruta usada para reconectar si el health check falla
+	DBConfig  models.DatabaseConfig // Pool de conexiones, también usado al reconectar
 	Config    BatchConfig
 	QueueMgr  *QueueManager
 	WaitGroup sync.WaitGroup
@@ -82,6 +100,16 @@ type BatchManager struct {
 	BatchMutex     sync.Mutex
 	LastFlush      time.Time
 	FlushTicker    *time.Ticker
+
+	dbMutex    sync.RWMutex
+	dbFileInfo os.FileInfo
+	healthy    bool
+
+	batchSize      int                  // Tamaño efectivo del batch según la ocupación de InputQueue; requiere BatchMutex
+	pending        int64                // Operaciones encoladas que aún no se han procesado
+	aggregatorDone chan struct{}        // Se cierra cuando batchAggregator termina
+	workerCancels  []context.CancelFunc // Detiene cada batchWorker, en orden de inicio
+	searchIndex    *BodySearchIndex     // Índice de búsqueda por contenido; SetSearchIndex permite compartirlo
 }
 
 // InsertOperation inserta una nueva operación en la base de datos
@@ -90,8 +118,8 @@ func InsertOperation(db *sql.DB, operation *Mockdata) error {
 	INSERT INTO mock_transactions (
 		uuid, recepcion_id, sender_id, request_headers, request_method, 
 		request_endpoint, request_body, response_headers, response_body, 
-		response_status_code, timestamp
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		response_status_code, transaction_type, trace_id, timestamp, tags
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	_, err := db.Exec(query,
 		operation.UUID,
@@ -104,13 +132,112 @@ func InsertOperation(db *sql.DB, operation *Mockdata) error {
 		operation.ResponseHeaders,
 		operation.ResponseBody,
 		operation.ResponseStatusCode,
+		transactionTypeOrDefault(operation.TransactionType),
+		operation.TraceID,
 		operation.Timestamp,
+		EncodeTags(operation.Tags),
 	)
 
 	return err
 }
 
+// ListOperations devuelve todas las operaciones de la base de datos, de la más antigua a la más reciente
+func ListOperations(db *sql.DB) ([]Mockdata, error) {
+	rows, err := db.Query(`
+	SELECT uuid, recepcion_id, sender_id, request_headers, request_method,
+		request_endpoint, request_body, response_headers, response_body,
+		response_status_code, transaction_type, trace_id, timestamp, tags
+	FROM mock_transactions ORDER BY timestamp ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying operations: %w", err)
+	}
+	defer rows.Close()
+
+	var operations []Mockdata
+	for rows.Next() {
+		var (
+			operation Mockdata
+			tags      string
+		)
+		if err := rows.Scan(
+			&operation.UUID,
+			&operation.RecepcionID,
+			&operation.SenderID,
+			&operation.RequestHeaders,
+			&operation.RequestMethod,
+			&operation.RequestEndpoint,
+			&operation.RequestBody,
+			&operation.ResponseHeaders,
+			&operation.ResponseBody,
+			&operation.ResponseStatusCode,
+			&operation.TransactionType,
+			&operation.TraceID,
+			&operation.Timestamp,
+			&tags,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning operation: %w", err)
+		}
+		if operation.Tags, err = DecodeTags(tags); err != nil {
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+	return operations, rows.Err()
+}
+
+// EncodeTags serializa los tags de una transacción para la columna tags; sin tags guarda {}
+// para que json_extract siempre reciba JSON válido
+func EncodeTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return "{}"
+	}
+	encoded, err := json.Marshal(tags)
+	if err != nil {
+		return "{}"
+	}
+	return string(encoded)
+}
+
+// DecodeTags lee la columna tags de una transacción
+func DecodeTags(tags string) (map[string]string, error) {
+	decoded := make(map[string]string)
+	if tags == "" {
+		return decoded, nil
+	}
+	if err := json.Unmarshal([]byte(tags), &decoded); err != nil {
+		return nil, fmt.Errorf("error decoding tags: %w", err)
+	}
+	return decoded, nil
+}
+
+// transactionTypeOrDefault retorna el tipo de transacción o "sync" si está vacío
+func transactionTypeOrDefault(transactionType string) string {
+	if transactionType == "" {
+		return TransactionTypeSync
+	}
+	return transactionType
+}
+
 // InitDB inicializa la base de datos usando la función interna
 func InitDB(dbPath string) (*sql.DB, error) {
 	return internal.InitDB(dbPath)
 }
+
+// InitDBFromConfig inicializa la base de datos con el pool de conexiones de la sección database
+// de la configuración; los valores en cero mantienen los de InitDB
+func InitDBFromConfig(dbPath string, cfg models.DatabaseConfig) (*sql.DB, error) {
+	dbConfig := internal.DefaultDBConfig()
+	if cfg.MaxOpenConns > 0 {
+		dbConfig.MaxOpenConns = cfg.MaxOpenConns
+	}
+	if cfg.MaxIdleConns > 0 {
+		dbConfig.MinConn = cfg.MaxIdleConns
+	}
+	if cfg.ConnMaxLifetimeSeconds > 0 {
+		dbConfig.ConnMaxLifetime = time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second
+	}
+	if cfg.ConnMaxIdleSeconds > 0 {
+		dbConfig.ConnMaxIdleTime = time.Duration(cfg.ConnMaxIdleSeconds) * time.Second
+	}
+	return internal.InitDBWithConfig(dbPath, dbConfig)
+}
@@ -0,0 +1,476 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	prom "catalyst/prometheus"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// histogramSamples devuelve el número y la suma de observaciones del histograma
+func histogramSamples(t *testing.T, histogram prometheus.Histogram) (uint64, float64) {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := histogram.Write(metric); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+}
+
+// summarySamples devuelve el número y la suma de observaciones del summary
+func summarySamples(t *testing.T, summary prometheus.Summary) (uint64, float64) {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := summary.Write(metric); err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	return metric.GetSummary().GetSampleCount(), metric.GetSummary().GetSampleSum()
+}
+
+func TestSampleQueueDepth(t *testing.T) {
+	bm := NewBatchManager(nil, BatchConfig{MaxQueueSize: 100, MaxBatchQueue: 10})
+
+	// Sin workers nadie consume las colas, así que la profundidad queda fija
+	if err := bm.QueueMgr.Start(); err != nil {
+		t.Fatalf("Failed to start queue manager: %v", err)
+	}
+	defer bm.QueueMgr.Stop()
+
+	for i := 0; i < 100; i++ {
+		if err := bm.QueueMgr.AddRequest(&Mockdata{UUID: "queued"}); err != nil {
+			t.Fatalf("Failed to enqueue request %d: %v", i, err)
+		}
+	}
+	if err := bm.QueueMgr.AddRequest(&Mockdata{UUID: "overflow"}); err != ErrQueueFull {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := bm.QueueMgr.AddBatch(&Batch{ID: "queued"}); err != nil {
+			t.Fatalf("Failed to enqueue batch %d: %v", i, err)
+		}
+	}
+
+	inputCount, inputSum := histogramSamples(t, prom.DatabaseInputQueueDepth)
+	batchCount, batchSum := histogramSamples(t, prom.DatabaseBatchQueueDepth)
+
+	const samples = 5
+	for i := 0; i < samples; i++ {
+		bm.sampleQueueDepth()
+	}
+
+	count, sum := histogramSamples(t, prom.DatabaseInputQueueDepth)
+	if count-inputCount != samples || sum-inputSum != samples*100 {
+		t.Errorf("Expected %d input queue observations of 100, got %d with sum %.0f", samples, count-inputCount, sum-inputSum)
+	}
+
+	count, sum = histogramSamples(t, prom.DatabaseBatchQueueDepth)
+	if count-batchCount != samples || sum-batchSum != samples*3 {
+		t.Errorf("Expected %d batch queue observations of 3, got %d with sum %.0f", samples, count-batchCount, sum-batchSum)
+	}
+}
+
+func TestUpdateBatchGauges(t *testing.T) {
+	bm := NewBatchManager(nil, BatchConfig{MaxQueueSize: 100, BatchSize: 50})
+	if err := bm.QueueMgr.Start(); err != nil {
+		t.Fatalf("Failed to start queue manager: %v", err)
+	}
+	defer bm.QueueMgr.Stop()
+
+	// 80 de 100 es el umbral típico de alerta antes de perder datos
+	for i := 0; i < 80; i++ {
+		if err := bm.QueueMgr.AddRequest(&Mockdata{UUID: "queued"}); err != nil {
+			t.Fatalf("Failed to enqueue request %d: %v", i, err)
+		}
+	}
+	bm.CurrentBatch.Size = 7
+
+	bm.BatchMutex.Lock()
+	bm.updateBatchGauges()
+	bm.BatchMutex.Unlock()
+
+	if got := testutil.ToFloat64(prom.DatabaseInputQueueUtilization); got != 0.8 {
+		t.Errorf("Expected input queue utilization 0.8, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.DatabaseCurrentBatchSize); got != 7 {
+		t.Errorf("Expected current batch size 7, got %v", got)
+	}
+}
+
+func TestDrainWithTimeout(t *testing.T) {
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "drain.db"), BatchConfig{
+		BatchSize:     20,
+		FlushInterval: time.Minute,
+		MaxQueueSize:  2000,
+		MaxBatchQueue: 100,
+		Timeout:       5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer bm.Stop()
+
+	processedBefore := testutil.ToFloat64(prom.DatabaseTotalProcessed)
+	batchesBefore := testutil.ToFloat64(prom.DatabaseTotalBatches)
+
+	const operations = 1000
+	for i := 0; i < operations; i++ {
+		if err := bm.AddOperation(&Mockdata{
+			UUID:            fmt.Sprintf("drain-%d", i),
+			RequestMethod:   "POST",
+			RequestEndpoint: "/drain",
+			Timestamp:       time.Now(),
+		}); err != nil {
+			t.Fatalf("AddOperation %d failed: %v", i, err)
+		}
+	}
+
+	if err := bm.DrainWithTimeout(10 * time.Second); err != nil {
+		t.Fatalf("DrainWithTimeout failed: %v", err)
+	}
+
+	var count int
+	if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions WHERE request_endpoint = '/drain'").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != operations {
+		t.Errorf("Expected %d persisted operations, got %d", operations, count)
+	}
+	if got := testutil.ToFloat64(prom.DatabaseTotalProcessed) - processedBefore; got != operations {
+		t.Errorf("Expected database_total_processed to increase by %d, got %v", operations, got)
+	}
+	if got := testutil.ToFloat64(prom.DatabaseTotalBatches) - batchesBefore; got < operations/20 {
+		t.Errorf("Expected at least %d batches in database_total_batches, got %v", operations/20, got)
+	}
+
+	// Once drained, new operations are inserted directly
+	if err := bm.AddOperation(&Mockdata{UUID: "after-drain", RequestMethod: "GET", RequestEndpoint: "/drain", Timestamp: time.Now()}); err != nil {
+		t.Errorf("AddOperation after drain failed: %v", err)
+	}
+}
+
+func TestStopWithoutDrain(t *testing.T) {
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "stop.db"), BatchConfig{
+		BatchSize:     20,
+		FlushInterval: 10 * time.Millisecond,
+		MaxQueueSize:  2000,
+		MaxBatchQueue: 100,
+		Timeout:       5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	const operations = 500
+	for i := 0; i < operations; i++ {
+		if err := bm.AddOperation(&Mockdata{
+			UUID:            fmt.Sprintf("stop-%d", i),
+			RequestMethod:   "POST",
+			RequestEndpoint: "/stop",
+			Timestamp:       time.Now(),
+		}); err != nil {
+			t.Fatalf("AddOperation %d failed: %v", i, err)
+		}
+	}
+
+	// Stop closes the queues while the workers, the aggregator and the auto flush are busy
+	bm.Stop()
+
+	var count int
+	if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions WHERE request_endpoint = '/stop'").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != operations {
+		t.Errorf("Expected %d persisted operations, got %d", operations, count)
+	}
+}
+
+func TestInsertSummaries(t *testing.T) {
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "summaries.db"), BatchConfig{
+		BatchSize:     10,
+		FlushInterval: time.Minute,
+		MaxQueueSize:  100,
+		MaxBatchQueue: 10,
+		Timeout:       5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	durationsBefore, _ := summarySamples(t, prom.DatabaseInsertDuration)
+	batchesBefore, recordsBefore := summarySamples(t, prom.DatabaseBatchSizeRecords)
+
+	const operations = 25
+	for i := 0; i < operations; i++ {
+		if err := bm.AddOperation(&Mockdata{
+			UUID:            fmt.Sprintf("summary-%d", i),
+			RequestMethod:   "POST",
+			RequestEndpoint: "/summary",
+			Timestamp:       time.Now(),
+		}); err != nil {
+			t.Fatalf("AddOperation %d failed: %v", i, err)
+		}
+	}
+	if err := bm.DrainWithTimeout(10 * time.Second); err != nil {
+		t.Fatalf("DrainWithTimeout failed: %v", err)
+	}
+	bm.Stop()
+
+	durations, durationSum := summarySamples(t, prom.DatabaseInsertDuration)
+	batches, records := summarySamples(t, prom.DatabaseBatchSizeRecords)
+	if durations-durationsBefore < 3 {
+		t.Errorf("Expected at least 3 observations in database_insert_duration_seconds, got %d", durations-durationsBefore)
+	}
+	if durationSum <= 0 {
+		t.Errorf("Expected a positive insert duration sum, got %v", durationSum)
+	}
+	if batches-batchesBefore != durations-durationsBefore {
+		t.Errorf("Expected one batch size per insert, got %d sizes for %d inserts", batches-batchesBefore, durations-durationsBefore)
+	}
+	if got := records - recordsBefore; got != operations {
+		t.Errorf("Expected database_batch_size_records to sum %d operations, got %v", operations, got)
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "reconfigure.db"), BatchConfig{
+		BatchSize:     10,
+		FlushInterval: 50 * time.Millisecond,
+		MaxQueueSize:  5000,
+		MaxBatchQueue: 100,
+		MaxWorkers:    3,
+		Timeout:       5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer bm.Stop()
+
+	// Las operaciones siguen llegando mientras cambia la configuración
+	const operations = 2000
+	added := make(chan error, 1)
+	go func() {
+		for i := 0; i < operations; i++ {
+			if err := bm.AddOperation(&Mockdata{
+				UUID:            fmt.Sprintf("reconfigure-%d", i),
+				RequestMethod:   "POST",
+				RequestEndpoint: "/reconfigure",
+				Timestamp:       time.Now(),
+			}); err != nil {
+				added <- fmt.Errorf("AddOperation %d failed: %w", i, err)
+				return
+			}
+			if i%200 == 0 {
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+		added <- nil
+	}()
+
+	if err := bm.Reconfigure(BatchConfig{MaxWorkers: 5, BatchSize: 25, FlushInterval: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	cfg := bm.CurrentConfig()
+	if cfg.MaxWorkers != 5 || cfg.BatchSize != 25 || cfg.FlushInterval != 20*time.Millisecond {
+		t.Errorf("Expected 5 workers, batch size 25 and 20ms flush interval, got %+v", cfg)
+	}
+	if cfg.MaxQueueSize != 5000 {
+		t.Errorf("Expected the queue size to be kept, got %d", cfg.MaxQueueSize)
+	}
+	bm.Mutex.RLock()
+	workers := len(bm.workerCancels)
+	bm.Mutex.RUnlock()
+	if workers != 5 {
+		t.Errorf("Expected 5 running workers, got %d", workers)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := bm.Reconfigure(BatchConfig{MaxWorkers: 2}); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+
+	if err := <-added; err != nil {
+		t.Fatal(err)
+	}
+	if err := bm.DrainWithTimeout(10 * time.Second); err != nil {
+		t.Fatalf("DrainWithTimeout failed: %v", err)
+	}
+
+	var count int
+	if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions WHERE request_endpoint = '/reconfigure'").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != operations {
+		t.Errorf("Expected %d persisted operations, got %d", operations, count)
+	}
+
+	if err := bm.Reconfigure(BatchConfig{BatchSize: -1}); err == nil {
+		t.Error("Expected error for a negative batch size")
+	}
+}
+
+func TestAdaptiveBatchSize(t *testing.T) {
+	tests := []struct {
+		queueLen, queueCap, original, expected int
+	}{
+		{0, 100, 50, 50},
+		{80, 100, 50, 50},
+		{81, 100, 50, 47},
+		{90, 100, 50, 25},
+		{99, 100, 50, 2},
+		{100, 100, 50, 1},
+		{95, 100, 1, 1},
+		{10, 0, 50, 50},
+	}
+	for _, tt := range tests {
+		if got := adaptiveBatchSize(tt.queueLen, tt.queueCap, tt.original); got != tt.expected {
+			t.Errorf("adaptiveBatchSize(%d, %d, %d) = %d, expected %d", tt.queueLen, tt.queueCap, tt.original, got, tt.expected)
+		}
+	}
+}
+
+func TestAdaptBatchSizeHysteresis(t *testing.T) {
+	bm := NewBatchManager(nil, BatchConfig{MaxQueueSize: 100, BatchSize: 50})
+	if err := bm.QueueMgr.Start(); err != nil {
+		t.Fatalf("Failed to start queue manager: %v", err)
+	}
+	defer bm.QueueMgr.Stop()
+
+	fill := func(depth int) {
+		for len(bm.QueueMgr.InputQueue) < depth {
+			if err := bm.QueueMgr.AddRequest(&Mockdata{UUID: "queued"}); err != nil {
+				t.Fatalf("Failed to enqueue request: %v", err)
+			}
+		}
+		for len(bm.QueueMgr.InputQueue) > depth {
+			<-bm.QueueMgr.InputQueue
+		}
+		bm.BatchMutex.Lock()
+		bm.adaptBatchSize()
+		bm.BatchMutex.Unlock()
+	}
+
+	steps := []struct {
+		depth    int
+		expected int
+	}{
+		{50, 50}, // Normal load
+		{90, 25}, // Near-full queue
+		{50, 25}, // Keeps the reduced size until the queue drains
+		{19, 50}, // Restored below 20%
+	}
+	for _, step := range steps {
+		fill(step.depth)
+		if bm.batchSize != step.expected {
+			t.Errorf("Queue depth %d: expected batch size %d, got %d", step.depth, step.expected, bm.batchSize)
+		}
+		if got := testutil.ToFloat64(prom.DatabaseAdaptiveBatchSize); got != float64(step.expected) {
+			t.Errorf("Queue depth %d: expected database_adaptive_batch_size %d, got %v", step.depth, step.expected, got)
+		}
+	}
+}
+
+func TestAdaptiveBatchSizeUnderLoad(t *testing.T) {
+	const (
+		queueSize  = 500
+		batchSize  = 50
+		normalLoad = 200
+		operations = 10 * normalLoad
+	)
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "load.db"), BatchConfig{
+		BatchSize:     batchSize,
+		FlushInterval: 50 * time.Millisecond,
+		MaxQueueSize:  queueSize,
+		MaxBatchQueue: 20,
+		Timeout:       5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer bm.Stop()
+
+	// Sample the queue depth while the load is sent
+	done := make(chan struct{})
+	maxDepth := make(chan int)
+	go func() {
+		deepest := 0
+		for {
+			select {
+			case <-done:
+				maxDepth <- deepest
+				return
+			default:
+			}
+			if depth := len(bm.QueueMgr.InputQueue); depth > deepest {
+				deepest = depth
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 10; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < operations/10; i++ {
+				if err := bm.AddOperation(&Mockdata{
+					UUID:            fmt.Sprintf("load-%d-%d", worker, i),
+					RequestMethod:   "POST",
+					RequestEndpoint: "/load",
+					Timestamp:       time.Now(),
+				}); err != nil {
+					t.Errorf("AddOperation failed: %v", err)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if err := bm.DrainWithTimeout(10 * time.Second); err != nil {
+		t.Fatalf("DrainWithTimeout failed: %v", err)
+	}
+	close(done)
+
+	if depth := <-maxDepth; depth > queueSize {
+		t.Errorf("Expected the input queue depth to stay within %d, got %d", queueSize, depth)
+	}
+
+	var count int
+	if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions WHERE request_endpoint = '/load'").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != operations {
+		t.Errorf("Expected %d persisted operations, got %d", operations, count)
+	}
+
+	// Once the queue is empty the configured batch size is restored
+	bm.BatchMutex.Lock()
+	effective := bm.batchSize
+	bm.BatchMutex.Unlock()
+	if effective != batchSize {
+		t.Errorf("Expected batch size %d after the load, got %d", batchSize, effective)
+	}
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	prom "catalyst/prometheus"
+)
+
+// BodySearchIndex es un índice invertido en memoria: palabra → conjunto de UUIDs
+type BodySearchIndex struct {
+	mu    sync.RWMutex
+	words map[string]map[string]struct{}
+	// docs guarda las palabras de cada UUID para poder quitarlo del índice
+	docs map[string]map[string]struct{}
+}
+
+// NewBodySearchIndex crea un índice vacío
+func NewBodySearchIndex() *BodySearchIndex {
+	return &BodySearchIndex{
+		words: make(map[string]map[string]struct{}),
+		docs:  make(map[string]map[string]struct{}),
+	}
+}
+
+// SearchIndex retorna el índice de búsqueda por contenido de las transacciones del BatchManager
+func (bm *BatchManager) SearchIndex() *BodySearchIndex {
+	return bm.searchIndex
+}
+
+// SetSearchIndex reemplaza el índice, p. ej. para compartirlo entre los BatchManager de la misma
+// base de datos. Debe llamarse antes de usar el BatchManager.
+func (bm *BatchManager) SetSearchIndex(idx *BodySearchIndex) {
+	bm.searchIndex = idx
+}
+
+// Add indexa las palabras del body para la transacción uuid
+func (idx *BodySearchIndex) Add(uuid, body string) {
+	tokens := tokenize(body)
+	if len(tokens) == 0 {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	doc, ok := idx.docs[uuid]
+	if !ok {
+		doc = make(map[string]struct{})
+		idx.docs[uuid] = doc
+	}
+	for _, token := range tokens {
+		uuids, ok := idx.words[token]
+		if !ok {
+			uuids = make(map[string]struct{})
+			idx.words[token] = uuids
+		}
+		uuids[uuid] = struct{}{}
+		doc[token] = struct{}{}
+	}
+	prom.DatabaseSearchIndexSize.Set(float64(len(idx.words)))
+}
+
+// Remove quita la transacción uuid del índice; las palabras que quedan sin UUIDs se eliminan
+func (idx *BodySearchIndex) Remove(uuid string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	doc, ok := idx.docs[uuid]
+	if !ok {
+		return
+	}
+	for token := range doc {
+		uuids := idx.words[token]
+		delete(uuids, uuid)
+		if len(uuids) == 0 {
+			delete(idx.words, token)
+		}
+	}
+	delete(idx.docs, uuid)
+	prom.DatabaseSearchIndexSize.Set(float64(len(idx.words)))
+}
+
+// Search retorna los UUIDs cuyo body contiene todas las palabras de la consulta, ordenados
+func (idx *BodySearchIndex) Search(query string) []string {
+	tokens := tokenize(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Intersectar empezando por la palabra con menos resultados
+	sets := make([]map[string]struct{}, 0, len(tokens))
+	for _, token := range tokens {
+		uuids, ok := idx.words[token]
+		if !ok {
+			return nil
+		}
+		sets = append(sets, uuids)
+	}
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+
+	var result []string
+	for uuid := range sets[0] {
+		found := true
+		for _, set := range sets[1:] {
+			if _, ok := set[uuid]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			result = append(result, uuid)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Len retorna el número de palabras indexadas
+func (idx *BodySearchIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.words)
+}
+
+// LoadSearchIndex indexa en SearchIndex los bodies de las transacciones ya guardadas en la base de datos
+func (bm *BatchManager) LoadSearchIndex() error {
+	idx := bm.searchIndex
+	db := bm.GetDB()
+	if db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	rows, err := db.Query("SELECT uuid, request_body, response_body FROM mock_transactions")
+	if err != nil {
+		return fmt.Errorf("error querying transactions for search index: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var uuid string
+		var requestBody, responseBody sql.NullString
+		if err := rows.Scan(&uuid, &requestBody, &responseBody); err != nil {
+			return fmt.Errorf("error scanning transaction for search index: %w", err)
+		}
+		idx.Add(uuid, requestBody.String)
+		idx.Add(uuid, responseBody.String)
+	}
+	return rows.Err()
+}
+
+// tokenize separa el texto en palabras alfanuméricas en minúsculas
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
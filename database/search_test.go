@@ -0,0 +1,271 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	prom "catalyst/prometheus"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBodySearchIndex(t *testing.T) {
+	idx := NewBodySearchIndex()
+	idx.Add("a", `{"status":"error","message":"card declined"}`)
+	idx.Add("b", `{"status":"ok","message":"Approved"}`)
+	idx.Add("c", `<error code="51">insufficient funds</error>`)
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"error", []string{"a", "c"}},
+		{"ERROR", []string{"a", "c"}},
+		{"card declined", []string{"a"}},
+		{"approved", []string{"b"}},
+		{"error approved", nil},
+		{"missing", nil},
+		{"  ", nil},
+	}
+
+	for _, tt := range tests {
+		if got := idx.Search(tt.query); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Search(%q) = %v, expected %v", tt.query, got, tt.expected)
+		}
+	}
+
+	if got := testutil.ToFloat64(prom.DatabaseSearchIndexSize); got != float64(idx.Len()) {
+		t.Errorf("Expected search index size gauge %d, got %v", idx.Len(), got)
+	}
+}
+
+func TestBodySearchIndexRemove(t *testing.T) {
+	idx := NewBodySearchIndex()
+	idx.Add("a", `{"status":"error","message":"card declined"}`)
+	idx.Add("a", `{"retry":true}`)
+	idx.Add("b", `{"status":"error"}`)
+
+	idx.Remove("a")
+	if got := idx.Search("error"); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("Expected only b after removing a, got %v", got)
+	}
+	if got := idx.Search("retry"); got != nil {
+		t.Errorf("Expected the words of every body of a to be removed, got %v", got)
+	}
+	// Only the words of b remain
+	if idx.Len() != 2 {
+		t.Errorf("Expected 2 indexed words, got %d", idx.Len())
+	}
+
+	idx.Remove("b")
+	idx.Remove("missing")
+	if idx.Len() != 0 {
+		t.Errorf("Expected an empty index, got %d words", idx.Len())
+	}
+	if got := testutil.ToFloat64(prom.DatabaseSearchIndexSize); got != 0 {
+		t.Errorf("Expected search index size gauge 0, got %v", got)
+	}
+}
+
+func TestLoadSearchIndex(t *testing.T) {
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "search.db"), BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	if err := InsertOperation(bm.GetDB(), &Mockdata{
+		UUID:            "stored",
+		RequestMethod:   "POST",
+		RequestEndpoint: "/pay",
+		RequestBody:     `{"amount":10}`,
+		ResponseBody:    `{"error":"timeout"}`,
+		Timestamp:       time.Now(),
+	}); err != nil {
+		t.Fatalf("InsertOperation failed: %v", err)
+	}
+
+	if err := bm.LoadSearchIndex(); err != nil {
+		t.Fatalf("LoadSearchIndex failed: %v", err)
+	}
+	if got := bm.SearchIndex().Search("timeout"); !reflect.DeepEqual(got, []string{"stored"}) {
+		t.Errorf("Expected the stored transaction to be indexed, got %v", got)
+	}
+}
+
+// seedSearchBenchmark guarda n transacciones, una de cada 100 con "error" en el body
+func seedSearchBenchmark(b *testing.B, n int) (*BatchManager, *BodySearchIndex) {
+	b.Helper()
+	bm, err := OpenBatchManager(filepath.Join(b.TempDir(), "bench.db"), BatchConfig{})
+	if err != nil {
+		b.Fatalf("OpenBatchManager failed: %v", err)
+	}
+
+	idx := NewBodySearchIndex()
+	for i := 0; i < n; i++ {
+		body := fmt.Sprintf(`{"id":%d,"status":"ok","message":"payment processed"}`, i)
+		if i%100 == 0 {
+			body = fmt.Sprintf(`{"id":%d,"status":"error","message":"payment rejected"}`, i)
+		}
+		operation := &Mockdata{
+			UUID:            fmt.Sprintf("bench-%d", i),
+			RequestMethod:   "POST",
+			RequestEndpoint: "/pay",
+			ResponseBody:    body,
+			Timestamp:       time.Now(),
+		}
+		if err := InsertOperation(bm.GetDB(), operation); err != nil {
+			b.Fatalf("InsertOperation failed: %v", err)
+		}
+		idx.Add(operation.UUID, operation.ResponseBody)
+	}
+	return bm, idx
+}
+
+func BenchmarkBodySearchIndex(b *testing.B) {
+	bm, idx := seedSearchBenchmark(b, 10000)
+	defer bm.GetDB().Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(idx.Search("error")) != 100 {
+			b.Fatal("unexpected number of results")
+		}
+	}
+}
+
+func BenchmarkBodySearchLike(b *testing.B) {
+	bm, _ := seedSearchBenchmark(b, 10000)
+	defer bm.GetDB().Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := bm.GetDB().Query("SELECT uuid FROM mock_transactions WHERE response_body LIKE ?", "%error%")
+		if err != nil {
+			b.Fatalf("Query failed: %v", err)
+		}
+		count := 0
+		for rows.Next() {
+			count++
+		}
+		rows.Close()
+		if count != 100 {
+			b.Fatal("unexpected number of results")
+		}
+	}
+}
+
+func TestIndexedBodyField(t *testing.T) {
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "fields.db"), BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	if err := bm.AddIndexedBodyField("customerId", "$.customerId"); err != nil {
+		t.Fatalf("AddIndexedBodyField failed: %v", err)
+	}
+	// Volver a crearlo al reiniciar el servidor no falla
+	if err := bm.AddIndexedBodyField("customerId", "$.customerId"); err != nil {
+		t.Fatalf("AddIndexedBodyField is not idempotent: %v", err)
+	}
+	if err := bm.AddIndexedBodyField("customerId", "$.customer.id"); err == nil {
+		t.Error("Expected an error changing the jsonpath of an indexed field")
+	}
+	if err := bm.AddIndexedBodyField("body", "$.body"); err == nil {
+		t.Error("Expected an error for a field whose column already exists")
+	}
+	if err := bm.AddIndexedBodyField("customerId2", "$.id'; DROP TABLE mock_transactions; --"); err == nil {
+		t.Error("Expected an error for an invalid jsonpath")
+	}
+
+	for i, body := range []string{`{"customerId":"123"}`, `{"customerId":456}`, `not json`, `{"customerId":"123"}`} {
+		if err := InsertOperation(bm.GetDB(), &Mockdata{
+			UUID:            fmt.Sprintf("tx-%d", i),
+			RequestMethod:   "POST",
+			RequestEndpoint: "/pay",
+			RequestBody:     body,
+			Timestamp:       time.Now(),
+		}); err != nil {
+			t.Fatalf("InsertOperation failed for body %q: %v", body, err)
+		}
+	}
+
+	for value, expected := range map[string]int{"123": 2, "456": 1, "789": 0} {
+		var count int
+		if err := bm.GetDB().QueryRow("SELECT COUNT(*) FROM mock_transactions WHERE request_customer_id = ?", value).Scan(&count); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if count != expected {
+			t.Errorf("Expected %d transactions with customerId %s, got %d", expected, value, count)
+		}
+	}
+
+	if indexed, err := bm.HasIndexedBodyField("customerId"); err != nil || !indexed {
+		t.Errorf("Expected customerId to be indexed, got %v (%v)", indexed, err)
+	}
+	if indexed, err := bm.HasIndexedBodyField("orderId"); err != nil || indexed {
+		t.Errorf("Expected orderId not to be indexed, got %v (%v)", indexed, err)
+	}
+}
+
+func TestTransactionTags(t *testing.T) {
+	bm, err := OpenBatchManager(filepath.Join(t.TempDir(), "tags.db"), BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+
+	tags := map[string]string{"X-Test-Name": "PaymentFlowTest", "X-Test-Suite": "checkout"}
+	for _, operation := range []*Mockdata{
+		{UUID: "tagged", RequestMethod: "POST", RequestEndpoint: "/pay", Tags: tags, Timestamp: time.Now()},
+		{UUID: "untagged", RequestMethod: "POST", RequestEndpoint: "/pay", Timestamp: time.Now().Add(time.Second)},
+	} {
+		if err := InsertOperation(bm.GetDB(), operation); err != nil {
+			t.Fatalf("InsertOperation failed: %v", err)
+		}
+	}
+
+	operations, err := ListOperations(bm.GetDB())
+	if err != nil {
+		t.Fatalf("ListOperations failed: %v", err)
+	}
+	if len(operations) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(operations))
+	}
+	if !reflect.DeepEqual(operations[0].Tags, tags) {
+		t.Errorf("Expected tags %v, got %v", tags, operations[0].Tags)
+	}
+	if len(operations[1].Tags) != 0 {
+		t.Errorf("Expected no tags, got %v", operations[1].Tags)
+	}
+
+	// Las transacciones sin tags guardan {} y json_extract no falla
+	var uuid string
+	if err := bm.GetDB().QueryRow(`SELECT uuid FROM mock_transactions WHERE json_extract(tags, '$."X-Test-Name"') = ?`, "PaymentFlowTest").Scan(&uuid); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if uuid != "tagged" {
+		t.Errorf("Expected the tagged transaction, got %s", uuid)
+	}
+}
+
+func TestBodyFieldColumn(t *testing.T) {
+	tests := map[string]string{
+		"customerId":  "request_customer_id",
+		"customer_id": "request_customer_id",
+		"CustomerID":  "request_customer_id",
+		"order2Total": "request_order2_total",
+	}
+	for field, expected := range tests {
+		if got, err := BodyFieldColumn(field); err != nil || got != expected {
+			t.Errorf("BodyFieldColumn(%q) = %q, %v; expected %q", field, got, err, expected)
+		}
+	}
+	if _, err := BodyFieldColumn("customer-id"); err == nil {
+		t.Error("Expected an error for a field that isn't a valid identifier")
+	}
+}
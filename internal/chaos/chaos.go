@@ -1,9 +1,15 @@
 package chaos
 
 import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
 	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"catalyst/internal/models"
@@ -11,51 +17,118 @@ import (
 
 // Engine manages chaos injection in HTTP responses
 type Engine struct {
+	mu   sync.Mutex
 	rand *rand.Rand
 }
 
 // NewEngine creates a new instance of the chaos engine
 func NewEngine() *Engine {
+	return NewEngineWithSeed(time.Now().UnixNano())
+}
+
+// NewEngineWithSeed creates a chaos engine whose decisions are reproducible for the given seed
+func NewEngineWithSeed(seed int64) *Engine {
 	return &Engine{
-		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+		rand: rand.New(rand.NewSource(seed)),
 	}
 }
 
-// ApplyChaos applies chaos injection based on the configuration
-func (e *Engine) ApplyChaos(w http.ResponseWriter, chaosConfig *models.ChaosInjection) bool {
+// roll returns a random percentage in [0, 100)
+func (e *Engine) roll() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rand.Float64() * 100
+}
+
+// stickyRoll returns a percentage in [0, 100) fixed for the client IP, path and method of the request.
+// clientIP comes from the router, so clients behind a trusted proxy are told apart.
+func stickyRoll(clientIP string, r *http.Request) float64 {
+	hash := fnv.New32a()
+	hash.Write([]byte(clientIP + r.URL.Path + r.Method))
+	return float64(hash.Sum32() % 100)
+}
+
+// Effect describes a chaos action applied to a request
+type Effect struct {
+	Type    string
+	Applied string
+}
+
+// ApplyChaos applies chaos injection based on the configuration. It reports whether the
+// request was aborted and the effects that fired. clientIP keys sticky decisions.
+func (e *Engine) ApplyChaos(w http.ResponseWriter, r *http.Request, clientIP string, chaosConfig *models.ChaosInjection) (bool, []Effect) {
 	if chaosConfig == nil {
-		return false
+		return false, nil
+	}
+
+	var effects []Effect
+
+	// Each effect rolls its probability first; the body size is an additional condition
+	roll := e.roll
+	if chaosConfig.Sticky {
+		sticky := stickyRoll(clientIP, r)
+		roll = func() float64 { return sticky }
 	}
+	triggered := bodyLargerThan(r, chaosConfig.TriggerIfBodyLargerThanBytes)
 
 	// Apply latency if configured
-	latency := e.applyLatency(chaosConfig.Latency)
-	if latency > 0 {
+	latency := e.applyLatency(chaosConfig.Latency, roll)
+	if latency > 0 && triggered {
 		time.Sleep(latency)
+		effects = append(effects, Effect{Type: "latency", Applied: latency.String()})
 	}
 
 	// Apply abort if configured
-	abortCode := e.applyAbort(chaosConfig.Abort)
-	if abortCode > 0 {
+	abortCode := e.applyAbort(chaosConfig.Abort, roll)
+	if abortCode > 0 && triggered {
 		w.WriteHeader(abortCode)
-		return true
+		return true, append(effects, Effect{Type: "abort", Applied: strconv.Itoa(abortCode)})
 	}
 
 	// Apply error if configured
-	errorCode := e.applyError(chaosConfig.Error)
-	if errorCode > 0 {
+	errorCode := e.applyError(chaosConfig.Error, roll)
+	if errorCode > 0 && triggered {
+		if chaosConfig.Error.DropAfterBytes > 0 {
+			dropConnection(w, errorCode, chaosConfig.Error.Response, chaosConfig.Error.DropAfterBytes)
+			return true, append(effects, Effect{Type: "drop", Applied: fmt.Sprintf("%d after %d bytes", errorCode, chaosConfig.Error.DropAfterBytes)})
+		}
+
 		w.WriteHeader(errorCode)
+		effects = append(effects, Effect{Type: "error", Applied: strconv.Itoa(errorCode)})
 		_, err := w.Write([]byte(chaosConfig.Error.Response))
 		if err != nil {
-			return false
+			return false, effects
 		}
+		return true, effects
+	}
+
+	return false, effects
+}
+
+// bodyLargerThan reports whether the request body exceeds threshold bytes. A threshold of 0
+// disables the check. Without Content-Length the body is read and put back for the handler.
+func bodyLargerThan(r *http.Request, threshold int64) bool {
+	if threshold <= 0 {
 		return true
 	}
+	if r == nil || r.Body == nil {
+		return false
+	}
+	if r.ContentLength >= 0 {
+		return r.ContentLength > threshold
+	}
 
-	return false
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return int64(len(body)) > threshold
 }
 
 // applyLatency returns a duration to delay the response based on the latency configuration
-func (e *Engine) applyLatency(latency models.Latency) time.Duration {
+func (e *Engine) applyLatency(latency models.Latency, roll func() float64) time.Duration {
 	if latency.Time <= 0 {
 		return 0
 	}
@@ -65,7 +138,7 @@ func (e *Engine) applyLatency(latency models.Latency) time.Duration {
 		return 0
 	}
 
-	if e.rand.Float64()*100 > probability {
+	if roll() > probability {
 		return 0
 	}
 
@@ -73,7 +146,7 @@ func (e *Engine) applyLatency(latency models.Latency) time.Duration {
 }
 
 // applyAbort returns an HTTP status code to abort the request based on the abort configuration
-func (e *Engine) applyAbort(abort models.Abort) int {
+func (e *Engine) applyAbort(abort models.Abort, roll func() float64) int {
 	if abort.Code <= 0 {
 		return 0
 	}
@@ -83,7 +156,7 @@ func (e *Engine) applyAbort(abort models.Abort) int {
 		return 0
 	}
 
-	if e.rand.Float64()*100 > probability {
+	if roll() > probability {
 		return 0
 	}
 
@@ -91,7 +164,7 @@ func (e *Engine) applyAbort(abort models.Abort) int {
 }
 
 // applyError returns an HTTP error status code based on the error configuration
-func (e *Engine) applyError(errorConfig models.Error) int {
+func (e *Engine) applyError(errorConfig models.Error, roll func() float64) int {
 	if errorConfig.Code <= 0 {
 		return 0
 	}
@@ -101,9 +174,45 @@ func (e *Engine) applyError(errorConfig models.Error) int {
 		return 0
 	}
 
-	if e.rand.Float64()*100 > probability {
+	if roll() > probability {
 		return 0
 	}
 
 	return errorConfig.Code
 }
+
+// dropConnection sends the status, headers and the first n bytes of body announcing the full
+// Content-Length, then resets the connection to simulate a drop mid-response
+func dropConnection(w http.ResponseWriter, code int, body string, n int) {
+	if n > len(body) {
+		n = len(body)
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		// Without access to the connection the partial body is all that can be simulated
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(code)
+		w.Write([]byte(body[:n]))
+		return
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", code, http.StatusText(code))
+	header := w.Header().Clone()
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Write(buf)
+	buf.WriteString("\r\n")
+	buf.WriteString(body[:n])
+	buf.Flush()
+
+	// Linger 0 makes Close send a RST instead of a graceful FIN
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+}
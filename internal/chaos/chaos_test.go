@@ -0,0 +1,141 @@
+package chaos
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"catalyst/internal/models"
+)
+
+func TestApplyChaosSticky(t *testing.T) {
+	engine := NewEngine()
+	config := &models.ChaosInjection{
+		Error:  models.Error{Code: 503, Probability: "50", Response: `{"error":"chaos"}`},
+		Sticky: true,
+	}
+
+	apply := func(client, port int) bool {
+		r := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+		r.RemoteAddr = fmt.Sprintf("172.16.0.1:%d", port)
+		aborted, _ := engine.ApplyChaos(httptest.NewRecorder(), r, fmt.Sprintf("10.0.0.%d", client), config)
+		return aborted
+	}
+
+	// Every request of a client to an endpoint gets the same outcome
+	outcomes := make(map[bool]int)
+	for client := 1; client <= 50; client++ {
+		first := apply(client, 40000)
+		for port := 40001; port <= 40010; port++ {
+			// The proxy connection changes between requests, the outcome does not
+			if got := apply(client, port); got != first {
+				t.Fatalf("Client 10.0.0.%d got chaos %v and then %v", client, first, got)
+			}
+		}
+		outcomes[first]++
+	}
+
+	// With 50% probability some clients get chaos and some do not, even behind the same proxy
+	if outcomes[true] == 0 || outcomes[false] == 0 {
+		t.Errorf("Expected clients on both sides of the probability, got %v", outcomes)
+	}
+}
+
+func TestStickyRoll(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/payment", nil)
+
+	roll := stickyRoll("192.168.1.10", r)
+	if roll < 0 || roll >= 100 {
+		t.Fatalf("Expected roll in [0, 100), got %v", roll)
+	}
+
+	r.RemoteAddr = "10.1.1.1:6000"
+	if got := stickyRoll("192.168.1.10", r); got != roll {
+		t.Errorf("Expected the roll to ignore the connection address, got %v and %v", roll, got)
+	}
+
+	// The roll covers the path and method too
+	rolls := make(map[float64]bool)
+	for _, path := range []string{"/a", "/b", "/c", "/d", "/e", "/f", "/g", "/h"} {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			rolls[stickyRoll("192.168.1.10", httptest.NewRequest(method, path, nil))] = true
+		}
+	}
+	if len(rolls) < 2 {
+		t.Errorf("Expected the roll to depend on path and method, got %v", rolls)
+	}
+}
+
+func TestNewEngineWithSeed(t *testing.T) {
+	config := &models.ChaosInjection{
+		Abort: models.Abort{Code: http.StatusServiceUnavailable, Probability: "50"},
+	}
+
+	countAborts := func() int {
+		engine := NewEngineWithSeed(42)
+		aborts := 0
+		for i := 0; i < 100; i++ {
+			w := httptest.NewRecorder()
+			if aborted, _ := engine.ApplyChaos(w, httptest.NewRequest(http.MethodGet, "/api/flaky", nil), "10.0.0.1", config); aborted {
+				if w.Code != http.StatusServiceUnavailable {
+					t.Fatalf("Expected abort status 503, got %d", w.Code)
+				}
+				aborts++
+			}
+		}
+		return aborts
+	}
+
+	// The handler tests rely on seed 42 aborting exactly 48 of 100 requests at 50%
+	for run := 0; run < 2; run++ {
+		if aborts := countAborts(); aborts != 48 {
+			t.Errorf("Run %d: expected 48 aborts, got %d", run, aborts)
+		}
+	}
+}
+
+func TestApplyChaosTriggerIfBodyLargerThan(t *testing.T) {
+	engine := NewEngine()
+	config := &models.ChaosInjection{
+		Abort:                        models.Abort{Code: http.StatusRequestEntityTooLarge, Probability: "100"},
+		TriggerIfBodyLargerThanBytes: 16,
+	}
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength bool
+		expected      bool
+	}{
+		{"Small body", "small", true, false},
+		{"Large body", strings.Repeat("x", 32), true, true},
+		{"Small body without Content-Length", "small", false, false},
+		{"Large body without Content-Length", strings.Repeat("x", 32), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/upload", strings.NewReader(tt.body))
+			if !tt.contentLength {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			aborted, _ := engine.ApplyChaos(w, r, "10.0.0.1", config)
+			if aborted != tt.expected {
+				t.Fatalf("Expected aborted %v, got %v", tt.expected, aborted)
+			}
+			if aborted && w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("Expected status 413, got %d", w.Code)
+			}
+
+			// Measuring the body must leave it intact for the handler
+			if body, _ := io.ReadAll(r.Body); string(body) != tt.body {
+				t.Errorf("Expected the body to be readable after chaos, got %q", body)
+			}
+		})
+	}
+}
@@ -2,18 +2,25 @@ package config
 
 import (
 	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 var config *models.MockServer
 
-// LoadConfig loads a mock server configuration from a YAML file
+// LoadConfig loads a mock server configuration from a YAML file, or a JSON file when the
+// extension is .json
 func LoadConfig(filePath string) (*models.MockServer, error) {
 	// Read the YAML file
 	f, err := os.OpenFile(filePath, os.O_RDONLY|os.O_CREATE, 0666)
@@ -30,10 +37,31 @@ func LoadConfig(filePath string) (*models.MockServer, error) {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	// Parse the YAML into the MockServer struct
+	return parseConfig(data, isJSONFile(filePath), configFileName(filePath))
+}
+
+// parseConfig decodes and validates a YAML or JSON config; name identifies configs that don't
+// set their own
+func parseConfig(data []byte, isJSON bool, name string) (*models.MockServer, error) {
 	var config models.MockServer
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("error parsing config file: %w", err)
+	if isJSON {
+		// JSON files use the json keys of the models, which the schema does not describe, so unknown
+		// keys are rejected while decoding instead
+		decoder := json.NewDecoder(strings.NewReader(os.ExpandEnv(string(data))))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return nil, fmt.Errorf("error parsing config file: %w", err)
+		}
+	} else {
+		// Parse the YAML into the MockServer struct
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("error parsing config file: %w", err)
+		}
+
+		// Validate the document against the configuration schema
+		if err := validateSchema(data); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
 	}
 
 	// Validate the configuration
@@ -41,10 +69,63 @@ func LoadConfig(filePath string) (*models.MockServer, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	// Files without a name are identified by their file name
+	if config.Name == "" {
+		config.Name = name
+	}
+
 	return &config, nil
 }
 
-// LoadConfigFromDir loads all YAML configuration files from a directory
+// isJSONFile reports whether the config file is JSON, by its extension
+func isJSONFile(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ".json")
+}
+
+// configFileName returns the file name without directory and extension, e.g. ./configs/foo.yml → foo
+func configFileName(filePath string) string {
+	base := filepath.Base(filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// FindConfigFile returns the YAML file of the config named name within dirPath: the file whose
+// top-level name matches, or else the one called name.yml, name.yaml or name.json
+func FindConfigFile(dirPath, name string) (string, error) {
+	var files []string
+	for _, ext := range []string{".yml", ".yaml", ".json"} {
+		matches, err := filepath.Glob(filepath.Join(dirPath, "*"+ext))
+		if err != nil {
+			return "", fmt.Errorf("error finding config files: %w", err)
+		}
+		files = append(files, matches...)
+	}
+
+	var byFileName string
+	for _, file := range files {
+		// The file name still works for files that set their own name, a matching name wins
+		if byFileName == "" && strings.EqualFold(configFileName(file), name) {
+			byFileName = file
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var header struct {
+			Name string `yaml:"name"`
+		}
+		if err := yaml.Unmarshal(data, &header); err == nil && strings.EqualFold(header.Name, name) {
+			return file, nil
+		}
+	}
+
+	if byFileName == "" {
+		return "", fmt.Errorf("no config file named %s in %s", name, dirPath)
+	}
+	return byFileName, nil
+}
+
+// LoadConfigFromDir loads all YAML and JSON configuration files from a directory
 func LoadConfigFromDir(dirPath string) ([]*models.MockServer, error) {
 	// Get all YAML files in the directory
 	files, err := filepath.Glob(filepath.Join(dirPath, "*.yaml"))
@@ -60,11 +141,37 @@ func LoadConfigFromDir(dirPath string) ([]*models.MockServer, error) {
 
 	files = append(files, ymlFiles...)
 
+	jsonFiles, err := filepath.Glob(filepath.Join(dirPath, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error finding JSON files: %w", err)
+	}
+
+	files = append(files, jsonFiles...)
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no YAML or JSON configuration files found in %s", dirPath)
+	}
+
+	return loadConfigFiles(files)
+}
+
+// LoadConfigFromGlob loads all configuration files matching a glob pattern,
+// e.g. ./configs/*/server.yaml
+func LoadConfigFromGlob(pattern string) ([]*models.MockServer, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("error matching glob pattern %s: %w", pattern, err)
+	}
+
 	if len(files) == 0 {
-		return nil, fmt.Errorf("no YAML configuration files found in %s", dirPath)
+		return nil, fmt.Errorf("no configuration files match %s", pattern)
 	}
 
-	// Load each configuration file
+	return loadConfigFiles(files)
+}
+
+// loadConfigFiles loads each configuration file
+func loadConfigFiles(files []string) ([]*models.MockServer, error) {
 	var configs []*models.MockServer
 	for _, file := range files {
 		config, err := LoadConfig(file)
@@ -74,18 +181,60 @@ func LoadConfigFromDir(dirPath string) ([]*models.MockServer, error) {
 		configs = append(configs, config)
 	}
 
+	if err := checkPortConflicts(files, configs); err != nil {
+		return nil, err
+	}
+
 	return configs, nil
 }
 
-// SaveConfig saves a mock server configuration to a YAML file
+// checkPortConflicts fails when two servers of the loaded files listen on the same port, which
+// would otherwise only fail at runtime when the second server is created
+func checkPortConflicts(files []string, configs []*models.MockServer) error {
+	portFiles := make(map[int][]string)
+	for i, config := range configs {
+		for _, server := range config.Http.Servers {
+			portFiles[server.Listen] = append(portFiles[server.Listen], files[i])
+		}
+	}
+
+	ports := make([]int, 0, len(portFiles))
+	for port := range portFiles {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	var conflicts []string
+	for _, port := range ports {
+		if definedIn := portFiles[port]; len(definedIn) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("port %d is defined in %s", port, strings.Join(definedIn, ", ")))
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("port conflicts between config files: %s", strings.Join(conflicts, "; "))
+}
+
+// SaveConfig saves a mock server configuration to a YAML file, or a JSON file when the
+// extension is .json
 func SaveConfig(config *models.MockServer, filePath string) error {
-	// Marshal the config to YAML
-	data, err := yaml.Marshal(config)
+	var (
+		data []byte
+		err  error
+	)
+	if isJSONFile(filePath) {
+		data, err = json.MarshalIndent(config, "", "  ")
+	} else {
+		// Marshal the config to YAML
+		data, err = yaml.Marshal(config)
+	}
 	if err != nil {
 		return fmt.Errorf("error marshaling config: %w", err)
 	}
 
-	// Write the YAML to the file
+	// Write the config to the file
 	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
 		return fmt.Errorf("error writing config file: %w", err)
 	}
@@ -94,6 +243,54 @@ func SaveConfig(config *models.MockServer, filePath string) error {
 }
 
 // validateConfig validates a mock server configuration
+// validateMethod checks that the method of a location is an uppercase RFC 7230 token, so custom
+// methods such as PURGE or PROPFIND can be mocked. Gin routes only accept letters, so methods with
+// other token characters (e.g. M-SEARCH) need path_regex.
+func validateMethod(location models.Location) error {
+	for _, r := range location.Method {
+		if !isTokenChar(r) || (r >= 'a' && r <= 'z') {
+			return fmt.Errorf("%q is not an uppercase HTTP token", location.Method)
+		}
+	}
+
+	if location.PathRegex == "" && strings.IndexFunc(location.Method, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		return fmt.Errorf("%q has characters other than A-Z, which are only supported with path_regex", location.Method)
+	}
+	return nil
+}
+
+// validateWildcard checks that a * in the path is a trailing /* named by wildcard_param
+func validateWildcard(location models.Location) error {
+	if location.WildcardParam == "" {
+		if strings.Contains(location.Path, "*") {
+			return fmt.Errorf("path %s has a * but no wildcard_param", location.Path)
+		}
+		return nil
+	}
+
+	if location.PathRegex != "" {
+		return fmt.Errorf("wildcard_param is not supported with path_regex")
+	}
+	if !strings.HasSuffix(location.Path, "/*") || strings.Count(location.Path, "*") != 1 {
+		return fmt.Errorf("path %s must end with /* to use wildcard_param", location.Path)
+	}
+	for _, r := range location.WildcardParam {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return fmt.Errorf("wildcard_param %q must only have letters, digits and _", location.WildcardParam)
+		}
+	}
+	return nil
+}
+
+// isTokenChar reports whether r is a tchar of RFC 7230, section 3.2.6
+func isTokenChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
+
 func validateConfig(config *models.MockServer) error {
 	if len(config.Http.Servers) == 0 {
 		return fmt.Errorf("no servers defined in configuration")
@@ -108,18 +305,102 @@ func validateConfig(config *models.MockServer) error {
 			return fmt.Errorf("server %d has no locations defined", i)
 		}
 
+		if (server.TLSCertFile == "") != (server.TLSKeyFile == "") {
+			return fmt.Errorf("server %d must set both tls_cert_file and tls_key_file", i)
+		}
+
+		tlsEnabled := server.TLSCertFile != "" || server.TLSAuto
+		if server.HTTP2 && !tlsEnabled {
+			return fmt.Errorf("server %d enables http2 without tls_cert_file/tls_key_file or tls_auto", i)
+		}
+		if server.H2C && tlsEnabled {
+			return fmt.Errorf("server %d enables h2c together with TLS", i)
+		}
+
+		if server.Timezone != "" {
+			if _, err := time.LoadLocation(server.Timezone); err != nil {
+				return fmt.Errorf("server %d has invalid timezone %q: %w", i, server.Timezone, err)
+			}
+		}
+
+		indexedFields := make(map[string]bool)
+		for _, indexed := range server.IndexedBodyFields {
+			if indexed.Field == "" || indexed.JSONPath == "" {
+				return fmt.Errorf("server %d has an indexed_body_fields entry without field or jsonpath", i)
+			}
+			if indexedFields[indexed.Field] {
+				return fmt.Errorf("server %d indexes body field %s more than once", i, indexed.Field)
+			}
+			indexedFields[indexed.Field] = true
+		}
+
+		if machine := server.StateMachine; machine != nil {
+			if len(machine.States) == 0 {
+				return fmt.Errorf("server %d has a state_machine without states", i)
+			}
+			if !machine.Has(machine.InitialState) {
+				return fmt.Errorf("server %d has initial_state %q that is not one of its states", i, machine.InitialState)
+			}
+		}
+
 		for j, location := range server.Location {
-			if location.Path == "" {
+			if location.Path == "" && location.PathRegex == "" {
 				return fmt.Errorf("server %d, location %d has empty path", i, j)
 			}
 
+			if location.PathRegex != "" {
+				if _, err := regexp.Compile(location.PathRegex); err != nil {
+					return fmt.Errorf("server %d, location %d has invalid path_regex: %w", i, j, err)
+				}
+				if location.StaticFilesDir != "" {
+					return fmt.Errorf("server %d, location %d cannot serve static_dir on a path_regex", i, j)
+				}
+			}
+
+			if err := validateWildcard(location); err != nil {
+				return fmt.Errorf("server %d, location %d has invalid wildcard: %w", i, j, err)
+			}
+
 			if location.Method == "" {
 				return fmt.Errorf("server %d, location %d has empty method", i, j)
 			}
 
+			if err := validateMethod(location); err != nil {
+				return fmt.Errorf("server %d, location %d has invalid method: %w", i, j, err)
+			}
+
 			if location.StatusCode <= 0 {
 				return fmt.Errorf("server %d, location %d has invalid status code: %d", i, j, location.StatusCode)
 			}
+
+			if location.Schema != "" && location.SchemaFile != "" {
+				return fmt.Errorf("server %d, location %d sets both schema and schema_file", i, j)
+			}
+
+			if err := validateDelaySchedule(location.ResponseDelaySchedule); err != nil {
+				return fmt.Errorf("server %d, location %d has invalid response_delay_schedule: %w", i, j, err)
+			}
+
+			if err := validateStateResponses(server.StateMachine, location); err != nil {
+				return fmt.Errorf("server %d, location %d has invalid state_responses: %w", i, j, err)
+			}
+
+			if err := validateResponseSequence(location); err != nil {
+				return fmt.Errorf("server %d, location %d has invalid response_sequence: %w", i, j, err)
+			}
+
+			if err := validateLocationAuth(location); err != nil {
+				return fmt.Errorf("server %d, location %d has invalid auth: %w", i, j, err)
+			}
+
+			for label, source := range location.PrometheusLabels {
+				if err := prom.ValidateLabelName(label); err != nil {
+					return fmt.Errorf("server %d, location %d has invalid prometheus_labels: %w", i, j, err)
+				}
+				if !strings.HasPrefix(source, "header:") && !strings.HasPrefix(source, "body:$") {
+					return fmt.Errorf("server %d, location %d has prometheus label %s with source %q, expected header:<name> or body:$.<path>", i, j, label, source)
+				}
+			}
 		}
 	}
 
@@ -139,9 +420,111 @@ func validateConfig(config *models.MockServer) error {
 		if server.Password == "" {
 			return fmt.Errorf("server has no password defined")
 		}
+		for k, seed := range server.Seed {
+			if seed.Table == "" {
+				return fmt.Errorf("postgres server %s, seed %d has no table defined", server.Name, k)
+			}
+			if seed.Rows < 0 {
+				return fmt.Errorf("postgres server %s, seed %d has negative rows: %d", server.Name, k, seed.Rows)
+			}
+		}
+	}
+
+	return nil
+}
 
+// validateStateResponses checks that every state and next_state is declared in the server state machine
+func validateStateResponses(machine *models.StateMachine, location models.Location) error {
+	if len(location.StateResponses) == 0 {
+		return nil
+	}
+	if machine == nil {
+		return fmt.Errorf("the server has no state_machine")
+	}
+	// A cached response would be served regardless of the current state
+	if location.CacheTTLSeconds > 0 {
+		return fmt.Errorf("cache_ttl_seconds cannot be combined with state_responses")
 	}
 
+	for state, response := range location.StateResponses {
+		if !machine.Has(state) {
+			return fmt.Errorf("unknown state %q", state)
+		}
+		if response.NextState != "" && !machine.Has(response.NextState) {
+			return fmt.Errorf("state %q has unknown next_state %q", state, response.NextState)
+		}
+	}
+	return nil
+}
+
+// validateLocationAuth checks that auth has the credentials its type needs
+func validateLocationAuth(location models.Location) error {
+	auth := location.Auth
+	if auth == nil {
+		if location.AuthPassThrough {
+			return fmt.Errorf("auth_pass_through requires auth")
+		}
+		return nil
+	}
+
+	switch auth.Type {
+	case "basic":
+		if auth.Username == "" || auth.Password == "" {
+			return fmt.Errorf("basic auth requires username and password")
+		}
+	case "bearer":
+		if auth.Token == "" {
+			return fmt.Errorf("bearer auth requires token")
+		}
+	default:
+		return fmt.Errorf("unknown type %q, expected basic or bearer", auth.Type)
+	}
+	return nil
+}
+
+// validateResponseSequence checks that response_sequence is not combined with other ways of
+// choosing the response
+func validateResponseSequence(location models.Location) error {
+	if len(location.ResponseSequence) == 0 {
+		if location.Loop {
+			return fmt.Errorf("loop requires a response_sequence")
+		}
+		return nil
+	}
+	if len(location.StateResponses) > 0 {
+		return fmt.Errorf("response_sequence cannot be combined with state_responses")
+	}
+	// A cached response would repeat the step it was rendered from
+	if location.CacheTTLSeconds > 0 {
+		return fmt.Errorf("cache_ttl_seconds cannot be combined with response_sequence")
+	}
+	return nil
+}
+
+// validateDelaySchedule checks the hours of each schedule and that no two schedules overlap
+func validateDelaySchedule(schedules []models.DelaySchedule) error {
+	var owner [24]int
+	for k, schedule := range schedules {
+		if schedule.StartHour < 0 || schedule.StartHour > 23 || schedule.EndHour < 0 || schedule.EndHour > 24 {
+			return fmt.Errorf("schedule %d has hours outside the 24h clock", k)
+		}
+		if schedule.StartHour == schedule.EndHour {
+			return fmt.Errorf("schedule %d has the same start and end hour", k)
+		}
+		if schedule.DelayMs < 0 {
+			return fmt.Errorf("schedule %d has negative delay_ms", k)
+		}
+
+		for hour := 0; hour < 24; hour++ {
+			if !schedule.Contains(hour) {
+				continue
+			}
+			if owner[hour] > 0 {
+				return fmt.Errorf("schedule %d overlaps schedule %d at hour %d", k, owner[hour]-1, hour)
+			}
+			owner[hour] = k + 1
+		}
+	}
 	return nil
 }
 
@@ -157,6 +540,41 @@ func GetConfigDir() string {
 	return "./config"
 }
 
+// GetAPISettings returns the management API settings. The first config defining an
+// api section wins; API_KEY, API_AUTH and JWT_SECRET environment variables fill empty values.
+func GetAPISettings(configs []*models.MockServer) *models.APISettings {
+	settings := &models.APISettings{}
+	for _, cfg := range configs {
+		if cfg != nil && cfg.API != nil {
+			*settings = *cfg.API
+			break
+		}
+	}
+
+	if settings.APIKey == "" {
+		settings.APIKey = os.Getenv("API_KEY")
+	}
+	if settings.Auth == "" {
+		settings.Auth = os.Getenv("API_AUTH")
+	}
+	if settings.JWTSecret == "" {
+		settings.JWTSecret = os.Getenv("JWT_SECRET")
+	}
+
+	return settings
+}
+
+// GetDatabaseSettings returns the connection pool settings of the transactions database.
+// The first config defining a database section wins.
+func GetDatabaseSettings(configs []*models.MockServer) models.DatabaseConfig {
+	for _, cfg := range configs {
+		if cfg != nil && cfg.Database != nil {
+			return *cfg.Database
+		}
+	}
+	return models.DatabaseConfig{}
+}
+
 // GetLogSettings returns the default logging configuration
 func GetLogSettings() *models.LogSettings {
 	return &models.LogSettings{
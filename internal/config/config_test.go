@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -194,6 +197,261 @@ func TestLoadConfigFromDir(t *testing.T) {
 	}
 }
 
+func TestLoadConfigName(t *testing.T) {
+	tempDir := t.TempDir()
+	location := `      location:
+        - path: /api/test
+          method: GET
+          status_code: 200
+`
+	files := map[string]string{
+		// Files written before the top-level name keep being identified by their file name
+		"legacy.yml":       "http:\n  servers:\n    - listen: 8080\n" + location,
+		"payments-v2.yaml": "name: payments\nversion: 2.1.0\nhttp:\n  servers:\n    - listen: 8081\n" + location,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	legacy, err := LoadConfig(filepath.Join(tempDir, "legacy.yml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if legacy.Name != "legacy" || legacy.Version != "" {
+		t.Errorf("Expected name legacy from the file name, got %q version %q", legacy.Name, legacy.Version)
+	}
+
+	named, err := LoadConfig(filepath.Join(tempDir, "payments-v2.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if named.Name != "payments" || named.Version != "2.1.0" {
+		t.Errorf("Expected payments 2.1.0, got %q version %q", named.Name, named.Version)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"legacy", "legacy.yml"},
+		{"payments", "payments-v2.yaml"},
+		{"PAYMENTS", "payments-v2.yaml"},
+		{"payments-v2", "payments-v2.yaml"},
+		{"unknown", ""},
+	}
+	for _, tt := range tests {
+		file, err := FindConfigFile(tempDir, tt.name)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("FindConfigFile(%s): expected an error, got %s", tt.name, file)
+			}
+			continue
+		}
+		if err != nil || filepath.Base(file) != tt.expected {
+			t.Errorf("FindConfigFile(%s): expected %s, got %s (%v)", tt.name, tt.expected, file, err)
+		}
+	}
+}
+
+func TestLoadConfigPostgresSeed(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "postgres.yaml")
+	configData := `http:
+  servers:
+    - listen: 8080
+      location:
+        - path: /api/test
+          method: GET
+          status_code: 200
+postgres:
+  servers:
+    - name: billing
+      host: localhost
+      port: 5432
+      database: billing
+      user: postgres
+      password: secret
+      seed:
+        - table: accounts
+          schema: public
+          rows: 10
+          overrides:
+            - column: status
+              value: active
+        - table: invoices
+          schema: billing
+          rows: 5
+`
+	if err := os.WriteFile(testFile, []byte(configData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	config, err := LoadConfig(testFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(config.PostgresServers.Postgres) != 1 {
+		t.Fatalf("Expected 1 postgres server, got %d", len(config.PostgresServers.Postgres))
+	}
+
+	seeds := config.PostgresServers.Postgres[0].Seed
+	if len(seeds) != 2 {
+		t.Fatalf("Expected 2 seeds, got %d", len(seeds))
+	}
+	if seeds[0].Table != "accounts" || seeds[0].Schema != "public" || seeds[0].Rows != 10 {
+		t.Errorf("Unexpected first seed: %+v", seeds[0])
+	}
+	if len(seeds[0].Overrides) != 1 || seeds[0].Overrides[0].Column != "status" || seeds[0].Overrides[0].Value != "active" {
+		t.Errorf("Unexpected overrides: %+v", seeds[0].Overrides)
+	}
+	if seeds[1].Table != "invoices" || seeds[1].Schema != "billing" || seeds[1].Rows != 5 {
+		t.Errorf("Unexpected second seed: %+v", seeds[1])
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("MOCK_TEST_GREETING", "hello")
+
+	yamlData := `name: payments
+http:
+  servers:
+    - listen: 8080
+      name: payments
+      default_response_headers:
+        X-Mock: "true"
+      location:
+        - path: /api/greeting
+          method: GET
+          response: '{"greeting": "hello"}'
+          status_code: 200
+          headers:
+            Content-Type: application/json
+        - path: /api/users/:id
+          method: DELETE
+          status_code: 204
+          async:
+            - url: http://localhost:9090/hook
+              method: POST
+              body: '{"deleted": true}'
+              retries: 2
+`
+	jsonData := `{
+  "name": "payments",
+  "http": {
+    "servers": [
+      {
+        "listen": 8080,
+        "name": "payments",
+        "default_response_headers": {"X-Mock": "true"},
+        "location": [
+          {
+            "path": "/api/greeting",
+            "method": "GET",
+            "response": "{\"greeting\": \"${MOCK_TEST_GREETING}\"}",
+            "statusCode": 200,
+            "headers": {"Content-Type": "application/json"}
+          },
+          {
+            "path": "/api/users/:id",
+            "method": "DELETE",
+            "statusCode": 204,
+            "async": [
+              {"url": "http://localhost:9090/hook", "method": "POST", "body": "{\"deleted\": true}", "retries": 2}
+            ]
+          }
+        ]
+      }
+    ]
+  }
+}
+`
+	yamlFile := filepath.Join(tempDir, "payments.yaml")
+	jsonFile := filepath.Join(tempDir, "payments.json")
+	if err := os.WriteFile(yamlFile, []byte(yamlData), 0644); err != nil {
+		t.Fatalf("Failed to write YAML file: %v", err)
+	}
+	if err := os.WriteFile(jsonFile, []byte(jsonData), 0644); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+
+	fromYAML, err := LoadConfig(yamlFile)
+	if err != nil {
+		t.Fatalf("LoadConfig YAML failed: %v", err)
+	}
+	fromJSON, err := LoadConfig(jsonFile)
+	if err != nil {
+		t.Fatalf("LoadConfig JSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("Expected identical configs\nYAML: %+v\nJSON: %+v", fromYAML, fromJSON)
+	}
+
+	// SaveConfig writes JSON for .json files, which loads back to the same config
+	saved := filepath.Join(tempDir, "saved.json")
+	if err := SaveConfig(fromJSON, saved); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "{") {
+		t.Errorf("Expected a JSON file, got %s", data)
+	}
+	reloaded, err := LoadConfig(saved)
+	if err != nil {
+		t.Fatalf("LoadConfig of saved JSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, reloaded) {
+		t.Errorf("Expected saved JSON to load the same config\nwant: %+v\ngot:  %+v", fromJSON, reloaded)
+	}
+
+	// Both files are loaded from the directory; their ports conflict
+	if _, err := LoadConfigFromDir(tempDir); err == nil || !strings.Contains(err.Error(), "port") {
+		t.Errorf("Expected a port conflict between the YAML and JSON files, got %v", err)
+	}
+
+	// Unknown keys are rejected like the schema does for YAML
+	typo := filepath.Join(tempDir, "typo.json")
+	if err := os.WriteFile(typo, []byte(`{"http": {"servers": [{"listen": 8081, "locaton": []}]}}`), 0644); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+	if _, err := LoadConfig(typo); err == nil || !strings.Contains(err.Error(), "locaton") {
+		t.Errorf("Expected unknown key error, got %v", err)
+	}
+}
+
+func TestLoadConfigFromDirPortConflict(t *testing.T) {
+	tempDir := t.TempDir()
+
+	server := `http:
+  servers:
+    - listen: 8080
+      location:
+        - path: /api/%s
+          method: GET
+          response: '{}'
+          status_code: 200
+`
+	for _, name := range []string{"payments.yaml", "accounts.yml"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(fmt.Sprintf(server, name)), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	_, err := LoadConfigFromDir(tempDir)
+	if err == nil {
+		t.Fatal("Expected an error for two files using port 8080")
+	}
+	for _, expected := range []string{"port 8080", "payments.yaml", "accounts.yml"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to mention %q, got: %v", expected, err)
+		}
+	}
+}
+
 func TestValidateConfig(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -303,6 +561,159 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "Custom method",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/cache", Method: "PURGE", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Lowercase method",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/cache", Method: "purge", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Method with separators",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/cache", Method: "GET /", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Non-letter method on a path",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/", Method: "M-SEARCH", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Non-letter method on a path_regex",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{PathRegex: "^/$", Method: "M-SEARCH", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Wildcard path with wildcard_param",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/api/*", WildcardParam: "path", Method: "GET", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Wildcard path without wildcard_param",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/api/*", Method: "GET", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Wildcard not at the end of the path",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/api/*/users", WildcardParam: "path", Method: "GET", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid wildcard_param name",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/api/*", WildcardParam: "a-b", Method: "GET", StatusCode: 200}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Postgres seed without table",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Location: []models.Location{{Path: "/api/test", Method: "GET", StatusCode: 200}},
+						},
+					},
+				},
+				PostgresServers: models.PostgresServers{
+					Postgres: []models.PostgresServer{
+						{
+							Name:     "billing",
+							Host:     "localhost",
+							Port:     5432,
+							Database: "billing",
+							User:     "postgres",
+							Password: "secret",
+							Seed:     []models.Seed{{Schema: "public", Rows: 10}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 		{
 			name: "Invalid status code",
 			config: &models.MockServer{
@@ -323,6 +734,196 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "Overlapping response delay schedules",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{
+									Path:       "/api/test",
+									Method:     "GET",
+									StatusCode: 200,
+									ResponseDelaySchedule: []models.DelaySchedule{
+										{StartHour: 22, EndHour: 2, DelayMs: 500},
+										{StartHour: 1, EndHour: 3, DelayMs: 100},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Adjacent response delay schedules",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Timezone: "America/New_York",
+							Location: []models.Location{
+								{
+									Path:       "/api/test",
+									Method:     "GET",
+									StatusCode: 200,
+									ResponseDelaySchedule: []models.DelaySchedule{
+										{StartHour: 9, EndHour: 17, DelayMs: 300},
+										{StartHour: 17, EndHour: 9, DelayMs: 10},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Invalid timezone",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen:   8080,
+							Timezone: "Mars/Olympus_Mons",
+							Location: []models.Location{
+								{Path: "/api/test", Method: "GET", StatusCode: 200},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Both schema and schema_file",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{
+									Path:       "/api/test",
+									Method:     "POST",
+									Schema:     `{"type": "object"}`,
+									SchemaFile: "./schemas/payment.json",
+									StatusCode: 200,
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Response sequence with cache",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{
+									Path:             "/api/test",
+									Method:           "GET",
+									StatusCode:       200,
+									CacheTTLSeconds:  60,
+									ResponseSequence: []models.SequenceResponse{{StatusCode: 503}, {StatusCode: 200}},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Loop without response sequence",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{Path: "/api/test", Method: "GET", StatusCode: 200, Loop: true},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Basic auth",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{Path: "/api/test", Method: "GET", StatusCode: 200, Auth: &models.LocationAuth{Type: "basic", Username: "alice", Password: "secret"}, AuthPassThrough: true},
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Basic auth without password",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{Path: "/api/test", Method: "GET", StatusCode: 200, Auth: &models.LocationAuth{Type: "basic", Username: "alice"}},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Unknown auth type",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{Path: "/api/test", Method: "GET", StatusCode: 200, Auth: &models.LocationAuth{Type: "digest", Token: "abc"}},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Auth pass through without auth",
+			config: &models.MockServer{
+				Http: models.Http{
+					Servers: []models.Server{
+						{
+							Listen: 8080,
+							Location: []models.Location{
+								{Path: "/api/test", Method: "GET", StatusCode: 200, AuthPassThrough: true},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -334,3 +935,200 @@ func TestValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAPISettings(t *testing.T) {
+	t.Setenv("API_KEY", "env-key")
+	t.Setenv("API_AUTH", "")
+	t.Setenv("JWT_SECRET", "env-secret")
+
+	// Without an api section the environment is used
+	settings := GetAPISettings([]*models.MockServer{{}})
+	if settings.APIKey != "env-key" {
+		t.Errorf("Expected API key from environment, got %s", settings.APIKey)
+	}
+
+	// The api section takes precedence over the environment
+	configs := []*models.MockServer{
+		{},
+		{API: &models.APISettings{APIKey: "yaml-key", Auth: "jwt"}},
+	}
+	settings = GetAPISettings(configs)
+	if settings.APIKey != "yaml-key" {
+		t.Errorf("Expected API key from config, got %s", settings.APIKey)
+	}
+	if settings.Auth != "jwt" {
+		t.Errorf("Expected auth mode jwt, got %s", settings.Auth)
+	}
+	if settings.JWTSecret != "env-secret" {
+		t.Errorf("Expected JWT secret from environment, got %s", settings.JWTSecret)
+	}
+}
+
+func TestGetDatabaseSettings(t *testing.T) {
+	if settings := GetDatabaseSettings([]*models.MockServer{{}}); settings != (models.DatabaseConfig{}) {
+		t.Errorf("Expected the default settings without a database section, got %+v", settings)
+	}
+
+	configs := []*models.MockServer{
+		{},
+		{Database: &models.DatabaseConfig{MaxOpenConns: 10, ConnMaxLifetimeSeconds: 300}},
+		{Database: &models.DatabaseConfig{MaxOpenConns: 2}},
+	}
+	settings := GetDatabaseSettings(configs)
+	if settings.MaxOpenConns != 10 || settings.ConnMaxLifetimeSeconds != 300 {
+		t.Errorf("Expected the first database section, got %+v", settings)
+	}
+}
+
+func TestLoadConfigFromGlob(t *testing.T) {
+	// Create nested test directories
+	tempDir := t.TempDir()
+
+	for i, service := range []string{"payments", "accounts"} {
+		dir := filepath.Join(tempDir, service)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create dir %s: %v", dir, err)
+		}
+
+		data := fmt.Sprintf(`http:
+  servers:
+    - listen: %d
+      location:
+        - path: /api/%s
+          method: GET
+          response: '{}'
+          status_code: 200
+`, 9000+i, service)
+		if err := os.WriteFile(filepath.Join(dir, "server.yaml"), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	configs, err := LoadConfigFromGlob(filepath.Join(tempDir, "*", "server.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfigFromGlob failed: %v", err)
+	}
+
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 configs, got %d", len(configs))
+	}
+
+	ports := make(map[int]bool)
+	for _, config := range configs {
+		for _, server := range config.Http.Servers {
+			ports[server.Listen] = true
+		}
+	}
+	if !ports[9000] || !ports[9001] {
+		t.Errorf("Expected servers on ports 9000 and 9001, got %v", ports)
+	}
+
+	// Zero matches is an error
+	if _, err := LoadConfigFromGlob(filepath.Join(tempDir, "*", "missing.yaml")); err == nil {
+		t.Error("Expected error when no files match the pattern")
+	}
+}
+
+func TestLoadConfigSchemaValidation(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "typo.yaml")
+
+	// `listten` and `methid` are typos; listen is also out of range and the method is not a token
+	configData := `http:
+  servers:
+    - listen: 70000
+      listten: 8080
+      location:
+        - path: /api/test
+          methid: GET
+          method: FE TCH
+          response: '{}'
+          status_code: 200
+`
+	if err := os.WriteFile(testFile, []byte(configData), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	_, err := LoadConfig(testFile)
+	if err == nil {
+		t.Fatal("Expected schema validation error")
+	}
+
+	var violations models.ValidationErrors
+	if !errors.As(err, &violations) {
+		t.Fatalf("Expected ValidationErrors, got %T: %v", err, err)
+	}
+
+	// One violation each for the unknown keys, the listen range and the method pattern
+	if len(violations) < 4 {
+		t.Errorf("Expected at least 4 violations, got %d: %v", len(violations), violations)
+	}
+
+	for _, expected := range []string{"listten", "methid", "/http/servers/0/listen", "/http/servers/0/location/0/method"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to mention %q, got: %v", expected, err)
+		}
+	}
+
+	// The shipped sample configuration must pass the schema
+	if _, err := LoadConfig(filepath.Join("..", "..", "config", "sample.yaml")); err != nil {
+		t.Errorf("Sample config failed validation: %v", err)
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	jsonHeaders := models.Headers{"Content-Type": "application/json"}
+	config := &models.MockServer{
+		Http: models.Http{
+			Servers: []models.Server{
+				{
+					Listen: 8080,
+					Location: []models.Location{
+						{Path: "/api/users", Method: "GET", Response: `{"users":[]}`, StatusCode: 200},
+						{Path: "/api/users", Method: "get", Response: `{"users":[]}`, StatusCode: 200},
+						{Path: "/api/broken", Method: "GET", Response: `{"users":`, StatusCode: 700},
+						{Path: "/api/text", Method: "GET", Response: `plain text`, StatusCode: 200},
+						{Path: "/api/typed", Method: "GET", Response: `oops`, Headers: &jsonHeaders, StatusCode: 200},
+						{Path: "/api/template", Method: "GET", Response: `{"id": {{.id}}}`, StatusCode: 200},
+						{Path: "/api/schema", Method: "POST", Schema: `{"type": 5}`, StatusCode: 201},
+						{Path: "/api/xsd", Method: "POST", Schema: `<xs:schema></xs:schema>`, StatusCode: 201},
+						{
+							Path:       "/api/async",
+							Method:     "POST",
+							StatusCode: 202,
+							Async: []models.Async{
+								{Url: "http://localhost:9000/callback", Method: "POST"},
+								{Url: "localhost:9000/callback", Method: "POST"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	warnings := LintConfig(config)
+
+	expected := map[string]string{
+		"/http/servers/0/location/1":             LintSeverityError,
+		"/http/servers/0/location/2/status_code": LintSeverityError,
+		"/http/servers/0/location/2/response":    LintSeverityWarning,
+		"/http/servers/0/location/4/response":    LintSeverityWarning,
+		"/http/servers/0/location/6/schema":      LintSeverityWarning,
+		"/http/servers/0/location/8/async/1/url": LintSeverityWarning,
+	}
+
+	if len(warnings) != len(expected) {
+		t.Errorf("Expected %d warnings, got %d: %v", len(expected), len(warnings), warnings)
+	}
+	for _, warning := range warnings {
+		severity, ok := expected[warning.Field]
+		if !ok {
+			t.Errorf("Unexpected warning %s", warning)
+			continue
+		}
+		if warning.Severity != severity {
+			t.Errorf("Expected severity %s for %s, got %s", severity, warning.Field, warning.Severity)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"catalyst/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Lint severities. None of them blocks startup.
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+// LintWarning reports a likely mistake in a configuration that is still loadable
+type LintWarning struct {
+	Severity string `json:"severity"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("[%s] %s: %s", w.Severity, w.Field, w.Message)
+}
+
+// LintConfig checks a configuration for common mistakes that validateConfig lets through
+func LintConfig(config *models.MockServer) []LintWarning {
+	warnings := make([]LintWarning, 0)
+	if config == nil {
+		return warnings
+	}
+
+	for i, server := range config.Http.Servers {
+		routes := make(map[string]int)
+
+		for j, location := range server.Location {
+			field := fmt.Sprintf("/http/servers/%d/location/%d", i, j)
+
+			route := strings.ToUpper(location.Method) + " " + location.Path
+			if first, exists := routes[route]; exists {
+				warnings = append(warnings, LintWarning{
+					Severity: LintSeverityError,
+					Field:    field,
+					Message:  fmt.Sprintf("duplicate route %s, already defined at location %d", route, first),
+				})
+			} else {
+				routes[route] = j
+			}
+
+			if location.StatusCode < 100 || location.StatusCode > 599 {
+				warnings = append(warnings, LintWarning{
+					Severity: LintSeverityError,
+					Field:    field + "/status_code",
+					Message:  fmt.Sprintf("status code %d is outside 100-599", location.StatusCode),
+				})
+			}
+
+			if looksLikeJSON(location) && !json.Valid([]byte(location.Response)) {
+				warnings = append(warnings, LintWarning{
+					Severity: LintSeverityWarning,
+					Field:    field + "/response",
+					Message:  "response is not valid JSON",
+				})
+			}
+
+			if location.Schema != "" && !strings.HasPrefix(strings.TrimSpace(location.Schema), "<") {
+				if err := compileJSONSchema(location.Schema); err != nil {
+					warnings = append(warnings, LintWarning{
+						Severity: LintSeverityWarning,
+						Field:    field + "/schema",
+						Message:  fmt.Sprintf("schema is not a valid JSON Schema: %v", err),
+					})
+				}
+			}
+
+			for k, async := range location.Async {
+				if !isValidURL(async.Url) {
+					warnings = append(warnings, LintWarning{
+						Severity: LintSeverityWarning,
+						Field:    fmt.Sprintf("%s/async/%d/url", field, k),
+						Message:  fmt.Sprintf("%q is not a valid http(s) URL", async.Url),
+					})
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// looksLikeJSON reports whether the response is meant to be JSON. Template expressions
+// make the raw response invalid JSON, so those responses are skipped.
+func looksLikeJSON(location models.Location) bool {
+	response := strings.TrimSpace(location.Response)
+	if response == "" || strings.Contains(response, "{{") {
+		return false
+	}
+
+	if location.Headers != nil {
+		for key, value := range *location.Headers {
+			if strings.EqualFold(key, "Content-Type") {
+				return strings.Contains(strings.ToLower(value), "json")
+			}
+		}
+	}
+
+	return strings.HasPrefix(response, "{") || strings.HasPrefix(response, "[")
+}
+
+func compileJSONSchema(schema string) error {
+	var data interface{}
+	if err := json.Unmarshal([]byte(schema), &data); err != nil {
+		return err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", data); err != nil {
+		return err
+	}
+	_, err := compiler.Compile("schema.json")
+	return err
+}
+
+func isValidURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
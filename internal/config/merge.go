@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"catalyst/internal/models"
+)
+
+// Merge combines a base configuration with an environment override. HTTP servers are matched
+// by name and postgres servers by name: a matching override server is merged into the base
+// one, otherwise it is added. Locations are matched by path and method, the override location
+// replacing the base one. Every other field takes the override value when it is set.
+// Neither argument is modified.
+func Merge(base, override *models.MockServer) *models.MockServer {
+	if base == nil && override == nil {
+		return nil
+	}
+	if base == nil {
+		base = &models.MockServer{}
+	}
+	if override == nil {
+		override = &models.MockServer{}
+	}
+
+	merged := &models.MockServer{
+		API: base.API,
+	}
+	if override.API != nil {
+		merged.API = override.API
+	}
+
+	merged.Http.Servers = append([]models.Server(nil), base.Http.Servers...)
+	for _, server := range override.Http.Servers {
+		if i := findServer(merged.Http.Servers, server.Name); i >= 0 {
+			merged.Http.Servers[i] = mergeServer(merged.Http.Servers[i], server)
+		} else {
+			merged.Http.Servers = append(merged.Http.Servers, server)
+		}
+	}
+
+	merged.PostgresServers.Postgres = append([]models.PostgresServer(nil), base.PostgresServers.Postgres...)
+	for _, server := range override.PostgresServers.Postgres {
+		replaced := false
+		for i, existing := range merged.PostgresServers.Postgres {
+			if server.Name != "" && existing.Name == server.Name {
+				overrideSetFields(&merged.PostgresServers.Postgres[i], server)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.PostgresServers.Postgres = append(merged.PostgresServers.Postgres, server)
+		}
+	}
+
+	return merged
+}
+
+// MergeConfigFiles loads every file and merges them in order, later files overriding earlier ones
+func MergeConfigFiles(files []string) (*models.MockServer, error) {
+	if len(files) < 2 {
+		return nil, fmt.Errorf("at least two configuration files are required to merge, got %d", len(files))
+	}
+
+	var merged *models.MockServer
+	for _, file := range files {
+		cfg, err := LoadConfig(file)
+		if err != nil {
+			return nil, fmt.Errorf("error loading config from %s: %w", file, err)
+		}
+		merged = Merge(merged, cfg)
+	}
+
+	if err := validateConfig(merged); err != nil {
+		return nil, fmt.Errorf("invalid merged configuration: %w", err)
+	}
+	return merged, nil
+}
+
+// ParseMergeList splits the --merge-configs value, e.g. base.yaml:prod.yaml
+func ParseMergeList(value string) []string {
+	var files []string
+	for _, file := range strings.Split(value, ":") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// findServer returns the index of the server with the given name, or -1. Unnamed servers never match.
+func findServer(servers []models.Server, name *string) int {
+	if name == nil || *name == "" {
+		return -1
+	}
+	for i, server := range servers {
+		if server.Name != nil && *server.Name == *name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeServer merges the override server fields and locations into the base server
+func mergeServer(base, override models.Server) models.Server {
+	locations := append([]models.Location(nil), base.Location...)
+	for _, location := range override.Location {
+		replaced := false
+		for i, existing := range locations {
+			if existing.Path == location.Path && strings.EqualFold(existing.Method, location.Method) {
+				locations[i] = location
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			locations = append(locations, location)
+		}
+	}
+
+	overrideSetFields(&base, override)
+	base.Location = locations
+	return base
+}
+
+// overrideSetFields copies every non-zero field of the override struct into the struct dst points to
+func overrideSetFields(dst interface{}, override interface{}) {
+	dstValue := reflect.ValueOf(dst).Elem()
+	overrideValue := reflect.ValueOf(override)
+	for i := 0; i < overrideValue.NumField(); i++ {
+		if field := overrideValue.Field(i); !field.IsZero() {
+			dstValue.Field(i).Set(field)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"catalyst/internal/models"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestMerge(t *testing.T) {
+	base := &models.MockServer{
+		Http: models.Http{Servers: []models.Server{
+			{
+				Listen:     8080,
+				Name:       strPtr("payments"),
+				Version:    strPtr("1.0.0"),
+				PathPrefix: "/v1",
+				Location: []models.Location{
+					{Path: "/api/pay", Method: "POST", Response: `{"status":"ok"}`, StatusCode: 200},
+					{Path: "/api/refund", Method: "POST", Response: `{"status":"ok"}`, StatusCode: 200},
+				},
+			},
+		}},
+		API: &models.APISettings{APIKey: "base-key"},
+	}
+	override := &models.MockServer{
+		Http: models.Http{Servers: []models.Server{
+			{
+				Listen:  9080,
+				Name:    strPtr("payments"),
+				Version: strPtr("1.1.0"),
+				Location: []models.Location{
+					{Path: "/api/pay", Method: "post", Response: `{"status":"declined"}`, StatusCode: 402},
+					{Path: "/api/status", Method: "GET", Response: `{"up":true}`, StatusCode: 200},
+				},
+			},
+			{
+				Listen:   9090,
+				Name:     strPtr("users"),
+				Location: []models.Location{{Path: "/api/users", Method: "GET", StatusCode: 200}},
+			},
+		}},
+	}
+
+	merged := Merge(base, override)
+
+	if len(merged.Http.Servers) != 2 {
+		t.Fatalf("Expected 2 servers after adding one, got %d", len(merged.Http.Servers))
+	}
+
+	payments := merged.Http.Servers[0]
+	if payments.Listen != 9080 || *payments.Version != "1.1.0" {
+		t.Errorf("Expected override scalar fields, got listen %d version %s", payments.Listen, *payments.Version)
+	}
+	if payments.PathPrefix != "/v1" {
+		t.Errorf("Expected unset override fields to keep the base value, got path_prefix %q", payments.PathPrefix)
+	}
+
+	if len(payments.Location) != 3 {
+		t.Fatalf("Expected 3 locations, got %d", len(payments.Location))
+	}
+	if payments.Location[0].StatusCode != 402 || payments.Location[0].Response != `{"status":"declined"}` {
+		t.Errorf("Expected the override location to replace /api/pay, got %+v", payments.Location[0])
+	}
+	if payments.Location[1].Path != "/api/refund" {
+		t.Errorf("Expected the base-only location to be kept, got %s", payments.Location[1].Path)
+	}
+	if payments.Location[2].Path != "/api/status" {
+		t.Errorf("Expected the override-only location to be added, got %s", payments.Location[2].Path)
+	}
+
+	if users := merged.Http.Servers[1]; *users.Name != "users" || users.Listen != 9090 {
+		t.Errorf("Expected the users server to be added, got %+v", users)
+	}
+
+	if merged.API == nil || merged.API.APIKey != "base-key" {
+		t.Errorf("Expected the base API settings to be kept, got %+v", merged.API)
+	}
+
+	// The inputs are left untouched
+	if base.Http.Servers[0].Listen != 8080 || len(base.Http.Servers[0].Location) != 2 || base.Http.Servers[0].Location[0].StatusCode != 200 {
+		t.Error("Expected Merge not to modify the base configuration")
+	}
+}
+
+func TestMergeConfigFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "base.yaml")
+	overridePath := filepath.Join(tempDir, "prod.yaml")
+
+	baseData := `http:
+  servers:
+    - listen: 8080
+      name: payments
+      location:
+        - path: /api/pay
+          method: POST
+          response: '{"status":"ok"}'
+          status_code: 200
+`
+	overrideData := `http:
+  servers:
+    - listen: 8080
+      name: payments
+      location:
+        - path: /api/pay
+          method: POST
+          response: '{"status":"slow"}'
+          status_code: 503
+`
+	if err := os.WriteFile(basePath, []byte(baseData), 0644); err != nil {
+		t.Fatalf("Failed to write base config: %v", err)
+	}
+	if err := os.WriteFile(overridePath, []byte(overrideData), 0644); err != nil {
+		t.Fatalf("Failed to write override config: %v", err)
+	}
+
+	merged, err := MergeConfigFiles(ParseMergeList(basePath + ":" + overridePath))
+	if err != nil {
+		t.Fatalf("MergeConfigFiles failed: %v", err)
+	}
+	if got := merged.Http.Servers[0].Location[0].StatusCode; got != 503 {
+		t.Errorf("Expected the override status code 503, got %d", got)
+	}
+
+	if _, err := MergeConfigFiles([]string{basePath}); err == nil {
+		t.Error("Expected an error merging a single file")
+	}
+}
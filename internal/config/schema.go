@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"catalyst/internal/models"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// configSchemaJSON is the JSON Schema describing the YAML configuration format
+//
+//go:embed schema.json
+var configSchemaJSON []byte
+
+var (
+	configSchemaOnce sync.Once
+	configSchema     *jsonschema.Schema
+	configSchemaErr  error
+)
+
+// getConfigSchema compiles the configuration schema once
+func getConfigSchema() (*jsonschema.Schema, error) {
+	configSchemaOnce.Do(func() {
+		schemaData, err := jsonschema.UnmarshalJSON(bytes.NewReader(configSchemaJSON))
+		if err != nil {
+			configSchemaErr = fmt.Errorf("error parsing config schema: %w", err)
+			return
+		}
+
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource("config-schema.json", schemaData); err != nil {
+			configSchemaErr = fmt.Errorf("error adding config schema resource: %w", err)
+			return
+		}
+
+		configSchema, configSchemaErr = compiler.Compile("config-schema.json")
+	})
+
+	return configSchema, configSchemaErr
+}
+
+// validateSchema validates the raw YAML document against the configuration schema.
+// Unknown keys such as `methid` or `listten` are reported, which yaml.Unmarshal silently ignores.
+func validateSchema(data []byte) error {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+
+	// An empty file is reported by validateConfig
+	if raw == nil {
+		return nil
+	}
+
+	// Re-marshal to JSON so the validator sees JSON types only
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("error converting config to JSON: %w", err)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("error converting config to JSON: %w", err)
+	}
+
+	schema, err := getConfigSchema()
+	if err != nil {
+		return err
+	}
+
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	var violations models.ValidationErrors
+	collectViolations(validationErr, message.NewPrinter(language.English), &violations)
+	return violations
+}
+
+// collectViolations flattens the validation error tree into one entry per failing keyword
+func collectViolations(err *jsonschema.ValidationError, printer *message.Printer, violations *models.ValidationErrors) {
+	if len(err.Causes) == 0 {
+		*violations = append(*violations, models.ValidationError{
+			Field:   "/" + strings.Join(err.InstanceLocation, "/"),
+			Message: err.ErrorKind.LocalizedString(printer),
+		})
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectViolations(cause, printer, violations)
+	}
+}
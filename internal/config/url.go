@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"catalyst/internal/models"
+)
+
+// maxConfigURLBytes limits the size of a config fetched by LoadConfigFromURL
+const maxConfigURLBytes = 10 << 20
+
+// LoadConfigFromURL loads a mock server configuration from an http(s) or file:// URL. HTTP requests
+// fail after timeout or on a non-2xx status. The config is YAML unless the URL path ends in .json,
+// and it is named after the last path segment, or the host, when it doesn't set a name.
+func LoadConfigFromURL(rawURL string, timeout time.Duration) (*models.MockServer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config URL %s: %w", rawURL, err)
+	}
+
+	var data []byte
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		data, err = fetchConfig(u.String(), timeout)
+	case "file":
+		// file:///etc/mock/a.yaml has the path in u.Path, file://a.yaml is relative
+		data, err = os.ReadFile(u.Host + u.Path)
+	default:
+		return nil, fmt.Errorf("unsupported config URL scheme %q, expected http, https or file", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config from %s: %w", rawURL, err)
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = u.Hostname()
+	}
+	return parseConfig(data, isJSONFile(name), strings.TrimSuffix(name, path.Ext(name)))
+}
+
+// fetchConfig downloads a config with an HTTP GET
+func fetchConfig(rawURL string, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigURLBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxConfigURLBytes {
+		return nil, fmt.Errorf("config is larger than %d bytes", maxConfigURLBytes)
+	}
+	return data, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFromURL(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mocks/payments.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(watchTestConfig("18301")))
+	})
+	mux.HandleFunc("/mocks/named.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("name: central\n" + watchTestConfig("18302")))
+	})
+	mux.HandleFunc("/mocks/invalid.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http:\n  servers:\n    - listen: 18303\n"))
+	})
+	mux.HandleFunc("/mocks/slow.yaml", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg, err := LoadConfigFromURL(server.URL+"/mocks/payments.yaml", time.Second)
+	if err != nil {
+		t.Fatalf("LoadConfigFromURL failed: %v", err)
+	}
+	if cfg.Name != "payments" {
+		t.Errorf("Expected the config to be named after the URL, got %q", cfg.Name)
+	}
+	if len(cfg.Http.Servers) != 1 || cfg.Http.Servers[0].Listen != 18301 {
+		t.Errorf("Expected a server on port 18301, got %+v", cfg.Http.Servers)
+	}
+
+	cfg, err = LoadConfigFromURL(server.URL+"/mocks/named.yaml", time.Second)
+	if err != nil {
+		t.Fatalf("LoadConfigFromURL failed: %v", err)
+	}
+	if cfg.Name != "central" {
+		t.Errorf("Expected the config to keep its name, got %q", cfg.Name)
+	}
+
+	tests := []struct {
+		name  string
+		url   string
+		error string
+	}{
+		{"missing config", server.URL + "/mocks/missing.yaml", "unexpected status 404"},
+		{"invalid config", server.URL + "/mocks/invalid.yaml", "invalid configuration"},
+		{"timeout", server.URL + "/mocks/slow.yaml", "Client.Timeout"},
+		{"unsupported scheme", "ftp://configs.example.com/payments.yaml", "unsupported config URL scheme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFromURL(tt.url, 200*time.Millisecond)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromFileURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "local.yml")
+	if err := os.WriteFile(path, []byte(watchTestConfig("18304")), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cfg, err := LoadConfigFromURL("file://"+filepath.ToSlash(path), time.Second)
+	if err != nil {
+		t.Fatalf("LoadConfigFromURL failed: %v", err)
+	}
+	if cfg.Name != "local" || cfg.Http.Servers[0].Listen != 18304 {
+		t.Errorf("Unexpected config %q with servers %+v", cfg.Name, cfg.Http.Servers)
+	}
+
+	// Unlike LoadConfig, a missing file is not created
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := LoadConfigFromURL("file://"+filepath.ToSlash(missing), time.Second); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("Expected the missing file not to be created, got %v", err)
+	}
+}
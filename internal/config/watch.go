@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"catalyst/internal/models"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DirWatcher loads the YAML and JSON config files added to a directory at runtime
+type DirWatcher struct {
+	watcher  *fsnotify.Watcher
+	onNew    func(*models.MockServer)
+	onRemove func(*models.MockServer)
+
+	mu sync.Mutex
+	// files maps the absolute path of each loaded file to its config
+	files map[string]*models.MockServer
+	done  chan struct{}
+}
+
+// WatchDir watches dir for YAML and JSON config files. Files created after the call are loaded
+// and passed to onNew; when a loaded file, or one present when the call was made, is removed its
+// config is passed to onRemove, which may be nil.
+func WatchDir(dir string, onNew func(*models.MockServer), onRemove func(*models.MockServer)) (*DirWatcher, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving config directory %s: %w", dir, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("error creating config directory watcher: %w", err)
+	}
+	if err := watcher.Add(absDir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("error watching config directory %s: %w", dir, err)
+	}
+
+	w := &DirWatcher{
+		watcher:  watcher,
+		onNew:    onNew,
+		onRemove: onRemove,
+		files:    make(map[string]*models.MockServer),
+		done:     make(chan struct{}),
+	}
+
+	// The files already there were loaded at startup; they are only tracked to know their ports on removal
+	entries, err := os.ReadDir(absDir)
+	if err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("error reading config directory %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(absDir, entry.Name())
+		if entry.IsDir() || !isConfigFile(path) {
+			continue
+		}
+		if cfg, err := LoadConfig(path); err == nil {
+			w.files[path] = cfg
+		}
+	}
+
+	go w.watch()
+	return w, nil
+}
+
+// Close stops watching the directory
+func (w *DirWatcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
+
+// watch handles the directory events until the watcher is closed
+func (w *DirWatcher) watch() {
+	defer close(w.done)
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			path := filepath.Clean(event.Name)
+			if !isConfigFile(path) {
+				continue
+			}
+
+			switch {
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				w.removeFile(path)
+			case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+				// Files are often created empty and written afterwards, so writes to a file
+				// that could not be loaded yet are retried
+				w.loadFile(path)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("ERROR: config directory watcher: %v", err)
+		}
+	}
+}
+
+// loadFile loads a file that is not loaded yet and passes it to onNew
+func (w *DirWatcher) loadFile(path string) {
+	w.mu.Lock()
+	_, loaded := w.files[path]
+	w.mu.Unlock()
+	if loaded {
+		return
+	}
+
+	// LoadConfig creates missing files, so a file removed meanwhile must not reach it
+	if info, err := os.Stat(path); err != nil || info.IsDir() || info.Size() == 0 {
+		return
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		log.Printf("ERROR: new config file %s not loaded: %v", path, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.files[path] = cfg
+	w.mu.Unlock()
+
+	log.Printf("New config file %s loaded", path)
+	w.onNew(cfg)
+}
+
+// removeFile forgets a removed file and passes its config to onRemove
+func (w *DirWatcher) removeFile(path string) {
+	w.mu.Lock()
+	cfg, loaded := w.files[path]
+	delete(w.files, path)
+	w.mu.Unlock()
+	if !loaded {
+		return
+	}
+
+	log.Printf("Config file %s removed", path)
+	if w.onRemove != nil {
+		w.onRemove(cfg)
+	}
+}
+
+// isConfigFile reports whether path has a .yaml, .yml or .json extension
+func isConfigFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
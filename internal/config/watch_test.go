@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"catalyst/internal/models"
+)
+
+func watchTestConfig(port string) string {
+	return `http:
+  servers:
+    - listen: ` + port + `
+      location:
+        - path: /api/test
+          method: GET
+          response: '{"test": true}'
+          status_code: 200
+`
+}
+
+func TestWatchDir(t *testing.T) {
+	tempDir := t.TempDir()
+	existing := filepath.Join(tempDir, "existing.yaml")
+	if err := os.WriteFile(existing, []byte(watchTestConfig("18201")), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	added := make(chan *models.MockServer, 4)
+	removed := make(chan *models.MockServer, 4)
+	watcher, err := WatchDir(tempDir,
+		func(cfg *models.MockServer) { added <- cfg },
+		func(cfg *models.MockServer) { removed <- cfg },
+	)
+	if err != nil {
+		t.Fatalf("WatchDir failed: %v", err)
+	}
+	defer watcher.Close()
+
+	waitConfig := func(ch chan *models.MockServer, what string) *models.MockServer {
+		t.Helper()
+		select {
+		case cfg := <-ch:
+			return cfg
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s config", what)
+			return nil
+		}
+	}
+
+	// A new YAML file is loaded
+	newFile := filepath.Join(tempDir, "new.yml")
+	if err := os.WriteFile(newFile, []byte(watchTestConfig("18202")), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	cfg := waitConfig(added, "added")
+	if cfg.Name != "new" {
+		t.Errorf("Expected config name new, got %q", cfg.Name)
+	}
+	if len(cfg.Http.Servers) != 1 || cfg.Http.Servers[0].Listen != 18202 {
+		t.Errorf("Expected a server on port 18202, got %+v", cfg.Http.Servers)
+	}
+
+	// Other files are ignored
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// Removing the file that was there before watching reports its config
+	if err := os.Remove(existing); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+	cfg = waitConfig(removed, "removed")
+	if len(cfg.Http.Servers) != 1 || cfg.Http.Servers[0].Listen != 18201 {
+		t.Errorf("Expected the removed server on port 18201, got %+v", cfg.Http.Servers)
+	}
+	if _, err := os.Stat(existing); !os.IsNotExist(err) {
+		t.Errorf("Expected removed file to stay removed, got %v", err)
+	}
+
+	if err := os.Remove(newFile); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+	cfg = waitConfig(removed, "removed")
+	if cfg.Http.Servers[0].Listen != 18202 {
+		t.Errorf("Expected the removed server on port 18202, got %d", cfg.Http.Servers[0].Listen)
+	}
+
+	select {
+	case cfg := <-added:
+		t.Errorf("Expected no other config to be added, got %+v", cfg)
+	default:
+	}
+}
+
+func TestWatchDirInvalidFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	added := make(chan *models.MockServer, 1)
+	watcher, err := WatchDir(tempDir, func(cfg *models.MockServer) { added <- cfg }, nil)
+	if err != nil {
+		t.Fatalf("WatchDir failed: %v", err)
+	}
+	defer watcher.Close()
+
+	// An invalid file is not loaded until it is fixed
+	path := filepath.Join(tempDir, "broken.yaml")
+	if err := os.WriteFile(path, []byte("http: ["), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	select {
+	case cfg := <-added:
+		t.Fatalf("Expected invalid config not to be loaded, got %+v", cfg)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(path, []byte(watchTestConfig("18203")), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	select {
+	case cfg := <-added:
+		if cfg.Http.Servers[0].Listen != 18203 {
+			t.Errorf("Expected a server on port 18203, got %d", cfg.Http.Servers[0].Listen)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for fixed config")
+	}
+}
+
+func TestWatchDirMissingDir(t *testing.T) {
+	if _, err := WatchDir(filepath.Join(t.TempDir(), "missing"), func(*models.MockServer) {}, nil); err == nil {
+		t.Error("Expected error watching a missing directory")
+	}
+}
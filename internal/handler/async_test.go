@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"catalyst/database"
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAsyncTransactionRecorded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"received":true}`))
+	}))
+	defer target.Close()
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "async.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Failed to start batch manager: %v", err)
+	}
+	defer bm.Stop()
+
+	h := NewHandler(nil, bm)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/api/orders", nil)
+	c.Set(traceIDKey, "trace-async")
+
+	h.handleAsyncCall(&models.Async{Url: target.URL, Method: "POST", Body: `{"id":1}`}, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := bm.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	operations, err := database.ListOperations(bm.GetDB())
+	if err != nil {
+		t.Fatalf("ListOperations failed: %v", err)
+	}
+	if len(operations) != 1 {
+		t.Fatalf("Expected 1 async transaction, got %d", len(operations))
+	}
+
+	operation := operations[0]
+	if operation.TransactionType != database.TransactionTypeAsync {
+		t.Errorf("Expected transaction type %q, got %q", database.TransactionTypeAsync, operation.TransactionType)
+	}
+	if operation.RequestEndpoint != target.URL || operation.RequestMethod != "POST" {
+		t.Errorf("Expected POST %s, got %s %s", target.URL, operation.RequestMethod, operation.RequestEndpoint)
+	}
+	if operation.ResponseStatusCode != http.StatusAccepted || operation.ResponseBody != `{"received":true}` {
+		t.Errorf("Expected the target response, got %d %s", operation.ResponseStatusCode, operation.ResponseBody)
+	}
+	if operation.TraceID != "trace-async" {
+		t.Errorf("Expected the request trace id, got %q", operation.TraceID)
+	}
+	if got := bm.SearchIndex().Search("received"); len(got) != 1 || got[0] != operation.UUID {
+		t.Errorf("Expected the async transaction to be searchable by body, got %v", got)
+	}
+}
+
+func TestAsyncFanOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const delay = 300 * time.Millisecond
+	var mu sync.Mutex
+	called := map[string]bool{}
+	var done sync.WaitGroup
+	done.Add(2)
+	slowTarget := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			called[name] = true
+			mu.Unlock()
+			time.Sleep(delay)
+			w.WriteHeader(http.StatusOK)
+			done.Done()
+		}))
+	}
+	first := slowTarget("first")
+	defer first.Close()
+	second := slowTarget("second")
+	defer second.Close()
+
+	// The failing target refuses connections and keeps retrying well after the others finished
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	failing.Close()
+	retries, retryDelay := 3, 1000
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:       "/api/orders",
+		Method:     "POST",
+		Response:   `{"id":1}`,
+		StatusCode: 201,
+		Async: []models.Async{
+			{Url: failing.URL, Method: "POST", Retries: &retries, RetryDelay: &retryDelay},
+			{Url: first.URL, Method: "POST"},
+			{Url: second.URL, Method: "POST"},
+		},
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/orders", nil)
+
+	start := time.Now()
+	h.HandleRequest(c, location)
+	if elapsed := time.Since(start); elapsed >= delay {
+		t.Errorf("Expected the response not to wait for async calls, took %v", elapsed)
+	}
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the async targets")
+	}
+
+	// Sequential calls would take at least the sum of the delays plus the failing retries
+	if elapsed := time.Since(start); elapsed >= 2*delay {
+		t.Errorf("Expected async calls to run concurrently, took %v", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !called["first"] || !called["second"] {
+		t.Errorf("Expected every async target to be called, got %v", called)
+	}
+}
+
+func TestWriteTimeoutSkipsRecordAndAsync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var mu sync.Mutex
+	calls := 0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	bm, err := database.OpenBatchManager(filepath.Join(t.TempDir(), "timeout.db"), database.BatchConfig{})
+	if err != nil {
+		t.Fatalf("OpenBatchManager failed: %v", err)
+	}
+	defer bm.GetDB().Close()
+	if err := bm.Start(); err != nil {
+		t.Fatalf("Failed to start batch manager: %v", err)
+	}
+	defer bm.Stop()
+
+	h := NewHandler(nil, bm)
+	location := models.Location{
+		Path:           "/api/slow",
+		Method:         "POST",
+		Response:       `{"message":"done"}`,
+		StatusCode:     201,
+		WriteTimeoutMs: 100,
+		ChaosInjection: &models.ChaosInjection{
+			Latency: models.Latency{Time: 300, Probability: "100"},
+		},
+		Async: []models.Async{{Url: target.URL, Method: "POST"}},
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", location.Path, nil)
+	h.HandleRequest(c, location)
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Expected status code 504, got %d", w.Code)
+	}
+
+	// Give the abandoned handler time to finish building the late response
+	time.Sleep(600 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := bm.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	operations, err := database.ListOperations(bm.GetDB())
+	if err != nil {
+		t.Fatalf("ListOperations failed: %v", err)
+	}
+	if len(operations) != 0 {
+		t.Errorf("Expected the late response not to be recorded, got %d transactions", len(operations))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 0 {
+		t.Errorf("Expected no async call after the timeout, got %d", calls)
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Location auth types
+const (
+	authTypeBasic  = "basic"
+	authTypeBearer = "bearer"
+)
+
+// authEnvPrefix reads a password or token from an environment variable, e.g. env:MY_TOKEN
+const authEnvPrefix = "env:"
+
+// authPassThroughKey marks the copied context of async calls that forward the Authorization header
+const authPassThroughKey = "auth_pass_through"
+
+// resolveAuthSecret returns the configured password or token, reading env:NAME values from the environment
+func resolveAuthSecret(value string) string {
+	if name, ok := strings.CutPrefix(value, authEnvPrefix); ok {
+		return os.Getenv(name)
+	}
+	return value
+}
+
+// isBcryptHash reports whether the configured password is a bcrypt hash
+func isBcryptHash(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
+// registerAuth checks the auth of a location and warns about plaintext passwords
+func (h *Handler) registerAuth(location models.Location) error {
+	auth := location.Auth
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case authTypeBasic:
+		password := resolveAuthSecret(auth.Password)
+		if password == "" {
+			return fmt.Errorf("basic auth for path %s has no password", location.Path)
+		}
+		if !isBcryptHash(password) {
+			h.Logger().Warn().
+				Str("path", location.Path).
+				Str("method", location.Method).
+				Msg("Basic auth password is not a bcrypt hash, comparing it as plaintext")
+		}
+	case authTypeBearer:
+		if resolveAuthSecret(auth.Token) == "" {
+			return fmt.Errorf("bearer auth for path %s has no token", location.Path)
+		}
+	default:
+		return fmt.Errorf("invalid auth type %q for path %s", auth.Type, location.Path)
+	}
+	return nil
+}
+
+// authorized reports whether the Authorization header carries the credentials of the location auth
+func authorized(c *gin.Context, auth *models.LocationAuth) bool {
+	switch auth.Type {
+	case authTypeBasic:
+		username, password, ok := c.Request.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(username), []byte(auth.Username)) != 1 {
+			return false
+		}
+		expected := resolveAuthSecret(auth.Password)
+		if isBcryptHash(expected) {
+			return bcrypt.CompareHashAndPassword([]byte(expected), []byte(password)) == nil
+		}
+		return expected != "" && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+	case authTypeBearer:
+		header := c.GetHeader("Authorization")
+		if len(header) <= 7 || !strings.EqualFold(header[:7], "Bearer ") {
+			return false
+		}
+		expected := resolveAuthSecret(auth.Token)
+		return expected != "" && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(header[7:])), []byte(expected)) == 1
+	}
+	return false
+}
+
+// rejectUnauthorized answers 401 with the challenge of the location auth type
+func rejectUnauthorized(c *gin.Context, auth *models.LocationAuth) {
+	if auth.Type == authTypeBasic {
+		c.Header("WWW-Authenticate", `Basic realm="mock"`)
+	} else {
+		c.Header("WWW-Authenticate", `Bearer realm="mock"`)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// responseCacheMaxEntries bounds the number of cached responses per server
+const responseCacheMaxEntries = 10000
+
+// cacheHeader tells whether the response came from the location cache
+const cacheHeader = "X-Cache"
+
+// responseCache keeps rendered location responses for their cache_ttl_seconds
+type responseCache struct {
+	entries *dedupCache
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+func newResponseCache(maxEntries int) *responseCache {
+	return &responseCache{entries: newDedupCache(maxEntries)}
+}
+
+// responseCacheKey builds method+path+sorted query string; the body is not part of the key
+func responseCacheKey(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	// Encode sorts by key
+	return c.Request.Method + " " + c.Request.URL.Path + "?" + url.Values(query).Encode()
+}
+
+// serveCacheHit writes a cached response without running the location again
+func (h *Handler) serveCacheHit(c *gin.Context, location models.Location, entry *dedupEntry) {
+	for key, values := range entry.headers {
+		// The request ID belongs to the current request, not the cached one
+		if key == "X-Request-Id" {
+			continue
+		}
+		c.Writer.Header()[key] = append([]string(nil), values...)
+	}
+
+	maxAge := int(time.Until(entry.expiresAt).Seconds())
+	c.Header(cacheHeader, "HIT")
+	c.Header("Cache-Control", "max-age="+strconv.Itoa(maxAge))
+	c.Status(entry.statusCode)
+	if _, err := c.Writer.Write(entry.body); err != nil {
+		h.Logger().Error().AnErr("error", err).Msg("Error writing cached response")
+	}
+
+	h.recordTransaction(c, location, func() string {
+		return string(entry.body)
+	})
+}
+
+// CacheStats returns the hit/miss counters and the live entries of the response cache
+func (h *Handler) CacheStats() models.CacheStats {
+	stats := models.CacheStats{
+		Hits:    h.cache.hits.Load(),
+		Misses:  h.cache.misses.Load(),
+		Entries: make([]models.CacheEntry, 0),
+	}
+
+	for _, entry := range h.cache.entries.snapshot() {
+		stats.Entries = append(stats.Entries, models.CacheEntry{
+			Key:        entry.fingerprint,
+			StatusCode: entry.statusCode,
+			SizeBytes:  len(entry.body),
+			ExpiresAt:  entry.expiresAt,
+		})
+	}
+
+	return stats
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// truncatedSuffix marks a request body cut at log_request_body_max_bytes
+const truncatedSuffix = "...[truncated]"
+
+// redactedValue replaces the fields listed in log_request_body_exclude_fields
+const redactedValue = "[REDACTED]"
+
+// loggedRequestBody returns the request body stored in the database. It is empty with
+// log_request_body: false, and multipart/form-data bodies are stored as their fields and file
+// metadata, without the file contents, unless log_multipart_files is set.
+func (h *Handler) loggedRequestBody(c *gin.Context, location models.Location) string {
+	if location.LogRequestBody != nil && !*location.LogRequestBody {
+		return ""
+	}
+
+	body := h.getRequestBody(c)
+	if body != "" && isMultipart(c) && !location.LogMultipartFiles {
+		// A body that doesn't parse is stored as received
+		if data, err := parseMultipartBody(c, []byte(body)); err == nil {
+			if encoded, err := json.Marshal(data); err == nil {
+				body = string(encoded)
+			}
+		}
+	}
+	return captureRequestBody(body, location)
+}
+
+// requestTags returns the tag_headers of the location present in the request, keyed by the
+// configured header name
+func requestTags(c *gin.Context, location models.Location) map[string]string {
+	var tags map[string]string
+	for _, header := range location.TagHeaders {
+		if value := c.GetHeader(header); value != "" {
+			if tags == nil {
+				tags = make(map[string]string, len(location.TagHeaders))
+			}
+			tags[header] = value
+		}
+	}
+	return tags
+}
+
+// captureRequestBody applies the location capture rules to the request body stored in the
+// database: excluded JSON fields are redacted first, then the result is truncated
+func captureRequestBody(body string, location models.Location) string {
+	if len(location.LogRequestBodyExcludeFields) > 0 {
+		body = redactJSONFields(body, location.LogRequestBodyExcludeFields)
+	}
+
+	if limit := location.LogRequestBodyMaxBytes; limit > 0 && len(body) > limit {
+		// Never cut a multi-byte character in half
+		for limit > 0 && !utf8.RuneStart(body[limit]) {
+			limit--
+		}
+		body = body[:limit] + truncatedSuffix
+	}
+	return body
+}
+
+// redactJSONFields replaces the values of the given fields, at any depth, with [REDACTED].
+// Bodies that are not valid JSON are returned unchanged.
+func redactJSONFields(body string, fields []string) string {
+	var data interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return body
+	}
+
+	excluded := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		excluded[strings.ToLower(field)] = true
+	}
+
+	if !redactValue(data, excluded) {
+		return body
+	}
+
+	redacted, err := json.Marshal(data)
+	if err != nil {
+		return body
+	}
+	return string(redacted)
+}
+
+// redactValue walks objects and arrays, reporting whether any field was redacted
+func redactValue(value interface{}, excluded map[string]bool) bool {
+	redacted := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if excluded[strings.ToLower(key)] {
+				v[key] = redactedValue
+				redacted = true
+				continue
+			}
+			if redactValue(nested, excluded) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, nested := range v {
+			if redactValue(nested, excluded) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
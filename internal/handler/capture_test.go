@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCaptureRequestBodyTruncation(t *testing.T) {
+	location := models.Location{LogRequestBodyMaxBytes: 10}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"body under the limit", "short", "short"},
+		{"body at the limit", "0123456789", "0123456789"},
+		{"body over the limit", strings.Repeat("a", 4096), strings.Repeat("a", 10) + "...[truncated]"},
+		{"multi-byte character at the limit", "012345678ñ", "012345678...[truncated]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := captureRequestBody(tt.body, location); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if got := captureRequestBody(strings.Repeat("a", 4096), models.Location{}); len(got) != 4096 {
+		t.Errorf("Expected no truncation without a limit, got %d bytes", len(got))
+	}
+}
+
+func TestCaptureRequestBodyExcludeFields(t *testing.T) {
+	location := models.Location{LogRequestBodyExcludeFields: []string{"password", "cvv", "pin"}}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			"top level fields",
+			`{"user":"ana","password":"secret","cvv":123}`,
+			`{"cvv":"[REDACTED]","password":"[REDACTED]","user":"ana"}`,
+		},
+		{
+			"nested objects and arrays",
+			`{"cards":[{"number":"4111","CVV":"999"}],"auth":{"pin":"1234"}}`,
+			`{"auth":{"pin":"[REDACTED]"},"cards":[{"CVV":"[REDACTED]","number":"4111"}]}`,
+		},
+		{
+			"no excluded fields keeps the body untouched",
+			`{"user": "ana"}`,
+			`{"user": "ana"}`,
+		},
+		{
+			"non JSON body",
+			`password=secret&user=ana`,
+			`password=secret&user=ana`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := captureRequestBody(tt.body, location); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoggedRequestBodyMultipart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(nil, nil)
+	disabled := false
+
+	tests := []struct {
+		name     string
+		location models.Location
+		contains []string
+		excludes []string
+	}{
+		{"fields and file metadata only", models.Location{}, []string{`"name":"Ana"`, `"filename":"invoice.pdf"`, `"size":20`}, []string{"secret-contents"}},
+		{"file contents with log_multipart_files", models.Location{LogMultipartFiles: true}, []string{"secret-contents", "Ana"}, nil},
+		{"nothing with log_request_body false", models.Location{LogRequestBody: &disabled, LogMultipartFiles: true}, nil, []string{"Ana", "secret-contents"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartRequest(t)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/api/upload", body)
+			c.Request.Header.Set("Content-Type", contentType)
+
+			logged := h.loggedRequestBody(c, tt.location)
+			for _, expected := range tt.contains {
+				if !strings.Contains(logged, expected) {
+					t.Errorf("Expected stored body to contain %s, got %s", expected, logged)
+				}
+			}
+			for _, unexpected := range tt.excludes {
+				if strings.Contains(logged, unexpected) {
+					t.Errorf("Expected stored body not to contain %s, got %s", unexpected, logged)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	location := models.Location{TagHeaders: []string{"X-Test-Name", "X-Test-Suite"}}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/pay", nil)
+	c.Request.Header.Set("X-Test-Name", "PaymentFlowTest")
+	c.Request.Header.Set("X-Other", "ignored")
+
+	tags := requestTags(c, location)
+	if len(tags) != 1 || tags["X-Test-Name"] != "PaymentFlowTest" {
+		t.Errorf("Expected only the present tag header, got %v", tags)
+	}
+
+	c.Request = httptest.NewRequest("POST", "/pay", nil)
+	if tags := requestTags(c, location); tags != nil {
+		t.Errorf("Expected no tags without the headers, got %v", tags)
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"catalyst/internal/models"
+)
+
+// requestCounterKey identifies a location in the realtime counters
+type requestCounterKey struct {
+	method string
+	path   string
+}
+
+// requestCounter counts handled requests and those answered with a 4xx/5xx status
+type requestCounter struct {
+	total  atomic.Int64
+	errors atomic.Int64
+}
+
+// requestCounters keeps per method+path counters in memory, independent of the BatchManager
+type requestCounters struct {
+	counters sync.Map
+}
+
+// record counts a handled request
+func (rc *requestCounters) record(method, path string, status int) {
+	key := requestCounterKey{method: method, path: path}
+	value, ok := rc.counters.Load(key)
+	if !ok {
+		value, _ = rc.counters.LoadOrStore(key, &requestCounter{})
+	}
+
+	counter := value.(*requestCounter)
+	counter.total.Add(1)
+	if status >= http.StatusBadRequest {
+		counter.errors.Add(1)
+	}
+}
+
+// snapshot returns the counters sorted by path and method
+func (rc *requestCounters) snapshot() []models.RequestCounter {
+	result := make([]models.RequestCounter, 0)
+	rc.counters.Range(func(key, value any) bool {
+		k := key.(requestCounterKey)
+		counter := value.(*requestCounter)
+		result = append(result, models.RequestCounter{
+			Method: k.method,
+			Path:   k.path,
+			Total:  counter.total.Load(),
+			Errors: counter.errors.Load(),
+		})
+		return true
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Method < result[j].Method
+	})
+	return result
+}
+
+// RequestCounters returns the realtime request counters of the server
+func (h *Handler) RequestCounters() []models.RequestCounter {
+	return h.counters.snapshot()
+}
+
+// ResetRequestCounters sets every realtime request counter back to zero
+func (h *Handler) ResetRequestCounters() {
+	h.counters.counters.Clear()
+}
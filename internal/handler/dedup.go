@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dedupMaxEntries bounds the number of fingerprints kept in memory
+const dedupMaxEntries = 10000
+
+// dedupHeader marks responses served from the deduplication cache
+const dedupHeader = "X-Mock-Dedup"
+
+// dedupEntry is a response stored for a request fingerprint
+type dedupEntry struct {
+	fingerprint string
+	statusCode  int
+	headers     http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// dedupCache is an LRU cache of responses with a per-entry TTL
+type dedupCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+// newDedupCache creates a dedup cache bounded to maxEntries
+func newDedupCache(maxEntries int) *dedupCache {
+	return &dedupCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached response for a fingerprint if it has not expired
+func (dc *dedupCache) get(fingerprint string) (*dedupEntry, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	elem, ok := dc.entries[fingerprint]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*dedupEntry)
+	if time.Now().After(entry.expiresAt) {
+		dc.order.Remove(elem)
+		delete(dc.entries, fingerprint)
+		return nil, false
+	}
+
+	dc.order.MoveToFront(elem)
+	return entry, true
+}
+
+// add stores a response, evicting the least recently used entry when full
+func (dc *dedupCache) add(entry *dedupEntry) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if elem, ok := dc.entries[entry.fingerprint]; ok {
+		elem.Value = entry
+		dc.order.MoveToFront(elem)
+		return
+	}
+
+	dc.entries[entry.fingerprint] = dc.order.PushFront(entry)
+
+	for dc.order.Len() > dc.maxEntries {
+		oldest := dc.order.Back()
+		dc.order.Remove(oldest)
+		delete(dc.entries, oldest.Value.(*dedupEntry).fingerprint)
+	}
+}
+
+// len returns the number of cached entries
+func (dc *dedupCache) len() int {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.order.Len()
+}
+
+// snapshot returns the entries that have not expired, most recently used first
+func (dc *dedupCache) snapshot() []*dedupEntry {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]*dedupEntry, 0, dc.order.Len())
+	for elem := dc.order.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*dedupEntry)
+		if now.Before(entry.expiresAt) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// requestFingerprint computes SHA256(method+path+query+headers+body) for the request. Only the
+// given headers are part of the fingerprint, so per-request headers such as X-Request-Id or
+// User-Agent don't tell identical requests apart.
+func requestFingerprint(c *gin.Context, headers []string) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	}
+
+	keys := make([]string, 0, len(headers))
+	for _, header := range headers {
+		keys = append(keys, http.CanonicalHeaderKey(header))
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + "\n"))
+	hash.Write([]byte(c.Request.URL.Path + "\n"))
+	hash.Write([]byte(c.Request.URL.RawQuery + "\n"))
+	for _, key := range keys {
+		hash.Write([]byte(key + ":" + strings.Join(c.Request.Header.Values(key), ",") + "\n"))
+	}
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// captureWriter copies everything written to the response so it can be cached
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
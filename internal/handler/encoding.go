@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// decompressRequestBody replaces a gzip or deflate encoded request body with its decoded bytes, so
+// schema validation, templates and the database see the plain body
+func decompressRequestBody(c *gin.Context) error {
+	encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+	if c.Request.Body == nil || (encoding != "gzip" && encoding != "x-gzip" && encoding != "deflate") {
+		return nil
+	}
+
+	compressed, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return fmt.Errorf("error reading %s request body: %w", encoding, err)
+	}
+
+	var body []byte
+	if encoding == "deflate" {
+		body, err = inflate(compressed)
+	} else {
+		var reader *gzip.Reader
+		reader, err = gzip.NewReader(bytes.NewReader(compressed))
+		if err == nil {
+			body, err = io.ReadAll(reader)
+			reader.Close()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error decompressing %s request body: %w", encoding, err)
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+	c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	c.Request.Header.Del("Content-Encoding")
+	return nil
+}
+
+// inflate decodes a deflate body. HTTP deflate is zlib-wrapped, but some clients send raw deflate.
+func inflate(compressed []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		raw := flate.NewReader(bytes.NewReader(compressed))
+		defer raw.Close()
+		return io.ReadAll(raw)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
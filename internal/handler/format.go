@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Request body formats accepted by request_format
+const (
+	RequestFormatJSON = "json"
+	RequestFormatXML  = "xml"
+	RequestFormatCSV  = "csv"
+	RequestFormatForm = "form"
+)
+
+// validRequestFormat reports whether format is one of the supported request formats
+func validRequestFormat(format string) bool {
+	switch format {
+	case RequestFormatJSON, RequestFormatXML, RequestFormatCSV, RequestFormatForm:
+		return true
+	}
+	return false
+}
+
+// isFormEncoded reports whether the request declares an application/x-www-form-urlencoded body
+func isFormEncoded(c *gin.Context) bool {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// maxMultipartMemory is the part of a multipart/form-data body kept in memory, the rest goes to temporary files
+const maxMultipartMemory = 32 << 20
+
+// multipartFile is the metadata of an uploaded file, exposed to templates as .Files.<field>
+type multipartFile struct {
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// isMultipart reports whether the request declares a multipart/form-data body
+func isMultipart(c *gin.Context) bool {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// parseMultipartBody parses a multipart/form-data body into its text fields, keeping the first value
+// of each, plus a Files entry with the metadata of the first file of each file field. The file
+// contents are not exposed and the body stays readable.
+func parseMultipartBody(c *gin.Context, body []byte) (map[string]interface{}, error) {
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	err := c.Request.ParseMultipartForm(maxMultipartMemory)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart body: %w", err)
+	}
+
+	form := c.Request.MultipartForm
+	fields := make(map[string]interface{}, len(form.Value)+1)
+	for key, values := range form.Value {
+		if len(values) > 0 {
+			fields[key] = values[0]
+		}
+	}
+
+	files := make(map[string]multipartFile, len(form.File))
+	for key, headers := range form.File {
+		if len(headers) > 0 {
+			files[key] = multipartFile{
+				Filename:    headers[0].Filename,
+				Size:        headers[0].Size,
+				ContentType: headers[0].Header.Get("Content-Type"),
+			}
+		}
+	}
+	fields["Files"] = files
+	return fields, nil
+}
+
+// parseFormBody parses a form-encoded body keeping the first value of each field
+func parseFormBody(body []byte) (map[string]string, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid form-encoded body: %w", err)
+	}
+
+	fields := make(map[string]string, len(values))
+	for key, value := range values {
+		if len(value) > 0 {
+			fields[key] = value[0]
+		}
+	}
+	return fields, nil
+}
+
+// validateRequestFormat checks that the request body parses as format, keeping the body readable
+func validateRequestFormat(c *gin.Context, format string) error {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			return fmt.Errorf("error reading request body: %w", err)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	}
+
+	switch format {
+	case RequestFormatJSON:
+		if !json.Valid(body) {
+			var data interface{}
+			return fmt.Errorf("invalid JSON body: %w", json.Unmarshal(body, &data))
+		}
+	case RequestFormatXML:
+		var root struct{ XMLName xml.Name }
+		if err := xml.Unmarshal(body, &root); err != nil {
+			return fmt.Errorf("invalid XML body: %w", err)
+		}
+	case RequestFormatCSV:
+		if _, err := csv.NewReader(bytes.NewReader(body)).ReadAll(); err != nil {
+			return fmt.Errorf("invalid CSV body: %w", err)
+		}
+	case RequestFormatForm:
+		if _, err := url.ParseQuery(string(body)); err != nil {
+			return fmt.Errorf("invalid form-encoded body: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+
+	"catalyst/database"
+	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// globalAsync is the server-wide async call with its parsed body template
+type globalAsync struct {
+	config models.GlobalAsync
+	body   *template.Template
+}
+
+// SetGlobalAsync sets the async call fired by every request of the server
+func (h *Handler) SetGlobalAsync(config models.GlobalAsync) error {
+	body, err := template.New("global_async").Parse(config.BodyTemplate)
+	if err != nil {
+		return fmt.Errorf("error parsing global_async body_template: %w", err)
+	}
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+	h.globalAsync = &globalAsync{config: config, body: body}
+	return nil
+}
+
+// fireGlobalAsync sends the global_async call of the server with the transaction of the request.
+// It runs in its own goroutine and never blocks the response.
+func (h *Handler) fireGlobalAsync(c *gin.Context, location models.Location, requestPath, requestMethod string) {
+	if h.globalAsync == nil || requestTimedOut(c) {
+		return
+	}
+
+	transaction := database.Mockdata{
+		UUID:               uuid.New().String(),
+		RecepcionID:        c.GetHeader("X-Recepcion-ID"),
+		SenderID:           c.GetHeader("X-Sender-ID"),
+		RequestMethod:      c.Request.Method,
+		RequestEndpoint:    c.Request.URL.Path,
+		RequestBody:        h.loggedRequestBody(c, location),
+		ResponseStatusCode: h.getActualStatusCode(c),
+		TransactionType:    database.TransactionTypeSync,
+		TraceID:            c.GetString(traceIDKey),
+		Timestamp:          time.Now(),
+	}
+
+	var body bytes.Buffer
+	if err := h.globalAsync.body.Execute(&body, transaction); err != nil {
+		h.Logger().ErrorCtx(c.Request.Context()).AnErr("error", err).Msg("Error rendering global_async body_template")
+		return
+	}
+
+	async := models.Async{
+		Url:     h.globalAsync.config.Url,
+		Method:  h.globalAsync.config.Method,
+		Body:    body.String(),
+		Headers: h.globalAsync.config.Headers,
+		Retries: h.globalAsync.config.Retries,
+	}
+	asyncCtx := c.Copy()
+	go h.handleAsyncCall(&async, asyncCtx)
+
+	prom.HandlerAsyncCallsTotal.WithLabelValues(requestPath, requestMethod, async.Url).Inc()
+}
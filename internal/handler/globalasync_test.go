@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGlobalAsync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var mu sync.Mutex
+	var callbacks []map[string]interface{}
+	audit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Expected a JSON callback body, got %s", body)
+		}
+		if r.Method != http.MethodPost || r.Header.Get("X-Audit") != "mock" {
+			t.Errorf("Expected a POST with the configured headers, got %s %v", r.Method, r.Header)
+		}
+		mu.Lock()
+		callbacks = append(callbacks, payload)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer audit.Close()
+
+	h := NewHandler(nil, nil)
+	err := h.SetGlobalAsync(models.GlobalAsync{
+		Url:          audit.URL,
+		BodyTemplate: `{"uuid":"{{ .UUID }}","method":"{{ .RequestMethod }}","endpoint":"{{ .RequestEndpoint }}","status":{{ .ResponseStatusCode }}}`,
+		Headers:      &models.Headers{"X-Audit": "mock"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set global_async: %v", err)
+	}
+
+	orders := models.Location{Path: "/api/orders", Method: "GET", Response: `{"id":1}`, StatusCode: 200}
+	payments := models.Location{
+		Path:       "/api/payments",
+		Method:     "POST",
+		Response:   `{"paid":true}`,
+		StatusCode: 201,
+		ChaosInjection: &models.ChaosInjection{
+			Abort: models.Abort{Code: http.StatusServiceUnavailable, Probability: "100"},
+		},
+	}
+	for _, location := range []models.Location{orders, payments} {
+		if err := h.RegisterLocation(location); err != nil {
+			t.Fatalf("Failed to register location: %v", err)
+		}
+	}
+
+	for _, location := range []models.Location{orders, orders, payments} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(location.Method, location.Path, nil)
+		h.HandleRequest(c, location)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		received := len(callbacks)
+		mu.Unlock()
+		if received == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 3 global_async callbacks, got %d", received)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	statuses := map[float64]int{}
+	for _, payload := range callbacks {
+		if payload["uuid"] == "" {
+			t.Errorf("Expected the callback to carry the transaction UUID, got %v", payload)
+		}
+		statuses[payload["status"].(float64)]++
+	}
+	if statuses[200] != 2 || statuses[503] != 1 {
+		t.Errorf("Expected two 200 callbacks and one chaos-aborted 503, got %v", statuses)
+	}
+}
+
+func TestSetGlobalAsyncInvalidTemplate(t *testing.T) {
+	h := NewHandler(nil, nil)
+	if err := h.SetGlobalAsync(models.GlobalAsync{Url: "http://localhost", BodyTemplate: "{{ .UUID "}); err == nil {
+		t.Error("Expected an error for an invalid body_template")
+	}
+}
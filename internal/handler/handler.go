@@ -9,8 +9,11 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 	"unsafe"
@@ -34,32 +37,156 @@ import (
 type Handler struct {
 	chaosEngine  *chaos.Engine
 	schemas      map[string]*jsonschema.Schema
+	schemaMu     sync.RWMutex
 	xsd          map[string]*string
-	Logger       *scribe.Scribe
+	logger       atomic.Pointer[scribe.Scribe]
 	BatchManager *database.BatchManager
+	dedup        *dedupCache
+	cache        *responseCache
+	serverChaos  *models.ChaosInjection
+	chaosHistory *chaosHistory
+	maxBodyBytes int64
+	counters     requestCounters
+
+	schemaWatcher *schemaWatcher
+
+	// rawSchemas holds the inline schemas not compiled yet by path:method, schemaOnce compiles
+	// each one on its first request and schemaErrors keeps the compilation failures
+	rawSchemas   map[string]string
+	schemaOnce   map[string]*sync.Once
+	schemaErrors map[string]error
+
+	// schemaJSON holds the source of every JSON schema, compiled or not, by path:method
+	schemaJSON map[string]string
+
+	overrideHeader       string
+	allowedOverrideCodes []int
+
+	stateMachine *stateMachine
+
+	globalAsync *globalAsync
+
+	// labels holds the prometheus_labels of each location by path:method
+	labels map[string]*locationLabels
+
+	// pathRegexes holds the compiled path_regex of each location
+	pathRegexes map[string]*regexp.Regexp
+
+	// transforms holds the request_transform of each location by path:method
+	transforms map[string][]transformStep
+
+	// sequences counts the calls to each location with a response_sequence by path:method
+	sequences sync.Map
+
+	// timezone and now evaluate response delay schedules; now is replaced in tests
+	timezone *time.Location
+	now      func() time.Time
 }
 
+// DefaultMaxResponseBodyBytes is the response size limit when max_response_body_bytes is not set
+const DefaultMaxResponseBodyBytes = 1 << 20
+
+// responseTooLargeBody is returned instead of a rendered response above the size limit
+const responseTooLargeBody = `{"error":"response body exceeds limit"}`
+
+// chaosContextKey stores the chaos config that aborted the request
+const chaosContextKey = "chaos_injection"
+
+// traceIDKey stores the request_trace_id of the request, shared with its async calls
+const traceIDKey = "request_trace_id"
+
+// traceIDHeader carries the request_trace_id to async calls with propagate_trace
+const traceIDHeader = "X-Trace-ID"
+
 var isValidXSD bool
 
 // NewHandler creates a new handler with the given chaos engine
 func NewHandler(logger *scribe.Scribe, batchManager *database.BatchManager) *Handler {
-	return &Handler{
+	h := &Handler{
 		chaosEngine:  chaos.NewEngine(),
 		schemas:      make(map[string]*jsonschema.Schema),
-		Logger:       logger,
+		rawSchemas:   make(map[string]string),
+		schemaOnce:   make(map[string]*sync.Once),
+		schemaErrors: make(map[string]error),
+		schemaJSON:   make(map[string]string),
 		BatchManager: batchManager,
 		xsd:          make(map[string]*string),
+		labels:       make(map[string]*locationLabels),
+		pathRegexes:  make(map[string]*regexp.Regexp),
+		transforms:   make(map[string][]transformStep),
+		dedup:        newDedupCache(dedupMaxEntries),
+		cache:        newResponseCache(responseCacheMaxEntries),
+		maxBodyBytes: DefaultMaxResponseBodyBytes,
+		timezone:     time.Local,
+		now:          time.Now,
 	}
+	h.logger.Store(logger)
+	return h
+}
+
+// MatchPathRegex reports whether the request path matches the path_regex of a registered location
+func (h *Handler) MatchPathRegex(location models.Location, path string) bool {
+	pattern, ok := h.pathRegexes[location.PathRegex]
+	return ok && pattern.MatchString(path)
+}
+
+// Logger returns the logger used for the current request
+func (h *Handler) Logger() *scribe.Scribe {
+	return h.logger.Load()
+}
+
+// SetLogger replaces the logger at runtime, e.g. to change its level
+func (h *Handler) SetLogger(logger *scribe.Scribe) {
+	h.logger.Store(logger)
+}
+
+// SetServerChaos sets the chaos injection applied to every location of the server
+func (h *Handler) SetServerChaos(chaosConfig *models.ChaosInjection) {
+	h.serverChaos = chaosConfig
+}
+
+// SetMaxResponseBodyBytes limits the size of rendered responses; 0 disables the limit
+func (h *Handler) SetMaxResponseBodyBytes(limit int64) {
+	h.maxBodyBytes = limit
+}
+
+// SetChaosSeed makes chaos decisions reproducible by seeding the chaos engine
+func (h *Handler) SetChaosSeed(seed int64) {
+	h.chaosEngine = chaos.NewEngineWithSeed(seed)
 }
 
 // RegisterLocation registers a location with the handler
 func (h *Handler) RegisterLocation(location models.Location) error {
-	h.Logger.Info().
+	h.Logger().Info().
 		Str("path", location.Path).
 		Str("method", location.Method).
 		Int("status_code", location.StatusCode).
 		Msg("Registering location")
 
+	if location.RequestFormat != "" && !validRequestFormat(location.RequestFormat) {
+		return fmt.Errorf("invalid request_format %q for path %s", location.RequestFormat, location.Path)
+	}
+
+	if err := h.registerLabels(location); err != nil {
+		return fmt.Errorf("invalid prometheus_labels for path %s: %w", location.Path, err)
+	}
+
+	if err := h.registerAuth(location); err != nil {
+		return err
+	}
+
+	if err := h.registerTransform(location); err != nil {
+		return fmt.Errorf("invalid request_transform for path %s: %w", location.Path, err)
+	}
+
+	if location.PathRegex != "" {
+		pattern, err := regexp.Compile(location.PathRegex)
+		if err != nil {
+			return fmt.Errorf("invalid path_regex %q: %w", location.PathRegex, err)
+		}
+		h.pathRegexes[location.PathRegex] = pattern
+	}
+
 	if location.Schema != "" {
 		var i interface{}
 		if err := xml.Unmarshal([]byte(location.Schema), &i); err != nil {
@@ -67,29 +194,45 @@ func (h *Handler) RegisterLocation(location models.Location) error {
 		} else {
 			isValidXSD = true
 			h.xsd[location.Path+":"+location.Method] = &location.Schema
-			h.Logger.Debug().
+			h.Logger().Debug().
 				Str("path", location.Path).
 				Str("method", location.Method).
 				Msg("XML XSD detected for location")
 		}
 	}
 
-	// If schema is provided, compile it
+	// If schema is provided, compile it on the first request of the location
 	if location.Schema != "" && !isValidXSD {
-		schema, err := h.compileSchema(location.Schema)
-		if err != nil {
-			h.Logger.Error().
+		if !json.Valid([]byte(location.Schema)) {
+			h.Logger().Error().
+				Str("path", location.Path).
+				Str("method", location.Method).
+				Msg("Error parsing schema for location")
+			return fmt.Errorf("error compiling schema for path %s: schema is not valid JSON", location.Path)
+		}
+		h.deferSchema(location.Path+":"+location.Method, location.Schema)
+		h.Logger().Debug().
+			Str("path", location.Path).
+			Str("method", location.Method).
+			Msg("Schema registered for location, compiled on first request")
+	}
+
+	// Otherwise load the schema from an external file, recompiled whenever it changes
+	if location.Schema == "" && location.SchemaFile != "" {
+		if err := h.registerSchemaFile(location.Path+":"+location.Method, location.SchemaFile); err != nil {
+			h.Logger().Error().
 				Str("path", location.Path).
 				Str("method", location.Method).
+				Str("schema_file", location.SchemaFile).
 				AnErr("error", err).
-				Msg("Error compiling schema for location")
-			return fmt.Errorf("error compiling schema for path %s: %w", location.Path, err)
+				Msg("Error loading schema file for location")
+			return fmt.Errorf("error loading schema file for path %s: %w", location.Path, err)
 		}
-		h.schemas[location.Path+":"+location.Method] = schema
-		h.Logger.Debug().
+		h.Logger().Debug().
 			Str("path", location.Path).
 			Str("method", location.Method).
-			Msg("Schema compiled successfully for location")
+			Str("schema_file", location.SchemaFile).
+			Msg("Schema file compiled successfully for location")
 	}
 
 	return nil
@@ -121,6 +264,47 @@ func (h *Handler) compileSchema(schemaStr string) (*jsonschema.Schema, error) {
 
 // HandleRequest handles an HTTP request based on the location configuration
 func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
+	// Compressed bodies are decoded before anything reads them
+	decodeErr := decompressRequestBody(c)
+
+	// Label values are read before handling, the body may be consumed afterwards
+	labels, labelValues := h.customLabelValues(c, location)
+	defer func() {
+		h.counters.record(c.Request.Method, location.Path, c.Writer.Status())
+		if labels != nil {
+			labels.record(labelValues, location.Path, c.Request.Method, c.Writer.Status())
+		}
+	}()
+
+	// Credentials are checked before anything else handles the request
+	if location.Auth != nil && !authorized(c, location.Auth) {
+		h.Logger().WarnCtx(scribe.WithCtx(c.Request.Context())).
+			Str("path", location.Path).
+			Str("method", c.Request.Method).
+			Str("auth_type", location.Auth.Type).
+			Msg("Unauthorized request")
+		rejectUnauthorized(c, location.Auth)
+		return
+	}
+
+	if decodeErr != nil {
+		h.Logger().ErrorCtx(scribe.WithCtx(c.Request.Context())).AnErr("error", decodeErr).Msg("Invalid compressed request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": decodeErr.Error()})
+		return
+	}
+
+	h.setRequestTransform(c, location)
+
+	if location.ReadTimeoutMs > 0 || location.WriteTimeoutMs > 0 {
+		h.handleWithTimeouts(c, location)
+		return
+	}
+
+	h.handleRequest(c, location)
+}
+
+// handleRequest builds the response for the location
+func (h *Handler) handleRequest(c *gin.Context, location models.Location) {
 	// Start timing for metrics
 	start := time.Now()
 	requestPath := location.Path // Usar location.Path para las métricas si es consistente
@@ -136,21 +320,76 @@ func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
 
 	logCtx := scribe.GetLogContext(ctx)
 
-	logCtx.Set("request_trace_id", uuid.New().String())
+	traceID := uuid.New().String()
+	logCtx.Set("request_trace_id", traceID)
+	c.Set(traceIDKey, traceID)
 	r := c.Request.WithContext(ctx)
 
 	c.Request = r
 
-	h.Logger.DebugCtx(ctx).
+	h.Logger().DebugCtx(ctx).
 		Str("method", c.Request.Method).
 		Str("path", c.Request.URL.Path).
 		Str("ip", c.ClientIP()).
 		Msg("Handling request")
 
-	// Apply chaos injection if configured
-	if location.ChaosInjection != nil {
-		if h.chaosEngine.ApplyChaos(c.Writer, location.ChaosInjection) {
-			h.Logger.WarnCtx(ctx).Msg("Request aborted by chaos injection")
+	// Locations with state_responses answer according to the server state
+	location = h.applyState(ctx, location)
+	location = h.applySequence(location)
+
+	// Tests can force the status code with the override header; chaos is then skipped
+	bypassChaos := false
+	if code, ok := h.statusOverride(c); ok {
+		h.Logger().DebugCtx(ctx).
+			Int("status_code", code).
+			Int("configured_status_code", location.StatusCode).
+			Msg("Status code overridden by request header")
+		location.StatusCode = code
+		bypassChaos = true
+	}
+
+	// Return the cached response for identical requests seen within the dedup window
+	if location.DedupWindowMs > 0 {
+		fingerprint, err := requestFingerprint(c, location.DedupHeaders)
+		if err != nil {
+			h.Logger().ErrorCtx(ctx).AnErr("error", err).Msg("Error computing request fingerprint")
+		} else if entry, ok := h.dedup.get(fingerprint); ok {
+			h.Logger().InfoCtx(ctx).Str("fingerprint", fingerprint).Msg("Duplicate request, serving cached response")
+			h.serveDedupHit(c, location, entry)
+			prom.HandlerDedupHitsTotal.WithLabelValues(requestPath, requestMethod).Inc()
+
+			statusCode := strconv.Itoa(c.Writer.Status())
+			prom.HandlerResquestTotal.WithLabelValues(requestPath, requestMethod, statusCode).Inc()
+			prom.HandlerRequestDuration.WithLabelValues(requestPath, requestMethod, statusCode).Observe(time.Since(start).Seconds())
+			return
+		} else {
+			writer := &captureWriter{ResponseWriter: c.Writer}
+			c.Writer = writer
+			defer func() {
+				h.dedup.add(&dedupEntry{
+					fingerprint: fingerprint,
+					statusCode:  writer.Status(),
+					headers:     writer.Header().Clone(),
+					body:        append([]byte(nil), writer.body.Bytes()...),
+					expiresAt:   time.Now().Add(time.Duration(location.DedupWindowMs) * time.Millisecond),
+				})
+			}()
+		}
+	}
+
+	// Apply chaos injection if configured, server-level chaos first and then the location's own
+	for _, chaosConfig := range []*models.ChaosInjection{h.serverChaos, location.ChaosInjection} {
+		if chaosConfig == nil || bypassChaos {
+			continue
+		}
+		aborted, effects := h.chaosEngine.ApplyChaos(c.Writer, c.Request, c.ClientIP(), chaosConfig)
+		h.recordChaos(c.Request.URL.Path, requestMethod, effects)
+		if aborted {
+			// An abort only sets the status; gin sends it on the first body write or WriteHeaderNow
+			c.AbortWithStatus(c.Writer.Status())
+			c.Set(chaosContextKey, chaosConfig)
+			h.Logger().WarnCtx(ctx).Msg("Request aborted by chaos injection")
+			h.fireGlobalAsync(c, location, requestPath, requestMethod)
 			// Insertar en BD con el status code modificado por chaos
 			h.insertTransactionToDB(c, location)
 
@@ -165,6 +404,30 @@ func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
 		}
 	}
 
+	// Simulate services that are slower at certain hours of the day
+	if delay := h.scheduledDelay(location.ResponseDelaySchedule); delay > 0 {
+		h.Logger().DebugCtx(ctx).Int("delay_ms", int(delay.Milliseconds())).Msg("Applying scheduled response delay")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	// Check the body format; with a schema only json adds to it, the schema already defines the format
+	if location.RequestFormat != "" && (location.Schema == "" || location.RequestFormat == RequestFormatJSON) {
+		if err := validateRequestFormat(c, location.RequestFormat); err != nil {
+			h.Logger().ErrorCtx(ctx).AnErr("format_error", err).Str("request_format", location.RequestFormat).Msg("Request body format validation failed")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			h.insertTransactionToDB(c, location)
+
+			statusCode := strconv.Itoa(c.Writer.Status())
+			prom.HandlerResquestTotal.WithLabelValues(requestPath, requestMethod, statusCode).Inc()
+			prom.HandlerRequestDuration.WithLabelValues(requestPath, requestMethod, statusCode).Observe(time.Since(start).Seconds())
+			prom.HandlerErrorsTotal.WithLabelValues(requestPath, requestMethod, "request_format_invalid").Inc()
+			return
+		}
+	}
+
 	if h.xsd[location.Path+":"+location.Method] != nil {
 		if err := validateXSD(c, location, h, ctx); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Schema validation failed: %v", err)})
@@ -173,9 +436,21 @@ func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
 	}
 	// Validate request body against schema if configured
 	if !isValidXSD {
-		if schema, ok := h.schemas[location.Path+":"+location.Method]; ok {
+		schema, ok, err := h.getSchema(location.Path + ":" + location.Method)
+		if err != nil {
+			h.Logger().ErrorCtx(ctx).AnErr("error", err).Msg("Error compiling schema for location")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Schema compilation failed: %v", err)})
+			h.insertTransactionToDB(c, location)
+
+			statusCode := strconv.Itoa(c.Writer.Status())
+			prom.HandlerResquestTotal.WithLabelValues(requestPath, requestMethod, statusCode).Inc()
+			prom.HandlerRequestDuration.WithLabelValues(requestPath, requestMethod, statusCode).Observe(time.Since(start).Seconds())
+			prom.HandlerErrorsTotal.WithLabelValues(requestPath, requestMethod, "schema_compilation_failed").Inc()
+			return
+		}
+		if ok {
 			if err := h.validateRequestBody(c, schema); err != nil {
-				h.Logger.ErrorCtx(ctx).AnErr("validation_error", err).Msg("Schema validation failed")
+				h.Logger().ErrorCtx(ctx).AnErr("validation_error", err).Msg("Schema validation failed")
 				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Schema validation failed: %v", err)})
 				// Insertar en BD con el status code real (400)
 				h.insertTransactionToDB(c, location)
@@ -192,27 +467,49 @@ func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
 		}
 	}
 
-	// Set response headers if configured
-	if location.Headers != nil {
-		for key, value := range *location.Headers {
-			c.Header(key, value)
+	// Serve the rendered response cached for this request within the location TTL
+	if location.CacheTTLSeconds > 0 {
+		key := responseCacheKey(c)
+		if entry, ok := h.cache.entries.get(key); ok {
+			h.cache.hits.Add(1)
+			h.Logger().DebugCtx(ctx).Str("cache_key", key).Msg("Serving cached response")
+			h.serveCacheHit(c, location, entry)
+
+			statusCode := strconv.Itoa(c.Writer.Status())
+			prom.HandlerResquestTotal.WithLabelValues(requestPath, requestMethod, statusCode).Inc()
+			prom.HandlerRequestDuration.WithLabelValues(requestPath, requestMethod, statusCode).Observe(time.Since(start).Seconds())
+			return
 		}
-	}
 
-	// Handle async call if configured
-	if location.Async != nil {
-		for _, v := range location.Async {
+		h.cache.misses.Add(1)
+		ttl := time.Duration(location.CacheTTLSeconds) * time.Second
+		c.Header(cacheHeader, "MISS")
+		c.Header("Cache-Control", "max-age="+strconv.Itoa(location.CacheTTLSeconds))
 
-			h.Logger.InfoCtx(ctx).
-				Str("async_url", v.Url).
-				Str("async_method", v.Method).
-				Msg("Starting async call")
+		writer := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			// Failed renders are not cached
+			if writer.Status() >= http.StatusInternalServerError {
+				return
+			}
+			h.cache.entries.add(&dedupEntry{
+				fingerprint: key,
+				statusCode:  writer.Status(),
+				headers:     writer.Header().Clone(),
+				body:        append([]byte(nil), writer.body.Bytes()...),
+				expiresAt:   time.Now().Add(ttl),
+			})
+		}()
+	}
 
-			go h.handleAsyncCall(&v, c)
-			// Contar las llamadas asíncronas
-			prom.HandlerAsyncCallsTotal.WithLabelValues(requestPath, requestMethod, v.Url).Inc()
+	// Handle async calls if configured. They are fanned out concurrently and never block the response.
+	if len(location.Async) > 0 && !requestTimedOut(c) {
+		asyncCtx := c.Copy()
+		if location.AuthPassThrough {
+			asyncCtx.Set(authPassThroughKey, true)
 		}
-
+		h.dispatchAsyncCalls(ctx, asyncCtx, requestPath, requestMethod, location.Async)
 	}
 
 	// Set response status code
@@ -220,15 +517,37 @@ func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
 
 	// Set response body if configured
 	if location.Response != "" {
-		// Solo establecer Content-Type si no fue definido en los headers del config
-		if location.Headers == nil || (*location.Headers)["Content-Type"] == "" {
+		// Solo establecer Content-Type si no fue definido en los headers del config ni por el servidor
+		if (location.Headers == nil || (*location.Headers)["Content-Type"] == "") && c.Writer.Header().Get("Content-Type") == "" {
 			c.Header("Content-Type", "application/json")
 		}
 
 		// Process template if it contains template variables
 		responseBody, err := h.processResponseTemplate(c, string(location.Response))
+
+		// A runaway template must not be sent, nor stored in the database
+		if err == nil && h.maxBodyBytes > 0 && int64(len(responseBody)) > h.maxBodyBytes {
+			h.Logger().ErrorCtx(ctx).
+				Int("size_bytes", len(responseBody)).
+				Int("limit_bytes", int(h.maxBodyBytes)).
+				Msg("Response body exceeds limit")
+			c.Data(http.StatusInternalServerError, "application/json", []byte(responseTooLargeBody))
+			h.recordTransaction(c, location, func() string {
+				return responseTooLargeBody
+			})
+
+			statusCode := strconv.Itoa(c.Writer.Status())
+			prom.HandlerResquestTotal.WithLabelValues(requestPath, requestMethod, statusCode).Inc()
+			prom.HandlerRequestDuration.WithLabelValues(requestPath, requestMethod, statusCode).Observe(time.Since(start).Seconds())
+			prom.HandlerResponseTooLargeTotal.WithLabelValues(requestPath, requestMethod).Inc()
+			return
+		}
+
+		if err == nil {
+			err = h.setResponseHeaders(c, location)
+		}
 		if err != nil {
-			h.Logger.ErrorCtx(ctx).AnErr("template_error", err).Msg("Error processing response template")
+			h.Logger().ErrorCtx(ctx).AnErr("template_error", err).Msg("Error processing response template")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing response template"})
 			// Insertar en BD con el status code real (500)
 			h.insertTransactionToDB(c, location)
@@ -243,14 +562,27 @@ func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
 			return
 		}
 
-		h.Logger.InfoCtx(ctx).Str("response", string(responseBody)).Msg("Response processed successfully")
+		// Minify after rendering so templates can keep indented JSON
+		if location.MinifyResponse && strings.Contains(c.Writer.Header().Get("Content-Type"), "json") {
+			responseBody = h.minifyJSON(ctx, requestPath, requestMethod, responseBody)
+		}
+
+		h.Logger().InfoCtx(ctx).Str("response", string(responseBody)).Msg("Response processed successfully")
 		c.String(location.StatusCode, responseBody)
+	} else {
+		if err := h.setResponseHeaders(c, location); err != nil {
+			h.Logger().ErrorCtx(ctx).AnErr("template_error", err).Msg("Error processing header template")
+		}
+		// Without a body gin does not send the status on its own
+		c.Writer.WriteHeaderNow()
 	}
 
-	h.Logger.InfoCtx(ctx).
+	h.Logger().InfoCtx(ctx).
 		Int("status_code", location.StatusCode).
 		Msg("Request completed successfully")
 
+	h.fireGlobalAsync(c, location, requestPath, requestMethod)
+
 	// Insertar en BD al finalizar la operación (casos exitosos)
 	h.insertTransactionToDB(c, location)
 
@@ -262,21 +594,71 @@ func (h *Handler) HandleRequest(c *gin.Context, location models.Location) {
 	// --- FIN DE CAPTURAR MÉTRICAS DE RESPUESTA ---
 }
 
+// setResponseHeaders sets the configured headers, evaluating each value as a template
+func (h *Handler) setResponseHeaders(c *gin.Context, location models.Location) error {
+	if location.Headers == nil {
+		return nil
+	}
+
+	for key, value := range *location.Headers {
+		rendered, err := h.processHeaderTemplate(c, value)
+		if err != nil {
+			return fmt.Errorf("error processing header %s: %w", key, err)
+		}
+		c.Header(key, rendered)
+	}
+
+	return nil
+}
+
+// minifyJSON strips insignificant whitespace from a JSON response. Bodies that are not valid
+// JSON are returned unchanged.
+func (h *Handler) minifyJSON(ctx context.Context, requestPath, requestMethod, body string) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(body)); err != nil {
+		h.Logger().WarnCtx(ctx).AnErr("error", err).Msg("Response is not valid JSON, skipping minification")
+		return body
+	}
+
+	prom.HandlerResponseBytesSavedTotal.WithLabelValues(requestPath, requestMethod).Add(float64(len(body) - compacted.Len()))
+	return compacted.String()
+}
+
+// serveDedupHit writes a cached response and records it flagged with the dedup header
+func (h *Handler) serveDedupHit(c *gin.Context, location models.Location, entry *dedupEntry) {
+	for key, values := range entry.headers {
+		// The request ID belongs to the current request, not the cached one
+		if key == "X-Request-Id" {
+			continue
+		}
+		c.Writer.Header()[key] = append([]string(nil), values...)
+	}
+	c.Header(dedupHeader, "true")
+	c.Status(entry.statusCode)
+	if _, err := c.Writer.Write(entry.body); err != nil {
+		h.Logger().Error().AnErr("error", err).Msg("Error writing cached response")
+	}
+
+	h.recordTransaction(c, location, func() string {
+		return string(entry.body)
+	})
+}
+
 func validateXSD(c *gin.Context, location models.Location, h *Handler, ctx context.Context) error {
 	if xmlSchema, err := xsd.ParseSchema([]byte(*h.xsd[location.Path+":"+location.Method])); err != nil {
-		h.Logger.ErrorCtx(ctx).AnErr("error", err).Msg("Error parsing XSD, will try to parse as JSON Schema")
+		h.Logger().ErrorCtx(ctx).AnErr("error", err).Msg("Error parsing XSD, will try to parse as JSON Schema")
 		isValidXSD = false
 	} else {
 		if xmlSchema != nil {
 			err = h.validateXSD(c, *xmlSchema)
 
 			if err != nil {
-				h.Logger.ErrorCtx(ctx).AnErr("error", err).Msg("Error validating XSD")
+				h.Logger().ErrorCtx(ctx).AnErr("error", err).Msg("Error validating XSD")
 				return err
 			}
 			isValidXSD = true
 		} else {
-			h.Logger.InfoCtx(ctx).Msg("Error compiling schema")
+			h.Logger().InfoCtx(ctx).Msg("Error compiling schema")
 			return err
 		}
 	}
@@ -287,49 +669,93 @@ func validateXSD(c *gin.Context, location models.Location, h *Handler, ctx conte
 func (h *Handler) validateRequestBody(c *gin.Context, schema *jsonschema.Schema) error {
 	ctx := c.Request.Context()
 
-	h.Logger.InfoCtx(ctx).Msg("Starting request body validation")
+	h.Logger().InfoCtx(ctx).Msg("Starting request body validation")
 
 	// Read the request body
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		h.Logger.ErrorCtx(ctx).AnErr("error", err).Msg("Error reading request body")
+		h.Logger().ErrorCtx(ctx).AnErr("error", err).Msg("Error reading request body")
 		return fmt.Errorf("error reading request body: %w", err)
 	}
 
 	// Restore the request body for later use
 	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 
-	// Parse the JSON
+	// Parse the JSON; form-encoded bodies are validated as an object of string fields
 	var data interface{}
 
-	if err := json.Unmarshal(body, &data); err != nil {
-		h.Logger.ErrorCtx(ctx).AnErr("error", err).Msg("Error parsing JSON")
+	if isFormEncoded(c) {
+		fields, err := parseFormBody(body)
+		if err != nil {
+			h.Logger().ErrorCtx(ctx).AnErr("error", err).Msg("Error parsing form body")
+			return err
+		}
+		object := make(map[string]interface{}, len(fields))
+		for key, value := range fields {
+			object[key] = value
+		}
+		data = object
+	} else if err := json.Unmarshal(body, &data); err != nil {
+		h.Logger().ErrorCtx(ctx).AnErr("error", err).Msg("Error parsing JSON")
 		return fmt.Errorf("error parsing JSON: %w", err)
 	}
 
 	// Validate against the schema
 	if err := schema.Validate(data); err != nil {
-		h.Logger.ErrorCtx(ctx).AnErr("validation_error", err).Msg("Schema validation failed")
+		h.Logger().ErrorCtx(ctx).AnErr("validation_error", err).Msg("Schema validation failed")
 		return err
 	}
 
-	h.Logger.DebugCtx(ctx).Msg("Request body validation successful")
+	h.Logger().DebugCtx(ctx).Msg("Request body validation successful")
 
 	return nil
 }
 
-// handleAsyncCall handles an asynchronous HTTP call
+// dispatchAsyncCalls launches every async call of a location in its own goroutine.
+// Each call applies its own retry policy independently of the others.
+func (h *Handler) dispatchAsyncCalls(ctx context.Context, c *gin.Context, requestPath, requestMethod string, asyncCalls []models.Async) {
+	var wg sync.WaitGroup
+
+	for i := range asyncCalls {
+		async := asyncCalls[i]
+
+		h.Logger().InfoCtx(ctx).
+			Str("async_url", async.Url).
+			Str("async_method", async.Method).
+			Msg("Starting async call")
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.handleAsyncCall(&async, c)
+		}()
+
+		// Contar las llamadas asíncronas
+		prom.HandlerAsyncCallsTotal.WithLabelValues(requestPath, requestMethod, async.Url).Inc()
+	}
+
+	go func() {
+		wg.Wait()
+		h.Logger().DebugCtx(ctx).
+			Int("async_calls", len(asyncCalls)).
+			Msg("All async calls finished")
+	}()
+}
+
+// handleAsyncCall handles an asynchronous HTTP call.
+// c must be a copy of the original context since it is shared between concurrent calls.
 func (h *Handler) handleAsyncCall(async *models.Async, c *gin.Context) {
 
 	ctx := scribe.WithCtx(c.Request.Context())
 
 	lc := scribe.GetLogContext(ctx)
 	lc.Set("async_request_trace_id", uuid.New().String())
+	traceID := c.GetString(traceIDKey)
+	if traceID != "" {
+		lc.Set("request_trace_id", traceID)
+	}
 
-	r := c.Request.WithContext(ctx)
-	c.Request = r
-
-	h.Logger.DebugCtx(ctx).
+	h.Logger().DebugCtx(ctx).
 		Str("url", async.Url).
 		Str("method", async.Method).
 		Msg("Creating async HTTP request")
@@ -348,7 +774,7 @@ func (h *Handler) handleAsyncCall(async *models.Async, c *gin.Context) {
 
 	req, err := http.NewRequest(async.Method, async.Url, body)
 	if err != nil {
-		h.Logger.ErrorCtx(ctx).
+		h.Logger().ErrorCtx(ctx).
 			Str("url", async.Url).
 			Str("method", async.Method).
 			AnErr("error", err).
@@ -363,6 +789,18 @@ func (h *Handler) handleAsyncCall(async *models.Async, c *gin.Context) {
 		}
 	}
 
+	// propagate_trace sends the trace of the original request, unless the headers already set one
+	if async.PropagateTrace && traceID != "" && req.Header.Get(traceIDHeader) == "" {
+		req.Header.Set(traceIDHeader, traceID)
+	}
+
+	// auth_pass_through forwards the credentials the request was authorized with
+	if c.GetBool(authPassThroughKey) {
+		if authorization := c.GetHeader("Authorization"); authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+	}
+
 	// Set default content type if not specified
 	if async.Body != "" && req.Header.Get("Content-Type") == "" {
 		req.Header.Set("Content-Type", "application/json")
@@ -376,31 +814,44 @@ func (h *Handler) handleAsyncCall(async *models.Async, c *gin.Context) {
 		retries = *async.Retries + 1
 	}
 
-	retryDelay := 100 // Default retry delay in milliseconds
-	if async.RetryDelay != nil {
-		retryDelay = *async.RetryDelay
-	}
-
 	for i := 0; i < retries; i++ {
+		// The body was consumed by the previous attempt
+		if i > 0 && req.GetBody != nil {
+			req.Body, _ = req.GetBody()
+		}
+
 		resp, lastErr = client.Do(req)
-		if lastErr == nil {
+		if lastErr == nil && (i == retries-1 || !shouldRetryStatus(async, resp)) {
 			break
 		}
 
 		if i < retries-1 {
-			h.Logger.WarnCtx(ctx).
-				Str("url", async.Url).
-				Int("attempt", i+1).
-				Int("max_retries", retries-1).
-				AnErr("error", lastErr).
-				Msg("Async request failed, retrying")
-			time.Sleep(time.Duration(retryDelay) * time.Millisecond)
+			delay := asyncRetryDelay(async, i)
+			if lastErr != nil {
+				h.Logger().WarnCtx(ctx).
+					Str("url", async.Url).
+					Int("attempt", i+1).
+					Int("max_retries", retries-1).
+					Int("delay_ms", int(delay.Milliseconds())).
+					AnErr("error", lastErr).
+					Msg("Async request failed, retrying")
+			} else {
+				h.Logger().WarnCtx(ctx).
+					Str("url", async.Url).
+					Int("attempt", i+1).
+					Int("max_retries", retries-1).
+					Int("delay_ms", int(delay.Milliseconds())).
+					Int("status_code", resp.StatusCode).
+					Msg("Async request returned a retryable status, retrying")
+				resp.Body.Close()
+			}
+			time.Sleep(delay)
 		}
 	}
 
 	// Handle response
 	if lastErr != nil {
-		h.Logger.ErrorCtx(ctx).
+		h.Logger().ErrorCtx(ctx).
 			Str("url", async.Url).
 			Str("method", async.Method).
 			Int("retries", retries-1).
@@ -411,41 +862,155 @@ func (h *Handler) handleAsyncCall(async *models.Async, c *gin.Context) {
 	defer resp.Body.Close()
 
 	// Log response status
-	h.Logger.InfoCtx(ctx).
+	h.Logger().InfoCtx(ctx).
 		Str("url", async.Url).
 		Str("method", async.Method).
 		Str("status", resp.Status).
 		Int("status_code", resp.StatusCode).
 		Msg("Async request completed successfully")
+
+	h.insertAsyncTransactionToDB(async, req, resp, traceID)
+}
+
+// insertAsyncTransactionToDB registra la respuesta de una llamada asíncrona como transacción "async",
+// con el trace_id de la petición que la originó
+func (h *Handler) insertAsyncTransactionToDB(async *models.Async, req *http.Request, resp *http.Response, traceID string) {
+	if h.BatchManager == nil || !h.BatchManager.IsRunning() {
+		return
+	}
+
+	requestHeaders, _ := json.Marshal(req.Header)
+	responseHeaders, _ := json.Marshal(resp.Header)
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.Logger().Error().AnErr("error", err).Msg("Error reading async response body for database")
+	}
+
+	operation := &database.Mockdata{
+		UUID:               uuid.New().String(),
+		RecepcionID:        req.Header.Get("X-Recepcion-ID"),
+		SenderID:           req.Header.Get("X-Sender-ID"),
+		RequestHeaders:     string(requestHeaders),
+		RequestMethod:      async.Method,
+		RequestEndpoint:    async.Url,
+		RequestBody:        async.Body,
+		ResponseHeaders:    string(responseHeaders),
+		ResponseBody:       string(responseBody),
+		ResponseStatusCode: resp.StatusCode,
+		TransactionType:    database.TransactionTypeAsync,
+		TraceID:            traceID,
+		Timestamp:          time.Now(),
+	}
+
+	if err := h.BatchManager.AddOperation(operation); err != nil {
+		h.Logger().Error().
+			Str("uuid", operation.UUID).
+			Str("url", async.Url).
+			AnErr("error", err).
+			Msg("Error inserting async transaction to database")
+		return
+	}
+
+	// Las llamadas asíncronas también se encuentran por contenido
+	h.BatchManager.SearchIndex().Add(operation.UUID, operation.RequestBody)
+	h.BatchManager.SearchIndex().Add(operation.UUID, operation.ResponseBody)
 }
 
 // processResponseTemplate processes the response template with request data
 func (h *Handler) processResponseTemplate(c *gin.Context, responseTemplate string) (string, error) {
+	start := time.Now()
+	rendered, err := h.renderTemplate(c, "response", responseTemplate)
+
+	// The route pattern keeps the label cardinality bounded; contexts without a route use the URL path
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	prom.HandlerTemplateDuration.WithLabelValues(path, c.Request.Method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		prom.HandlerTemplateErrorsTotal.WithLabelValues(path, c.Request.Method).Inc()
+	}
+
+	return rendered, err
+}
+
+// processHeaderTemplate processes a response header value with the same request data as the body
+func (h *Handler) processHeaderTemplate(c *gin.Context, headerTemplate string) (string, error) {
+	return h.renderTemplate(c, "header", headerTemplate)
+}
+
+// renderTemplate executes a Go template against the request data (body fields and .Query)
+func (h *Handler) renderTemplate(c *gin.Context, name string, text string) (string, error) {
 	// Check if template contains template variables
-	if !strings.Contains(responseTemplate, "{{") {
-		return responseTemplate, nil
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	requestData, err := h.templateData(c)
+	if err != nil {
+		return "", err
 	}
 
+	tmpl, err := template.New(name).Funcs(h.templateFuncs(c)).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	// Execute template with request data (map[string]interface{} pasado como contexto raíz)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, requestData); err != nil {
+		// Si el error persiste aquí, es probable que la sintaxis de la plantilla (YAML) sea el problema.
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// templateData builds the root context of the templates from the request
+func (h *Handler) templateData(c *gin.Context) (map[string]interface{}, error) {
 	// Parse request body to extract data for template variables
 	// Utilizamos map[string]interface{} para que las propiedades del JSON (como .Amount) sean accesibles
 	var requestData map[string]interface{}
 	if c.Request.Body != nil {
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
-			return "", fmt.Errorf("error reading request body: %w", err)
+			return nil, fmt.Errorf("error reading request body: %w", err)
 		}
 
 		// Restore the request body for potential later use
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 
-		if len(body) > 0 {
+		if len(body) > 0 && isMultipart(c) {
+			// Los campos de texto se exponen como .field y los archivos como .Files.field.Filename
+			requestData, err = parseMultipartBody(c, body)
+			if err != nil {
+				return nil, err
+			}
+		} else if len(body) > 0 && isFormEncoded(c) {
+			// Los campos del formulario se exponen igual que los del JSON, p. ej. .amount
+			fields, err := parseFormBody(body)
+			if err != nil {
+				return nil, err
+			}
+			requestData = make(map[string]interface{}, len(fields))
+			for key, value := range fields {
+				requestData[key] = value
+			}
+		} else if len(body) > 0 {
 			// Intentamos hacer Unmarshal en un mapa para facilitar el acceso por nombre de campo
 			if err := json.Unmarshal(body, &requestData); err != nil {
-				return "", fmt.Errorf("error parsing request JSON: %w", err)
+				return nil, fmt.Errorf("error parsing request JSON: %w", err)
 			}
 		}
 	}
 
+	// El request_transform de la location se aplica antes de exponer los campos
+	requestData, err := applyRequestTransform(c, requestData)
+	if err != nil {
+		return nil, err
+	}
+
 	// Agregar query parameters al contexto del template
 	if requestData == nil {
 		requestData = make(map[string]interface{})
@@ -459,8 +1024,20 @@ func (h *Handler) processResponseTemplate(c *gin.Context, responseTemplate strin
 	}
 	requestData["Query"] = queryParams
 
-	// Create template with custom functions (incluyendo randInt y now que devuelve time.Time)
-	tmpl, err := template.New("response").Funcs(template.FuncMap{
+	// Agregar parametros de la ruta (incluido el wildcard_param) como .Params.name
+	routeParams := make(map[string]string, len(c.Params))
+	for _, param := range c.Params {
+		routeParams[param.Key] = param.Value
+	}
+	requestData["Params"] = routeParams
+
+	return requestData, nil
+}
+
+// templateFuncs returns the custom functions available to templates
+func (h *Handler) templateFuncs(c *gin.Context) template.FuncMap {
+	// Funciones personalizadas (incluyendo randInt y now que devuelve time.Time)
+	return template.FuncMap{
 		"toJson": func(v interface{}) string {
 			jsonBytes, err := json.Marshal(v)
 			if err != nil {
@@ -503,20 +1080,7 @@ func (h *Handler) processResponseTemplate(c *gin.Context, responseTemplate strin
 		"query": func(key string) string {
 			return c.Query(key)
 		},
-	}).Parse(responseTemplate)
-
-	if err != nil {
-		return "", fmt.Errorf("error parsing template: %w", err)
-	}
-
-	// Execute template with request data (map[string]interface{} pasado como contexto raíz)
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, requestData); err != nil {
-		// Si el error persiste aquí, es probable que la sintaxis de la plantilla (YAML) sea el problema.
-		return "", fmt.Errorf("error executing template: %w", err)
 	}
-
-	return buf.String(), nil
 }
 
 func (h *Handler) validateXSD(c *gin.Context, schema xsd.Schema) error {
@@ -546,22 +1110,34 @@ func (h *Handler) validateXSD(c *gin.Context, schema xsd.Schema) error {
 
 // insertTransactionToDB inserta la transacción en la base de datos
 func (h *Handler) insertTransactionToDB(c *gin.Context, location models.Location) {
+	h.recordTransaction(c, location, func() string {
+		return h.getActualResponseBody(c, location)
+	})
+}
+
+// recordTransaction agrega la transacción al batch; responseBody solo se evalúa si hay BatchManager activo
+func (h *Handler) recordTransaction(c *gin.Context, location models.Location, responseBody func() string) {
 	if h.BatchManager == nil {
-		h.Logger.Warn().Msg("BatchManager is nil, skipping database insertion")
+		h.Logger().Warn().Msg("BatchManager is nil, skipping database insertion")
 		return
 	}
 
 	// Verificar si BatchManager está corriendo
 	if !h.BatchManager.IsRunning() {
-		h.Logger.Warn().Msg("BatchManager is not running, skipping database insertion")
+		h.Logger().Warn().Msg("BatchManager is not running, skipping database insertion")
+		return
+	}
+
+	// El cliente ya recibió un 504, la respuesta construida después no se registra
+	if requestTimedOut(c) {
+		h.Logger().Debug().Msg("Write timeout expired, skipping database insertion")
 		return
 	}
 
 	// Extraer datos del request
 	requestHeaders, _ := json.Marshal(c.Request.Header)
-	requestBody := h.getRequestBody(c)
+	requestBody := h.loggedRequestBody(c, location)
 	responseHeaders, _ := json.Marshal(c.Writer.Header())
-	responseBody := h.getActualResponseBody(c, location)
 
 	// Obtener el status code real del response writer
 	actualStatusCode := h.getActualStatusCode(c)
@@ -586,20 +1162,27 @@ func (h *Handler) insertTransactionToDB(c *gin.Context, location models.Location
 		RequestEndpoint:    c.Request.URL.Path,
 		RequestBody:        requestBody,
 		ResponseHeaders:    string(responseHeaders),
-		ResponseBody:       responseBody,
+		ResponseBody:       responseBody(),
 		ResponseStatusCode: actualStatusCode,
+		TransactionType:    database.TransactionTypeSync,
+		TraceID:            c.GetString(traceIDKey),
 		Timestamp:          time.Now(),
+		Tags:               requestTags(c, location),
 	}
 
 	// Insertar en batch
 	if err := h.BatchManager.AddOperation(operation); err != nil {
-		h.Logger.Error().
+		h.Logger().Error().
 			Str("uuid", operation.UUID).
 			Str("recepcion_id", operation.RecepcionID).
 			AnErr("error", err).
 			Msg("Error inserting transaction to database")
 	} else {
-		h.Logger.Info().
+		// Indexar los bodies para la búsqueda por contenido
+		h.BatchManager.SearchIndex().Add(operation.UUID, operation.RequestBody)
+		h.BatchManager.SearchIndex().Add(operation.UUID, operation.ResponseBody)
+
+		h.Logger().Info().
 			Str("uuid", operation.UUID).
 			Str("recepcion_id", operation.RecepcionID).
 			Str("method", operation.RequestMethod).
@@ -617,7 +1200,7 @@ func (h *Handler) getRequestBody(c *gin.Context) string {
 
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		h.Logger.Error().AnErr("error", err).Msg("Error reading request body for database")
+		h.Logger().Error().AnErr("error", err).Msg("Error reading request body for database")
 		return ""
 	}
 
@@ -657,6 +1240,13 @@ func (h *Handler) getActualResponseBody(c *gin.Context, location models.Location
 	// Si el status code es diferente al configurado, significa que hubo chaos injection
 	actualStatusCode := h.getActualStatusCode(c)
 
+	// La inyección que abortó la request queda registrada en el contexto
+	if value, exists := c.Get(chaosContextKey); exists {
+		if chaosConfig, ok := value.(*models.ChaosInjection); ok {
+			return chaosConfig.Error.Response
+		}
+	}
+
 	// Si hay chaos injection activado (status code diferente al configurado)
 	if location.ChaosInjection != nil && actualStatusCode != location.StatusCode {
 		// Para casos de chaos injection, devolver el response del chaos config
@@ -2,13 +2,28 @@ package handler
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"strings"
 	"testing"
+	"time"
 
 	"catalyst/internal/models"
+	prom "catalyst/prometheus"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestHandleRequest(t *testing.T) {
@@ -200,3 +215,1545 @@ func TestSchemaValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestDedupWindow(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler
+	h := NewHandler(nil, nil)
+
+	location := models.Location{
+		Path:          "/api/dedup",
+		Method:        "POST",
+		Response:      `{"random":"{{ randInt 0 1000000 }}"}`,
+		StatusCode:    201,
+		DedupWindowMs: 500,
+	}
+
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/dedup", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	first := send(`{"id":1}`)
+	if first.Header().Get(dedupHeader) != "" {
+		t.Errorf("First request should not be served from the dedup cache")
+	}
+
+	// An identical request within the window gets the exact same response
+	second := send(`{"id":1}`)
+	if second.Header().Get(dedupHeader) != "true" {
+		t.Errorf("Expected %s header on duplicate request", dedupHeader)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("Expected cached response %d %q, got %d %q", first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+
+	// A different body is a different fingerprint
+	third := send(`{"id":2}`)
+	if third.Header().Get(dedupHeader) != "" {
+		t.Errorf("Request with a different body should not be deduplicated")
+	}
+}
+
+func TestDedupFingerprintHeaders(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:          "/api/dedup-headers",
+		Method:        "POST",
+		Response:      `{"random":"{{ randInt 0 1000000 }}"}`,
+		StatusCode:    201,
+		DedupWindowMs: 500,
+		DedupHeaders:  []string{"x-tenant"},
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	send := func(query, requestID, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", location.Path+query, bytes.NewBufferString(`{"id":1}`))
+		req.Header.Set("X-Request-Id", requestID)
+		req.Header.Set("User-Agent", "client-"+requestID)
+		req.Header.Set("X-Tenant", tenant)
+		w := httptest.NewRecorder()
+		// The request ID middleware sets the response header before the handler runs
+		w.Header().Set("X-Request-Id", requestID)
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	send("", "first", "acme")
+
+	// Per-request headers are not part of the fingerprint
+	duplicate := send("", "second", "acme")
+	if duplicate.Header().Get(dedupHeader) != "true" {
+		t.Errorf("Expected requests differing only in X-Request-Id and User-Agent to be deduplicated")
+	}
+	if got := duplicate.Header().Get("X-Request-Id"); got != "second" {
+		t.Errorf("Expected the X-Request-Id of the current request, got %q", got)
+	}
+
+	// The query and the configured headers are
+	if w := send("?page=2", "third", "acme"); w.Header().Get(dedupHeader) != "" {
+		t.Errorf("Request with a different query should not be deduplicated")
+	}
+	if w := send("", "fourth", "globex"); w.Header().Get(dedupHeader) != "" {
+		t.Errorf("Request with a different configured header should not be deduplicated")
+	}
+}
+
+func TestDedupCacheEviction(t *testing.T) {
+	cache := newDedupCache(2)
+	expires := time.Now().Add(time.Minute)
+
+	cache.add(&dedupEntry{fingerprint: "a", expiresAt: expires})
+	cache.add(&dedupEntry{fingerprint: "b", expiresAt: expires})
+
+	// Touch "a" so "b" becomes the least recently used entry
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("Expected entry a to be cached")
+	}
+	cache.add(&dedupEntry{fingerprint: "c", expiresAt: expires})
+
+	if cache.len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.len())
+	}
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("Expected entry b to be evicted")
+	}
+
+	cache.add(&dedupEntry{fingerprint: "d", expiresAt: time.Now().Add(-time.Second)})
+	if _, ok := cache.get("d"); ok {
+		t.Errorf("Expected expired entry to be ignored")
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler
+	h := NewHandler(nil, nil)
+
+	location := models.Location{
+		Path:            "/api/cached",
+		Method:          "GET",
+		Response:        `{"random":"{{ randInt 0 1000000 }}"}`,
+		StatusCode:      200,
+		CacheTTLSeconds: 60,
+	}
+
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	send := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", target, nil)
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	first := send("/api/cached?b=2&a=1")
+	if first.Header().Get(cacheHeader) != "MISS" {
+		t.Errorf("Expected first request to be a cache MISS, got %q", first.Header().Get(cacheHeader))
+	}
+	if first.Header().Get("Cache-Control") != "max-age=60" {
+		t.Errorf("Expected Cache-Control max-age=60, got %q", first.Header().Get("Cache-Control"))
+	}
+
+	// Query parameter order does not change the key
+	second := send("/api/cached?a=1&b=2")
+	if second.Header().Get(cacheHeader) != "HIT" {
+		t.Errorf("Expected second request to be a cache HIT, got %q", second.Header().Get(cacheHeader))
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("Expected cached response %d %q, got %d %q", first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+
+	third := send("/api/cached?a=2")
+	if third.Header().Get(cacheHeader) != "MISS" {
+		t.Errorf("Request with a different query should not be served from the cache")
+	}
+
+	stats := h.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("Expected 1 hit and 2 misses, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+	if len(stats.Entries) != 2 {
+		t.Errorf("Expected 2 cache entries, got %d", len(stats.Entries))
+	}
+}
+
+func TestMaxResponseBodyBytes(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler
+	h := NewHandler(nil, nil)
+
+	// 10240 iterations of 1KB render a 10MB body
+	location := models.Location{
+		Path:       "/api/huge",
+		Method:     "GET",
+		Response:   "{{ range 10240 }}" + strings.Repeat("x", 1024) + "{{ end }}",
+		StatusCode: 200,
+	}
+
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	send := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/huge", nil)
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	// The default limit is 1MB
+	w := send()
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if w.Body.String() != `{"error":"response body exceeds limit"}` {
+		t.Errorf("Unexpected body %q", w.Body.String())
+	}
+
+	// 0 disables the limit
+	h.SetMaxResponseBodyBytes(0)
+	w = send()
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without limit, got %d", w.Code)
+	}
+	if w.Body.Len() != 10240*1024 {
+		t.Errorf("Expected a 10MB body, got %d bytes", w.Body.Len())
+	}
+}
+
+func TestRequestCounters(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler without BatchManager, counters do not depend on it
+	h := NewHandler(nil, nil)
+
+	location := models.Location{
+		Path:       "/api/pay",
+		Method:     "POST",
+		Response:   `{"paid":true}`,
+		StatusCode: 201,
+		ChaosInjection: &models.ChaosInjection{
+			Abort: models.Abort{Code: 503, Probability: "100"},
+		},
+	}
+	healthy := models.Location{
+		Path:       "/api/health",
+		Method:     "GET",
+		Response:   `{"status":"ok"}`,
+		StatusCode: 200,
+	}
+
+	send := func(location models.Location) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(location.Method, location.Path, nil)
+		h.HandleRequest(c, location)
+	}
+
+	send(location)
+	send(location)
+	send(healthy)
+
+	counters := h.RequestCounters()
+	expected := []models.RequestCounter{
+		{Method: "GET", Path: "/api/health", Total: 1, Errors: 0},
+		{Method: "POST", Path: "/api/pay", Total: 2, Errors: 2},
+	}
+	if len(counters) != len(expected) {
+		t.Fatalf("Expected %d counters, got %v", len(expected), counters)
+	}
+	for i := range expected {
+		if counters[i] != expected[i] {
+			t.Errorf("Expected counter %v, got %v", expected[i], counters[i])
+		}
+	}
+
+	h.ResetRequestCounters()
+	if counters := h.RequestCounters(); len(counters) != 0 {
+		t.Errorf("Expected no counters after reset, got %v", counters)
+	}
+}
+
+func TestMinifyResponse(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler
+	h := NewHandler(nil, nil)
+
+	location := models.Location{
+		Path:   "/api/minify",
+		Method: "GET",
+		Response: `{
+    "id": {{ randInt 1 2 }},
+    "items": [
+        { "name": "a b" },
+        { "name": "c" }
+    ]
+}`,
+		StatusCode:     200,
+		MinifyResponse: true,
+	}
+
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/minify", nil)
+	h.HandleRequest(c, location)
+
+	// Whitespace inside strings is kept
+	expected := `{"id":1,"items":[{"name":"a b"},{"name":"c"}]}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected minified body %q, got %q", expected, w.Body.String())
+	}
+}
+
+func TestRequestFormat(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		format      string
+		schema      string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"valid json", "json", "", `{"id":1}`, 200, ""},
+		{"invalid json", "json", "", `{"id":`, 400, "invalid JSON body"},
+		{"valid xml", "xml", "", `<order><id>1</id></order>`, 200, ""},
+		{"invalid xml", "xml", "", `<order><id>1</order>`, 400, "invalid XML body"},
+		{"valid csv", "csv", "", "id,name\n1,foo\n", 200, ""},
+		{"invalid csv", "csv", "", "id,name\n1,\"foo\n", 400, "invalid CSV body"},
+		{"valid form", "form", "", "id=1&name=foo", 200, ""},
+		{"invalid form", "form", "", "id=%zz", 400, "invalid form-encoded body"},
+		{"json with schema", "json", `{"type":"object","required":["id"]}`, `{"name":"foo"}`, 400, "Schema validation failed"},
+		{"invalid json with schema", "json", `{"type":"object","required":["id"]}`, `{"id":`, 400, "invalid JSON body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, nil)
+			location := models.Location{
+				Path:          "/api/format",
+				Method:        "POST",
+				Schema:        tt.schema,
+				RequestFormat: tt.format,
+				Response:      `{"message":"ok"}`,
+				StatusCode:    200,
+			}
+			if err := h.RegisterLocation(location); err != nil {
+				t.Fatalf("Failed to register location: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/format", strings.NewReader(tt.body))
+			h.HandleRequest(c, location)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantMessage != "" && !strings.Contains(w.Body.String(), tt.wantMessage) {
+				t.Errorf("Expected error containing %q, got %s", tt.wantMessage, w.Body.String())
+			}
+		})
+	}
+
+	h := NewHandler(nil, nil)
+	if err := h.RegisterLocation(models.Location{Path: "/api/format", Method: "POST", RequestFormat: "yaml", StatusCode: 200}); err == nil {
+		t.Error("Expected an error for an unsupported request_format")
+	}
+}
+
+func TestTemplateMetrics(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler
+	h := NewHandler(nil, nil)
+
+	send := func(path, response string) *httptest.ResponseRecorder {
+		location := models.Location{Path: path, Method: "GET", Response: response, StatusCode: 200}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", path, nil)
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	renders := func(path string) uint64 {
+		metric := &dto.Metric{}
+		prom.HandlerTemplateDuration.WithLabelValues(path, "GET").(prometheus.Histogram).Write(metric)
+		return metric.GetHistogram().GetSampleCount()
+	}
+
+	errorsBefore := testutil.ToFloat64(prom.HandlerTemplateErrorsTotal.WithLabelValues("/api/broken-template", "GET"))
+	rendered := renders("/api/good-template")
+
+	if w := send("/api/broken-template", `{"value":"{{ .missing "}`); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 for a broken template, got %d", w.Code)
+	}
+	if got := testutil.ToFloat64(prom.HandlerTemplateErrorsTotal.WithLabelValues("/api/broken-template", "GET")); got != errorsBefore+1 {
+		t.Errorf("Expected 1 template error, got %v", got-errorsBefore)
+	}
+
+	if w := send("/api/good-template", `{"value":"{{ randInt 1 2 }}"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if got := renders("/api/good-template"); got <= rendered {
+		t.Error("Expected the template render duration to be observed")
+	}
+	if got := testutil.ToFloat64(prom.HandlerTemplateErrorsTotal.WithLabelValues("/api/good-template", "GET")); got != 0 {
+		t.Errorf("Expected no template errors for a valid template, got %v", got)
+	}
+}
+
+func TestHeaderTemplates(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler
+	h := NewHandler(nil, nil)
+
+	location := models.Location{
+		Path:       "/api/header-template",
+		Method:     "POST",
+		Response:   `{"message":"ok"}`,
+		StatusCode: 200,
+		Headers: &models.Headers{
+			"X-Correlation-ID": "{{.correlationId}}",
+			"X-Tenant":         "{{.Query.tenant}}",
+			"X-Year":           `{{ now.Format "2006" }}`,
+			"X-Static":         "static-value",
+		},
+	}
+
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/header-template?tenant=acme", bytes.NewBufferString(`{"correlationId":"abc-123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.HandleRequest(c, location)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status code 200, got %d", w.Code)
+	}
+
+	expected := map[string]string{
+		"X-Correlation-ID": "abc-123",
+		"X-Tenant":         "acme",
+		"X-Year":           time.Now().Format("2006"),
+		"X-Static":         "static-value",
+	}
+	for key, value := range expected {
+		if got := w.Header().Get(key); got != value {
+			t.Errorf("Expected header %s=%s, got %s", key, value, got)
+		}
+	}
+}
+
+func TestRequestTimeouts(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler
+	h := NewHandler(nil, nil)
+
+	location := models.Location{
+		Path:           "/api/slow",
+		Method:         "POST",
+		Response:       `{"message":"done"}`,
+		StatusCode:     201,
+		ReadTimeoutMs:  100,
+		WriteTimeoutMs: 100,
+	}
+
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	serve := func(location models.Location, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", location.Path, body)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	t.Run("Body within read timeout", func(t *testing.T) {
+		w := serve(location, bytes.NewBufferString(`{"data":"test"}`))
+		if w.Code != 201 {
+			t.Errorf("Expected status code 201, got %d", w.Code)
+		}
+		if w.Body.String() != `{"message":"done"}` {
+			t.Errorf("Unexpected body %q", w.Body.String())
+		}
+	})
+
+	t.Run("Slow body exceeds read timeout", func(t *testing.T) {
+		reader, writer := io.Pipe()
+		defer writer.Close()
+
+		// Send part of the body, then stall
+		go func() {
+			writer.Write([]byte(`{"data":`))
+		}()
+
+		start := time.Now()
+		w := serve(location, reader)
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected status code 504, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "gateway timeout") {
+			t.Errorf("Unexpected body %q", w.Body.String())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Read timeout took too long: %v", elapsed)
+		}
+	})
+
+	t.Run("Slow response exceeds write timeout", func(t *testing.T) {
+		slow := location
+		slow.ChaosInjection = &models.ChaosInjection{
+			Latency: models.Latency{Time: 500, Probability: "100"},
+		}
+
+		w := serve(slow, bytes.NewBufferString(`{"data":"test"}`))
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected status code 504, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "done") {
+			t.Errorf("Late response must not reach the client, got %q", w.Body.String())
+		}
+	})
+}
+
+func TestServerChaosInjection(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a new handler with server-level chaos that always aborts
+	h := NewHandler(nil, nil)
+	h.SetServerChaos(&models.ChaosInjection{
+		Abort: models.Abort{Code: http.StatusServiceUnavailable, Probability: "100"},
+	})
+
+	locations := []models.Location{
+		{
+			Path:       "/api/plain",
+			Method:     "GET",
+			Response:   `{"message":"plain"}`,
+			StatusCode: 200,
+		},
+		{
+			Path:       "/api/location-chaos",
+			Method:     "GET",
+			Response:   `{"message":"location"}`,
+			StatusCode: 200,
+			ChaosInjection: &models.ChaosInjection{
+				Error: models.Error{Code: http.StatusTeapot, Probability: "100", Response: "location error"},
+			},
+		},
+	}
+
+	for _, location := range locations {
+		t.Run(location.Path, func(t *testing.T) {
+			if err := h.RegisterLocation(location); err != nil {
+				t.Fatalf("Failed to register location: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(location.Method, location.Path, nil)
+
+			h.HandleRequest(c, location)
+
+			// The server abort short-circuits the location response and its own chaos
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("Expected status code 503, got %d", w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("Expected empty body, got %q", w.Body.String())
+			}
+		})
+	}
+
+	// Without server-level chaos the location chaos applies
+	h.SetServerChaos(nil)
+	location := locations[1]
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(location.Method, location.Path, nil)
+
+	h.HandleRequest(c, location)
+
+	if w.Code != http.StatusTeapot || w.Body.String() != "location error" {
+		t.Errorf("Expected location chaos 418, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestChaosSeedIsReproducible(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	location := models.Location{
+		Path:       "/api/flaky",
+		Method:     "GET",
+		Response:   `{"message":"ok"}`,
+		StatusCode: 200,
+		ChaosInjection: &models.ChaosInjection{
+			Abort: models.Abort{Code: http.StatusServiceUnavailable, Probability: "50"},
+		},
+	}
+
+	countAborts := func() int {
+		h := NewHandler(nil, nil)
+		h.SetChaosSeed(42)
+		if err := h.RegisterLocation(location); err != nil {
+			t.Fatalf("Failed to register location: %v", err)
+		}
+
+		aborts := 0
+		for i := 0; i < 100; i++ {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(location.Method, location.Path, nil)
+			h.HandleRequest(c, location)
+			if w.Code == http.StatusServiceUnavailable {
+				aborts++
+			}
+		}
+		return aborts
+	}
+
+	// Seed 42 yields exactly 48 aborts out of 100 at 50%
+	for run := 0; run < 2; run++ {
+		if aborts := countAborts(); aborts != 48 {
+			t.Errorf("Run %d: expected 48 aborts, got %d", run, aborts)
+		}
+	}
+}
+
+func TestChaosDropAfterBytes(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:       "/api/drop",
+		Method:     "GET",
+		Response:   `{"message":"ok"}`,
+		StatusCode: 200,
+		ChaosInjection: &models.ChaosInjection{
+			Error: models.Error{
+				Code:           http.StatusOK,
+				Probability:    "100",
+				Response:       `{"message":"this body is cut before the end"}`,
+				DropAfterBytes: 10,
+			},
+		},
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.GET(location.Path, func(c *gin.Context) {
+		h.HandleRequest(c, location)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("GET /api/drop HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		t.Fatalf("Failed to write request: %v", err)
+	}
+
+	// The server closes the connection, so reading ends with EOF or a reset
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	raw, _ := io.ReadAll(conn)
+
+	response := string(raw)
+	headerEnd := strings.Index(response, "\r\n\r\n")
+	if headerEnd < 0 {
+		t.Fatalf("Incomplete response headers: %q", response)
+	}
+
+	if !strings.HasPrefix(response, "HTTP/1.1 200 OK") {
+		t.Errorf("Unexpected status line in %q", response)
+	}
+	if !strings.Contains(response[:headerEnd], "Content-Length: 45") {
+		t.Errorf("Expected the full Content-Length to be announced, got %q", response[:headerEnd])
+	}
+	if body := response[headerEnd+4:]; body != `{"message"` {
+		t.Errorf("Expected the first 10 bytes of the body, got %q", body)
+	}
+}
+
+func TestChaosHistoryRecordsEffects(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:       "/api/chaos",
+		Method:     "GET",
+		Response:   `{"message":"ok"}`,
+		StatusCode: 200,
+		ChaosInjection: &models.ChaosInjection{
+			Latency: models.Latency{Time: 1, Probability: "100"},
+			Abort:   models.Abort{Code: http.StatusBadGateway, Probability: "100"},
+		},
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	serve := func() {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(location.Method, location.Path, nil)
+		h.HandleRequest(c, location)
+	}
+
+	// Disabled by default
+	serve()
+	if events := h.ChaosHistory("", "", 0); len(events) != 0 {
+		t.Fatalf("Expected no history when disabled, got %v", events)
+	}
+
+	h.EnableChaosHistory()
+	serve()
+
+	events := h.ChaosHistory("/api/chaos", "GET", 0)
+	if len(events) != 2 {
+		t.Fatalf("Expected latency and abort events, got %v", events)
+	}
+	if events[0].Type != "abort" || events[0].Applied != "502" {
+		t.Errorf("Expected abort 502 as newest event, got %+v", events[0])
+	}
+	if events[1].Type != "latency" || events[1].Applied != "1ms" {
+		t.Errorf("Expected latency 1ms, got %+v", events[1])
+	}
+}
+
+func TestChaosTriggerIfBodyLargerThan(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:       "/api/upload",
+		Method:     "POST",
+		Response:   `{"message":"stored"}`,
+		StatusCode: 201,
+		ChaosInjection: &models.ChaosInjection{
+			Abort:                        models.Abort{Code: http.StatusRequestEntityTooLarge, Probability: "100"},
+			TriggerIfBodyLargerThanBytes: 1024,
+		},
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	large := `{"data":"` + strings.Repeat("x", 2048) + `"}`
+	tests := []struct {
+		name           string
+		body           io.Reader
+		expectedStatus int
+	}{
+		{"Small body", bytes.NewBufferString(`{"data":"small"}`), 201},
+		{"Large body", bytes.NewBufferString(large), http.StatusRequestEntityTooLarge},
+		// Without Content-Length the body is read to measure it
+		{"Large body without Content-Length", io.MultiReader(strings.NewReader(large)), http.StatusRequestEntityTooLarge},
+		{"Small body without Content-Length", io.MultiReader(strings.NewReader(`{"data":"small"}`)), 201},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(location.Method, location.Path, tt.body)
+			if _, ok := tt.body.(*bytes.Buffer); !ok {
+				c.Request.ContentLength = -1
+			}
+
+			h.HandleRequest(c, location)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestStatusCodeOverrideHeader(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Create a handler that always aborts through chaos
+	h := NewHandler(nil, nil)
+	h.SetStatusOverride("X-Force-Status", []int{200, 201, 400, 503})
+
+	location := models.Location{
+		Path:       "/api/override",
+		Method:     "GET",
+		Response:   `{"ok":true}`,
+		StatusCode: 200,
+		ChaosInjection: &models.ChaosInjection{
+			Abort: models.Abort{Code: 502, Probability: "100"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+	}{
+		{"allowed code bypasses chaos", "503", http.StatusServiceUnavailable},
+		{"code not allowed is ignored", "418", http.StatusBadGateway},
+		{"non numeric value is ignored", "abc", http.StatusBadGateway},
+		{"value that is not 3 digits is ignored", "2000", http.StatusBadGateway},
+		{"missing header keeps chaos", "", http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/override", nil)
+			if tt.value != "" {
+				c.Request.Header.Set("X-Force-Status", tt.value)
+			}
+
+			h.HandleRequest(c, location)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+			if c.Request.Header.Get("X-Force-Status") != "" {
+				t.Error("Expected the override header to be stripped from the request")
+			}
+		})
+	}
+
+	// Locations without a body send the overridden status too
+	empty := models.Location{Path: "/api/override-empty", Method: "GET", StatusCode: 200}
+	if err := h.RegisterLocation(empty); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", empty.Path, nil)
+	c.Request.Header.Set("X-Force-Status", "201")
+
+	h.HandleRequest(c, empty)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for an empty body, got %d", w.Code)
+	}
+}
+
+func TestResponseDelaySchedule(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	if err := h.SetTimezone("America/New_York"); err != nil {
+		t.Fatalf("Failed to set timezone: %v", err)
+	}
+
+	schedules := []models.DelaySchedule{
+		{StartHour: 9, EndHour: 17, DelayMs: 50},
+		{StartHour: 23, EndHour: 1, DelayMs: 200},
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Duration
+	}{
+		// 15:00 UTC is 10:00 in New York during winter
+		{"business hours in the server timezone", time.Date(2025, 1, 15, 15, 0, 0, 0, time.UTC), 50 * time.Millisecond},
+		{"window wrapping midnight", time.Date(2025, 1, 15, 5, 30, 0, 0, time.UTC), 200 * time.Millisecond},
+		{"outside every window", time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.now = func() time.Time { return tt.now }
+			if got := h.scheduledDelay(schedules); got != tt.expected {
+				t.Errorf("Expected delay %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// The matching delay is applied before responding
+	h.now = func() time.Time { return time.Date(2025, 1, 15, 15, 0, 0, 0, time.UTC) }
+	location := models.Location{
+		Path:                  "/api/scheduled",
+		Method:                "GET",
+		Response:              `{"ok":true}`,
+		StatusCode:            200,
+		ResponseDelaySchedule: schedules,
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/scheduled", nil)
+
+	start := time.Now()
+	h.HandleRequest(c, location)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the response to be delayed at least 50ms, took %v", elapsed)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestFormEncodedRequests(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+
+	location := models.Location{
+		Path:   "/api/form",
+		Method: "POST",
+		Schema: `{
+			"type": "object",
+			"properties": {
+				"name": { "type": "string", "minLength": 1 },
+				"amount": { "type": "string", "pattern": "^[0-9]+$" }
+			},
+			"required": ["name", "amount"]
+		}`,
+		Response:   `{"greeting":"Hello {{ .name }}","amount":{{ .amount }},"source":"{{ .Query.source }}"}`,
+		StatusCode: 200,
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "form fields in the template",
+			body:           "name=Ana+Maria&amount=150",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"greeting":"Hello Ana Maria","amount":150,"source":"web"}`,
+		},
+		{
+			name:           "missing required form field",
+			body:           "name=Ana",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "form field not matching the schema",
+			body:           "name=Ana&amount=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/form?source=web", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+			h.HandleRequest(c, location)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStateMachine(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	if _, ok := h.State(); ok {
+		t.Fatal("Expected no state machine before SetStateMachine")
+	}
+	h.SetStateMachine(models.StateMachine{States: []string{"pending", "approved", "failed"}, InitialState: "pending"})
+
+	location := models.Location{
+		Path:       "/api/payment",
+		Method:     "GET",
+		Response:   `{"status":"unknown"}`,
+		StatusCode: 200,
+		StateResponses: map[string]models.StateResponse{
+			"pending":  {Response: `{"status":"pending"}`, StatusCode: 202, NextState: "approved"},
+			"approved": {Response: `{"status":"approved"}`, NextState: "failed"},
+		},
+	}
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/payment", nil)
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	steps := []struct {
+		code int
+		body string
+	}{
+		{http.StatusAccepted, `{"status":"pending"}`},
+		{http.StatusOK, `{"status":"approved"}`},
+		// "failed" has no state response, the location's own response is served
+		{http.StatusOK, `{"status":"unknown"}`},
+		{http.StatusOK, `{"status":"unknown"}`},
+	}
+	for i, step := range steps {
+		w := serve()
+		if w.Code != step.code || w.Body.String() != step.body {
+			t.Errorf("Call %d: expected %d %s, got %d %s", i+1, step.code, step.body, w.Code, w.Body.String())
+		}
+	}
+	if state, _ := h.State(); state != "failed" {
+		t.Errorf("Expected state failed, got %s", state)
+	}
+
+	if err := h.SetState("unknown"); err == nil {
+		t.Error("Expected an error setting an unknown state")
+	}
+	if err := h.SetState(""); err != nil {
+		t.Fatalf("Failed to reset state: %v", err)
+	}
+	if w := serve(); w.Code != http.StatusAccepted {
+		t.Errorf("Expected the initial state response after reset, got %d", w.Code)
+	}
+}
+
+func TestPrometheusLabels(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:       "/api/labels",
+		Method:     "POST",
+		Response:   `{"ok":true}`,
+		StatusCode: 200,
+		PrometheusLabels: map[string]string{
+			"tenant": "header:X-Tenant-ID",
+			"plan":   "body:$.subscription.plans[0]",
+		},
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/labels", strings.NewReader(`{"subscription":{"plans":["gold"]}}`))
+	c.Request.Header.Set("X-Tenant-ID", "acme")
+	h.HandleRequest(c, location)
+
+	// The counter is registered on the default registry, so it shows up in the collected metrics
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() != ":handler_request_by_plan_tenant_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, pair := range metric.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			if labels["tenant"] == "acme" && labels["plan"] == "gold" && labels["path"] == "/api/labels" && labels["status_code"] == "200" {
+				found = metric.GetCounter().GetValue() == 1
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected a request counted with tenant=acme and plan=gold")
+	}
+
+	// Registering the location again, e.g. after a restart, reuses the counter
+	if err := NewHandler(nil, nil).RegisterLocation(location); err != nil {
+		t.Errorf("Expected the counter to be reused, got %v", err)
+	}
+
+	invalid := location
+	invalid.PrometheusLabels = map[string]string{"tenant-id": "header:X-Tenant-ID"}
+	if err := h.RegisterLocation(invalid); err == nil {
+		t.Error("Expected an error for a label name that is not valid in Prometheus")
+	}
+	invalid.PrometheusLabels = map[string]string{"tenant": "query:tenant"}
+	if err := h.RegisterLocation(invalid); err == nil {
+		t.Error("Expected an error for an unknown label source")
+	}
+}
+
+func TestJSONPathValue(t *testing.T) {
+	var document interface{} = map[string]interface{}{
+		"tenantId": "acme",
+		"count":    float64(3),
+		"items":    []interface{}{map[string]interface{}{"sku": "A1"}},
+		"matrix":   []interface{}{[]interface{}{"x", "y"}},
+	}
+
+	tests := map[string]string{
+		"$.tenantId":      "acme",
+		"$.count":         "3",
+		"$.items[0].sku":  "A1",
+		"$.matrix[0][1]":  "y",
+		"$.items[5].sku":  "",
+		"$.missing.field": "",
+	}
+	for path, expected := range tests {
+		if got := jsonPathValue(document, path); got != expected {
+			t.Errorf("jsonPathValue(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
+
+func TestAsyncRetryStatusCodes(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	var attempts []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		attempts = append(attempts, string(body))
+		if len(attempts) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	retries, retryDelay := 3, 10
+	async := models.Async{
+		Url:              upstream.URL,
+		Method:           "POST",
+		Body:             `{"event":"paid"}`,
+		Retries:          &retries,
+		RetryDelay:       &retryDelay,
+		RetryBackoff:     "exponential",
+		RetryStatusCodes: []int{500, 502, 503},
+	}
+
+	h := NewHandler(nil, nil)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/api/payment", nil)
+
+	start := time.Now()
+	h.handleAsyncCall(&async, c)
+	elapsed := time.Since(start)
+
+	if len(attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(attempts))
+	}
+	for i, body := range attempts {
+		if body != async.Body {
+			t.Errorf("Attempt %d: expected body %s, got %q", i+1, async.Body, body)
+		}
+	}
+	// Exponential backoff waits 10ms and then 20ms
+	if elapsed < 30*time.Millisecond {
+		t.Errorf("Expected at least 30ms of backoff, took %v", elapsed)
+	}
+}
+
+func TestAsyncPropagateTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Trace-ID"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	h := NewHandler(nil, nil)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/api/payment", nil)
+	c.Set(traceIDKey, "trace-123")
+
+	h.handleAsyncCall(&models.Async{Url: upstream.URL, Method: "POST", PropagateTrace: true}, c)
+	h.handleAsyncCall(&models.Async{Url: upstream.URL, Method: "POST"}, c)
+	// A trace configured in the headers wins over the propagated one
+	headers := models.Headers{"X-Trace-ID": "configured"}
+	h.handleAsyncCall(&models.Async{Url: upstream.URL, Method: "POST", Headers: &headers, PropagateTrace: true}, c)
+
+	expected := []string{"trace-123", "", "configured"}
+	if strings.Join(received, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected X-Trace-ID headers %v, got %v", expected, received)
+	}
+}
+
+func TestAsyncRetryDelay(t *testing.T) {
+	delay, maxDelay := 100, 500
+	tests := []struct {
+		backoff  string
+		maxDelay *int
+		attempt  int
+		expected time.Duration
+	}{
+		{"", nil, 3, 100 * time.Millisecond},
+		{"constant", nil, 0, 100 * time.Millisecond},
+		{"linear", nil, 0, 100 * time.Millisecond},
+		{"linear", nil, 2, 300 * time.Millisecond},
+		{"exponential", nil, 0, 100 * time.Millisecond},
+		{"exponential", nil, 3, 800 * time.Millisecond},
+		{"exponential", &maxDelay, 3, 500 * time.Millisecond},
+		{"linear", &maxDelay, 9, 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		async := &models.Async{RetryDelay: &delay, RetryBackoff: tt.backoff, RetryMaxDelay: tt.maxDelay}
+		if got := asyncRetryDelay(async, tt.attempt); got != tt.expected {
+			t.Errorf("%q attempt %d: expected %v, got %v", tt.backoff, tt.attempt, tt.expected, got)
+		}
+	}
+}
+
+func TestCompressedRequestBody(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:   "/api/orders",
+		Method: "POST",
+		Schema: `{
+			"type": "object",
+			"properties": { "id": { "type": "integer" } },
+			"required": ["id"]
+		}`,
+		Response:   `{"order":{{ .id }}}`,
+		StatusCode: 200,
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	compress := func(encoding, body string) []byte {
+		var buf bytes.Buffer
+		var writer io.WriteCloser
+		switch encoding {
+		case "gzip":
+			writer = gzip.NewWriter(&buf)
+		case "deflate":
+			writer = zlib.NewWriter(&buf)
+		case "raw-deflate":
+			writer, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		}
+		writer.Write([]byte(body))
+		writer.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name           string
+		encoding       string
+		body           []byte
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"gzip", "gzip", compress("gzip", `{"id":7}`), 200, `{"order":7}`},
+		{"zlib deflate", "deflate", compress("deflate", `{"id":8}`), 200, `{"order":8}`},
+		{"raw deflate", "deflate", compress("raw-deflate", `{"id":9}`), 200, `{"order":9}`},
+		{"gzip fails schema", "gzip", compress("gzip", `{"name":"x"}`), 400, ""},
+		{"plain body", "", []byte(`{"id":10}`), 200, `{"order":10}`},
+		{"corrupt gzip", "gzip", []byte(`{"id":11}`), 400, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/orders", bytes.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if tt.encoding != "" {
+				c.Request.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			h.HandleRequest(c, location)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLocationAuth(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+	t.Setenv("MOCK_TEST_TOKEN", "s3cret")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	locations := map[string]models.Location{
+		"bcrypt":    {Path: "/basic", Method: "GET", Response: `{"ok":true}`, StatusCode: 200, Auth: &models.LocationAuth{Type: "basic", Username: "alice", Password: string(hash)}},
+		"plaintext": {Path: "/plain", Method: "GET", Response: `{"ok":true}`, StatusCode: 200, Auth: &models.LocationAuth{Type: "basic", Username: "alice", Password: "hunter2"}},
+		"bearer":    {Path: "/bearer", Method: "GET", Response: `{"ok":true}`, StatusCode: 200, Auth: &models.LocationAuth{Type: "bearer", Token: "env:MOCK_TEST_TOKEN"}},
+	}
+	h := NewHandler(nil, nil)
+	for name, location := range locations {
+		if err := h.RegisterLocation(location); err != nil {
+			t.Fatalf("Failed to register %s location: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name              string
+		location          string
+		authorization     string
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{"basic with bcrypt password", "bcrypt", "Basic YWxpY2U6aHVudGVyMg==", 200, ""},
+		{"basic wrong password", "bcrypt", "Basic YWxpY2U6d3Jvbmc=", 401, `Basic realm="mock"`},
+		{"basic wrong user", "bcrypt", "Basic Ym9iOmh1bnRlcjI=", 401, `Basic realm="mock"`},
+		{"basic missing header", "bcrypt", "", 401, `Basic realm="mock"`},
+		{"basic with plaintext password", "plaintext", "Basic YWxpY2U6aHVudGVyMg==", 200, ""},
+		{"bearer from env", "bearer", "Bearer s3cret", 200, ""},
+		{"bearer wrong token", "bearer", "Bearer nope", 401, `Bearer realm="mock"`},
+		{"bearer with basic credentials", "bearer", "Basic YWxpY2U6aHVudGVyMg==", 401, `Bearer realm="mock"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := locations[tt.location]
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", location.Path, nil)
+			if tt.authorization != "" {
+				c.Request.Header.Set("Authorization", tt.authorization)
+			}
+
+			h.HandleRequest(c, location)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.expectedChallenge {
+				t.Errorf("Expected WWW-Authenticate %q, got %q", tt.expectedChallenge, got)
+			}
+		})
+	}
+
+	// A token read from an unset variable never matches
+	if err := h.RegisterLocation(models.Location{Path: "/unset", Method: "GET", StatusCode: 200, Auth: &models.LocationAuth{Type: "bearer", Token: "env:MOCK_TEST_UNSET_TOKEN"}}); err == nil {
+		t.Error("Expected error registering bearer auth with an unset token variable")
+	}
+}
+
+func TestAuthPassThrough(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	forwarded := make(chan string, 2)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded <- r.Header.Get("Authorization")
+	}))
+	defer upstream.Close()
+
+	h := NewHandler(nil, nil)
+	async := models.Async{Url: upstream.URL, Method: "POST"}
+
+	for _, passThrough := range []bool{true, false} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/api/payment", nil)
+		c.Request.Header.Set("Authorization", "Bearer s3cret")
+		if passThrough {
+			c.Set(authPassThroughKey, true)
+		}
+
+		h.handleAsyncCall(&async, c)
+
+		want := ""
+		if passThrough {
+			want = "Bearer s3cret"
+		}
+		if got := <-forwarded; got != want {
+			t.Errorf("auth_pass_through %v: expected Authorization %q, got %q", passThrough, want, got)
+		}
+	}
+}
+
+func TestResponseSequence(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	sequence := []models.SequenceResponse{
+		{StatusCode: 503, Response: `{"error":"unavailable"}`},
+		{StatusCode: 200, Response: `{"status":"ok"}`},
+		{Response: `{"status":"done"}`},
+	}
+
+	serve := func(location models.Location) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(location.Method, location.Path, nil)
+		h.HandleRequest(c, location)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		location models.Location
+		expected []string
+	}{
+		{
+			name: "last step repeats",
+			location: models.Location{
+				Path: "/api/once", Method: "GET", StatusCode: 202, ResponseSequence: sequence,
+			},
+			expected: []string{
+				`503 {"error":"unavailable"}`,
+				`200 {"status":"ok"}`,
+				`202 {"status":"done"}`,
+				`202 {"status":"done"}`,
+				`202 {"status":"done"}`,
+			},
+		},
+		{
+			name: "loop starts over",
+			location: models.Location{
+				Path: "/api/loop", Method: "GET", StatusCode: 202, ResponseSequence: sequence, Loop: true,
+			},
+			expected: []string{
+				`503 {"error":"unavailable"}`,
+				`200 {"status":"ok"}`,
+				`202 {"status":"done"}`,
+				`503 {"error":"unavailable"}`,
+				`200 {"status":"ok"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, expected := range tt.expected {
+				w := serve(tt.location)
+				if got := fmt.Sprintf("%d %s", w.Code, w.Body.String()); got != expected {
+					t.Errorf("Call %d: expected %s, got %s", i+1, expected, got)
+				}
+			}
+		})
+	}
+}
+
+// multipartRequest builds a multipart/form-data body with a text field and an uploaded file
+func multipartRequest(t *testing.T) (*bytes.Buffer, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.WriteField("name", "Ana"); err != nil {
+		t.Fatalf("Failed to write field: %v", err)
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="doc"; filename="invoice.pdf"`)
+	header.Set("Content-Type", "application/pdf")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatalf("Failed to create file part: %v", err)
+	}
+	part.Write([]byte("%PDF-secret-contents"))
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func TestMultipartRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	location := models.Location{
+		Path:       "/api/upload",
+		Method:     "POST",
+		Response:   `{"name":"{{ .name }}","file":"{{ .Files.doc.Filename }}","size":{{ .Files.doc.Size }},"type":"{{ .Files.doc.ContentType }}","source":"{{ .Query.source }}"}`,
+		StatusCode: 200,
+	}
+	h := NewHandler(nil, nil)
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	body, contentType := multipartRequest(t)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/upload?source=web", body)
+	c.Request.Header.Set("Content-Type", contentType)
+
+	h.HandleRequest(c, location)
+
+	expected := `{"name":"Ana","file":"invoice.pdf","size":20,"type":"application/pdf","source":"web"}`
+	if w.Code != http.StatusOK || w.Body.String() != expected {
+		t.Errorf("Expected 200 with %s, got %d: %s", expected, w.Code, w.Body.String())
+	}
+}
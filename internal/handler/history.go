@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"sync/atomic"
+	"time"
+
+	"catalyst/internal/chaos"
+	"catalyst/internal/models"
+)
+
+// chaosHistorySize is the number of chaos events kept per server
+const chaosHistorySize = 10000
+
+// chaosHistory is a ring buffer of chaos events. Writers claim a slot through the atomic head
+// and readers load the slots without taking a lock.
+type chaosHistory struct {
+	events []atomic.Pointer[models.ChaosEvent]
+	head   atomic.Uint64
+}
+
+func newChaosHistory(size int) *chaosHistory {
+	return &chaosHistory{events: make([]atomic.Pointer[models.ChaosEvent], size)}
+}
+
+func (r *chaosHistory) add(event models.ChaosEvent) {
+	slot := (r.head.Add(1) - 1) % uint64(len(r.events))
+	r.events[slot].Store(&event)
+}
+
+// list returns the events matching path and method, newest first. Empty filters match everything
+// and limit <= 0 returns all of them.
+func (r *chaosHistory) list(path, method string, limit int) []models.ChaosEvent {
+	head := r.head.Load()
+	size := uint64(len(r.events))
+
+	count := head
+	if count > size {
+		count = size
+	}
+
+	events := make([]models.ChaosEvent, 0)
+	for i := uint64(1); i <= count; i++ {
+		event := r.events[(head-i)%size].Load()
+		if event == nil {
+			continue
+		}
+		if path != "" && event.Path != path {
+			continue
+		}
+		if method != "" && event.Method != method {
+			continue
+		}
+
+		events = append(events, *event)
+		if limit > 0 && len(events) == limit {
+			break
+		}
+	}
+	return events
+}
+
+// EnableChaosHistory starts recording every chaos effect applied by the handler
+func (h *Handler) EnableChaosHistory() {
+	h.chaosHistory = newChaosHistory(chaosHistorySize)
+}
+
+// ChaosHistory returns the recorded chaos events, newest first. It is empty when the history is disabled.
+func (h *Handler) ChaosHistory(path, method string, limit int) []models.ChaosEvent {
+	if h.chaosHistory == nil {
+		return []models.ChaosEvent{}
+	}
+	return h.chaosHistory.list(path, method, limit)
+}
+
+func (h *Handler) recordChaos(path, method string, effects []chaos.Effect) {
+	if h.chaosHistory == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, effect := range effects {
+		h.chaosHistory.add(models.ChaosEvent{
+			Timestamp: now,
+			Path:      path,
+			Method:    method,
+			Type:      effect.Type,
+			Applied:   effect.Applied,
+		})
+	}
+}
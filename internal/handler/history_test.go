@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+
+	"catalyst/internal/models"
+)
+
+func TestChaosHistoryRingBuffer(t *testing.T) {
+	history := newChaosHistory(3)
+
+	for i := 0; i < 5; i++ {
+		method := "GET"
+		if i%2 == 1 {
+			method = "POST"
+		}
+		history.add(models.ChaosEvent{Path: fmt.Sprintf("/api/%d", i), Method: method, Type: "abort"})
+	}
+
+	// Only the last three events survive, newest first
+	events := history.list("", "", 0)
+	expected := []string{"/api/4", "/api/3", "/api/2"}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), events)
+	}
+	for i, path := range expected {
+		if events[i].Path != path {
+			t.Errorf("Event %d: expected %s, got %s", i, path, events[i].Path)
+		}
+	}
+
+	if events := history.list("", "POST", 0); len(events) != 1 || events[0].Path != "/api/3" {
+		t.Errorf("Expected only /api/3 for POST, got %v", events)
+	}
+	if events := history.list("/api/2", "", 0); len(events) != 1 {
+		t.Errorf("Expected one event for /api/2, got %v", events)
+	}
+	if events := history.list("", "", 2); len(events) != 2 {
+		t.Errorf("Expected limit to apply, got %v", events)
+	}
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Sources of a prometheus_labels value, e.g. "header:X-Tenant-ID" or "body:$.tenantId"
+const (
+	labelSourceHeader = "header:"
+	labelSourceBody   = "body:"
+)
+
+// locationLabels are the custom Prometheus labels of a location and the counter they are added to
+type locationLabels struct {
+	names   []string
+	sources []string
+	counter *prometheus.CounterVec
+}
+
+// validateLabelSource checks that a prometheus_labels value reads a header or a JSON body path
+func validateLabelSource(source string) error {
+	switch {
+	case strings.HasPrefix(source, labelSourceHeader) && len(source) > len(labelSourceHeader):
+		return nil
+	case strings.HasPrefix(source, labelSourceBody+"$"):
+		return nil
+	}
+	return fmt.Errorf("invalid prometheus label source %q, expected header:<name> or body:$.<path>", source)
+}
+
+// registerLabels validates the prometheus_labels of a location and gets the counter for them
+func (h *Handler) registerLabels(location models.Location) error {
+	if len(location.PrometheusLabels) == 0 {
+		return nil
+	}
+
+	labels := &locationLabels{}
+	for name := range location.PrometheusLabels {
+		labels.names = append(labels.names, name)
+	}
+	sort.Strings(labels.names)
+	for _, name := range labels.names {
+		source := location.PrometheusLabels[name]
+		if err := validateLabelSource(source); err != nil {
+			return err
+		}
+		labels.sources = append(labels.sources, source)
+	}
+
+	counter, err := prom.CustomLabelCounter(labels.names)
+	if err != nil {
+		return err
+	}
+	labels.counter = counter
+	h.labels[location.Path+":"+location.Method] = labels
+	return nil
+}
+
+// customLabelValues reads the values of the location prometheus_labels from the request. Missing
+// headers and body fields are empty values.
+func (h *Handler) customLabelValues(c *gin.Context, location models.Location) (*locationLabels, prometheus.Labels) {
+	labels, ok := h.labels[location.Path+":"+location.Method]
+	if !ok {
+		return nil, nil
+	}
+
+	values := prometheus.Labels{}
+	var body interface{}
+	bodyParsed := false
+	for i, name := range labels.names {
+		source := labels.sources[i]
+		if header, ok := strings.CutPrefix(source, labelSourceHeader); ok {
+			values[name] = c.GetHeader(header)
+			continue
+		}
+
+		if !bodyParsed {
+			bodyParsed = true
+			if err := json.Unmarshal([]byte(h.getRequestBody(c)), &body); err != nil {
+				body = nil
+			}
+		}
+		values[name] = jsonPathValue(body, strings.TrimPrefix(source, labelSourceBody))
+	}
+	return labels, values
+}
+
+// record counts the request on the counter of its prometheus_labels
+func (labels *locationLabels) record(values prometheus.Labels, path, method string, statusCode int) {
+	values["path"] = path
+	values["method"] = method
+	values["status_code"] = strconv.Itoa(statusCode)
+	labels.counter.With(values).Inc()
+}
+
+// jsonPathValue returns the value at a path like $.tenant.id or $.items[0].sku as a string
+func jsonPathValue(document interface{}, path string) string {
+	current := document
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "$"), ".") {
+		if segment == "" {
+			continue
+		}
+
+		key, rest, _ := strings.Cut(segment, "[")
+		if key != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return ""
+			}
+			current = object[key]
+		}
+
+		// Array indexes, possibly nested as in matrix[0][1]
+		for rest != "" {
+			index, remaining, found := strings.Cut(rest, "]")
+			if !found {
+				return ""
+			}
+			position, err := strconv.Atoi(index)
+			array, ok := current.([]interface{})
+			if err != nil || !ok || position < 0 || position >= len(array) {
+				return ""
+			}
+			current = array[position]
+			rest = strings.TrimPrefix(remaining, "[")
+		}
+	}
+
+	switch value := current.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+}
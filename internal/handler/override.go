@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetStatusOverride lets requests force the response status code through header. When
+// allowedCodes is not empty, only those codes are honored.
+func (h *Handler) SetStatusOverride(header string, allowedCodes []int) {
+	h.overrideHeader = header
+	h.allowedOverrideCodes = allowedCodes
+}
+
+// statusOverride returns the status code forced by the override header, if any. The header is
+// removed from the request so it is not echoed in templates nor recorded.
+func (h *Handler) statusOverride(c *gin.Context) (int, bool) {
+	if h.overrideHeader == "" {
+		return 0, false
+	}
+
+	value := c.GetHeader(h.overrideHeader)
+	if value == "" {
+		return 0, false
+	}
+	c.Request.Header.Del(h.overrideHeader)
+
+	if len(value) != 3 {
+		return 0, false
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil || code < 100 || code > 599 {
+		return 0, false
+	}
+
+	if len(h.allowedOverrideCodes) == 0 {
+		return code, true
+	}
+	for _, allowed := range h.allowedOverrideCodes {
+		if code == allowed {
+			return code, true
+		}
+	}
+	return 0, false
+}
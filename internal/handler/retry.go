@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"catalyst/internal/models"
+)
+
+// Async retry_backoff strategies
+const (
+	retryBackoffConstant    = "constant"
+	retryBackoffLinear      = "linear"
+	retryBackoffExponential = "exponential"
+)
+
+// defaultRetryDelay is the delay between async retries when retry_delay is not set, in milliseconds
+const defaultRetryDelay = 100
+
+// asyncRetryDelay returns how long to wait after the failed attempt (0-based) of an async call,
+// capped by retry_max_delay_ms
+func asyncRetryDelay(async *models.Async, attempt int) time.Duration {
+	base := defaultRetryDelay
+	if async.RetryDelay != nil {
+		base = *async.RetryDelay
+	}
+
+	delay := float64(base)
+	switch async.RetryBackoff {
+	case retryBackoffLinear:
+		delay = float64(base) * float64(attempt+1)
+	case retryBackoffExponential:
+		delay = float64(base) * math.Pow(2, float64(attempt))
+	}
+
+	if async.RetryMaxDelay != nil && delay > float64(*async.RetryMaxDelay) {
+		delay = float64(*async.RetryMaxDelay)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// shouldRetryStatus reports whether the response status is one of the async retry_status_codes
+func shouldRetryStatus(async *models.Async, resp *http.Response) bool {
+	for _, code := range async.RetryStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
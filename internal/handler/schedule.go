@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"catalyst/internal/models"
+)
+
+// SetTimezone sets the timezone used to evaluate response delay schedules
+func (h *Handler) SetTimezone(name string) error {
+	timezone, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("error loading timezone %s: %w", name, err)
+	}
+	h.timezone = timezone
+	return nil
+}
+
+// scheduledDelay returns the delay of the schedule matching the current hour, if any
+func (h *Handler) scheduledDelay(schedules []models.DelaySchedule) time.Duration {
+	if len(schedules) == 0 {
+		return 0
+	}
+
+	hour := h.now().In(h.timezone).Hour()
+	for _, schedule := range schedules {
+		if schedule.Contains(hour) {
+			return time.Duration(schedule.DelayMs) * time.Millisecond
+		}
+	}
+	return 0
+}
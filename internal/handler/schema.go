@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// deferSchema stores an inline schema to be compiled on the first request of the location, so
+// configs with many endpoints don't pay every compilation at startup
+func (h *Handler) deferSchema(key, schemaStr string) {
+	h.schemaMu.Lock()
+	defer h.schemaMu.Unlock()
+	delete(h.schemas, key)
+	delete(h.schemaErrors, key)
+	h.rawSchemas[key] = schemaStr
+	h.schemaOnce[key] = &sync.Once{}
+	h.schemaJSON[key] = schemaStr
+}
+
+// setSchema stores the compiled schema for a location key along with the JSON it was compiled from
+func (h *Handler) setSchema(key, source string, schema *jsonschema.Schema) {
+	h.schemaMu.Lock()
+	defer h.schemaMu.Unlock()
+	delete(h.rawSchemas, key)
+	delete(h.schemaOnce, key)
+	delete(h.schemaErrors, key)
+	h.schemas[key] = schema
+	h.schemaJSON[key] = source
+}
+
+// GetSchemas returns the JSON of the schema of each location by path:method, including the
+// inline schemas not compiled yet and the current version of every schema_file
+func (h *Handler) GetSchemas() map[string]string {
+	h.schemaMu.RLock()
+	defer h.schemaMu.RUnlock()
+	schemas := make(map[string]string, len(h.schemaJSON))
+	for key, source := range h.schemaJSON {
+		schemas[key] = source
+	}
+	return schemas
+}
+
+// getSchema returns the compiled schema for a location key, compiling a deferred schema on
+// first access. A schema that fails to compile returns the same error on every request.
+func (h *Handler) getSchema(key string) (*jsonschema.Schema, bool, error) {
+	h.schemaMu.RLock()
+	schema, compiled := h.schemas[key]
+	raw, deferred := h.rawSchemas[key]
+	once := h.schemaOnce[key]
+	h.schemaMu.RUnlock()
+
+	if compiled {
+		return schema, true, nil
+	}
+	if !deferred {
+		return nil, false, nil
+	}
+
+	once.Do(func() {
+		schema, err := h.compileSchema(raw)
+
+		h.schemaMu.Lock()
+		defer h.schemaMu.Unlock()
+		// The location was registered again while compiling, the new schema wins
+		if h.schemaOnce[key] != once {
+			return
+		}
+		if err != nil {
+			h.schemaErrors[key] = err
+			return
+		}
+		h.schemas[key] = schema
+	})
+
+	h.schemaMu.RLock()
+	defer h.schemaMu.RUnlock()
+	if err, failed := h.schemaErrors[key]; failed {
+		return nil, true, err
+	}
+	schema, compiled = h.schemas[key]
+	return schema, compiled, nil
+}
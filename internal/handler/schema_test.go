@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const lazySchema = `{"type": "object", "required": ["amount"], "properties": {"amount": {"type": "number"}}}`
+
+func TestLazySchemaCompilation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:       "/api/payments",
+		Method:     "POST",
+		Response:   `{"status":"ok"}`,
+		StatusCode: 200,
+		Schema:     lazySchema,
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	key := location.Path + ":" + location.Method
+	if _, compiled := h.schemas[key]; compiled {
+		t.Fatal("Expected the schema not to be compiled at registration")
+	}
+
+	// Concurrent first requests compile the schema once and all of them are validated
+	var wg sync.WaitGroup
+	codes := make([]int, 20)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := `{"amount": 10}`
+			if i%2 == 1 {
+				body = `{"currency": "EUR"}`
+			}
+			codes[i] = postSchemaRequest(h, location, body)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		expected := http.StatusOK
+		if i%2 == 1 {
+			expected = http.StatusBadRequest
+		}
+		if code != expected {
+			t.Errorf("Request %d: expected status %d, got %d", i, expected, code)
+		}
+	}
+	if _, compiled := h.schemas[key]; !compiled {
+		t.Error("Expected the schema to be compiled after the first request")
+	}
+}
+
+func TestLazySchemaCompilationError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, nil)
+	location := models.Location{
+		Path:       "/api/broken",
+		Method:     "POST",
+		Response:   `{"status":"ok"}`,
+		StatusCode: 200,
+		Schema:     `{"$ref": "#/definitions/missing"}`,
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Expected registration to defer compilation, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := postSchemaRequest(h, location, `{"amount": 10}`); code != http.StatusInternalServerError {
+			t.Errorf("Request %d: expected status 500, got %d", i, code)
+		}
+	}
+
+	// Invalid JSON is still rejected at registration
+	location.Schema = `{"type": `
+	if err := h.RegisterLocation(location); err == nil {
+		t.Error("Expected an error registering a schema that is not valid JSON")
+	}
+}
+
+// registerSchemaLocations registers n locations with an inline schema, compiling them all when eager
+func registerSchemaLocations(b *testing.B, n int, eager bool) {
+	h := NewHandler(nil, nil)
+	for i := 0; i < n; i++ {
+		location := models.Location{
+			Path:       fmt.Sprintf("/api/resource/%d", i),
+			Method:     "POST",
+			Response:   `{"status":"ok"}`,
+			StatusCode: 200,
+			Schema:     lazySchema,
+		}
+		if err := h.RegisterLocation(location); err != nil {
+			b.Fatalf("Failed to register location: %v", err)
+		}
+		if eager {
+			if _, _, err := h.getSchema(location.Path + ":" + location.Method); err != nil {
+				b.Fatalf("Failed to compile schema: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkSchemaStartupEager(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		registerSchemaLocations(b, 200, true)
+	}
+}
+
+func BenchmarkSchemaStartupLazy(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		registerSchemaLocations(b, 200, false)
+	}
+}
+
+func TestGetSchemas(t *testing.T) {
+	h := NewHandler(nil, nil)
+	inline := models.Location{Path: "/api/payments", Method: "POST", Response: `{}`, StatusCode: 200, Schema: lazySchema}
+	if err := h.RegisterLocation(inline); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	schemaFile := filepath.Join(t.TempDir(), "refund.json")
+	if err := os.WriteFile(schemaFile, []byte(`{"type":"object"}`), 0644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+	fromFile := models.Location{Path: "/api/refunds", Method: "POST", Response: `{}`, StatusCode: 200, SchemaFile: schemaFile}
+	if err := h.RegisterLocation(fromFile); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+	defer h.Close()
+
+	schemas := h.GetSchemas()
+	if len(schemas) != 2 {
+		t.Fatalf("Expected 2 schemas, got %v", schemas)
+	}
+	if schemas["/api/payments:POST"] != lazySchema {
+		t.Errorf("Expected the inline schema before its first request, got %s", schemas["/api/payments:POST"])
+	}
+	if schemas["/api/refunds:POST"] != `{"type":"object"}` {
+		t.Errorf("Expected the schema file contents, got %s", schemas["/api/refunds:POST"])
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaWatcher recompiles schemas loaded from schema_file when the file changes
+type schemaWatcher struct {
+	watcher *fsnotify.Watcher
+	// files maps the absolute schema path to the location keys compiled from it
+	files map[string][]string
+	done  chan struct{}
+}
+
+// loadSchemaFile reads and compiles the JSON schema at path, returning it with the file contents
+func (h *Handler) loadSchemaFile(path string) (*jsonschema.Schema, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading schema file %s: %w", path, err)
+	}
+	if !json.Valid(data) {
+		return nil, "", fmt.Errorf("schema file %s is not valid JSON", path)
+	}
+	schema, err := h.compileSchema(string(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return schema, string(data), nil
+}
+
+// registerSchemaFile compiles the schema file for a location key and watches it for changes
+func (h *Handler) registerSchemaFile(key, path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("error resolving schema file %s: %w", path, err)
+	}
+
+	schema, source, err := h.loadSchemaFile(absPath)
+	if err != nil {
+		return err
+	}
+	h.setSchema(key, source, schema)
+
+	h.schemaMu.Lock()
+	defer h.schemaMu.Unlock()
+
+	if h.schemaWatcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("error creating schema file watcher: %w", err)
+		}
+		h.schemaWatcher = &schemaWatcher{
+			watcher: watcher,
+			files:   make(map[string][]string),
+			done:    make(chan struct{}),
+		}
+		go h.watchSchemaFiles(h.schemaWatcher)
+	}
+
+	// Editors usually replace the file on save, so the directory is watched instead of the file
+	if _, watched := h.schemaWatcher.files[absPath]; !watched {
+		if err := h.schemaWatcher.watcher.Add(filepath.Dir(absPath)); err != nil {
+			return fmt.Errorf("error watching schema file %s: %w", path, err)
+		}
+	}
+	h.schemaWatcher.files[absPath] = append(h.schemaWatcher.files[absPath], key)
+
+	return nil
+}
+
+// watchSchemaFiles recompiles the schemas whose file was written or replaced. A schema that
+// no longer compiles keeps the previous version.
+func (h *Handler) watchSchemaFiles(sw *schemaWatcher) {
+	defer close(sw.done)
+
+	for {
+		select {
+		case event, ok := <-sw.watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+
+			h.schemaMu.RLock()
+			keys := sw.files[filepath.Clean(event.Name)]
+			h.schemaMu.RUnlock()
+			if len(keys) == 0 {
+				continue
+			}
+
+			schema, source, err := h.loadSchemaFile(event.Name)
+			if err != nil {
+				h.Logger().Error().
+					Str("schema_file", event.Name).
+					AnErr("error", err).
+					Msg("Error reloading schema file, keeping previous schema")
+				continue
+			}
+			for _, key := range keys {
+				h.setSchema(key, source, schema)
+			}
+			h.Logger().Info().
+				Str("schema_file", event.Name).
+				Msg("Schema file reloaded")
+		case err, ok := <-sw.watcher.Errors:
+			if !ok {
+				return
+			}
+			h.Logger().Error().AnErr("error", err).Msg("Schema file watcher error")
+		}
+	}
+}
+
+// Close stops watching schema files
+func (h *Handler) Close() {
+	h.schemaMu.Lock()
+	sw := h.schemaWatcher
+	h.schemaWatcher = nil
+	h.schemaMu.Unlock()
+
+	if sw == nil {
+		return
+	}
+	sw.watcher.Close()
+	<-sw.done
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func postSchemaRequest(h *Handler, location models.Location, body string) int {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", location.Path, bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.HandleRequest(c, location)
+	return w.Code
+}
+
+func TestSchemaFileLoad(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	schemaPath := filepath.Join(t.TempDir(), "payment.json")
+	schema := `{"type": "object", "required": ["amount"], "properties": {"amount": {"type": "number"}}}`
+	if err := os.WriteFile(schemaPath, []byte(schema), 0o644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+
+	h := NewHandler(nil, nil)
+	defer h.Close()
+
+	location := models.Location{
+		Path:       "/api/payment",
+		Method:     "POST",
+		SchemaFile: schemaPath,
+		Response:   `{"message":"valid"}`,
+		StatusCode: 200,
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	if code := postSchemaRequest(h, location, `{"amount": 10}`); code != http.StatusOK {
+		t.Errorf("Expected status 200 for a valid body, got %d", code)
+	}
+	if code := postSchemaRequest(h, location, `{"currency": "EUR"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid body, got %d", code)
+	}
+}
+
+func TestSchemaFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalidPath := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidPath, []byte(`{"type": `), 0o644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"file not found", filepath.Join(dir, "missing.json")},
+		{"file is not valid JSON", invalidPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, nil)
+			defer h.Close()
+
+			location := models.Location{Path: "/api/payment", Method: "POST", SchemaFile: tt.path, StatusCode: 200}
+			if err := h.RegisterLocation(location); err == nil {
+				t.Error("Expected an error registering the location")
+			}
+		})
+	}
+}
+
+func TestSchemaFileHotReload(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	schemaPath := filepath.Join(t.TempDir(), "payment.json")
+	if err := os.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["amount"]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+
+	h := NewHandler(nil, nil)
+	defer h.Close()
+
+	location := models.Location{
+		Path:       "/api/payment",
+		Method:     "POST",
+		SchemaFile: schemaPath,
+		Response:   `{"message":"valid"}`,
+		StatusCode: 200,
+	}
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	if code := postSchemaRequest(h, location, `{"amount": 10}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200 before the reload, got %d", code)
+	}
+
+	// Require a currency as well; the same body must now be rejected
+	if err := os.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["amount", "currency"]}`), 0o644); err != nil {
+		t.Fatalf("Failed to update schema file: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for postSchemaRequest(h, location, `{"amount": 10}`) != http.StatusBadRequest {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the schema to be reloaded after the file changed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"sync/atomic"
+
+	"catalyst/internal/models"
+)
+
+// applySequence replaces the location response with the next step of its response_sequence.
+// Once the sequence is exhausted the last step repeats, or it starts over with loop.
+func (h *Handler) applySequence(location models.Location) models.Location {
+	steps := len(location.ResponseSequence)
+	if steps == 0 {
+		return location
+	}
+
+	value, ok := h.sequences.Load(location.Path + ":" + location.Method)
+	if !ok {
+		value, _ = h.sequences.LoadOrStore(location.Path+":"+location.Method, &atomic.Int64{})
+	}
+	call := int(value.(*atomic.Int64).Add(1) - 1)
+
+	index := call
+	if location.Loop {
+		index = call % steps
+	} else if index >= steps {
+		index = steps - 1
+	}
+
+	step := location.ResponseSequence[index]
+	location.Response = step.Response
+	if step.StatusCode != 0 {
+		location.StatusCode = step.StatusCode
+	}
+	if step.Headers != nil {
+		location.Headers = step.Headers
+	}
+	return location
+}
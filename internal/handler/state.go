@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"catalyst/internal/models"
+)
+
+// stateMachine holds the current state shared by every location of the server
+type stateMachine struct {
+	config  models.StateMachine
+	current atomic.Value
+}
+
+// SetStateMachine enables state_responses, starting from the initial state
+func (h *Handler) SetStateMachine(config models.StateMachine) {
+	machine := &stateMachine{config: config}
+	machine.current.Store(config.InitialState)
+	h.stateMachine = machine
+}
+
+// State returns the current state and whether the server has a state machine
+func (h *Handler) State() (string, bool) {
+	if h.stateMachine == nil {
+		return "", false
+	}
+	return h.stateMachine.current.Load().(string), true
+}
+
+// SetState moves the state machine to state; an empty state resets it to the initial state
+func (h *Handler) SetState(state string) error {
+	if h.stateMachine == nil {
+		return fmt.Errorf("server has no state machine")
+	}
+	if state == "" {
+		state = h.stateMachine.config.InitialState
+	}
+	if !h.stateMachine.config.Has(state) {
+		return fmt.Errorf("unknown state %q", state)
+	}
+	h.stateMachine.current.Store(state)
+	return nil
+}
+
+// applyState replaces the location response with the one configured for the current state and
+// moves to its next_state. Locations without a response for the state keep their own.
+func (h *Handler) applyState(ctx context.Context, location models.Location) models.Location {
+	if h.stateMachine == nil || len(location.StateResponses) == 0 {
+		return location
+	}
+
+	state := h.stateMachine.current.Load().(string)
+	response, ok := location.StateResponses[state]
+	if !ok {
+		return location
+	}
+
+	location.Response = response.Response
+	if response.StatusCode != 0 {
+		location.StatusCode = response.StatusCode
+	}
+	if response.Headers != nil {
+		location.Headers = response.Headers
+	}
+
+	// Only the request that observed the state moves it, concurrent requests don't skip states
+	if response.NextState != "" && h.stateMachine.current.CompareAndSwap(state, response.NextState) {
+		h.Logger().DebugCtx(ctx).
+			Str("state", state).
+			Str("next_state", response.NextState).
+			Msg("State machine transition")
+	}
+	return location
+}
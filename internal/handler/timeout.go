@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+
+	"github.com/SOLUCIONESSYCOM/scribe"
+	"github.com/gin-gonic/gin"
+)
+
+// handleWithTimeouts applies the location read/write timeouts around handleRequest.
+// The body is read with read_timeout_ms and the response must be produced within
+// write_timeout_ms, otherwise the client gets a 504.
+func (h *Handler) handleWithTimeouts(c *gin.Context, location models.Location) {
+	ctx := scribe.WithCtx(c.Request.Context())
+
+	if location.ReadTimeoutMs > 0 {
+		timeout := time.Duration(location.ReadTimeoutMs) * time.Millisecond
+		if err := readBodyWithTimeout(c, timeout); err != nil {
+			h.Logger().WarnCtx(ctx).
+				Str("path", c.Request.URL.Path).
+				Int("read_timeout_ms", location.ReadTimeoutMs).
+				AnErr("error", err).
+				Msg("Request body not received within read timeout")
+			h.abortGatewayTimeout(c, location, "read_timeout")
+			return
+		}
+	}
+
+	if location.WriteTimeoutMs <= 0 {
+		h.handleRequest(c, location)
+		return
+	}
+
+	timeout := time.Duration(location.WriteTimeoutMs) * time.Millisecond
+	timeoutCtx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	// The goroutine works on a copy writing to a buffer, so nothing reaches the client
+	// unless it finishes before the deadline
+	writer := newTimeoutWriter(c.Writer)
+	cp := c.Copy()
+	cp.Writer = writer
+	// handleRequest sees the deadline, so it stops recording and dispatching once the 504 is sent
+	cp.Request = cp.Request.WithContext(timeoutCtx)
+
+	done := make(chan struct{})
+	panicChan := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicChan <- p
+			}
+		}()
+		h.handleRequest(cp, location)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicChan:
+		panic(p)
+	case <-done:
+		writer.flush()
+	case <-timeoutCtx.Done():
+		writer.timeout()
+		h.Logger().WarnCtx(ctx).
+			Str("path", c.Request.URL.Path).
+			Int("write_timeout_ms", location.WriteTimeoutMs).
+			Msg("Response not produced within write timeout")
+		h.abortGatewayTimeout(c, location, "write_timeout")
+	}
+}
+
+// requestTimedOut reports whether the write timeout expired while the request was being handled
+func requestTimedOut(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
+
+// readBodyWithTimeout buffers the request body, giving up when timeout elapses
+func readBodyWithTimeout(c *gin.Context, timeout time.Duration) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	type result struct {
+		body []byte
+		err  error
+	}
+
+	body := c.Request.Body
+	done := make(chan result, 1)
+	go func() {
+		data, err := io.ReadAll(body)
+		done <- result{data, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return r.err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(r.body))
+		return nil
+	case <-ctx.Done():
+		body.Close()
+		c.Request.Body = http.NoBody
+		return ctx.Err()
+	}
+}
+
+// abortGatewayTimeout responds 504 and records the timeout metrics
+func (h *Handler) abortGatewayTimeout(c *gin.Context, location models.Location, reason string) {
+	c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "gateway timeout"})
+
+	statusCode := strconv.Itoa(http.StatusGatewayTimeout)
+	prom.HandlerResquestTotal.WithLabelValues(location.Path, c.Request.Method, statusCode).Inc()
+	prom.HandlerErrorsTotal.WithLabelValues(location.Path, c.Request.Method, reason).Inc()
+}
+
+// timeoutWriter buffers the response until the handler finishes or the deadline passes
+type timeoutWriter struct {
+	gin.ResponseWriter
+	mu          sync.Mutex
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		ResponseWriter: w,
+		header:         make(http.Header),
+		status:         http.StatusOK,
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.wroteHeader || code <= 0 {
+		return
+	}
+	w.status = code
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.wroteHeader = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.wroteHeader = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.wroteHeader {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.wroteHeader
+}
+
+// flush copies the buffered response to the real writer
+func (w *timeoutWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for key, values := range w.header {
+		w.ResponseWriter.Header()[key] = values
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// timeout discards anything the handler writes from now on
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+}
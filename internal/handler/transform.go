@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itchyny/gojq"
+)
+
+// request_transform step types
+const (
+	transformRenameField  = "rename_field"
+	transformRemoveField  = "remove_field"
+	transformSetField     = "set_field"
+	transformJQExpression = "jq_expression"
+)
+
+// requestTransformKey stores the request_transform pipeline of the location in the request context
+const requestTransformKey = "request_transform"
+
+// transformStep is a validated request_transform step; code is the compiled jq_expression
+type transformStep struct {
+	models.TransformStep
+	code *gojq.Code
+}
+
+// registerTransform validates the request_transform of a location and compiles its jq expressions
+func (h *Handler) registerTransform(location models.Location) error {
+	if len(location.RequestTransform) == 0 {
+		return nil
+	}
+
+	steps := make([]transformStep, 0, len(location.RequestTransform))
+	for i, configured := range location.RequestTransform {
+		step := transformStep{TransformStep: configured}
+		switch configured.Type {
+		case transformRenameField:
+			if configured.Field == "" || configured.To == "" {
+				return fmt.Errorf("request_transform step %d: rename_field requires field and to", i)
+			}
+		case transformRemoveField, transformSetField:
+			if configured.Field == "" {
+				return fmt.Errorf("request_transform step %d: %s requires field", i, configured.Type)
+			}
+		case transformJQExpression:
+			query, err := gojq.Parse(configured.Expression)
+			if err != nil {
+				return fmt.Errorf("request_transform step %d: invalid jq expression %q: %w", i, configured.Expression, err)
+			}
+			code, err := gojq.Compile(query)
+			if err != nil {
+				return fmt.Errorf("request_transform step %d: invalid jq expression %q: %w", i, configured.Expression, err)
+			}
+			step.code = code
+		default:
+			return fmt.Errorf("request_transform step %d: invalid type %q", i, configured.Type)
+		}
+		steps = append(steps, step)
+	}
+
+	h.transforms[location.Path+":"+location.Method] = steps
+	return nil
+}
+
+// setRequestTransform makes the request_transform of the location available to the templates
+func (h *Handler) setRequestTransform(c *gin.Context, location models.Location) {
+	if steps, ok := h.transforms[location.Path+":"+location.Method]; ok {
+		c.Set(requestTransformKey, steps)
+	}
+}
+
+// applyRequestTransform runs the request_transform of the request, if any, over the parsed body
+func applyRequestTransform(c *gin.Context, body map[string]interface{}) (map[string]interface{}, error) {
+	value, ok := c.Get(requestTransformKey)
+	if !ok {
+		return body, nil
+	}
+	if body == nil {
+		body = make(map[string]interface{})
+	}
+
+	for i, step := range value.([]transformStep) {
+		var err error
+		switch step.Type {
+		case transformRenameField:
+			if moved, found := removeField(body, step.Field); found {
+				err = setField(body, step.To, moved)
+			}
+		case transformRemoveField:
+			removeField(body, step.Field)
+		case transformSetField:
+			err = setField(body, step.Field, jsonValue(step.Value))
+		case transformJQExpression:
+			body, err = runJQ(step.code, body)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("request_transform step %d (%s): %w", i, step.Type, err)
+		}
+	}
+	return body, nil
+}
+
+// runJQ applies a compiled jq expression to the body; its first output must be an object
+func runJQ(code *gojq.Code, body map[string]interface{}) (map[string]interface{}, error) {
+	iter := code.Run(body)
+	output, ok := iter.Next()
+	if !ok {
+		return nil, fmt.Errorf("jq expression returned no output")
+	}
+	if err, isErr := output.(error); isErr {
+		return nil, err
+	}
+	result, ok := output.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jq expression returned %T, expected an object", output)
+	}
+	return result, nil
+}
+
+// removeField deletes the value at a dotted path and returns it
+func removeField(body map[string]interface{}, path string) (interface{}, bool) {
+	keys := strings.Split(path, ".")
+	parent := body
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := parent[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		parent = next
+	}
+
+	last := keys[len(keys)-1]
+	value, found := parent[last]
+	delete(parent, last)
+	return value, found
+}
+
+// setField sets the value at a dotted path, creating the missing intermediate objects
+func setField(body map[string]interface{}, path string, value interface{}) error {
+	keys := strings.Split(path, ".")
+	parent := body
+	for _, key := range keys[:len(keys)-1] {
+		switch next := parent[key].(type) {
+		case map[string]interface{}:
+			parent = next
+		case nil:
+			created := make(map[string]interface{})
+			parent[key] = created
+			parent = created
+		default:
+			return fmt.Errorf("field %s is not an object", key)
+		}
+	}
+	parent[keys[len(keys)-1]] = value
+	return nil
+}
+
+// jsonValue converts a configured set_field value to the types of a decoded JSON body,
+// where every number is a float64
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = jsonValue(item)
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[key] = jsonValue(item)
+		}
+		return normalized
+	}
+	return value
+}
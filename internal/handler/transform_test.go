@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"catalyst/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestTransform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	location := models.Location{
+		Path:       "/adapter",
+		Method:     "POST",
+		StatusCode: 200,
+		Response:   `{"id":"{{.customer.id}}","tier":"{{.customer.tier}}","total":{{.total}},"card":"{{.card}}","source":"{{.source}}"}`,
+		RequestTransform: []models.TransformStep{
+			{Type: "rename_field", Field: "clientId", To: "customer.id"},
+			{Type: "remove_field", Field: "card"},
+			{Type: "set_field", Field: "customer.tier", Value: "gold"},
+			{Type: "set_field", Field: "source", Value: "mock"},
+			{Type: "jq_expression", Expression: `.total = ([.items[].price] | add)`},
+		},
+	}
+
+	h := NewHandler(nil, nil)
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `{"clientId":"c-42","card":"4111111111111111","items":[{"price":5},{"price":7.5}]}`
+	c.Request = httptest.NewRequest("POST", "/adapter", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.HandleRequest(c, location)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	expected := `{"id":"c-42","tier":"gold","total":12.5,"card":"<no value>","source":"mock"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestRequestTransformValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		step  models.TransformStep
+		error string
+	}{
+		{"invalid jq syntax", models.TransformStep{Type: "jq_expression", Expression: ".foo | ]"}, "invalid jq expression"},
+		{"undefined jq function", models.TransformStep{Type: "jq_expression", Expression: "nosuchfunc(1)"}, "invalid jq expression"},
+		{"rename without target", models.TransformStep{Type: "rename_field", Field: "a"}, "rename_field requires field and to"},
+		{"set without field", models.TransformStep{Type: "set_field", Value: 1}, "set_field requires field"},
+		{"unknown type", models.TransformStep{Type: "uppercase", Field: "a"}, `invalid type "uppercase"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := models.Location{Path: "/adapter", Method: "POST", RequestTransform: []models.TransformStep{tt.step}}
+			err := NewHandler(nil, nil).RegisterLocation(location)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestRequestTransformJQErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	location := models.Location{
+		Path:             "/adapter",
+		Method:           "POST",
+		StatusCode:       200,
+		Response:         `{"id":"{{.id}}"}`,
+		RequestTransform: []models.TransformStep{{Type: "jq_expression", Expression: ".items"}},
+	}
+	h := NewHandler(nil, nil)
+	if err := h.RegisterLocation(location); err != nil {
+		t.Fatalf("Failed to register location: %v", err)
+	}
+
+	// A jq expression that does not produce an object cannot feed the templates
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/adapter", bytes.NewBufferString(`{"items":[1,2]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.HandleRequest(c, location)
+
+	if w.Code != 500 {
+		t.Errorf("Expected status 500, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTransformFields(t *testing.T) {
+	body := map[string]interface{}{"a": map[string]interface{}{"b": "value"}, "c": "scalar"}
+
+	if value, found := removeField(body, "a.b"); !found || value != "value" {
+		t.Errorf("Expected to remove a.b, got %v, %v", value, found)
+	}
+	if _, found := removeField(body, "missing.b"); found {
+		t.Error("Expected missing.b not to be found")
+	}
+	if err := setField(body, "x.y.z", 1.0); err != nil {
+		t.Fatalf("setField failed: %v", err)
+	}
+	if got := jsonPathValue(body, "$.x.y.z"); got != "1" {
+		t.Errorf("Expected x.y.z to be 1, got %q", got)
+	}
+	if err := setField(body, "c.d", 1.0); err == nil {
+		t.Error("Expected error setting a field under a scalar")
+	}
+
+	value := jsonValue(map[string]interface{}{"n": 3, "list": []interface{}{1, "a"}})
+	if got := value.(map[string]interface{})["n"]; got != 3.0 {
+		t.Errorf("Expected n converted to float64, got %T", got)
+	}
+}
@@ -0,0 +1,72 @@
+package invalid
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGenerateInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		typeName    string
+		invalidType InvalidUTF8Type
+	}{
+		{"incomplete", IncompleteSequence},
+		{"continuation", ContinuationByteOnly},
+		{"overlong", OverlongSequence},
+		{"invalid_range", InvalidByteRange},
+		{"surrogate", SurrogateHalf},
+		{"random", RandomInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typeName, func(t *testing.T) {
+			// Values are random, so each type is generated several times
+			for i := 0; i < 50; i++ {
+				invalid := GenerateInvalidUTF8(tt.invalidType)
+				if len(invalid) == 0 {
+					t.Fatal("Expected non-empty value")
+				}
+				if utf8.Valid(invalid) {
+					t.Fatalf("Expected invalid UTF-8, got %X", invalid)
+				}
+
+				byName := GetInvalidUTF8ByTypeName(tt.typeName)
+				if byName == "" {
+					t.Fatal("Expected non-empty value by type name")
+				}
+				if utf8.ValidString(byName) {
+					t.Fatalf("Expected invalid UTF-8 by type name, got %X", byName)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateValidUTF8(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		valid := GenerateValidUTF8()
+		if !utf8.ValidString(valid) {
+			t.Fatalf("Expected valid UTF-8, got %X", valid)
+		}
+		if !IsValidUTF8([]byte(valid)) {
+			t.Fatalf("Expected IsValidUTF8 to accept %q", valid)
+		}
+	}
+}
+
+func FuzzGenerateInvalidUTF8(f *testing.F) {
+	for _, typeName := range []string{"incomplete", "continuation", "overlong", "invalid_range", "surrogate", "random", "", "unknown"} {
+		f.Add(typeName)
+	}
+
+	f.Fuzz(func(t *testing.T, typeName string) {
+		// Unknown type names fall back to random invalid values
+		invalid := GetInvalidUTF8ByTypeName(typeName)
+		if invalid == "" {
+			t.Fatalf("Expected non-empty value for type name %q", typeName)
+		}
+		if utf8.ValidString(invalid) {
+			t.Fatalf("Expected invalid UTF-8 for type name %q, got %X", typeName, invalid)
+		}
+	})
+}
@@ -3,18 +3,47 @@ package logger
 import (
 	"catalyst/internal/config"
 	"catalyst/internal/models"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/SOLUCIONESSYCOM/scribe"
 	"github.com/google/uuid"
 )
 
+// Levels son los niveles que acepta scribe, del más al menos detallado
+var Levels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// ErrInvalidLevel se devuelve cuando el nivel no está en Levels
+var ErrInvalidLevel = errors.New("invalid log level")
+
+// ValidateLevel comprueba que el nivel sea uno de Levels
+func ValidateLevel(level string) error {
+	for _, valid := range Levels {
+		if level == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q, expected one of %s", ErrInvalidLevel, level, strings.Join(Levels, ", "))
+}
+
+// DefaultLevel es el nivel mínimo configurado globalmente
+func DefaultLevel() string {
+	return config.GetLogSettings().MinLevel
+}
+
 func GetLoggerContext(server models.LogDescriptor) (*scribe.Scribe, error) {
+	return GetLoggerContextWithLevel(server, DefaultLevel())
+}
+
+// GetLoggerContextWithLevel crea el logger del servidor con un nivel mínimo explícito
+func GetLoggerContextWithLevel(server models.LogDescriptor, level string) (*scribe.Scribe, error) {
 
 	logSettings := config.GetLogSettings()
 
 	loggerConfig := &scribe.ConfigLogger{
 		FilePath:          server.Path,                    // FilePath donde se guardarán los logs
-		MinLevel:          logSettings.MinLevel,           // Nivel mínimo de log (trace, debug, info, warn, error, fatal)
+		MinLevel:          level,                          // Nivel mínimo de log (trace, debug, info, warn, error, fatal)
 		RotationMaxSizeMB: logSettings.RotationMaxSizeMB,  // Tamaño máximo del archivo antes de rotar
 		MaxBackups:        logSettings.MaxBackups,         // Número máximo de archivos de respaldo
 		MaxAgeDay:         logSettings.MaxAgeDay,          // Días máximos para conservar los logs
@@ -1,10 +1,33 @@
 package models
 
-import "github.com/testcontainers/testcontainers-go/modules/postgres"
+import (
+	"time"
+
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
 
 type MockServer struct {
+	// Name identifies the config file in logs, the API and restarts; it defaults to the file name
+	Name            string          `yaml:"name,omitempty" json:"name,omitempty"`
+	Version         string          `yaml:"version,omitempty" json:"version,omitempty"`
 	Http            Http            `yaml:"http" json:"http"`
 	PostgresServers PostgresServers `yaml:"postgres" json:"postgres"`
+	API             *APISettings    `yaml:"api" json:"api"`
+	Database        *DatabaseConfig `yaml:"database,omitempty" json:"database,omitempty"`
+}
+
+// DatabaseConfig configures the connection pool of the transactions database; zero values keep the defaults
+type DatabaseConfig struct {
+	MaxOpenConns           int `yaml:"max_open_conns" json:"max_open_conns"`
+	MaxIdleConns           int `yaml:"max_idle_conns" json:"max_idle_conns"`
+	ConnMaxLifetimeSeconds int `yaml:"conn_max_lifetime_seconds" json:"conn_max_lifetime_seconds"`
+	ConnMaxIdleSeconds     int `yaml:"conn_max_idle_seconds" json:"conn_max_idle_seconds"`
+}
+
+type APISettings struct {
+	APIKey    string `yaml:"api_key" json:"api_key"`
+	Auth      string `yaml:"api_auth" json:"api_auth"`
+	JWTSecret string `yaml:"jwt_secret" json:"jwt_secret"`
 }
 type Http struct {
 	Servers []Server `yaml:"servers" json:"servers"`
@@ -15,13 +38,62 @@ type PostgresServers struct {
 }
 
 type Server struct {
-	Listen         int             `yaml:"listen" json:"listen"`
-	Logger         *bool           `yaml:"logger" json:"logger"`
-	LoggerPath     *string         `yaml:"logger_path" json:"logger_path"`
-	Name           *string         `yaml:"name" json:"name"`
-	Version        *string         `yaml:"version" json:"version"`
-	ChaosInjection *ChaosInjection `yaml:"chaos_injection" json:"chaos_injection"`
-	Location       []Location      `yaml:"location" json:"location"`
+	Listen                 int             `yaml:"listen" json:"listen"`
+	Logger                 *bool           `yaml:"logger" json:"logger"`
+	LoggerPath             *string         `yaml:"logger_path" json:"logger_path"`
+	Name                   *string         `yaml:"name" json:"name"`
+	Version                *string         `yaml:"version" json:"version"`
+	ChaosInjection         *ChaosInjection `yaml:"chaos_injection" json:"chaos_injection"`
+	ChaosSeed              *int64          `yaml:"chaos_seed" json:"chaos_seed"`
+	StartupFailProbability float64         `yaml:"startup_fail_probability" json:"startup_fail_probability"`
+	ChaosHistoryEnabled    bool            `yaml:"chaos_history_enabled" json:"chaos_history_enabled"`
+	DefaultResponseHeaders Headers         `yaml:"default_response_headers" json:"default_response_headers"`
+	PathPrefix             string          `yaml:"path_prefix" json:"path_prefix"`
+	MaxResponseBodyBytes   *int64          `yaml:"max_response_body_bytes" json:"max_response_body_bytes"`
+	MaxRequestHeaderBytes  int             `yaml:"max_request_header_bytes" json:"max_request_header_bytes"`
+	StatusOverrideHeader   string          `yaml:"status_code_override_header" json:"status_code_override_header"`
+	AllowedOverrideCodes   []int           `yaml:"allowed_override_codes" json:"allowed_override_codes"`
+	Timezone               string          `yaml:"timezone" json:"timezone"`
+	TLSCertFile            string          `yaml:"tls_cert_file" json:"tls_cert_file"`
+	TLSKeyFile             string          `yaml:"tls_key_file" json:"tls_key_file"`
+	TLSAuto                bool            `yaml:"tls_auto" json:"tls_auto"`
+	HTTP2                  bool            `yaml:"http2" json:"http2"`
+	H2C                    bool            `yaml:"h2c" json:"h2c"`
+	Group                  string          `yaml:"group" json:"group"`
+	IndexedBodyFields      []IndexedField  `yaml:"indexed_body_fields" json:"indexed_body_fields"`
+	StateMachine           *StateMachine   `yaml:"state_machine" json:"state_machine"`
+	GlobalAsync            *GlobalAsync    `yaml:"global_async" json:"global_async"`
+	TrustedProxies         []string        `yaml:"trusted_proxies" json:"trusted_proxies"`
+	SecurityHeaders        SecurityHeaders `yaml:"security_headers" json:"security_headers"`
+	Middleware             []Middleware    `yaml:"middleware" json:"middleware"`
+	Location               []Location      `yaml:"location" json:"location"`
+
+	// Connection timeouts of the HTTP server in seconds; read_header_timeout_seconds defaults to 5
+	ReadTimeoutSeconds       int `yaml:"read_timeout_seconds" json:"read_timeout_seconds"`
+	WriteTimeoutSeconds      int `yaml:"write_timeout_seconds" json:"write_timeout_seconds"`
+	IdleTimeoutSeconds       int `yaml:"idle_timeout_seconds" json:"idle_timeout_seconds"`
+	ReadHeaderTimeoutSeconds int `yaml:"read_header_timeout_seconds" json:"read_header_timeout_seconds"`
+}
+
+// SecurityHeaders sets the security response headers of a server by key, e.g.
+// content_security_policy. An empty value removes a header sent by default.
+type SecurityHeaders map[string]string
+
+// Middleware is a request middleware of a server, run in list order before routing
+type Middleware struct {
+	// Type is add_header, remove_header, rewrite_path, log_only or rate_limit
+	Type string `yaml:"type" json:"type"`
+	// Header and Value are the request header of add_header and remove_header
+	Header string `yaml:"header" json:"header"`
+	Value  string `yaml:"value" json:"value"`
+	// Pattern is the regex of rewrite_path, replaced by Replacement ($1 expands the first group)
+	Pattern     string `yaml:"pattern" json:"pattern"`
+	Replacement string `yaml:"replacement" json:"replacement"`
+	// Message is logged by log_only
+	Message string `yaml:"message" json:"message"`
+	// RequestsPerSecond and Burst configure the token bucket of rate_limit
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	Burst             int     `yaml:"burst" json:"burst"`
 }
 
 type LogDescriptor struct {
@@ -33,15 +105,136 @@ type LogDescriptor struct {
 }
 
 type Location struct {
-	Path           string          `yaml:"path" json:"path"`
-	Method         string          `yaml:"method" json:"method"`
-	StaticFilesDir string          `yaml:"static_dir" json:"static_dir"`
-	Schema         string          `yaml:"schema" json:"schema"`
-	Response       string          `yaml:"response" json:"response"`
-	Async          []Async         `yaml:"async" json:"async"`
-	Headers        *Headers        `yaml:"headers" json:"headers"`
-	StatusCode     int             `yaml:"status_code" json:"statusCode"`
-	ChaosInjection *ChaosInjection `yaml:"chaos_injection" json:"chaos_injection"`
+	Path            string          `yaml:"path" json:"path"`
+	PathRegex       string          `yaml:"path_regex" json:"path_regex"`
+	Method          string          `yaml:"method" json:"method"`
+	StaticFilesDir  string          `yaml:"static_dir" json:"static_dir"`
+	Schema          string          `yaml:"schema" json:"schema"`
+	SchemaFile      string          `yaml:"schema_file" json:"schema_file"`
+	Response        string          `yaml:"response" json:"response"`
+	Async           []Async         `yaml:"async" json:"async"`
+	Headers         *Headers        `yaml:"headers" json:"headers"`
+	StatusCode      int             `yaml:"status_code" json:"statusCode"`
+	ChaosInjection  *ChaosInjection `yaml:"chaos_injection" json:"chaos_injection"`
+	DedupWindowMs   int             `yaml:"dedup_window_ms" json:"dedup_window_ms"`
+	DedupHeaders    []string        `yaml:"dedup_headers" json:"dedup_headers"`
+	ReadTimeoutMs   int             `yaml:"read_timeout_ms" json:"read_timeout_ms"`
+	WriteTimeoutMs  int             `yaml:"write_timeout_ms" json:"write_timeout_ms"`
+	CacheTTLSeconds int             `yaml:"cache_ttl_seconds" json:"cache_ttl_seconds"`
+	MinifyResponse  bool            `yaml:"minify_response" json:"minify_response"`
+	RequestFormat   string          `yaml:"request_format" json:"request_format"`
+
+	ResponseDelaySchedule []DelaySchedule `yaml:"response_delay_schedule" json:"response_delay_schedule"`
+
+	// LogRequestBody stores the request body with the transaction (default true); LogMultipartFiles
+	// also stores the file contents of multipart/form-data bodies
+	LogRequestBody              *bool    `yaml:"log_request_body" json:"log_request_body"`
+	LogMultipartFiles           bool     `yaml:"log_multipart_files" json:"log_multipart_files"`
+	LogRequestBodyMaxBytes      int      `yaml:"log_request_body_max_bytes" json:"log_request_body_max_bytes"`
+	LogRequestBodyExcludeFields []string `yaml:"log_request_body_exclude_fields" json:"log_request_body_exclude_fields"`
+
+	StateResponses map[string]StateResponse `yaml:"state_responses" json:"state_responses"`
+
+	PrometheusLabels map[string]string `yaml:"prometheus_labels" json:"prometheus_labels"`
+
+	// ResponseSequence is served in order on successive calls; the last item repeats unless Loop is set
+	ResponseSequence []SequenceResponse `yaml:"response_sequence" json:"response_sequence"`
+	Loop             bool               `yaml:"loop" json:"loop"`
+
+	// Auth requires credentials on the location; AuthPassThrough forwards them to the async calls
+	Auth            *LocationAuth `yaml:"auth" json:"auth"`
+	AuthPassThrough bool          `yaml:"auth_pass_through" json:"auth_pass_through"`
+
+	// RequestTransform reshapes the JSON request body, step by step, before the templates see it
+	RequestTransform []TransformStep `yaml:"request_transform" json:"request_transform"`
+
+	// MockOnlyIfHeader ("Name: value") serves the location only to requests carrying exactly that header
+	MockOnlyIfHeader string `yaml:"mock_only_if_header" json:"mock_only_if_header"`
+
+	// WildcardParam names the trailing * of Path, e.g. /api/* with "path" is routed as /api/*path
+	// and the matched remainder is available to templates as {{.Params.path}}
+	WildcardParam string `yaml:"wildcard_param" json:"wildcard_param"`
+
+	// TagHeaders are request headers, e.g. X-Test-Name, stored as tags of the transaction when present
+	TagHeaders []string `yaml:"tag_headers" json:"tag_headers"`
+}
+
+// TransformStep is one step of a request_transform pipeline. Field and To are dotted paths
+// such as customer.id.
+type TransformStep struct {
+	// Type is rename_field, remove_field, set_field or jq_expression
+	Type       string      `yaml:"type" json:"type"`
+	Field      string      `yaml:"field" json:"field"`
+	To         string      `yaml:"to" json:"to"`
+	Value      interface{} `yaml:"value" json:"value"`
+	Expression string      `yaml:"expression" json:"expression"`
+}
+
+// LocationAuth protects a location with Basic or Bearer authentication. Password and Token accept
+// env:NAME to read the value from an environment variable.
+type LocationAuth struct {
+	Type     string `yaml:"type" json:"type"`
+	Username string `yaml:"username" json:"username"`
+	// Password is a bcrypt hash, or plaintext which logs a warning
+	Password string `yaml:"password" json:"password"`
+	Token    string `yaml:"token" json:"token"`
+}
+
+// StateMachine lists the states a server moves through; locations with state_responses
+// answer according to the current state
+type StateMachine struct {
+	States       []string `yaml:"states" json:"states"`
+	InitialState string   `yaml:"initial_state" json:"initial_state"`
+}
+
+// Has reports whether state is one of the configured states
+func (s StateMachine) Has(state string) bool {
+	for _, configured := range s.States {
+		if configured == state {
+			return true
+		}
+	}
+	return false
+}
+
+// StateResponse replaces the location response while the server is in a state. Serving it
+// moves the server to NextState, when set.
+type StateResponse struct {
+	Response   string   `yaml:"response" json:"response"`
+	StatusCode int      `yaml:"status_code" json:"status_code"`
+	Headers    *Headers `yaml:"headers" json:"headers"`
+	NextState  string   `yaml:"next_state" json:"next_state"`
+}
+
+// SequenceResponse is one step of a location response_sequence. Unset fields keep the
+// location's own status code and headers.
+type SequenceResponse struct {
+	Response   string   `yaml:"response" json:"response"`
+	StatusCode int      `yaml:"status_code" json:"status_code"`
+	Headers    *Headers `yaml:"headers" json:"headers"`
+}
+
+// IndexedField extracts a request body field into an indexed column of mock_transactions,
+// so transactions can be searched by its value
+type IndexedField struct {
+	Field    string `yaml:"field" json:"field"`
+	JSONPath string `yaml:"jsonpath" json:"jsonpath"`
+}
+
+// DelaySchedule delays responses from StartHour (inclusive) to EndHour (exclusive). A window
+// whose StartHour is greater than its EndHour wraps around midnight.
+type DelaySchedule struct {
+	StartHour int `yaml:"start_hour" json:"start_hour"`
+	EndHour   int `yaml:"end_hour" json:"end_hour"`
+	DelayMs   int `yaml:"delay_ms" json:"delay_ms"`
+}
+
+// Contains reports whether the hour (0-23) falls within the schedule
+func (s DelaySchedule) Contains(hour int) bool {
+	if s.StartHour <= s.EndHour {
+		return hour >= s.StartHour && hour < s.EndHour
+	}
+	return hour >= s.StartHour || hour < s.EndHour
 }
 
 type Headers map[string]string
@@ -54,12 +247,64 @@ type Async struct {
 	Timeout    *int     `yaml:"timeout" json:"timeout"`
 	Retries    *int     `yaml:"retries" json:"retries"`
 	RetryDelay *int     `yaml:"retry_delay" json:"retryDelay"`
+	// RetryBackoff is how the delay grows between retries: constant (default), linear or exponential
+	RetryBackoff     string `yaml:"retry_backoff" json:"retryBackoff"`
+	RetryMaxDelay    *int   `yaml:"retry_max_delay_ms" json:"retryMaxDelayMs"`
+	RetryStatusCodes []int  `yaml:"retry_status_codes" json:"retryStatusCodes"`
+	// PropagateTrace sends the request_trace_id of the original request as X-Trace-ID
+	PropagateTrace bool `yaml:"propagate_trace" json:"propagateTrace"`
+}
+
+// GlobalAsync is an async call fired by every request a server handles, chaos-aborted ones included.
+// BodyTemplate is rendered with the transaction of the request (.UUID, .RequestMethod,
+// .RequestEndpoint, .ResponseStatusCode, ...).
+type GlobalAsync struct {
+	Url          string   `yaml:"url" json:"url"`
+	Method       string   `yaml:"method" json:"method"`
+	BodyTemplate string   `yaml:"body_template" json:"body_template"`
+	Headers      *Headers `yaml:"headers" json:"headers"`
+	Retries      *int     `yaml:"retries" json:"retries"`
 }
 
 type ChaosInjection struct {
-	Latency Latency `yaml:"latency" json:"latency"`
-	Abort   Abort   `yaml:"abort" json:"abort"`
-	Error   Error   `yaml:"error" json:"error"`
+	Latency                      Latency `yaml:"latency" json:"latency"`
+	Abort                        Abort   `yaml:"abort" json:"abort"`
+	Error                        Error   `yaml:"error" json:"error"`
+	TriggerIfBodyLargerThanBytes int64   `yaml:"trigger_if_body_larger_than_bytes" json:"trigger_if_body_larger_than_bytes"`
+	// Sticky derives the roll from the client IP, path and method, so a client always gets the same outcome
+	Sticky bool `yaml:"chaos_sticky" json:"chaos_sticky"`
+}
+
+// ChaosEvent records a chaos effect applied to a request
+type ChaosEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Path      string    `json:"path"`
+	Method    string    `json:"method"`
+	Type      string    `json:"type"`
+	Applied   string    `json:"applied"`
+}
+
+// CacheStats summarizes the location response cache of a server
+type CacheStats struct {
+	Hits    int64        `json:"hits"`
+	Misses  int64        `json:"misses"`
+	Entries []CacheEntry `json:"entries"`
+}
+
+// CacheEntry describes a cached response
+type CacheEntry struct {
+	Key        string    `json:"key"`
+	StatusCode int       `json:"status_code"`
+	SizeBytes  int       `json:"size_bytes"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// RequestCounter is the in-memory request count of a location
+type RequestCounter struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Total  int64  `json:"total"`
+	Errors int64  `json:"errors"`
 }
 
 type Latency struct {
@@ -73,9 +318,10 @@ type Abort struct {
 }
 
 type Error struct {
-	Code        int    `yaml:"code" json:"code"`
-	Probability string `yaml:"probability" json:"probability"`
-	Response    string `yaml:"response" json:"response"`
+	Code           int    `yaml:"code" json:"code"`
+	Probability    string `yaml:"probability" json:"probability"`
+	Response       string `yaml:"response" json:"response"`
+	DropAfterBytes int    `yaml:"drop_after_bytes" json:"drop_after_bytes"`
 }
 
 type LogSettings struct {
@@ -91,25 +337,45 @@ type LogSettings struct {
 }
 
 type PostgresServer struct {
-	Name              string                      `yaml:"name" json:"name"`
-	User              string                      `yaml:"user" json:"user"`
-	Password          string                      `yaml:"password" json:"password"`
-	Host              string                      `yaml:"host" json:"host"`
-	Port              int                         `yaml:"port" json:"port"`
-	Database          string                      `yaml:"database" json:"database"`
-	InitScript        string                      `yaml:"init_script" json:"init_script"`
-	Seed              []Seed                      `yaml:"seed" json:"seed"`
-	PostgresContainer *postgres.PostgresContainer `yaml:"postgres_container" json:"postgres_container"`
-	Logger            *bool                       `yaml:"logger" json:"logger"`
-	LoggerPath        *string                     `yaml:"logger_path" json:"logger_path"`
-	File              *bool                       `yaml:"file" json:"file"`
+	Name               string                      `yaml:"name" json:"name"`
+	User               string                      `yaml:"user" json:"user"`
+	Password           string                      `yaml:"password" json:"password"`
+	Host               string                      `yaml:"host" json:"host"`
+	Port               int                         `yaml:"port" json:"port"`
+	Database           string                      `yaml:"database" json:"database"`
+	InitScript         string                      `yaml:"init_script" json:"init_script"`
+	Seed               []Seed                      `yaml:"seed" json:"seed"`
+	PostgresContainer  *postgres.PostgresContainer `yaml:"postgres_container" json:"postgres_container"`
+	Logger             *bool                       `yaml:"logger" json:"logger"`
+	LoggerPath         *string                     `yaml:"logger_path" json:"logger_path"`
+	File               *bool                       `yaml:"file" json:"file"`
+	Image              string                      `yaml:"image" json:"image"`
+	PostgresArgs       map[string]string           `yaml:"postgres_args" json:"postgres_args"`
+	GenerateInitScript bool                        `yaml:"generate_init_script" json:"generate_init_script"`
+	QueryStubs         []QueryStub                 `yaml:"query_stubs" json:"query_stubs"`
 }
 
 type Seed struct {
-	Table     string      `yaml:"table" json:"table"`
-	Schema    string      `yaml:"schema" json:"schema"`
-	Rows      int         `yaml:"rows" json:"rows"`
-	Overrides []Overrides `yaml:"overrides" json:"overrides"`
+	Table     string       `yaml:"table" json:"table"`
+	Schema    string       `yaml:"schema" json:"schema"`
+	Rows      int          `yaml:"rows" json:"rows"`
+	Overrides []Overrides  `yaml:"overrides" json:"overrides"`
+	CSVFile   string       `yaml:"csv_file" json:"csv_file"`
+	Columns   []SeedColumn `yaml:"columns" json:"columns"`
+}
+
+// SeedColumn describes a column of the table created when generate_init_script is set
+type SeedColumn struct {
+	Name       string `yaml:"name" json:"name"`
+	Type       string `yaml:"type" json:"type"`
+	Nullable   bool   `yaml:"nullable" json:"nullable"`
+	PrimaryKey bool   `yaml:"primary_key" json:"primary_key"`
+}
+
+// QueryStub answers the queries matching SQLPattern with the rows in ResponseJSON
+type QueryStub struct {
+	SQLPattern   string `yaml:"sql_pattern" json:"sql_pattern"`
+	ResponseJSON string `yaml:"response_json" json:"response_json"`
 }
 
 type Overrides struct {
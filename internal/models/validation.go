@@ -0,0 +1,28 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError represents a validation error with field details
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Value   string `json:"value,omitempty"`
+}
+
+func (ve ValidationError) Error() string {
+	return fmt.Sprintf("validation error for field '%s': %s", ve.Field, ve.Message)
+}
+
+// ValidationErrors represents multiple validation errors
+type ValidationErrors []ValidationError
+
+func (ve ValidationErrors) Error() string {
+	var messages []string
+	for _, err := range ve {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,95 @@
+package postgres_server
+
+import (
+	"catalyst/internal/logger"
+	"catalyst/internal/models"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestConnectionString(t *testing.T) {
+	connection := connectionString("mock", "p@ss:word/#", "localhost:49153", "orders")
+
+	parsed, err := url.Parse(connection)
+	if err != nil {
+		t.Fatalf("Expected a valid URL, got %q: %v", connection, err)
+	}
+	if parsed.Scheme != "postgres" || parsed.Host != "localhost:49153" || parsed.Path != "/orders" {
+		t.Errorf("Unexpected connection string %q", connection)
+	}
+	if password, _ := parsed.User.Password(); parsed.User.Username() != "mock" || password != "p@ss:word/#" {
+		t.Errorf("Expected the credentials to round-trip, got %v", parsed.User)
+	}
+	if parsed.Query().Get("sslmode") != "disable" {
+		t.Errorf("Expected sslmode=disable, got %q", parsed.RawQuery)
+	}
+}
+
+func TestGetConnectionStringNotStarted(t *testing.T) {
+	manager := NewPostgresManager()
+	loggerPath := t.TempDir()
+	if err := manager.CreateServer(models.PostgresServer{Name: "orders", Port: 5441, Database: "orders", LoggerPath: &loggerPath}); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	if _, err := manager.GetConnectionString(5441); err == nil {
+		t.Error("Expected an error for a server that is not started")
+	}
+	if _, err := manager.GetConnectionString(5442); err == nil {
+		t.Error("Expected an error for an unknown port")
+	}
+	if connections := manager.ConnectionStrings(); len(connections) != 0 {
+		t.Errorf("Expected no connection strings before starting, got %v", connections)
+	}
+}
+
+func TestGetConnectionStringStarted(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	server := &Server{
+		Name:       "test-postgres-connection",
+		User:       "postgres",
+		Password:   "password",
+		Host:       "localhost",
+		Port:       5443,
+		Database:   "testdb",
+		LoggerPath: t.TempDir(),
+	}
+	log, err := logger.GetLoggerContext(models.LogDescriptor{
+		Name:   server.Name,
+		Path:   server.LoggerPath,
+		Logger: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to get logger: %v", err)
+	}
+	server.logger = log
+
+	container, err := server.Start()
+	if err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	server.PostgresContainer = container
+	defer server.Stop()
+
+	manager := NewPostgresManager()
+	manager.servers[server.Port] = server
+
+	connection, err := manager.GetConnectionString(server.Port)
+	if err != nil {
+		t.Fatalf("GetConnectionString failed: %v", err)
+	}
+	parsed, err := url.Parse(connection)
+	if err != nil {
+		t.Fatalf("Expected a valid URL, got %q: %v", connection, err)
+	}
+	if port, err := strconv.Atoi(parsed.Port()); err != nil || port == 0 || parsed.Path != "/testdb" {
+		t.Errorf("Expected the mapped port and database, got %q", connection)
+	}
+	if connections := manager.ConnectionStrings(); connections[server.Port] != connection {
+		t.Errorf("Expected ConnectionStrings to include %q, got %v", connection, connections)
+	}
+}
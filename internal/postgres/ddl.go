@@ -0,0 +1,85 @@
+package postgres_server
+
+import (
+	"catalyst/internal/models"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GenerateInitDDL builds the CREATE SCHEMA/CREATE TABLE statements for every seed that declares columns
+func GenerateInitDDL(seeds []models.Seed) (string, error) {
+	var ddl strings.Builder
+
+	for _, seed := range seeds {
+		if len(seed.Columns) == 0 {
+			continue
+		}
+		if seed.Table == "" {
+			return "", fmt.Errorf("seed with columns has no table")
+		}
+
+		schema := seed.Schema
+		if schema == "" {
+			schema = "public"
+		}
+
+		var definitions []string
+		var primaryKey []string
+		for _, column := range seed.Columns {
+			if column.Name == "" || column.Type == "" {
+				return "", fmt.Errorf("table %s.%s has a column without name or type", schema, seed.Table)
+			}
+			if strings.ContainsAny(column.Type, ";") {
+				return "", fmt.Errorf("invalid type %q for column %s", column.Type, column.Name)
+			}
+
+			definition := pgx.Identifier{column.Name}.Sanitize() + " " + column.Type
+			if !column.Nullable && !column.PrimaryKey {
+				definition += " NOT NULL"
+			}
+			definitions = append(definitions, definition)
+
+			if column.PrimaryKey {
+				primaryKey = append(primaryKey, pgx.Identifier{column.Name}.Sanitize())
+			}
+		}
+
+		if len(primaryKey) > 0 {
+			definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
+		}
+
+		fmt.Fprintf(&ddl, "CREATE SCHEMA IF NOT EXISTS %s;\n", pgx.Identifier{schema}.Sanitize())
+		fmt.Fprintf(&ddl, "CREATE TABLE IF NOT EXISTS %s (\n    %s\n);\n\n",
+			pgx.Identifier{schema, seed.Table}.Sanitize(),
+			strings.Join(definitions, ",\n    "))
+	}
+
+	return ddl.String(), nil
+}
+
+// writeInitDDL writes the generated DDL to a temp file and returns its path
+func writeInitDDL(seeds []models.Seed) (string, error) {
+	ddl, err := GenerateInitDDL(seeds)
+	if err != nil {
+		return "", err
+	}
+	if ddl == "" {
+		return "", fmt.Errorf("generate_init_script is set but no seed declares columns")
+	}
+
+	f, err := os.CreateTemp("", "catalyst-init-*.sql")
+	if err != nil {
+		return "", fmt.Errorf("failed to create init script: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(ddl); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write init script: %w", err)
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,101 @@
+package postgres_server
+
+import (
+	prom "catalyst/prometheus"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// healthCheckInterval es cada cuánto se consulta el estado del contenedor
+	healthCheckInterval = 5 * time.Second
+	// maxCrashRestarts es la cantidad de reintentos antes de dar el contenedor por caído
+	maxCrashRestarts = 3
+	// restartBackoff es la espera del primer reintento, se duplica en cada intento
+	restartBackoff = time.Second
+)
+
+// startHealthMonitor lanza la goroutine que reinicia el contenedor si se detiene inesperadamente
+func (s *Server) startHealthMonitor() {
+	if s.PostgresContainer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopMonitor = cancel
+	s.stopped.Store(false)
+
+	go s.monitorHealth(ctx)
+}
+
+func (s *Server) monitorHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if s.stopped.Load() {
+			return
+		}
+
+		state, err := s.PostgresContainer.State(ctx)
+		if err != nil {
+			s.logger.Error().Msg(fmt.Sprintf("Failed to get state of Postgres container: %v with Name: %s", err, s.Name))
+			continue
+		}
+
+		if !state.Running && !s.stopped.Load() {
+			s.logger.Error().Msg(fmt.Sprintf("Postgres container %s stopped unexpectedly with status %s (exit code %d)", s.Name, state.Status, state.ExitCode))
+			s.restartCrashed(ctx)
+		}
+	}
+}
+
+// restartCrashed intenta levantar de nuevo el contenedor con backoff exponencial
+func (s *Server) restartCrashed(ctx context.Context) {
+	if !s.isRestarting.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.isRestarting.Store(false)
+
+	backoff := restartBackoff
+	for attempt := 1; attempt <= maxCrashRestarts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		if s.stopped.Load() {
+			return
+		}
+
+		if err := s.PostgresContainer.Start(ctx); err != nil {
+			s.logger.Error().Msg(fmt.Sprintf("Failed to restart Postgres container: %v with Name: %s, retry %d/%d", err, s.Name, attempt, maxCrashRestarts))
+			continue
+		}
+
+		prom.PostgresCrashRestartsTotal.WithLabelValues(s.Name).Inc()
+		s.logger.Info().Msg(fmt.Sprintf("Postgres container %s restarted after crash, attempt %d/%d", s.Name, attempt, maxCrashRestarts))
+
+		// Sin un puerto fijo el contenedor puede recibir otro puerto al reiniciar
+		if s.proxy != nil {
+			upstream, err := containerAddress(ctx, s.PostgresContainer)
+			if err != nil {
+				s.logger.Error().Msg(fmt.Sprintf("Failed to update query proxy for %s: %v", s.Name, err))
+			} else {
+				s.proxy.setUpstream(upstream)
+			}
+		}
+		return
+	}
+
+	s.logger.Error().Msg(fmt.Sprintf("Giving up restarting Postgres container %s after %d attempts", s.Name, maxCrashRestarts))
+}
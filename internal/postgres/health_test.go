@@ -0,0 +1,75 @@
+package postgres_server
+
+import (
+	"catalyst/internal/logger"
+	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHealthMonitorRestartsCrashedContainer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	server := &Server{
+		Name:       "test-postgres-health",
+		User:       "postgres",
+		Password:   "password",
+		Host:       "localhost",
+		Port:       5440,
+		Database:   "testdb",
+		LoggerPath: t.TempDir(),
+	}
+
+	log, err := logger.GetLoggerContext(models.LogDescriptor{
+		Name:   server.Name,
+		Path:   server.LoggerPath,
+		Logger: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to get logger: %v", err)
+	}
+	server.logger = log
+
+	container, err := server.Start()
+	if err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	server.PostgresContainer = container
+	defer server.Stop()
+
+	server.startHealthMonitor()
+	restarts := testutil.ToFloat64(prom.PostgresCrashRestartsTotal.WithLabelValues(server.Name))
+
+	// Stopping the container behind the server's back simulates a crash
+	ctx := context.Background()
+	timeout := time.Duration(0)
+	if err := container.Stop(ctx, &timeout); err != nil {
+		t.Fatalf("Failed to kill container: %v", err)
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		if testutil.ToFloat64(prom.PostgresCrashRestartsTotal.WithLabelValues(server.Name)) > restarts {
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	if got := testutil.ToFloat64(prom.PostgresCrashRestartsTotal.WithLabelValues(server.Name)); got != restarts+1 {
+		t.Fatalf("Expected one crash restart, counter went from %v to %v", restarts, got)
+	}
+
+	state, err := container.State(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	if !state.Running {
+		t.Errorf("Expected container to be running after restart, status %s", state.Status)
+	}
+}
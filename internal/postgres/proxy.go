@@ -0,0 +1,470 @@
+package postgres_server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/SOLUCIONESSYCOM/scribe"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+// Códigos de los mensajes de arranque que no llevan byte de tipo
+const (
+	cancelRequestCode = 80877102
+	sslRequestCode    = 80877103
+	gssEncRequestCode = 80877104
+)
+
+var parameterRe = regexp.MustCompile(`\$(\d+)`)
+
+// queryProxy escucha en el puerto configurado del servidor y reenvía el protocolo
+// de Postgres al contenedor, respondiendo localmente las queries que tienen un stub
+type queryProxy struct {
+	listener net.Listener
+	upstream string
+	stubs    []*queryStub
+	logger   *scribe.Scribe
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+func newQueryProxy(port int, upstream string, stubs []*queryStub, logger *scribe.Scribe) (*queryProxy, error) {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+
+	p := &queryProxy{
+		listener: listener,
+		upstream: upstream,
+		stubs:    stubs,
+		logger:   logger,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.serve()
+
+	return p, nil
+}
+
+func (p *queryProxy) serve() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.handle(conn)
+		}()
+	}
+}
+
+// Close deja de aceptar conexiones y cierra las que siguen abiertas
+func (p *queryProxy) Close() error {
+	err := p.listener.Close()
+
+	p.mu.Lock()
+	for conn := range p.conns {
+		conn.Close()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return err
+}
+
+// setUpstream cambia el destino de las conexiones nuevas, por ejemplo tras reiniciar el contenedor
+func (p *queryProxy) setUpstream(address string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.upstream = address
+}
+
+func (p *queryProxy) upstreamAddress() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.upstream
+}
+
+func (p *queryProxy) track(conn net.Conn, add bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if add {
+		p.conns[conn] = struct{}{}
+	} else {
+		delete(p.conns, conn)
+	}
+}
+
+func (p *queryProxy) handle(client net.Conn) {
+	p.track(client, true)
+	defer p.track(client, false)
+	defer client.Close()
+
+	reader := bufio.NewReader(client)
+
+	var startup []byte
+	for {
+		msg, err := readStartupMessage(reader)
+		if err != nil {
+			return
+		}
+
+		code := binary.BigEndian.Uint32(msg[4:8])
+		if code == sslRequestCode || code == gssEncRequestCode {
+			// El proxy no soporta cifrado, el cliente sigue en texto plano
+			if _, err := client.Write([]byte{'N'}); err != nil {
+				return
+			}
+			continue
+		}
+
+		startup = msg
+		break
+	}
+
+	address := p.upstreamAddress()
+	upstream, err := net.Dial("tcp", address)
+	if err != nil {
+		p.logger.Error().Msg(fmt.Sprintf("Query proxy failed to connect to %s: %v", address, err))
+		return
+	}
+	p.track(upstream, true)
+	defer p.track(upstream, false)
+	defer upstream.Close()
+
+	if _, err := upstream.Write(startup); err != nil {
+		return
+	}
+
+	// CancelRequest se reenvía tal cual y el servidor cierra la conexión
+	if binary.BigEndian.Uint32(startup[4:8]) == cancelRequestCode {
+		return
+	}
+
+	session := &proxySession{
+		proxy:    p,
+		client:   client,
+		upstream: upstream,
+		stmts:    make(map[string]*stubStatement),
+		portals:  make(map[string]*stubPortal),
+	}
+	session.txStatus.Store('I')
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		session.relayUpstream(bufio.NewReader(upstream))
+		client.Close()
+	}()
+
+	session.relayClient(reader)
+	upstream.Close()
+	<-done
+}
+
+type stubStatement struct {
+	stub      *queryStub
+	query     string
+	paramOIDs []uint32
+}
+
+type stubPortal struct {
+	stub    *queryStub
+	formats []int16
+}
+
+// proxySession mantiene el estado del protocolo extendido de una conexión
+type proxySession struct {
+	proxy    *queryProxy
+	client   net.Conn
+	upstream net.Conn
+	writeMu  sync.Mutex
+
+	// Último estado de transacción informado por el contenedor en ReadyForQuery
+	txStatus atomic.Uint32
+
+	stmts   map[string]*stubStatement
+	portals map[string]*stubPortal
+
+	// forwarded indica si en el ciclo actual (hasta Sync) se reenvió algún mensaje.
+	// Los stubs se responden al momento, por lo que un ciclo que mezcla statements
+	// con stub y sin stub puede recibir las respuestas fuera de orden.
+	forwarded bool
+	// failed descarta los mensajes hasta el siguiente Sync tras un error local
+	failed bool
+}
+
+// relayUpstream copia los mensajes del contenedor al cliente
+func (s *proxySession) relayUpstream(reader *bufio.Reader) {
+	for {
+		typ, body, err := readMessage(reader)
+		if err != nil {
+			return
+		}
+		if typ == 'Z' && len(body) > 0 {
+			s.txStatus.Store(uint32(body[0]))
+		}
+		if err := s.writeClient(encodeMessage(typ, body)); err != nil {
+			return
+		}
+	}
+}
+
+// relayClient procesa los mensajes del cliente, respondiendo los stubs y reenviando el resto
+func (s *proxySession) relayClient(reader *bufio.Reader) {
+	for {
+		typ, body, err := readMessage(reader)
+		if err != nil {
+			return
+		}
+
+		if err := s.handleMessage(typ, body); err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.proxy.logger.Error().Msg(fmt.Sprintf("Query proxy error: %v", err))
+			}
+			return
+		}
+
+		if typ == 'X' {
+			return
+		}
+	}
+}
+
+func (s *proxySession) handleMessage(typ byte, body []byte) error {
+	if s.failed && typ != 'S' {
+		return nil
+	}
+
+	switch typ {
+	case 'Q':
+		var msg pgproto3.Query
+		if err := msg.Decode(body); err != nil {
+			return err
+		}
+		if stub := matchQueryStub(s.proxy.stubs, msg.String); stub != nil {
+			s.logStub(msg.String)
+			return s.send(append(s.rows(stub, nil), &pgproto3.ReadyForQuery{TxStatus: s.status()})...)
+		}
+
+	case 'P':
+		var msg pgproto3.Parse
+		if err := msg.Decode(body); err != nil {
+			return err
+		}
+		if stub := matchQueryStub(s.proxy.stubs, msg.Query); stub != nil {
+			s.logStub(msg.Query)
+			s.stmts[msg.Name] = &stubStatement{stub: stub, query: msg.Query, paramOIDs: msg.ParameterOIDs}
+			return s.send(&pgproto3.ParseComplete{})
+		}
+		delete(s.stmts, msg.Name)
+
+	case 'B':
+		var msg pgproto3.Bind
+		if err := msg.Decode(body); err != nil {
+			return err
+		}
+		if stmt, ok := s.stmts[msg.PreparedStatement]; ok {
+			s.portals[msg.DestinationPortal] = &stubPortal{stub: stmt.stub, formats: msg.ResultFormatCodes}
+			return s.send(&pgproto3.BindComplete{})
+		}
+		delete(s.portals, msg.DestinationPortal)
+
+	case 'D':
+		var msg pgproto3.Describe
+		if err := msg.Decode(body); err != nil {
+			return err
+		}
+		if msg.ObjectType == 'S' {
+			if stmt, ok := s.stmts[msg.Name]; ok {
+				return s.send(
+					&pgproto3.ParameterDescription{ParameterOIDs: stmt.parameterOIDs()},
+					rowDescription(stmt.stub, nil),
+				)
+			}
+		} else if portal, ok := s.portals[msg.Name]; ok {
+			return s.send(rowDescription(portal.stub, portal.formats))
+		}
+
+	case 'E':
+		var msg pgproto3.Execute
+		if err := msg.Decode(body); err != nil {
+			return err
+		}
+		if portal, ok := s.portals[msg.Portal]; ok {
+			// La descripción ya se envió con Describe, solo van las filas
+			return s.send(s.rows(portal.stub, portal.formats)[1:]...)
+		}
+
+	case 'C':
+		var msg pgproto3.Close
+		if err := msg.Decode(body); err != nil {
+			return err
+		}
+		if msg.ObjectType == 'S' {
+			if _, ok := s.stmts[msg.Name]; ok {
+				delete(s.stmts, msg.Name)
+				return s.send(&pgproto3.CloseComplete{})
+			}
+		} else if _, ok := s.portals[msg.Name]; ok {
+			delete(s.portals, msg.Name)
+			return s.send(&pgproto3.CloseComplete{})
+		}
+
+	case 'S':
+		s.failed = false
+		if !s.forwarded {
+			return s.send(&pgproto3.ReadyForQuery{TxStatus: s.status()})
+		}
+		s.forwarded = false
+
+	case 'H':
+		// Las respuestas de los stubs ya se enviaron, solo se reenvía si el contenedor tiene pendientes
+		if !s.forwarded {
+			return nil
+		}
+	}
+
+	switch typ {
+	case 'P', 'B', 'D', 'E', 'C', 'H':
+		s.forwarded = true
+	}
+	_, err := s.upstream.Write(encodeMessage(typ, body))
+	return err
+}
+
+// rows construye RowDescription, DataRow y CommandComplete para un stub
+func (s *proxySession) rows(stub *queryStub, formats []int16) []pgproto3.BackendMessage {
+	msgs := []pgproto3.BackendMessage{rowDescription(stub, formats)}
+	for _, row := range stub.rows {
+		values := make([][]byte, len(row))
+		for i, value := range row {
+			values[i] = stub.columns[i].encode(value, resultFormat(formats, i))
+		}
+		msgs = append(msgs, &pgproto3.DataRow{Values: values})
+	}
+	return append(msgs, &pgproto3.CommandComplete{CommandTag: []byte(fmt.Sprintf("SELECT %d", len(stub.rows)))})
+}
+
+func rowDescription(stub *queryStub, formats []int16) *pgproto3.RowDescription {
+	fields := make([]pgproto3.FieldDescription, len(stub.columns))
+	for i, column := range stub.columns {
+		fields[i] = pgproto3.FieldDescription{
+			Name:         []byte(column.name),
+			DataTypeOID:  column.oid,
+			DataTypeSize: column.typeSize(),
+			TypeModifier: -1,
+			Format:       resultFormat(formats, i),
+		}
+	}
+	return &pgproto3.RowDescription{Fields: fields}
+}
+
+// parameterOIDs completa como text los parámetros que el cliente no tipó en Parse
+func (st *stubStatement) parameterOIDs() []uint32 {
+	count := len(st.paramOIDs)
+	for _, match := range parameterRe.FindAllStringSubmatch(st.query, -1) {
+		if n, err := strconv.Atoi(match[1]); err == nil && n > count {
+			count = n
+		}
+	}
+
+	oids := make([]uint32, count)
+	for i := range oids {
+		if i < len(st.paramOIDs) && st.paramOIDs[i] != 0 {
+			oids[i] = st.paramOIDs[i]
+		} else {
+			oids[i] = textOID
+		}
+	}
+	return oids
+}
+
+func (s *proxySession) status() byte {
+	return byte(s.txStatus.Load())
+}
+
+func (s *proxySession) logStub(query string) {
+	s.proxy.logger.Info().Msg(fmt.Sprintf("Query answered by stub: %s", query))
+}
+
+func (s *proxySession) send(msgs ...pgproto3.BackendMessage) error {
+	var buf []byte
+	var err error
+	for _, msg := range msgs {
+		if buf, err = msg.Encode(buf); err != nil {
+			s.failed = true
+			return s.send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "XX000", Message: err.Error()})
+		}
+	}
+	return s.writeClient(buf)
+}
+
+func (s *proxySession) writeClient(data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.client.Write(data)
+	return err
+}
+
+// readStartupMessage lee un mensaje de arranque completo, incluida su longitud
+func readStartupMessage(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	size := int(binary.BigEndian.Uint32(header))
+	if size < 8 || size > 10000 {
+		return nil, fmt.Errorf("invalid startup message length %d", size)
+	}
+
+	msg := make([]byte, size)
+	copy(msg, header)
+	if _, err := io.ReadFull(r, msg[4:]); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// readMessage lee un mensaje normal del protocolo: tipo, longitud y cuerpo
+func readMessage(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	size := int(binary.BigEndian.Uint32(header[1:]))
+	if size < 4 {
+		return 0, nil, fmt.Errorf("invalid message length %d", size)
+	}
+
+	body := make([]byte, size-4)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+func encodeMessage(typ byte, body []byte) []byte {
+	msg := make([]byte, 5, 5+len(body))
+	msg[0] = typ
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(body)+4))
+	return append(msg, body...)
+}
@@ -0,0 +1,75 @@
+package seeder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// csvFixture holds the rows of a seed CSV file; the first row holds the column names
+type csvFixture struct {
+	columns []string
+	rows    [][]string
+}
+
+// loadCSVFixture reads a CSV fixture file
+func loadCSVFixture(path string) (*csvFixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open csv file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv file %s: %w", path, err)
+	}
+
+	if len(records) < 2 {
+		return nil, fmt.Errorf("csv file %s needs a header row and at least one data row", path)
+	}
+
+	columns := make([]string, len(records[0]))
+	for i, column := range records[0] {
+		columns[i] = strings.TrimSpace(column)
+	}
+
+	return &csvFixture{
+		columns: columns,
+		rows:    records[1:],
+	}, nil
+}
+
+// dropUnknownColumns removes the CSV columns that don't exist in the table and returns them
+func (f *csvFixture) dropUnknownColumns(tableColumns []ColumnInfo) []string {
+	known := make(map[string]bool, len(tableColumns))
+	for _, column := range tableColumns {
+		known[column.Name] = true
+	}
+
+	var unknown []string
+	for i, column := range f.columns {
+		if !known[column] {
+			unknown = append(unknown, column)
+			f.columns[i] = ""
+		}
+	}
+
+	return unknown
+}
+
+// row returns the values of the i-th seeded row by column name, cycling through the CSV rows
+func (f *csvFixture) row(i int) map[string]string {
+	record := f.rows[i%len(f.rows)]
+
+	values := make(map[string]string, len(f.columns))
+	for j, column := range f.columns {
+		if column == "" || j >= len(record) {
+			continue
+		}
+		values[column] = record[j]
+	}
+
+	return values
+}
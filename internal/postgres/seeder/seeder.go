@@ -18,9 +18,12 @@ import (
 
 // ColumnInfo represents the metadata for a database column
 type ColumnInfo struct {
-	Name       string
-	DataType   string
-	IsNullable bool
+	Name        string
+	DataType    string
+	IsNullable  bool
+	UDTName     string   // Underlying type name, e.g. the enum name or _int4 for int[]
+	EnumValues  []string // Labels from pg_enum when the column is an enum
+	Constraints []string // CHECK clauses on the column or its domain
 }
 
 // MigrationService handles database seeding operations
@@ -158,6 +161,13 @@ func RandomUUID() string {
 	return id.UUID
 }
 
+// RandomTime generates a random time within the last ten years. faker's date and timestamp tags
+// produce strings, so they can't fill a time.Time.
+func RandomTime() time.Time {
+	const span = 10 * 365 * 24 * time.Hour
+	return time.Now().Add(-time.Duration(rand.Int63n(int64(span)))).Truncate(time.Second)
+}
+
 // GenerateFakeValue generates a fake value based on the column data type
 func (m *MigrationService) GenerateFakeValue(column ColumnInfo) string {
 	// Handle NULL values for nullable columns (randomly make ~10% of values NULL)
@@ -165,8 +175,31 @@ func (m *MigrationService) GenerateFakeValue(column ColumnInfo) string {
 		return "NULL"
 	}
 
+	// Enum columns and columns restricted by a CHECK (... IN (...)) get a valid member
+	if values := column.allowedValues(); len(values) > 0 {
+		value := values[rand.Intn(len(values))]
+		return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", "''"))
+	}
+
 	// Convert data type to lowercase for easier comparison
 	dataType := strings.ToLower(column.DataType)
+	if dataType == "user-defined" && column.UDTName != "" {
+		dataType = strings.ToLower(column.UDTName)
+	}
+
+	// Types whose names would otherwise match the generic patterns below (e.g. interval -> int)
+	switch {
+	case dataType == "array" || strings.HasSuffix(dataType, "[]"):
+		return m.fakeArray(column)
+	case strings.Contains(dataType, "bytea"):
+		return fakeBytea()
+	case strings.Contains(dataType, "money"):
+		return fakeMoney()
+	case strings.Contains(dataType, "interval"):
+		return fakeInterval()
+	case strings.Contains(dataType, "hstore"):
+		return fakeHstore()
+	}
 
 	switch {
 	case strings.Contains(dataType, "int"):
@@ -197,18 +230,10 @@ func (m *MigrationService) GenerateFakeValue(column ColumnInfo) string {
 		}
 		return "FALSE"
 	case strings.Contains(dataType, "date"):
-		var d struct {
-			Date time.Time `faker:"date"`
-		}
-		_ = faker.FakeData(&d)
-		return fmt.Sprintf("'%s'", d.Date.Format("2006-01-02"))
+		return fmt.Sprintf("'%s'", RandomTime().Format("2006-01-02"))
 	case strings.Contains(dataType, "time"):
 		if strings.Contains(dataType, "timestamp") {
-			var ts struct {
-				Timestamp time.Time `faker:"timestamp"`
-			}
-			_ = faker.FakeData(&ts)
-			return fmt.Sprintf("'%s'", ts.Timestamp.Format("2006-01-02 15:04:05"))
+			return fmt.Sprintf("'%s'", RandomTime().Format("2006-01-02 15:04:05"))
 		}
 
 		var t struct {
@@ -255,6 +280,44 @@ func (m *MigrationService) GenerateFakeValue(column ColumnInfo) string {
 	}
 }
 
+// predefinedColumns are the columns of common tables, used until the actual table is introspected
+var predefinedColumns = map[string][]ColumnInfo{
+	"users": {
+		{Name: "id", DataType: "serial", IsNullable: false},
+		{Name: "username", DataType: "varchar", IsNullable: false},
+		{Name: "email", DataType: "varchar", IsNullable: false},
+		{Name: "password", DataType: "varchar", IsNullable: false},
+		{Name: "created_at", DataType: "timestamp", IsNullable: false},
+		{Name: "updated_at", DataType: "timestamp", IsNullable: true},
+	},
+	"products": {
+		{Name: "id", DataType: "serial", IsNullable: false},
+		{Name: "name", DataType: "varchar", IsNullable: false},
+		{Name: "description", DataType: "text", IsNullable: true},
+		{Name: "price", DataType: "numeric", IsNullable: false},
+		{Name: "stock", DataType: "int", IsNullable: false},
+		{Name: "created_at", DataType: "timestamp", IsNullable: false},
+		{Name: "updated_at", DataType: "timestamp", IsNullable: true},
+	},
+	"orders": {
+		{Name: "id", DataType: "serial", IsNullable: false},
+		{Name: "user_id", DataType: "int", IsNullable: false},
+		{Name: "status", DataType: "varchar", IsNullable: false},
+		{Name: "total", DataType: "numeric", IsNullable: false},
+		{Name: "created_at", DataType: "timestamp", IsNullable: false},
+		{Name: "updated_at", DataType: "timestamp", IsNullable: true},
+	},
+}
+
+// defaultColumns are used for the tables without predefined columns
+var defaultColumns = []ColumnInfo{
+	{Name: "id", DataType: "serial", IsNullable: false},
+	{Name: "name", DataType: "varchar", IsNullable: false},
+	{Name: "description", DataType: "text", IsNullable: true},
+	{Name: "created_at", DataType: "timestamp", IsNullable: false},
+	{Name: "updated_at", DataType: "timestamp", IsNullable: true},
+}
+
 // Migrate inserts seed data directly into the database using pgx
 func (m *MigrationService) Migrate(ctx context.Context, seed models.Seed) error {
 	m.Logger.Info().Msg(fmt.Sprintf("Starting migration for table %s.%s with %d rows", seed.Schema, seed.Table, seed.Rows))
@@ -264,71 +327,23 @@ func (m *MigrationService) Migrate(ctx context.Context, seed models.Seed) error
 		return fmt.Errorf("postgres container is not initialized")
 	}
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", m.Server.User, m.Server.Password, m.Server.Host, m.Server.Port, m.Server.Database, "sslmode=disable")
-
-	// Connect to the database
-	config, err := pgxpool.ParseConfig(connStr)
+	pool, err := m.connect(ctx)
 	if err != nil {
-		m.Logger.Error().Msg(fmt.Sprintf("Failed to parse connection string: %v", err))
-		return err
-	}
-
-	pool, err := pgxpool.NewWithConfig(ctx, config)
-	if err != nil {
-		m.Logger.Error().Msg(fmt.Sprintf("Failed to connect to database: %v", err))
+		m.Logger.Error().Msg(err.Error())
 		return err
 	}
 	defer pool.Close()
 
 	// Create a map of column overrides for quick lookup
-	overrides := make(map[string]string)
-	for _, override := range seed.Overrides {
-		overrides[override.Column] = override.Value
-	}
-
-	// Define a set of common column types for introspection
-	columnTypes := map[string][]ColumnInfo{
-		"users": {
-			{Name: "id", DataType: "serial", IsNullable: false},
-			{Name: "username", DataType: "varchar", IsNullable: false},
-			{Name: "email", DataType: "varchar", IsNullable: false},
-			{Name: "password", DataType: "varchar", IsNullable: false},
-			{Name: "created_at", DataType: "timestamp", IsNullable: false},
-			{Name: "updated_at", DataType: "timestamp", IsNullable: true},
-		},
-		"products": {
-			{Name: "id", DataType: "serial", IsNullable: false},
-			{Name: "name", DataType: "varchar", IsNullable: false},
-			{Name: "description", DataType: "text", IsNullable: true},
-			{Name: "price", DataType: "numeric", IsNullable: false},
-			{Name: "stock", DataType: "int", IsNullable: false},
-			{Name: "created_at", DataType: "timestamp", IsNullable: false},
-			{Name: "updated_at", DataType: "timestamp", IsNullable: true},
-		},
-		"orders": {
-			{Name: "id", DataType: "serial", IsNullable: false},
-			{Name: "user_id", DataType: "int", IsNullable: false},
-			{Name: "status", DataType: "varchar", IsNullable: false},
-			{Name: "total", DataType: "numeric", IsNullable: false},
-			{Name: "created_at", DataType: "timestamp", IsNullable: false},
-			{Name: "updated_at", DataType: "timestamp", IsNullable: true},
-		},
-	}
+	overrides := overrideValues(seed)
 
 	// Use predefined columns if available, otherwise use a default set
 	var columns []ColumnInfo
-	if predefinedColumns, exists := columnTypes[strings.ToLower(seed.Table)]; exists {
-		columns = predefinedColumns
+	if predefined, exists := predefinedColumns[strings.ToLower(seed.Table)]; exists {
+		columns = predefined
 		m.Logger.Info().Msg(fmt.Sprintf("Using predefined columns for table %s", seed.Table))
 	} else {
-		// Default columns if table is not recognized
-		columns = []ColumnInfo{
-			{Name: "id", DataType: "serial", IsNullable: false},
-			{Name: "name", DataType: "varchar", IsNullable: false},
-			{Name: "description", DataType: "text", IsNullable: true},
-			{Name: "created_at", DataType: "timestamp", IsNullable: false},
-			{Name: "updated_at", DataType: "timestamp", IsNullable: true},
-		}
+		columns = defaultColumns
 		m.Logger.Info().Msg(fmt.Sprintf("Using default columns for table %s", seed.Table))
 	}
 
@@ -379,35 +394,29 @@ func (m *MigrationService) Migrate(ctx context.Context, seed models.Seed) error
 		}
 	}
 
-	// Get actual columns from the database
-	rows, err := pool.Query(ctx, `
-		SELECT column_name, data_type, is_nullable 
-		FROM information_schema.columns 
-		WHERE table_schema = $1 AND table_name = $2
-	`, seed.Schema, seed.Table)
-
+	// Replace predefined columns with actual columns from the database
+	dbColumns, err := m.tableColumns(ctx, pool, seed.Schema, seed.Table)
 	if err != nil {
-		m.Logger.Error().Msg(fmt.Sprintf("Failed to get columns for table %s.%s: %v", seed.Schema, seed.Table, err))
+		m.Logger.Error().Msg(err.Error())
 		return err
 	}
-	defer rows.Close()
+	if len(dbColumns) > 0 {
+		columns = dbColumns
+		m.Logger.Info().Msg(fmt.Sprintf("Using actual columns from database for table %s.%s", seed.Schema, seed.Table))
+	}
 
-	// Replace predefined columns with actual columns from the database
-	dbColumns := []ColumnInfo{}
-	for rows.Next() {
-		var col ColumnInfo
-		var isNullable string
-		if err := rows.Scan(&col.Name, &col.DataType, &isNullable); err != nil {
-			m.Logger.Error().Msg(fmt.Sprintf("Failed to scan column info: %v", err))
+	// Load fixture rows from the CSV file, if configured
+	var fixture *csvFixture
+	if seed.CSVFile != "" {
+		fixture, err = loadCSVFixture(seed.CSVFile)
+		if err != nil {
+			m.Logger.Error().Msg(fmt.Sprintf("Failed to load csv file for table %s.%s: %v", seed.Schema, seed.Table, err))
 			return err
 		}
-		col.IsNullable = isNullable == "YES"
-		dbColumns = append(dbColumns, col)
-	}
 
-	if len(dbColumns) > 0 {
-		columns = dbColumns
-		m.Logger.Info().Msg(fmt.Sprintf("Using actual columns from database for table %s.%s", seed.Schema, seed.Table))
+		for _, column := range fixture.dropUnknownColumns(columns) {
+			m.Logger.Warn().Msg(fmt.Sprintf("CSV column %s does not exist in table %s.%s, skipping it", column, seed.Schema, seed.Table))
+		}
 	}
 
 	// Start a transaction for batch inserts
@@ -427,51 +436,18 @@ func (m *MigrationService) Migrate(ctx context.Context, seed models.Seed) error
 
 	// Generate insert statements
 	for i := 0; i < seed.Rows; i++ {
-		var columnNames []string
-		var placeholders []string
-		var values []interface{}
-
-		for _, col := range columns {
-			// Skip serial columns as they are auto-generated
-			if strings.Contains(strings.ToLower(col.DataType), "serial") {
-				continue
-			}
-
-			columnNames = append(columnNames, col.Name)
-			placeholders = append(placeholders, fmt.Sprintf("$%d", len(placeholders)+1))
-
-			// Check if there's an override for this column
-			if val, exists := overrides[col.Name]; exists {
-				values = append(values, val)
-			} else {
-				// Generate fake data based on column type
-				fakeValue := m.GenerateFakeValue(col)
-
-				// Remove quotes for SQL parameters
-				if strings.HasPrefix(fakeValue, "'") && strings.HasSuffix(fakeValue, "'") {
-					fakeValue = fakeValue[1 : len(fakeValue)-1]
-				}
-
-				// Handle NULL values
-				if fakeValue == "NULL" {
-					values = append(values, nil)
-				} else {
-					values = append(values, fakeValue)
-				}
-			}
-		}
+		columnNames, values := m.rowValues(columns, overrides, fixture, i)
 
 		// Skip if no columns to insert
 		if len(columnNames) == 0 {
 			continue
 		}
 
-		query := fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES (%s)",
-			seed.Schema, seed.Table,
-			strings.Join(columnNames, ", "),
-			strings.Join(placeholders, ", "))
-
-		batch.Queue(query, values...)
+		placeholders := make([]string, len(values))
+		for j := range values {
+			placeholders[j] = fmt.Sprintf("$%d", j+1)
+		}
+		batch.Queue(insertQuery(seed, columnNames, placeholders), values...)
 
 		// Execute batch every 100 rows to avoid large transactions
 		if i > 0 && i%100 == 0 {
@@ -502,3 +478,191 @@ func (m *MigrationService) Migrate(ctx context.Context, seed models.Seed) error
 	m.Logger.Info().Msg(fmt.Sprintf("Successfully migrated table %s.%s with %d rows", seed.Schema, seed.Table, seed.Rows))
 	return nil
 }
+
+// DryRun returns the INSERT statements Migrate would run for the seed, with their values inlined,
+// without executing anything. With a postgres container the columns are read from the table, which
+// must exist; otherwise the seed columns, or the predefined ones, are used.
+func (m *MigrationService) DryRun(ctx context.Context, seed models.Seed) ([]string, error) {
+	columns := seedColumns(seed)
+	if m.PostgresContainer != nil {
+		pool, err := m.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer pool.Close()
+
+		dbColumns, err := m.tableColumns(ctx, pool, seed.Schema, seed.Table)
+		if err != nil {
+			return nil, err
+		}
+		if len(dbColumns) == 0 {
+			return nil, fmt.Errorf("table %s.%s does not exist", seed.Schema, seed.Table)
+		}
+		columns = dbColumns
+	}
+
+	var fixture *csvFixture
+	if seed.CSVFile != "" {
+		var err error
+		if fixture, err = loadCSVFixture(seed.CSVFile); err != nil {
+			return nil, err
+		}
+		fixture.dropUnknownColumns(columns)
+	}
+
+	overrides := overrideValues(seed)
+	statements := make([]string, 0, seed.Rows)
+	for i := 0; i < seed.Rows; i++ {
+		columnNames, values := m.rowValues(columns, overrides, fixture, i)
+		if len(columnNames) == 0 {
+			continue
+		}
+
+		literals := make([]string, len(values))
+		for j, value := range values {
+			literals[j] = sqlLiteral(value)
+		}
+		statements = append(statements, insertQuery(seed, columnNames, literals))
+	}
+	return statements, nil
+}
+
+// connect opens a pool to the seeded database
+func (m *MigrationService) connect(ctx context.Context) (*pgxpool.Pool, error) {
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", m.Server.User, m.Server.Password, m.Server.Host, m.Server.Port, m.Server.Database, "sslmode=disable")
+
+	config, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return pool, nil
+}
+
+// tableColumns reads the columns of a table with their enum labels and CHECK clauses. A missing
+// table has no columns.
+func (m *MigrationService) tableColumns(ctx context.Context, pool *pgxpool.Pool, schema, table string) ([]ColumnInfo, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT column_name, data_type, is_nullable, udt_name
+		FROM information_schema.columns 
+		WHERE table_schema = $1 AND table_name = $2
+	`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns for table %s.%s: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	columns := []ColumnInfo{}
+	for rows.Next() {
+		var col ColumnInfo
+		var isNullable string
+		if err := rows.Scan(&col.Name, &col.DataType, &isNullable, &col.UDTName); err != nil {
+			return nil, fmt.Errorf("failed to scan column info: %w", err)
+		}
+		col.IsNullable = isNullable == "YES"
+		columns = append(columns, col)
+	}
+	rows.Close()
+
+	if len(columns) > 0 {
+		if err := m.introspectColumns(ctx, pool, schema, table, columns); err != nil {
+			return nil, fmt.Errorf("failed to introspect columns for table %s.%s: %w", schema, table, err)
+		}
+	}
+	return columns, nil
+}
+
+// seedColumns returns the columns of the seed table without introspecting it: the seed columns,
+// the predefined columns of common tables or the default ones
+func seedColumns(seed models.Seed) []ColumnInfo {
+	if len(seed.Columns) > 0 {
+		columns := make([]ColumnInfo, len(seed.Columns))
+		for i, column := range seed.Columns {
+			columns[i] = ColumnInfo{Name: column.Name, DataType: column.Type, IsNullable: column.Nullable}
+		}
+		return columns
+	}
+	if predefined, exists := predefinedColumns[strings.ToLower(seed.Table)]; exists {
+		return predefined
+	}
+	return defaultColumns
+}
+
+// overrideValues maps each overridden column to its value
+func overrideValues(seed models.Seed) map[string]string {
+	overrides := make(map[string]string)
+	for _, override := range seed.Overrides {
+		overrides[override.Column] = override.Value
+	}
+	return overrides
+}
+
+// rowValues returns the columns and values of the i-th seeded row, taken from the overrides, the CSV
+// fixture or generated. Serial columns are left to the database.
+func (m *MigrationService) rowValues(columns []ColumnInfo, overrides map[string]string, fixture *csvFixture, i int) ([]string, []interface{}) {
+	var columnNames []string
+	var values []interface{}
+
+	var fixtureRow map[string]string
+	if fixture != nil {
+		fixtureRow = fixture.row(i)
+	}
+
+	for _, col := range columns {
+		// Skip serial columns as they are auto-generated
+		if strings.Contains(strings.ToLower(col.DataType), "serial") {
+			continue
+		}
+
+		columnNames = append(columnNames, col.Name)
+
+		// Check if there's an override for this column
+		if val, exists := overrides[col.Name]; exists {
+			values = append(values, val)
+		} else if val, exists := fixtureRow[col.Name]; exists {
+			// Empty CSV cells are inserted as NULL
+			if val == "" {
+				values = append(values, nil)
+			} else {
+				values = append(values, val)
+			}
+		} else {
+			// Generate fake data based on column type
+			fakeValue := m.GenerateFakeValue(col)
+
+			// Remove quotes for SQL parameters
+			if strings.HasPrefix(fakeValue, "'") && strings.HasSuffix(fakeValue, "'") {
+				fakeValue = fakeValue[1 : len(fakeValue)-1]
+			}
+
+			// Handle NULL values
+			if fakeValue == "NULL" {
+				values = append(values, nil)
+			} else {
+				values = append(values, fakeValue)
+			}
+		}
+	}
+
+	return columnNames, values
+}
+
+// insertQuery builds the INSERT of a row from its column names and value expressions
+func insertQuery(seed models.Seed, columnNames, values []string) string {
+	return fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES (%s)",
+		seed.Schema, seed.Table,
+		strings.Join(columnNames, ", "),
+		strings.Join(values, ", "))
+}
+
+// sqlLiteral renders a query parameter as a SQL literal
+func sqlLiteral(value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+	return "'" + strings.ReplaceAll(fmt.Sprint(value), "'", "''") + "'"
+}
@@ -0,0 +1,265 @@
+package seeder
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"catalyst/internal/models"
+)
+
+func TestGenerateFakeValueTypes(t *testing.T) {
+	m := &MigrationService{}
+
+	tests := []struct {
+		name    string
+		column  ColumnInfo
+		pattern string
+	}{
+		{
+			name:    "Money",
+			column:  ColumnInfo{Name: "amount", DataType: "money"},
+			pattern: `^'\d+\.\d{2}'$`,
+		},
+		{
+			name:    "Interval",
+			column:  ColumnInfo{Name: "duration", DataType: "interval"},
+			pattern: `^'\d+ days \d+ hours \d+ minutes'$`,
+		},
+		{
+			name:    "Hstore",
+			column:  ColumnInfo{Name: "attrs", DataType: "USER-DEFINED", UDTName: "hstore"},
+			pattern: `^'"[a-zA-Z]+"=>"[a-zA-Z]+"'$`,
+		},
+		{
+			name:    "Date",
+			column:  ColumnInfo{Name: "birth_date", DataType: "date"},
+			pattern: `^'\d{4}-\d{2}-\d{2}'$`,
+		},
+		{
+			name:    "Timestamp",
+			column:  ColumnInfo{Name: "created_at", DataType: "timestamp without time zone"},
+			pattern: `^'\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}'$`,
+		},
+		{
+			name:    "Integer array",
+			column:  ColumnInfo{Name: "scores", DataType: "ARRAY", UDTName: "_int4"},
+			pattern: `^'\{"-?\d+"(,"-?\d+"){0,2}\}'$`,
+		},
+		{
+			name:    "Text array",
+			column:  ColumnInfo{Name: "tags", DataType: "ARRAY", UDTName: "_text"},
+			pattern: `^'\{".*"\}'$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := regexp.MustCompile(tt.pattern)
+			for i := 0; i < 20; i++ {
+				value := m.GenerateFakeValue(tt.column)
+				if !re.MatchString(value) {
+					t.Fatalf("Value %q does not match %s", value, tt.pattern)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateFakeValueBytea(t *testing.T) {
+	m := &MigrationService{}
+
+	value := m.GenerateFakeValue(ColumnInfo{Name: "payload", DataType: "bytea"})
+	if !strings.HasPrefix(value, `'\x`) || !strings.HasSuffix(value, "'") {
+		t.Fatalf("Expected hex bytea literal, got %q", value)
+	}
+
+	data, err := hex.DecodeString(value[3 : len(value)-1])
+	if err != nil {
+		t.Fatalf("Invalid hex in %q: %v", value, err)
+	}
+	if len(data) == 0 {
+		t.Error("Expected random bytes")
+	}
+}
+
+func TestGenerateFakeValueEnum(t *testing.T) {
+	m := &MigrationService{}
+	column := ColumnInfo{
+		Name:       "status",
+		DataType:   "USER-DEFINED",
+		UDTName:    "order_status",
+		EnumValues: []string{"pending", "paid", "shipped"},
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		value := m.GenerateFakeValue(column)
+		switch value {
+		case "'pending'", "'paid'", "'shipped'":
+			seen[value] = true
+		default:
+			t.Fatalf("Value %q is not an enum member", value)
+		}
+	}
+
+	if len(seen) < 2 {
+		t.Errorf("Expected random enum members, got %v", seen)
+	}
+}
+
+func TestGenerateFakeValueCheckConstraint(t *testing.T) {
+	m := &MigrationService{}
+
+	tests := []struct {
+		name   string
+		clause string
+	}{
+		{
+			name:   "Column IN list",
+			clause: "((st_respst_negoci)::text = ANY ((ARRAY['A'::character varying, 'R'::character varying])::text[]))",
+		},
+		{
+			name:   "Domain VALUE IN list",
+			clause: "(VALUE IN ('A', 'R'))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := ColumnInfo{
+				Name:        "st_respst_negoci",
+				DataType:    "character varying",
+				Constraints: []string{tt.clause},
+			}
+			for i := 0; i < 20; i++ {
+				if value := m.GenerateFakeValue(column); value != "'A'" && value != "'R'" {
+					t.Fatalf("Value %q violates %s", value, tt.clause)
+				}
+			}
+		})
+	}
+
+	// Range checks carry no literal list, so the generic generator is used
+	column := ColumnInfo{Name: "stock", DataType: "integer", Constraints: []string{"(stock >= 0)"}}
+	if values := column.allowedValues(); values != nil {
+		t.Errorf("Expected no allowed values for a range check, got %v", values)
+	}
+}
+
+func TestCSVFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	data := "username,email,nickname\nalice,alice@example.com,al\nbob,,bobby\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write csv file: %v", err)
+	}
+
+	fixture, err := loadCSVFixture(path)
+	if err != nil {
+		t.Fatalf("loadCSVFixture failed: %v", err)
+	}
+
+	// nickname is not a table column
+	unknown := fixture.dropUnknownColumns([]ColumnInfo{
+		{Name: "id", DataType: "integer"},
+		{Name: "username", DataType: "character varying"},
+		{Name: "email", DataType: "character varying"},
+	})
+	if len(unknown) != 1 || unknown[0] != "nickname" {
+		t.Errorf("Expected nickname to be reported as unknown, got %v", unknown)
+	}
+
+	// Rows cycle when more rows are seeded than the CSV holds
+	expected := []string{"alice", "bob", "alice", "bob", "alice"}
+	for i, username := range expected {
+		row := fixture.row(i)
+		if row["username"] != username {
+			t.Errorf("Row %d: expected username %s, got %s", i, username, row["username"])
+		}
+		if _, exists := row["nickname"]; exists {
+			t.Errorf("Row %d: unknown column must not be returned", i)
+		}
+	}
+
+	if email, exists := fixture.row(1)["email"]; !exists || email != "" {
+		t.Errorf("Expected empty email for bob, got %q (exists=%v)", email, exists)
+	}
+
+	// A header-only file is rejected
+	empty := filepath.Join(t.TempDir(), "empty.csv")
+	if err := os.WriteFile(empty, []byte("username\n"), 0644); err != nil {
+		t.Fatalf("Failed to write csv file: %v", err)
+	}
+	if _, err := loadCSVFixture(empty); err == nil {
+		t.Error("Expected error for a csv file without data rows")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	m := &MigrationService{}
+
+	seed := models.Seed{
+		Schema:    "inventory",
+		Table:     "products",
+		Rows:      25,
+		Overrides: []models.Overrides{{Column: "name", Value: "O'Brien"}},
+	}
+	statements, err := m.DryRun(context.Background(), seed)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	if len(statements) != seed.Rows {
+		t.Fatalf("Expected %d statements, got %d", seed.Rows, len(statements))
+	}
+	for i, statement := range statements {
+		if !strings.HasPrefix(statement, "INSERT INTO inventory.products (name, description, price, stock, created_at, updated_at) VALUES (") {
+			t.Fatalf("Statement %d has unexpected table or columns: %s", i, statement)
+		}
+		// Values are inlined, overrides escaped, and the serial id is left to the database
+		if strings.Contains(statement, "$1") || !strings.Contains(statement, "VALUES ('O''Brien', ") {
+			t.Errorf("Statement %d has unexpected values: %s", i, statement)
+		}
+	}
+}
+
+func TestDryRunSeedColumns(t *testing.T) {
+	m := &MigrationService{}
+
+	path := filepath.Join(t.TempDir(), "accounts.csv")
+	if err := os.WriteFile(path, []byte("code,unknown\nA1,x\n,y\n"), 0644); err != nil {
+		t.Fatalf("Failed to write csv file: %v", err)
+	}
+
+	statements, err := m.DryRun(context.Background(), models.Seed{
+		Schema:  "billing",
+		Table:   "accounts",
+		Rows:    3,
+		CSVFile: path,
+		Columns: []models.SeedColumn{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true},
+			{Name: "code", Type: "varchar(10)"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	expected := []string{
+		"INSERT INTO billing.accounts (code) VALUES ('A1')",
+		"INSERT INTO billing.accounts (code) VALUES (NULL)",
+		"INSERT INTO billing.accounts (code) VALUES ('A1')",
+	}
+	if strings.Join(statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %v, got %v", expected, statements)
+	}
+
+	// A missing fixture is an error
+	if _, err := m.DryRun(context.Background(), models.Seed{Schema: "billing", Table: "accounts", Rows: 1, CSVFile: path + ".missing"}); err == nil {
+		t.Error("Expected error for a missing csv file")
+	}
+}
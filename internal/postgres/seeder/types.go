@@ -0,0 +1,172 @@
+package seeder
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// quotedLiteral matches single-quoted SQL literals, including escaped quotes
+var quotedLiteral = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+// castLiteral matches a quoted literal followed by optional casts such as ::character varying or ::text[]
+const castLiteral = `'(?:[^']|'')*'(?:::[\w." ]+(?:\[\])?)*`
+
+// allowedList captures the literal list of an IN (...) or = ANY ((ARRAY[...])::type[]) clause, as
+// emitted by pg_get_constraintdef
+var allowedList = regexp.MustCompile(`(?i)(?:\bIN\s*\(|=\s*ANY\s*\(+\s*ARRAY\s*\[)\s*(` + castLiteral + `(?:\s*,\s*` + castLiteral + `)*)`)
+
+// introspectColumns fills the enum labels and CHECK clauses of each column
+func (m *MigrationService) introspectColumns(ctx context.Context, pool *pgxpool.Pool, schema, table string, columns []ColumnInfo) error {
+	constraints := make(map[string][]string)
+
+	// CHECK constraints on the table columns and on the domains they use
+	rows, err := pool.Query(ctx, `
+		SELECT ccu.column_name, cc.check_clause
+		FROM information_schema.check_constraints cc
+		JOIN information_schema.constraint_column_usage ccu
+			ON cc.constraint_schema = ccu.constraint_schema AND cc.constraint_name = ccu.constraint_name
+		WHERE ccu.table_schema = $1 AND ccu.table_name = $2
+		UNION ALL
+		SELECT c.column_name, cc.check_clause
+		FROM information_schema.columns c
+		JOIN information_schema.domain_constraints dc
+			ON dc.domain_schema = c.domain_schema AND dc.domain_name = c.domain_name
+		JOIN information_schema.check_constraints cc
+			ON cc.constraint_schema = dc.constraint_schema AND cc.constraint_name = dc.constraint_name
+		WHERE c.table_schema = $1 AND c.table_name = $2
+	`, schema, table)
+	if err != nil {
+		return fmt.Errorf("failed to query check constraints: %w", err)
+	}
+	for rows.Next() {
+		var column, clause string
+		if err := rows.Scan(&column, &clause); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan check constraint: %w", err)
+		}
+		constraints[column] = append(constraints[column], clause)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read check constraints: %w", err)
+	}
+
+	for i := range columns {
+		columns[i].Constraints = constraints[columns[i].Name]
+
+		if !strings.EqualFold(columns[i].DataType, "USER-DEFINED") || columns[i].UDTName == "" {
+			continue
+		}
+
+		labels, err := enumLabels(ctx, pool, columns[i].UDTName)
+		if err != nil {
+			return err
+		}
+		columns[i].EnumValues = labels
+	}
+
+	return nil
+}
+
+// enumLabels returns the members of an enum type from pg_type/pg_enum, empty for other types
+func enumLabels(ctx context.Context, pool *pgxpool.Pool, typeName string) ([]string, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT e.enumlabel
+		FROM pg_type t
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		WHERE t.typname = $1
+		ORDER BY e.enumsortorder
+	`, typeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enum labels for %s: %w", typeName, err)
+	}
+	defer rows.Close()
+
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, fmt.Errorf("failed to scan enum label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, rows.Err()
+}
+
+// allowedValues returns the enum labels, or the literals of a CHECK (... IN (...)) /
+// CHECK (... = ANY ((ARRAY[...])::type[])) constraint
+func (c ColumnInfo) allowedValues() []string {
+	if len(c.EnumValues) > 0 {
+		return c.EnumValues
+	}
+
+	for _, clause := range c.Constraints {
+		list := allowedList.FindStringSubmatch(clause)
+		if list == nil {
+			continue
+		}
+
+		var values []string
+		for _, match := range quotedLiteral.FindAllStringSubmatch(list[1], -1) {
+			values = append(values, strings.ReplaceAll(match[1], "''", "'"))
+		}
+		if len(values) > 0 {
+			return values
+		}
+	}
+
+	return nil
+}
+
+// fakeArray generates a PostgreSQL array literal such as '{"a","b"}' using the element type
+func (m *MigrationService) fakeArray(column ColumnInfo) string {
+	elementType := strings.TrimPrefix(column.UDTName, "_")
+	if elementType == "" {
+		elementType = strings.TrimSuffix(column.DataType, "[]")
+	}
+	element := ColumnInfo{Name: column.Name, DataType: elementType}
+
+	count := 1 + mathrand.Intn(3)
+	elements := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		value := m.GenerateFakeValue(element)
+		if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		elements = append(elements, `"`+value+`"`)
+	}
+
+	return fmt.Sprintf("'{%s}'", strings.ReplaceAll(strings.Join(elements, ","), "'", "''"))
+}
+
+// fakeBytea generates random bytes in the bytea hex format
+func fakeBytea() string {
+	data := make([]byte, 8+mathrand.Intn(24))
+	_, _ = rand.Read(data)
+	return fmt.Sprintf("'\\x%s'", hex.EncodeToString(data))
+}
+
+// fakeMoney generates a decimal string with two fraction digits
+func fakeMoney() string {
+	return fmt.Sprintf("'%d.%02d'", mathrand.Intn(100000), mathrand.Intn(100))
+}
+
+// fakeInterval generates an interval string like '3 days 2 hours'
+func fakeInterval() string {
+	return fmt.Sprintf("'%d days %d hours %d minutes'", mathrand.Intn(30), mathrand.Intn(24), mathrand.Intn(60))
+}
+
+// fakeHstore generates a single-pair hstore literal
+func fakeHstore() string {
+	return fmt.Sprintf(`'"%s"=>"%s"'`, RandomString(6), RandomString(10))
+}
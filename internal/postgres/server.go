@@ -10,25 +10,40 @@ import (
 	testcontainers "github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"net"
+	"os"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Server struct {
-	Name              string
-	User              string
-	Password          string
-	Host              string
-	Port              int
-	Database          string
-	InitScript        string
-	Seed              []models.Seed
-	PostgresContainer *postgres.PostgresContainer
-	logger            *scribe.Scribe
-	LoggerPath        string
+	Name               string
+	User               string
+	Password           string
+	Host               string
+	Port               int
+	Database           string
+	InitScript         string
+	Seed               []models.Seed
+	PostgresContainer  *postgres.PostgresContainer
+	logger             *scribe.Scribe
+	LoggerPath         string
+	Image              string
+	PostgresArgs       map[string]string
+	GenerateInitScript bool
+	QueryStubs         []models.QueryStub
+	proxy              *queryProxy
+	stopped            atomic.Bool
+	isRestarting       atomic.Bool
+	stopMonitor        context.CancelFunc
 }
 
+// defaultImage is used when the server config doesn't set an image
+const defaultImage = "postgres:16-alpine"
+
 type PostgresManager struct {
 	servers map[int]*Server
 	wg      sync.WaitGroup
@@ -79,16 +94,20 @@ func (m *PostgresManager) CreateServer(config models.PostgresServer) error {
 		return err
 	}
 	server := &Server{
-		Name:       config.Name,
-		User:       config.User,
-		Password:   config.Password,
-		Host:       config.Host,
-		Port:       config.Port,
-		Database:   config.Database,
-		InitScript: config.InitScript,
-		LoggerPath: logPath,
-		logger:     log,
-		Seed:       config.Seed,
+		Name:               config.Name,
+		User:               config.User,
+		Password:           config.Password,
+		Host:               config.Host,
+		Port:               config.Port,
+		Database:           config.Database,
+		InitScript:         config.InitScript,
+		LoggerPath:         logPath,
+		logger:             log,
+		Seed:               config.Seed,
+		Image:              config.Image,
+		PostgresArgs:       config.PostgresArgs,
+		GenerateInitScript: config.GenerateInitScript,
+		QueryStubs:         config.QueryStubs,
 	}
 
 	m.servers[config.Port] = server
@@ -102,6 +121,12 @@ func (m *PostgresManager) Stop() {
 }
 
 func (s *Server) Stop() {
+	// A manual stop must not be taken as a crash by the health monitor
+	s.stopped.Store(true)
+	if s.stopMonitor != nil {
+		s.stopMonitor()
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	stop := 5 * time.Second
@@ -113,6 +138,11 @@ func (s *Server) Stop() {
 			s.logger.Error().Msg(fmt.Sprintf("Panic recover. Error stopping Postgres container: %v with Name: %s", a, s.Name))
 		}
 	}()
+	if s.proxy != nil {
+		s.proxy.Close()
+		s.proxy = nil
+	}
+
 	err := s.PostgresContainer.Stop(ctx, &stop)
 
 	if err != nil {
@@ -160,9 +190,11 @@ func (m *PostgresManager) Start() error {
 			}
 
 			// If the server has a seed configuration, run the migration
-			if s.Seed != nil {
+			if len(s.Seed) > 0 {
 				prepareMigration(s, ctx)
 			}
+
+			s.startHealthMonitor()
 		}(server)
 	}
 
@@ -183,6 +215,7 @@ func prepareMigration(s *Server, ctx context.Context) {
 		Host:              s.Host,
 		Port:              s.Port,
 		Database:          s.Database,
+		Seed:              s.Seed,
 		PostgresContainer: s.PostgresContainer,
 		Logger:            &loggerEnabled,
 		LoggerPath:        &s.LoggerPath,
@@ -210,30 +243,50 @@ func prepareMigration(s *Server, ctx context.Context) {
 
 func (s *Server) Start() (*postgres.PostgresContainer, error) {
 	ctx := context.TODO()
-	var scripts testcontainers.CustomizeRequestOption
-	var opts []testcontainers.ContainerCustomizer
 
-	if s.InitScript != "" {
-		scripts = postgres.WithInitScripts(s.InitScript)
-		opts = []testcontainers.ContainerCustomizer{
-			testcontainers.WithExposedPorts(strconv.Itoa(s.Port) + ":5432/tcp"),
-			postgres.WithDatabase(s.Database),
-			postgres.WithUsername(s.User),
-			postgres.WithPassword(s.Password),
-			scripts,
-			testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections")),
+	var stubs []*queryStub
+	exposedPort := strconv.Itoa(s.Port) + ":5432/tcp"
+	if len(s.QueryStubs) > 0 {
+		var err error
+		if stubs, err = compileQueryStubs(s.QueryStubs); err != nil {
+			return nil, err
 		}
+		// The stub proxy listens on the configured port and forwards to a random host port
+		exposedPort = "5432/tcp"
+	}
+
+	opts := []testcontainers.ContainerCustomizer{
+		testcontainers.WithExposedPorts(exposedPort),
+		postgres.WithDatabase(s.Database),
+		postgres.WithUsername(s.User),
+		postgres.WithPassword(s.Password),
+		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections")),
 	}
 
-	if scripts == nil {
-		scripts = postgres.WithInitScripts("")
-		opts = []testcontainers.ContainerCustomizer{
-			testcontainers.WithExposedPorts(strconv.Itoa(s.Port) + ":5432/tcp"),
-			postgres.WithDatabase(s.Database),
-			postgres.WithUsername(s.User),
-			postgres.WithPassword(s.Password),
-			testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections")),
+	var scripts []string
+	if s.GenerateInitScript {
+		// The generated DDL runs first so a custom init script can rely on the tables
+		generated, err := writeInitDDL(s.Seed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate init script: %w", err)
 		}
+		defer os.Remove(generated)
+		scripts = append(scripts, generated)
+	}
+	if s.InitScript != "" {
+		scripts = append(scripts, s.InitScript)
+	}
+	if len(scripts) > 0 {
+		opts = append(opts, postgres.WithInitScripts(scripts...))
+	}
+
+	if len(s.PostgresArgs) > 0 {
+		opts = append(opts, testcontainers.WithCmd(postgresCommand(s.PostgresArgs)...))
+	}
+
+	image := s.Image
+	if image == "" {
+		image = defaultImage
 	}
 
 	defer func() {
@@ -244,7 +297,7 @@ func (s *Server) Start() (*postgres.PostgresContainer, error) {
 	// Run the container. The postgres.Run function returns a pointer to a
 	// postgres.PostgresContainer and an error.
 	postgresContainer, err := postgres.Run(ctx,
-		"postgres:16-alpine",
+		image,
 		opts...,
 	)
 
@@ -252,6 +305,66 @@ func (s *Server) Start() (*postgres.PostgresContainer, error) {
 		return nil, err
 	}
 
+	if stubs != nil {
+		if err := s.startQueryProxy(ctx, postgresContainer, stubs); err != nil {
+			postgresContainer.Terminate(ctx)
+			return nil, err
+		}
+	}
+
 	return postgresContainer, nil
 
 }
+
+// startQueryProxy puts the query stub proxy in front of the container's mapped port
+func (s *Server) startQueryProxy(ctx context.Context, container *postgres.PostgresContainer, stubs []*queryStub) error {
+	upstream, err := containerAddress(ctx, container)
+	if err != nil {
+		return err
+	}
+
+	proxy, err := newQueryProxy(s.Port, upstream, stubs, s.logger)
+	if err != nil {
+		return err
+	}
+	s.proxy = proxy
+
+	s.logger.Info().Msg(fmt.Sprintf("Query stub proxy for %s listening on port %d with %d stubs", s.Name, s.Port, len(stubs)))
+	return nil
+}
+
+// containerAddress returns the host address mapped to the container's 5432 port
+func containerAddress(ctx context.Context, container *postgres.PostgresContainer) (string, error) {
+	host, err := container.Host(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get container host: %w", err)
+	}
+
+	port, err := container.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		return "", fmt.Errorf("failed to get container port: %w", err)
+	}
+
+	return net.JoinHostPort(host, port.Port()), nil
+}
+
+// postgresCommand builds the container command passing each setting as `-c key=value`.
+// fsync=off is kept from the module default command unless it is overridden.
+func postgresCommand(args map[string]string) []string {
+	settings := map[string]string{"fsync": "off"}
+	for key, value := range args {
+		settings[key] = value
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	cmd := []string{"postgres"}
+	for _, key := range keys {
+		cmd = append(cmd, "-c", key+"="+settings[key])
+	}
+	return cmd
+}
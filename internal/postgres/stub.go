@@ -0,0 +1,243 @@
+package postgres_server
+
+import (
+	"bytes"
+	"catalyst/internal/models"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OIDs de los tipos con los que se describen las columnas de un stub
+const (
+	boolOID   uint32 = 16
+	int8OID   uint32 = 20
+	textOID   uint32 = 25
+	jsonOID   uint32 = 114
+	float8OID uint32 = 701
+)
+
+// queryStub es la versión compilada de models.QueryStub
+type queryStub struct {
+	pattern string
+	re      *regexp.Regexp
+	columns []stubColumn
+	rows    [][]*string
+}
+
+type stubColumn struct {
+	name string
+	oid  uint32
+}
+
+// compileQueryStubs valida y compila los stubs configurados para un servidor
+func compileQueryStubs(stubs []models.QueryStub) ([]*queryStub, error) {
+	compiled := make([]*queryStub, 0, len(stubs))
+	for i, stub := range stubs {
+		s, err := compileQueryStub(stub)
+		if err != nil {
+			return nil, fmt.Errorf("query_stubs[%d]: %w", i, err)
+		}
+		compiled = append(compiled, s)
+	}
+	return compiled, nil
+}
+
+func compileQueryStub(stub models.QueryStub) (*queryStub, error) {
+	if stub.SQLPattern == "" {
+		return nil, fmt.Errorf("sql_pattern is required")
+	}
+
+	columns, rows, err := parseStubRows(stub.ResponseJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response_json: %w", err)
+	}
+
+	s := &queryStub{pattern: stub.SQLPattern, columns: columns, rows: rows}
+	// Si el patrón no es una regex válida solo se usa como substring
+	if re, err := regexp.Compile(stub.SQLPattern); err == nil {
+		s.re = re
+	}
+	return s, nil
+}
+
+// matches indica si la query contiene el patrón o cumple la regex
+func (s *queryStub) matches(query string) bool {
+	if strings.Contains(query, s.pattern) {
+		return true
+	}
+	return s.re != nil && s.re.MatchString(query)
+}
+
+func matchQueryStub(stubs []*queryStub, query string) *queryStub {
+	for _, stub := range stubs {
+		if stub.matches(query) {
+			return stub
+		}
+	}
+	return nil
+}
+
+// parseStubRows decodifica un array JSON de objetos conservando el orden de las claves,
+// que define el orden de las columnas. Un valor null o ausente se devuelve como NULL.
+func parseStubRows(data string) ([]stubColumn, [][]*string, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, nil, fmt.Errorf("expected a JSON array of rows")
+	}
+
+	var names []string
+	index := make(map[string]int)
+	var objects []map[string]json.RawMessage
+
+	for dec.More() {
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return nil, nil, fmt.Errorf("row %d is not a JSON object", len(objects))
+		}
+
+		object := make(map[string]json.RawMessage)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			key := tok.(string)
+
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, nil, err
+			}
+			object[key] = value
+
+			if _, exists := index[key]; !exists {
+				index[key] = len(names)
+				names = append(names, key)
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	columns := make([]stubColumn, len(names))
+	rows := make([][]*string, len(objects))
+	for i := range rows {
+		rows[i] = make([]*string, len(names))
+	}
+
+	for c, name := range names {
+		columns[c] = stubColumn{name: name, oid: textOID}
+
+		var kind uint32
+		mixed := false
+		for r, object := range objects {
+			raw, exists := object[name]
+			if !exists || string(raw) == "null" {
+				continue
+			}
+
+			valueKind, text := jsonValueText(raw)
+			rows[r][c] = &text
+
+			if kind == 0 {
+				kind = valueKind
+			} else if kind != valueKind {
+				mixed = true
+			}
+		}
+
+		// Las columnas con tipos mezclados se describen como text
+		if kind != 0 && !mixed {
+			columns[c].oid = kind
+		}
+	}
+
+	return columns, rows, nil
+}
+
+// jsonValueText devuelve el OID que corresponde al valor y su representación en texto
+func jsonValueText(raw json.RawMessage) (uint32, string) {
+	raw = bytes.TrimSpace(raw)
+	switch raw[0] {
+	case '"':
+		var s string
+		json.Unmarshal(raw, &s)
+		return textOID, s
+	case 't':
+		return boolOID, "t"
+	case 'f':
+		return boolOID, "f"
+	case '{', '[':
+		return jsonOID, string(raw)
+	default:
+		if _, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			return int8OID, string(raw)
+		}
+		return float8OID, string(raw)
+	}
+}
+
+// typeSize devuelve el tamaño que se informa en el RowDescription
+func (c stubColumn) typeSize() int16 {
+	switch c.oid {
+	case boolOID:
+		return 1
+	case int8OID, float8OID:
+		return 8
+	default:
+		return -1
+	}
+}
+
+// encode serializa un valor en formato texto (0) o binario (1)
+func (c stubColumn) encode(value *string, format int16) []byte {
+	if value == nil {
+		return nil
+	}
+	if format == 0 {
+		return []byte(*value)
+	}
+
+	switch c.oid {
+	case boolOID:
+		if *value == "t" {
+			return []byte{1}
+		}
+		return []byte{0}
+	case int8OID:
+		n, _ := strconv.ParseInt(*value, 10, 64)
+		return binary.BigEndian.AppendUint64(nil, uint64(n))
+	case float8OID:
+		f, _ := strconv.ParseFloat(*value, 64)
+		return binary.BigEndian.AppendUint64(nil, math.Float64bits(f))
+	default:
+		// text y json usan la misma representación en binario
+		return []byte(*value)
+	}
+}
+
+// resultFormat resuelve el formato de una columna según los códigos enviados en Bind
+func resultFormat(formats []int16, column int) int16 {
+	switch len(formats) {
+	case 0:
+		return 0
+	case 1:
+		return formats[0]
+	default:
+		if column < len(formats) {
+			return formats[column]
+		}
+		return 0
+	}
+}
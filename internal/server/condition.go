@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// headerCondition is the parsed mock_only_if_header of a location
+type headerCondition struct {
+	name  string
+	value string
+}
+
+// parseHeaderCondition parses a mock_only_if_header condition written as "Name: value"
+func parseHeaderCondition(condition string) (*headerCondition, error) {
+	if condition == "" {
+		return nil, nil
+	}
+
+	name, value, found := strings.Cut(condition, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return nil, fmt.Errorf("invalid mock_only_if_header %q, expected \"Name: value\"", condition)
+	}
+	return &headerCondition{name: name, value: strings.TrimSpace(value)}, nil
+}
+
+// matches reports whether the request carries the header with exactly the expected value
+func (hc *headerCondition) matches(c *gin.Context) bool {
+	if hc == nil {
+		return true
+	}
+	for _, value := range c.Request.Header.Values(hc.name) {
+		if value == hc.value {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyIfHeader serves the location only for requests carrying its mock_only_if_header. Other
+// requests are handled by fallback, the path_regex locations when there are any, and get the
+// 404 of an unknown route when nothing answers them.
+func onlyIfHeader(condition *headerCondition, next gin.HandlerFunc, fallback func() gin.HandlerFunc) gin.HandlerFunc {
+	if condition == nil {
+		return next
+	}
+	return func(c *gin.Context) {
+		if condition.matches(c) {
+			next(c)
+			return
+		}
+
+		if handler := fallback(); handler != nil {
+			handler(c)
+		}
+		if !c.Writer.Written() {
+			c.String(http.StatusNotFound, "404 page not found")
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"catalyst/api"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// groupStartTimeout bounds how long StartGroup waits for a server port to be freed and to accept connections
+const groupStartTimeout = 5 * time.Second
+
+// Groups lists the server groups with how many of their servers are running
+func (m *Manager) Groups() []api.ServerGroup {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	byName := make(map[string]*api.ServerGroup)
+	for _, server := range m.servers {
+		if server.group == "" {
+			continue
+		}
+		group, exists := byName[server.group]
+		if !exists {
+			group = &api.ServerGroup{Name: server.group}
+			byName[server.group] = group
+		}
+		group.Servers = append(group.Servers, server.name)
+		if server.running.Load() {
+			group.Running++
+		}
+	}
+
+	groups := make([]api.ServerGroup, 0, len(byName))
+	for _, group := range byName {
+		sort.Strings(group.Servers)
+		group.ServerCount = len(group.Servers)
+		group.Status = api.GroupStatus(group.Running, group.ServerCount)
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// StartGroup starts the stopped servers of a group concurrently and waits until they accept connections
+func (m *Manager) StartGroup(name string) error {
+	servers, err := m.groupServers(name)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		if server.running.Load() {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, s *Server) {
+			defer wg.Done()
+			errs[i] = m.startGroupServer(s)
+		}(i, server)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// StopGroup gracefully stops the running servers of a group concurrently
+func (m *Manager) StopGroup(name string) error {
+	servers, err := m.groupServers(name)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		if !server.running.Load() {
+			continue
+		}
+		wg.Add(1)
+		go func(s *Server) {
+			defer wg.Done()
+			s.shutdown()
+			log.Printf("Server %s on port %d stopped with group %s", s.name, s.Port, name)
+		}(server)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// groupServers returns the servers of a group, or api.ErrGroupNotFound when no server has it
+func (m *Manager) groupServers(name string) ([]*Server, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var servers []*Server
+	for _, server := range m.servers {
+		if name != "" && server.group == name {
+			servers = append(servers, server)
+		}
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("%w: %s", api.ErrGroupNotFound, name)
+	}
+	return servers, nil
+}
+
+// startGroupServer starts a stopped server and waits until its port accepts connections
+func (m *Manager) startGroupServer(s *Server) error {
+	if !waitForPortToBeFree(s.Port, groupStartTimeout) {
+		return fmt.Errorf("port %d still in use after %s", s.Port, groupStartTimeout)
+	}
+
+	startErr := make(chan error, 1)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		err := s.Start()
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("Error starting server on port %d: %v", s.Port, err)
+		}
+		startErr <- err
+	}()
+
+	deadline := time.Now().Add(groupStartTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-startErr:
+			return fmt.Errorf("server on port %d stopped while starting: %w", s.Port, err)
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", s.Port), 500*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("server on port %d did not accept connections after %s", s.Port, groupStartTimeout)
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SOLUCIONESSYCOM/scribe"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type requestLoggedKey struct{}
+
+// RequestLoggingMiddleware logs every request at INFO level with structured fields. The logger
+// is looked up per request so it can be replaced at runtime.
+func RequestLoggingMiddleware(logger func() *scribe.Scribe) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// stripPathPrefix runs the chain again through HandleContext, log the request only once
+		if c.Request.Context().Value(requestLoggedKey{}) != nil {
+			c.Next()
+			return
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestLoggedKey{}, true))
+
+		start := time.Now()
+		method := c.Request.Method
+		path := c.Request.URL.Path
+
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Header("X-Request-ID", requestID)
+
+		c.Next()
+
+		// Size is -1 until something is written
+		responseBytes := 0
+		if c.Writer.Written() {
+			responseBytes = c.Writer.Size()
+		}
+
+		logger().Info().
+			Str("method", method).
+			Str("path", path).
+			Int("status", c.Writer.Status()).
+			Int("latency_ms", int(time.Since(start).Milliseconds())).
+			Str("client_ip", c.ClientIP()).
+			Str("request_id", requestID).
+			Str("user_agent", c.Request.UserAgent()).
+			Int("response_bytes", responseBytes).
+			Msg("Request handled")
+	}
+}
+
+// MaxHeaderBytesMiddleware rejects with 431 the requests whose header names and values add up to
+// more than maxBytes
+func MaxHeaderBytesMiddleware(maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		total := 0
+		for key, values := range c.Request.Header {
+			for _, value := range values {
+				total += len(key) + len(value)
+			}
+		}
+
+		if total > maxBytes {
+			prom.HandlerHeaderTooLargeTotal.WithLabelValues(c.FullPath(), c.Request.Method).Inc()
+			c.AbortWithStatusJSON(http.StatusRequestHeaderFieldsTooLarge, gin.H{
+				"error": fmt.Sprintf("request headers are %d bytes, the limit is %d", total, maxBytes),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// DefaultHeadersMiddleware adds the server-level response headers. They are set before the
+// location handler runs, so location headers override them.
+func DefaultHeadersMiddleware(headers models.Headers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for key, value := range headers {
+			c.Header(key, value)
+		}
+		c.Next()
+	}
+}
+
+// securityHeaderNames maps the security_headers keys to the response header they set
+var securityHeaderNames = map[string]string{
+	"content_security_policy":   "Content-Security-Policy",
+	"x_frame_options":           "X-Frame-Options",
+	"x_content_type_options":    "X-Content-Type-Options",
+	"strict_transport_security": "Strict-Transport-Security",
+	"referrer_policy":           "Referrer-Policy",
+}
+
+// defaultSecurityHeaders are sent unless security_headers sets the key, an empty value disables them
+var defaultSecurityHeaders = models.SecurityHeaders{
+	"x_content_type_options": "nosniff",
+}
+
+// SecurityHeadersMiddleware adds the security response headers of the server over the defaults.
+// Like the default response headers, location headers override them.
+func SecurityHeadersMiddleware(config models.SecurityHeaders) (gin.HandlerFunc, error) {
+	values := make(map[string]string, len(defaultSecurityHeaders)+len(config))
+	for key, value := range defaultSecurityHeaders {
+		values[key] = value
+	}
+	for key, value := range config {
+		if _, ok := securityHeaderNames[key]; !ok {
+			return nil, fmt.Errorf("unknown security header %q", key)
+		}
+		values[key] = value
+	}
+
+	headers := make(map[string]string, len(values))
+	for key, value := range values {
+		if value != "" {
+			headers[securityHeaderNames[key]] = value
+		}
+	}
+
+	return func(c *gin.Context) {
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		c.Next()
+	}, nil
+}
+
+// Types of the configured middleware list
+const (
+	middlewareAddHeader    = "add_header"
+	middlewareRemoveHeader = "remove_header"
+	middlewareRewritePath  = "rewrite_path"
+	middlewareLogOnly      = "log_only"
+	middlewareRateLimit    = "rate_limit"
+)
+
+// middlewareRanKey marks in the request context the configured middleware that already ran
+type middlewareRanKey struct{ index int }
+
+// ConfiguredMiddleware builds the middleware list of a server in order. rewrite_path routes the
+// request again through router once the path changes; the middleware that already ran is skipped
+// then, as it is when stripPathPrefix routes again.
+func ConfiguredMiddleware(router *gin.Engine, middleware []models.Middleware, logger func() *scribe.Scribe) ([]gin.HandlerFunc, error) {
+	handlers := make([]gin.HandlerFunc, 0, len(middleware))
+	for i, config := range middleware {
+		handler, err := newConfiguredMiddleware(router, config, logger)
+		if err != nil {
+			return nil, fmt.Errorf("middleware %d: %w", i, err)
+		}
+		handlers = append(handlers, runOnce(i, handler))
+	}
+	return handlers, nil
+}
+
+// newConfiguredMiddleware validates one middleware and returns its handler
+func newConfiguredMiddleware(router *gin.Engine, config models.Middleware, logger func() *scribe.Scribe) (gin.HandlerFunc, error) {
+	switch config.Type {
+	case middlewareAddHeader:
+		if config.Header == "" {
+			return nil, fmt.Errorf("add_header requires header")
+		}
+		return func(c *gin.Context) {
+			c.Request.Header.Set(config.Header, config.Value)
+			c.Next()
+		}, nil
+	case middlewareRemoveHeader:
+		if config.Header == "" {
+			return nil, fmt.Errorf("remove_header requires header")
+		}
+		return func(c *gin.Context) {
+			c.Request.Header.Del(config.Header)
+			c.Next()
+		}, nil
+	case middlewareRewritePath:
+		if config.Pattern == "" {
+			return nil, fmt.Errorf("rewrite_path requires pattern")
+		}
+		pattern, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite_path pattern %q: %w", config.Pattern, err)
+		}
+		return rewritePath(router, pattern, config.Replacement), nil
+	case middlewareLogOnly:
+		message := config.Message
+		if message == "" {
+			message = "Request received"
+		}
+		return func(c *gin.Context) {
+			logger().Info().
+				Str("method", c.Request.Method).
+				Str("path", c.Request.URL.Path).
+				Str("query", c.Request.URL.RawQuery).
+				Str("client_ip", c.ClientIP()).
+				Msg(message)
+			c.Next()
+		}, nil
+	case middlewareRateLimit:
+		if config.RequestsPerSecond <= 0 {
+			return nil, fmt.Errorf("rate_limit requires a positive requests_per_second")
+		}
+		return rateLimit(newTokenBucket(config.RequestsPerSecond, config.Burst)), nil
+	}
+	return nil, fmt.Errorf("invalid middleware type %q", config.Type)
+}
+
+// runOnce runs handler the first time the request goes through the chain
+func runOnce(index int, handler gin.HandlerFunc) gin.HandlerFunc {
+	key := middlewareRanKey{index: index}
+	return func(c *gin.Context) {
+		if c.Request.Context().Value(key) != nil {
+			c.Next()
+			return
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), key, true))
+		handler(c)
+	}
+}
+
+// rewritePath replaces the matches of pattern in the request path and routes the request again,
+// since Gin picks the route before running the global middleware
+func rewritePath(router *gin.Engine, pattern *regexp.Regexp, replacement string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		rewritten := pattern.ReplaceAllString(path, replacement)
+		if rewritten == path {
+			c.Next()
+			return
+		}
+
+		c.Request.URL.Path = rewritten
+		c.Request.URL.RawPath = ""
+		router.HandleContext(c)
+		c.Abort()
+	}
+}
+
+// tokenBucket allows rate requests per second on average, and up to burst at once
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket; burst defaults to one second of requests
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// take spends a token, or returns how long until the next one is available
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// rateLimit rejects with 429 the requests above the rate of the bucket
+func rateLimit(bucket *tokenBucket) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, wait := bucket.take(time.Now())
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -6,15 +6,17 @@ import (
 	"catalyst/internal/config"
 	"catalyst/internal/logger"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"net"
 	"net/http"
-	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SOLUCIONESSYCOM/scribe"
@@ -28,15 +30,36 @@ import (
 )
 
 type Server struct {
-	Port       int
-	Router     *gin.Engine
-	httpServer *http.Server
-	handler    *handler.Handler
-	locations  []models.Location
-	logger     *scribe.Scribe
+	Port           int
+	ListenAddr     string
+	Router         *gin.Engine
+	httpServer     *http.Server
+	handler        *handler.Handler
+	locations      []models.Location
+	logger         *scribe.Scribe
+	name           string
+	logConfig      models.LogDescriptor
+	logLevel       atomic.Value
+	tls            tlsSettings
+	group          string
+	running        atomic.Bool
+	startedAt      atomic.Value
+	states         *models.StateMachine
+	maxHeaderBytes int
+	timeouts       httpTimeouts
+}
+
+// httpTimeouts are the connection timeouts of a server's http.Server; zero means no timeout
+type httpTimeouts struct {
+	read       time.Duration
+	write      time.Duration
+	idle       time.Duration
+	readHeader time.Duration
 }
 
 type Manager struct {
+	// mu guards servers, configs and failedServers, which the API and the config watcher change at runtime
+	mu             sync.RWMutex
 	servers        map[int]*Server
 	apiServer      *Server
 	metricsServer  *Server
@@ -46,8 +69,29 @@ type Manager struct {
 	configDir      string
 	restartManager *api.RestartManager
 	logger         *scribe.Scribe
+	startCalled    atomic.Bool
+	started        atomic.Bool
+	failedServers  []int
+	buildInfo      api.BuildInfo
+	dbConfig       models.DatabaseConfig
+	dbPath         string
+	postgres       api.PostgresConnectionProvider
+	searchIndex    *database.BodySearchIndex
 }
 
+// defaultDatabasePath is the transactions database of the servers unless SetDatabasePath changes it
+const defaultDatabasePath = "./database.db"
+
+// batchDrainTimeout bounds how long Stop waits for queued transactions to be persisted
+const batchDrainTimeout = 10 * time.Second
+
+// defaultReadHeaderTimeout bounds how long a server waits for the request headers when
+// read_header_timeout_seconds is not set, so slow clients can't hold connections open (Slowloris)
+const defaultReadHeaderTimeout = 5 * time.Second
+
+// ErrChaosStartupFail is returned when startup_fail_probability makes a server fail on purpose
+var ErrChaosStartupFail = errors.New("chaos startup failure")
+
 func NewManager() *Manager {
 
 	m := models.LogDescriptor{
@@ -65,50 +109,269 @@ func NewManager() *Manager {
 		restartChan: make(chan string, 10),
 		configs:     make([]*models.MockServer, 0),
 		logger:      logCtx,
+		dbPath:      defaultDatabasePath,
 	}
 }
 
+// SetBuildInfo sets the version of the binary, used by servers that don't configure one and
+// reported by GET /api/mock/version. It must be called before creating the servers.
+func (m *Manager) SetBuildInfo(version, buildTime string) {
+	m.buildInfo = api.BuildInfo{Version: version, BuildTime: buildTime}
+}
+
+// SetDatabaseConfig sets the connection pool of the transactions database opened by the servers
+// created afterwards
+func (m *Manager) SetDatabaseConfig(dbConfig models.DatabaseConfig) {
+	m.dbConfig = dbConfig
+}
+
+// SetDatabasePath sets the transactions database file of the servers created afterwards
+func (m *Manager) SetDatabasePath(path string) {
+	m.dbPath = path
+}
+
+// SetSearchIndex sets the body search index shared by the servers created afterwards, so the API
+// finds the transactions of every server. It must be called before creating the servers.
+func (m *Manager) SetSearchIndex(idx *database.BodySearchIndex) {
+	m.searchIndex = idx
+}
+
+// FlushTransactions persists the transactions queued by every server, waiting until ctx expires
+func (m *Manager) FlushTransactions(ctx context.Context) error {
+	for port, server := range m.serverList() {
+		if server.handler == nil || server.handler.BatchManager == nil {
+			continue
+		}
+		if _, err := server.handler.BatchManager.Flush(ctx); err != nil {
+			return fmt.Errorf("error flushing transactions of server on port %d: %w", port, err)
+		}
+	}
+	return nil
+}
+
+// SetPostgresConnections sets the postgres servers whose connection strings are returned by
+// GET /api/mock/postgres/connection-strings. It must be called before creating the API server.
+func (m *Manager) SetPostgresConnections(provider api.PostgresConnectionProvider) {
+	m.postgres = provider
+}
+
+// withVersion resolves the version of a server of config: its own, the config's top-level
+// version or, when neither is set, the version of the binary
+func (m *Manager) withVersion(config *models.MockServer, server models.Server) models.Server {
+	if server.Version != nil {
+		return server
+	}
+	version := m.buildInfo.Version
+	if config.Version != "" {
+		version = config.Version
+	}
+	server.Version = &version
+	return server
+}
+
+// withDefaultName names a server without name after its config, adding the port when the config
+// has several servers, so a file dropped without names can be found by name like the others
+func withDefaultName(config *models.MockServer, server models.Server) models.Server {
+	if (server.Name != nil && *server.Name != "") || config.Name == "" {
+		return server
+	}
+	name := config.Name
+	if len(config.Http.Servers) > 1 {
+		name = fmt.Sprintf("%s-%d", config.Name, server.Listen)
+	}
+	server.Name = &name
+	return server
+}
+
+// CreateServers creates the servers of a config. Once the manager is started, e.g. for a config
+// file added at runtime, the servers are started as well. Servers already running with the same
+// name and port, e.g. a clone whose config file was persisted by the API, are skipped. Servers
+// without name are named after the config first.
 func (m *Manager) CreateServers(config *models.MockServer) error {
+	for i, serverConfig := range config.Http.Servers {
+		config.Http.Servers[i] = withDefaultName(config, serverConfig)
+	}
 
+	m.mu.Lock()
+	pending := make([]models.Server, 0, len(config.Http.Servers))
+	for _, serverConfig := range config.Http.Servers {
+		if m.isRunning(serverConfig) {
+			m.logger.Info().Msg(fmt.Sprintf("server %s on port %d of config %s already running", *serverConfig.Name, serverConfig.Listen, config.Name))
+			continue
+		}
+		pending = append(pending, serverConfig)
+	}
+	if len(pending) == 0 && len(config.Http.Servers) > 0 {
+		m.mu.Unlock()
+		return nil
+	}
 	m.configs = append(m.configs, config)
 
-	for _, serverConfig := range config.Http.Servers {
-		if err := m.CreateServer(serverConfig); err != nil {
-			return fmt.Errorf("error creating server on port %d: %w", serverConfig.Listen, err)
+	var created []*Server
+	var err error
+	for _, serverConfig := range pending {
+		serverConfig = m.withVersion(config, serverConfig)
+		server, createErr := m.createServer(serverConfig)
+		if createErr != nil {
+			// Simulated failures leave the rest of the servers running
+			if errors.Is(createErr, ErrChaosStartupFail) {
+				m.logger.Warn().Msg(fmt.Sprintf("server on port %d of config %s not started: %v", serverConfig.Listen, config.Name, createErr))
+				m.failedServers = append(m.failedServers, serverConfig.Listen)
+				continue
+			}
+			err = fmt.Errorf("error creating server on port %d of config %s: %w", serverConfig.Listen, config.Name, createErr)
+			break
+		}
+		created = append(created, server)
+	}
+	m.mu.Unlock()
+
+	if m.startCalled.Load() {
+		for _, server := range created {
+			m.runServer(server)
 		}
 	}
+	return err
+}
+
+// isRunning reports whether the manager has a server with the name and port of serverConfig; the
+// caller must hold m.mu
+func (m *Manager) isRunning(serverConfig models.Server) bool {
+	server, exists := m.servers[serverConfig.Listen]
+	return exists && serverConfig.Name != nil && strings.EqualFold(server.name, *serverConfig.Name)
+}
+
+// StopServer gracefully shuts down the server on port, persists its pending transactions and
+// removes it along with its stored config
+func (m *Manager) StopServer(port int) error {
+	m.mu.Lock()
+	server, exists := m.servers[port]
+	if !exists {
+		m.mu.Unlock()
+		return fmt.Errorf("server on port %d not found", port)
+	}
+	delete(m.servers, port)
+
+	// Stored configs may be shared with the caller, so they are copied instead of modified
+	configs := make([]*models.MockServer, 0, len(m.configs))
+	for _, storedConfig := range m.configs {
+		servers := make([]models.Server, 0, len(storedConfig.Http.Servers))
+		for _, serverConfig := range storedConfig.Http.Servers {
+			if serverConfig.Listen != port {
+				servers = append(servers, serverConfig)
+			}
+		}
+		if len(servers) == len(storedConfig.Http.Servers) {
+			configs = append(configs, storedConfig)
+			continue
+		}
+		if len(servers) > 0 {
+			remaining := *storedConfig
+			remaining.Http.Servers = servers
+			configs = append(configs, &remaining)
+		}
+	}
+	m.configs = configs
+	m.mu.Unlock()
+
+	// The server is already out of the manager, so shutting it down doesn't block other callers
+	server.Stop()
+	if server.handler != nil && server.handler.BatchManager != nil {
+		if err := server.handler.BatchManager.DrainWithTimeout(batchDrainTimeout); err != nil {
+			log.Printf("Error draining batch manager of server on port %d: %v", port, err)
+		}
+		server.handler.BatchManager.Stop()
+	}
+
+	m.logger.Info().Msg(fmt.Sprintf("Server on port %d stopped and removed", port))
 	return nil
 }
 
+// FailedServers returns the ports of the servers that failed by startup chaos
+func (m *Manager) FailedServers() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]int(nil), m.failedServers...)
+}
+
+// newLogDescriptor describes the logger of a server. name, logger and logger_path are optional: a
+// server without a name logs as server-<port> and without logger it does not log
+func newLogDescriptor(config models.Server, version string) models.LogDescriptor {
+	descriptor := models.LogDescriptor{
+		Name:    fmt.Sprintf("server-%d", config.Listen),
+		Version: version,
+	}
+	if config.Name != nil {
+		descriptor.Name = *config.Name
+	}
+	if config.LoggerPath != nil {
+		descriptor.Path = *config.LoggerPath
+	}
+	if config.Logger != nil {
+		descriptor.File = *config.Logger
+		descriptor.Logger = *config.Logger
+	}
+	return descriptor
+}
+
+// rollStartupFailure decides whether the server fails on startup, using chaos_seed when set
+func rollStartupFailure(config models.Server) bool {
+	if config.StartupFailProbability <= 0 {
+		return false
+	}
+
+	roll := rand.Float64()
+	if config.ChaosSeed != nil {
+		roll = rand.New(rand.NewSource(*config.ChaosSeed)).Float64()
+	}
+	return roll < config.StartupFailProbability
+}
+
+// CreateServer creates a server and adds it to the manager without starting it
 func (m *Manager) CreateServer(config models.Server) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.createServer(config)
+	return err
+}
+
+// createServer creates a server and adds it to the manager; the caller must hold m.mu
+func (m *Manager) createServer(config models.Server) (*Server, error) {
 	if _, exists := m.servers[config.Listen]; exists {
-		return fmt.Errorf("server on port %d already exists", config.Listen)
+		return nil, fmt.Errorf("server on port %d already exists", config.Listen)
+	}
+
+	if rollStartupFailure(config) {
+		return nil, fmt.Errorf("failed to bind port %d: %w", config.Listen, ErrChaosStartupFail)
 	}
 
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
+	// Without trusted_proxies no proxy is trusted, so X-Forwarded-For can't spoof the client IP
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted_proxies for server on port %d: %w", config.Listen, err)
+	}
 
 	var log *scribe.Scribe
 	var err error
 
-	log, err = logger.GetLoggerContext(models.LogDescriptor{
-		Name:    *config.Name,
-		Version: *config.Version,
-		Path:    *config.LoggerPath,
-		File:    *config.Logger,
-		Logger:  *config.Logger,
-	})
+	version := m.buildInfo.Version
+	if config.Version != nil {
+		version = *config.Version
+	}
+
+	logConfig := newLogDescriptor(config, version)
+	log, err = logger.GetLoggerContext(logConfig)
 
 	if err != nil {
 		log = &scribe.Scribe{}
 	}
-	router.Use(gin.Recovery())
 
-	db, err := database.InitDB("./database.db")
+	tlsConfig, err := newTLSSettings(config)
 	if err != nil {
-		log.Error().AnErr("error initializing database:", err).Msg("error initializing database")
-		return err
+		return nil, fmt.Errorf("invalid TLS configuration for server on port %d: %w", config.Listen, err)
 	}
 
 	batchConfig := database.BatchConfig{
@@ -120,36 +383,131 @@ func (m *Manager) CreateServer(config models.Server) error {
 		Timeout:       30 * time.Second,
 		RetryAttempts: 3,
 	}
-	batchManager := database.NewBatchManager(db, batchConfig)
+	batchManager, err := database.OpenBatchManagerFromConfig(m.dbPath, batchConfig, m.dbConfig)
+	if err != nil {
+		log.Error().AnErr("error initializing database:", err).Msg("error initializing database")
+		return nil, err
+	}
+	if m.searchIndex != nil {
+		batchManager.SetSearchIndex(m.searchIndex)
+	}
+
+	for _, indexed := range config.IndexedBodyFields {
+		if err := batchManager.AddIndexedBodyField(indexed.Field, indexed.JSONPath); err != nil {
+			return nil, fmt.Errorf("error creating indexed body fields for server on port %d: %w", config.Listen, err)
+		}
+	}
 
 	if err := batchManager.Start(); err != nil {
 		log.Error().AnErr("error initializing batch nanager:", err).Msg("error initializing database")
-		return fmt.Errorf("error starting batch manager: %v", err)
+		return nil, fmt.Errorf("error starting batch manager: %v", err)
 	}
 
 	h := handler.NewHandler(log, batchManager)
+	h.SetServerChaos(config.ChaosInjection)
+	if config.ChaosSeed != nil {
+		h.SetChaosSeed(*config.ChaosSeed)
+	}
+	if config.ChaosHistoryEnabled {
+		h.EnableChaosHistory()
+	}
+	if config.MaxResponseBodyBytes != nil {
+		h.SetMaxResponseBodyBytes(*config.MaxResponseBodyBytes)
+	}
+	if config.StatusOverrideHeader != "" {
+		h.SetStatusOverride(config.StatusOverrideHeader, config.AllowedOverrideCodes)
+	}
+	if config.Timezone != "" {
+		if err := h.SetTimezone(config.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	if config.StateMachine != nil {
+		h.SetStateMachine(*config.StateMachine)
+	}
+	if config.GlobalAsync != nil {
+		if err := h.SetGlobalAsync(*config.GlobalAsync); err != nil {
+			return nil, err
+		}
+	}
 
-	h.Logger = log
+	// The request log follows the handler logger so runtime level changes apply to it too
+	router.Use(gin.Recovery())
+	middleware, err := ConfiguredMiddleware(router, config.Middleware, h.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid middleware for server on port %d: %w", config.Listen, err)
+	}
+	router.Use(middleware...)
+	router.Use(RequestLoggingMiddleware(h.Logger))
+	if config.MaxRequestHeaderBytes > 0 {
+		router.Use(MaxHeaderBytesMiddleware(config.MaxRequestHeaderBytes))
+	}
+	securityHeaders, err := SecurityHeadersMiddleware(config.SecurityHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid security_headers for server on port %d: %w", config.Listen, err)
+	}
+	router.Use(securityHeaders)
+	if len(config.DefaultResponseHeaders) > 0 {
+		router.Use(DefaultHeadersMiddleware(config.DefaultResponseHeaders))
+	}
+
+	if prefix := normalizePathPrefix(config.PathPrefix); prefix != "" {
+		router.Use(stripPathPrefix(router, prefix))
+	}
 
 	server := &Server{
-		Port:      config.Listen,
-		Router:    router,
-		handler:   h,
-		locations: config.Location,
-		logger:    log,
+		Port:           config.Listen,
+		Router:         router,
+		handler:        h,
+		locations:      config.Location,
+		logger:         log,
+		name:           logConfig.Name,
+		logConfig:      logConfig,
+		tls:            tlsConfig,
+		group:          config.Group,
+		states:         config.StateMachine,
+		maxHeaderBytes: config.MaxRequestHeaderBytes,
+		timeouts:       newHTTPTimeouts(config),
 	}
+	server.logLevel.Store(logger.DefaultLevel())
 
 	if err := server.registerRoutes(); err != nil {
-		return fmt.Errorf("error registering routes: %w", err)
+		return nil, fmt.Errorf("error registering routes: %w", err)
 	}
 
 	m.servers[config.Listen] = server
 
-	return nil
+	return server, nil
 }
 
 func (s *Server) registerRoutes() error {
+	var (
+		regexLocations []models.Location
+		regexHandler   gin.HandlerFunc
+	)
+	// Locations skipped by mock_only_if_header fall through to the path_regex locations
+	fallback := func() gin.HandlerFunc { return regexHandler }
+
 	for _, location := range s.locations {
+		condition, err := parseHeaderCondition(location.MockOnlyIfHeader)
+		if err != nil {
+			return fmt.Errorf("error registering location %s %s%s: %w", location.Method, location.Path, location.PathRegex, err)
+		}
+
+		// Gin routes can't hold a regex, path_regex locations are matched when no route does
+		if location.PathRegex != "" {
+			if location.Path == "" {
+				location.Path = location.PathRegex
+			}
+			if err := s.handler.RegisterLocation(location); err != nil {
+				return fmt.Errorf("error registering location %s: %w", location.PathRegex, err)
+			}
+			regexLocations = append(regexLocations, location)
+			s.logger.Info().Msg(fmt.Sprintf("Registered regex route: %s %s", location.Method, location.PathRegex))
+			continue
+		}
+
+		location.Path = wildcardRoute(location)
 		if err := s.handler.RegisterLocation(location); err != nil {
 			s.logger.Error().AnErr(fmt.Sprintf("error registering location %s: %w", location.Path, err), err)
 			return err
@@ -160,45 +518,188 @@ func (s *Server) registerRoutes() error {
 			//currentPath, _ := os.Getwd()
 			s.Router.Static(location.Path, "/Users/quintero/GolandProjects/Catalyst/config/samplesite")
 		} else {
-			s.Router.Handle(location.Method, location.Path, func(loc models.Location) gin.HandlerFunc {
+			s.Router.Handle(location.Method, location.Path, onlyIfHeader(condition, func(loc models.Location) gin.HandlerFunc {
 				return func(c *gin.Context) {
 					s.handler.HandleRequest(c, loc)
 				}
-			}(location))
+			}(location), fallback))
 		}
 
 		s.logger.Info().Msg(fmt.Sprintf("Registered route: %s %s", location.Method, location.Path))
 	}
 
+	if len(regexLocations) > 0 {
+		regexHandler = s.regexRoute(regexLocations)
+		s.Router.NoRoute(regexHandler)
+	}
+
 	return nil
 }
 
-func (m *Manager) Start() error {
-	for port, server := range m.servers {
-		m.wg.Add(1)
-		go func(s *Server, p int) {
-			defer m.wg.Done()
-			if err := s.Start(); err != nil && err != http.ErrServerClosed {
-				log.Printf("Error starting server on port %d: %v", p, err)
+// regexRoute serves the first path_regex location, in config order, matching the method, path
+// and mock_only_if_header of a request no Gin route matched. Requests matching none keep the 404.
+func (s *Server) regexRoute(locations []models.Location) gin.HandlerFunc {
+	conditions := make([]*headerCondition, len(locations))
+	for i, location := range locations {
+		// Already validated by registerRoutes
+		conditions[i], _ = parseHeaderCondition(location.MockOnlyIfHeader)
+	}
+
+	return func(c *gin.Context) {
+		for i, location := range locations {
+			if strings.EqualFold(location.Method, c.Request.Method) && s.handler.MatchPathRegex(location, c.Request.URL.Path) && conditions[i].matches(c) {
+				s.handler.HandleRequest(c, location)
+				return
 			}
-		}(server, port)
+		}
+	}
+}
+
+// wildcardRoute returns the Gin route of the location, naming the trailing * of the path after
+// wildcard_param
+func wildcardRoute(location models.Location) string {
+	if location.WildcardParam == "" || !strings.HasSuffix(location.Path, "*") {
+		return location.Path
+	}
+	return location.Path + location.WildcardParam
+}
+
+// normalizePathPrefix returns the prefix with a leading slash and no trailing slash
+func normalizePathPrefix(prefix string) string {
+	prefix = strings.TrimRight(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
+// stripPathPrefix removes the reverse proxy prefix from the request path and routes the
+// request again, so locations are matched (and stored) without the prefix.
+// Global middleware also runs for unmatched routes, which is where prefixed requests land.
+func stripPathPrefix(router *gin.Engine, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			c.Next()
+			return
+		}
+
+		c.Request.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/")
+		if c.Request.URL.RawPath != "" {
+			c.Request.URL.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(c.Request.URL.RawPath, prefix), "/")
+		}
+
+		router.HandleContext(c)
+		c.Abort()
+	}
+}
+
+func (m *Manager) Start() error {
+	m.startCalled.Store(true)
+
+	for _, server := range m.serverList() {
+		m.runServer(server)
 	}
 
 	return nil
 }
 
+// serverList returns a copy of the servers so callers can work on them, e.g. dial or shut them
+// down, without holding the lock
+func (m *Manager) serverList() map[int]*Server {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	servers := make(map[int]*Server, len(m.servers))
+	for port, server := range m.servers {
+		servers[port] = server
+	}
+	return servers
+}
+
+// runServer starts a server in the background
+func (m *Manager) runServer(server *Server) {
+	m.wg.Add(1)
+	go func(s *Server) {
+		defer m.wg.Done()
+		if err := s.Start(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Error starting server on port %d: %v", s.Port, err)
+		}
+	}(server)
+}
+
+// NotReadyPorts dials every mock server and returns the ports that refuse connections
+func (m *Manager) NotReadyPorts() []int {
+	notReady := make([]int, 0)
+	for port := range m.serverList() {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 500*time.Millisecond)
+		if err != nil {
+			notReady = append(notReady, port)
+			continue
+		}
+		conn.Close()
+	}
+	sort.Ints(notReady)
+	return notReady
+}
+
+// Started reports whether every server completed its initial Start(), i.e. Start()
+// was called and all ports accepted a connection once. The result is latched.
+func (m *Manager) Started() bool {
+	if m.started.Load() {
+		return true
+	}
+	if !m.startCalled.Load() || len(m.NotReadyPorts()) > 0 {
+		return false
+	}
+	m.started.Store(true)
+	return true
+}
+
+// newHTTPTimeouts reads the connection timeouts of the server config, defaulting the header timeout
+func newHTTPTimeouts(config models.Server) httpTimeouts {
+	timeouts := httpTimeouts{
+		read:       time.Duration(config.ReadTimeoutSeconds) * time.Second,
+		write:      time.Duration(config.WriteTimeoutSeconds) * time.Second,
+		idle:       time.Duration(config.IdleTimeoutSeconds) * time.Second,
+		readHeader: time.Duration(config.ReadHeaderTimeoutSeconds) * time.Second,
+	}
+	if timeouts.readHeader == 0 {
+		timeouts.readHeader = defaultReadHeaderTimeout
+	}
+	return timeouts
+}
+
 func (s *Server) Start() error {
 	addr := ":" + strconv.Itoa(s.Port)
 	s.httpServer = &http.Server{
-		Addr:    addr,
-		Handler: s.Router,
+		Addr:              addr,
+		Handler:           s.Router,
+		ReadTimeout:       s.timeouts.read,
+		WriteTimeout:      s.timeouts.write,
+		IdleTimeout:       s.timeouts.idle,
+		ReadHeaderTimeout: s.timeouts.readHeader,
+	}
+	if s.maxHeaderBytes > 0 {
+		// net/http allows 4096 bytes over MaxHeaderBytes, so the middleware still answers 431 for
+		// headers just over the limit; this only stops clients sending far larger headers
+		s.httpServer.MaxHeaderBytes = s.maxHeaderBytes
+	}
+	if err := s.tls.configureHTTPServer(s.httpServer); err != nil {
+		return fmt.Errorf("error configuring HTTP/2 for server on port %d: %w", s.Port, err)
 	}
 
 	s.logger.Info().Msg(fmt.Sprintf("Starting server on port %d", s.Port))
-	return s.httpServer.ListenAndServe()
+	s.running.Store(true)
+	s.startedAt.Store(time.Now())
+	defer s.running.Store(false)
+	return s.tls.listenAndServe(s.httpServer)
 }
 
-func (m *Manager) CreateAPIServer(batchManager *database.BatchManager, configDir string) error {
+func (m *Manager) CreateAPIServer(batchManager *database.BatchManager, configDir string, settings *models.APISettings) error {
 	m.configDir = configDir
 
 	gin.SetMode(gin.ReleaseMode)
@@ -206,11 +707,13 @@ func (m *Manager) CreateAPIServer(batchManager *database.BatchManager, configDir
 	router := gin.New()
 	router.Use(gin.Recovery())
 
-	api.SetupRoutes(router, batchManager, configDir, m.restartChan)
-
-	m.apiServer = &Server{
-		Port:   8282,
-		Router: router,
+	auth := api.AuthConfig{}
+	if settings != nil {
+		auth = api.AuthConfig{
+			Mode:      settings.Auth,
+			APIKey:    settings.APIKey,
+			JWTSecret: settings.JWTSecret,
+		}
 	}
 
 	m.restartManager = api.NewRestartManager(m.restartChan, func(serverName string) error {
@@ -218,21 +721,75 @@ func (m *Manager) CreateAPIServer(batchManager *database.BatchManager, configDir
 		return nil
 	})
 
+	api.SetupRoutes(router, api.APIDependencies{
+		BatchManager:   batchManager,
+		ConfigDir:      configDir,
+		RestartChan:    m.restartChan,
+		Configs:        m,
+		ChaosHistory:   m,
+		CacheStats:     m,
+		LogLevels:      m,
+		RealtimeStats:  m,
+		Launcher:       m,
+		Groups:         m,
+		States:         m,
+		Servers:        m,
+		Schemas:        m,
+		RestartManager: m.restartManager,
+		Postgres:       m.postgres,
+		BuildInfo:      m.buildInfo,
+		Auth:           auth,
+	})
+	api.SetupProbeRoutes(router, batchManager, m)
+
+	m.apiServer = &Server{
+		Port:   8282,
+		Router: router,
+	}
+
 	return nil
 }
 
+// DefaultMetricsListenAddr is the metrics server bind address when none is configured
+const DefaultMetricsListenAddr = "0.0.0.0"
+
+// MetricsConfig configures the Prometheus metrics server
+type MetricsConfig struct {
+	Port       int
+	AuthToken  string // When set, /metrics requires `Authorization: Bearer <token>`
+	ListenAddr string // Bind address, e.g. 127.0.0.1 to expose metrics on loopback only
+}
+
 func (m *Manager) CreateMetricsServer(port int) error {
+	return m.CreateMetricsServerWithConfig(MetricsConfig{Port: port})
+}
+
+// CreateMetricsServerWithConfig creates the metrics server with optional bearer token auth
+func (m *Manager) CreateMetricsServerWithConfig(cfg MetricsConfig) error {
+	if cfg.Port <= 0 {
+		return fmt.Errorf("invalid metrics port: %d", cfg.Port)
+	}
+	if cfg.ListenAddr == "" {
+		cfg.ListenAddr = DefaultMetricsListenAddr
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
 	router.Use(gin.Recovery())
 
 	// Setup metrics endpoint
-	router.GET("/metrics", gin.WrapH(prom.PromHTTPHandler()))
+	metricsHandler := gin.WrapH(prom.PromHTTPHandler())
+	if cfg.AuthToken != "" {
+		router.GET("/metrics", api.BearerTokenMiddleware(cfg.AuthToken), metricsHandler)
+	} else {
+		router.GET("/metrics", metricsHandler)
+	}
 
 	m.metricsServer = &Server{
-		Port:   port,
-		Router: router,
+		Port:       cfg.Port,
+		ListenAddr: cfg.ListenAddr,
+		Router:     router,
 	}
 
 	return nil
@@ -246,7 +803,7 @@ func (m *Manager) StartMetricsServer() error {
 	m.wg.Add(1)
 	go func() {
 		defer m.wg.Done()
-		addr := ":" + strconv.Itoa(m.metricsServer.Port)
+		addr := net.JoinHostPort(m.metricsServer.ListenAddr, strconv.Itoa(m.metricsServer.Port))
 		m.metricsServer.httpServer = &http.Server{
 			Addr:    addr,
 			Handler: m.metricsServer.Router,
@@ -313,21 +870,15 @@ func (m *Manager) GetRestartChan() chan string {
 	return m.restartChan
 }
 
+// ReloadConfig loads again the config file named serverName, matched by its top-level name or its file name
 func (m *Manager) ReloadConfig(serverName string) (*models.MockServer, error) {
-	var configFile string
-
-	if m.configDir != "" {
-		extensions := []string{".yml", ".yaml"}
-		for _, ext := range extensions {
-			configFile = filepath.Join(m.configDir, serverName+ext)
-			if _, err := os.Stat(configFile); err == nil {
-				break
-			}
-		}
+	if m.configDir == "" {
+		return nil, fmt.Errorf("configuración no encontrada para el servidor: %s", serverName)
 	}
 
-	if configFile == "" {
-		return nil, fmt.Errorf("configuración no encontrada para el servidor: %s", serverName)
+	configFile, err := config.FindConfigFile(m.configDir, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("configuración no encontrada para el servidor %s: %w", serverName, err)
 	}
 
 	config, err := config.LoadConfig(configFile)
@@ -335,7 +886,7 @@ func (m *Manager) ReloadConfig(serverName string) (*models.MockServer, error) {
 		return nil, fmt.Errorf("error cargando configuración actualizada: %w", err)
 	}
 
-	log.Printf("Configuración recargada exitosamente para servidor: %s", serverName)
+	log.Printf("Configuración %s (versión %q) recargada exitosamente para servidor: %s", config.Name, config.Version, serverName)
 	return config, nil
 }
 
@@ -368,7 +919,7 @@ func (m *Manager) restartServerAttempt(serverName string) error {
 	var targetServerConfig models.Server
 	var found bool
 	for _, serverConfig := range config.Http.Servers {
-		if strings.EqualFold(*serverConfig.Name, serverName) {
+		if serverConfig.Name != nil && strings.EqualFold(*serverConfig.Name, serverName) {
 			targetServerConfig = serverConfig
 			found = true
 			log.Printf("DEBUG: Nueva configuración encontrada - nombre: %s, puerto: %d", *serverConfig.Name, serverConfig.Listen)
@@ -376,14 +927,26 @@ func (m *Manager) restartServerAttempt(serverName string) error {
 		}
 	}
 
+	// El nombre de la configuración identifica a su servidor cuando define uno solo
+	if !found && strings.EqualFold(config.Name, serverName) && len(config.Http.Servers) == 1 {
+		targetServerConfig = config.Http.Servers[0]
+		if targetServerConfig.Name != nil {
+			serverName = *targetServerConfig.Name
+		}
+		found = true
+		log.Printf("DEBUG: Nueva configuración encontrada por nombre de configuración %s - servidor: %s, puerto: %d", config.Name, serverName, targetServerConfig.Listen)
+	}
+
 	if !found {
 		return fmt.Errorf("servidor %s no encontrado en configuración recargada", serverName)
 	}
+	targetServerConfig = m.withVersion(config, targetServerConfig)
 
 	var targetPort int
 	var targetServer *Server
 	newPort := targetServerConfig.Listen
 
+	m.mu.Lock()
 	if server, exists := m.servers[newPort]; exists {
 		targetServer = server
 		targetPort = newPort
@@ -391,7 +954,7 @@ func (m *Manager) restartServerAttempt(serverName string) error {
 	} else {
 		for _, storedConfig := range m.configs {
 			for _, serverConfig := range storedConfig.Http.Servers {
-				if strings.EqualFold(*serverConfig.Name, serverName) {
+				if serverConfig.Name != nil && strings.EqualFold(*serverConfig.Name, serverName) {
 					oldPort := serverConfig.Listen
 					if oldPort != newPort {
 						if server, exists := m.servers[oldPort]; exists {
@@ -410,9 +973,12 @@ func (m *Manager) restartServerAttempt(serverName string) error {
 	}
 
 	if targetServer != nil {
+		// Se quita del manager antes de detenerlo para no bloquear al resto mientras se apaga
+		delete(m.servers, targetPort)
+		m.mu.Unlock()
+
 		log.Printf("DEBUG: Deteniendo servidor en puerto %d", targetPort)
 		targetServer.Stop()
-		delete(m.servers, targetPort)
 
 		if targetPort == newPort {
 			if !waitForPortToBeFree(targetPort, 5*time.Second) {
@@ -430,6 +996,7 @@ func (m *Manager) restartServerAttempt(serverName string) error {
 			}
 			return ports
 		}())
+		m.mu.Unlock()
 		log.Printf("DEBUG: Servidor no está en ejecución, se creará en puerto %d", newPort)
 	}
 
@@ -442,20 +1009,20 @@ func (m *Manager) restartServerAttempt(serverName string) error {
 	}
 
 	// 6. Crear nuevo servidor con configuración actualizada
-	log.Printf("DEBUG: Creando servidor con configuración actualizada - nombre: %s, puerto: %d", *targetServerConfig.Name, targetServerConfig.Listen)
+	log.Printf("DEBUG: Creando servidor con configuración actualizada - nombre: %s, puerto: %d", serverName, targetServerConfig.Listen)
 
 	if !isPortAvailable(targetServerConfig.Listen) {
 		return fmt.Errorf("puerto %d aún está ocupado", targetServerConfig.Listen)
 	}
 
-	if err := m.CreateServer(targetServerConfig); err != nil {
+	m.mu.Lock()
+	newServer, err := m.createServer(targetServerConfig)
+	if err != nil {
+		m.mu.Unlock()
 		return fmt.Errorf("error creando servidor actualizado: %w", err)
 	}
-
-	newServer := m.servers[targetServerConfig.Listen]
-	if newServer == nil {
-		return fmt.Errorf("error: nuevo servidor no se creó correctamente")
-	}
+	m.updateStoredConfig(serverName, config)
+	m.mu.Unlock()
 
 	m.wg.Add(1)
 	go func(s *Server, p int, name string) {
@@ -466,14 +1033,14 @@ func (m *Manager) restartServerAttempt(serverName string) error {
 	}(newServer, targetServerConfig.Listen, serverName)
 
 	log.Printf("Servidor %s reiniciado exitosamente en puerto %d", serverName, targetServerConfig.Listen)
-	m.updateStoredConfig(serverName, config)
 	return nil
 }
 
+// updateStoredConfig reemplaza en memoria la configuración del servidor; quien llama debe tener m.mu
 func (m *Manager) updateStoredConfig(serverName string, newConfig *models.MockServer) {
 	for i, storedConfig := range m.configs {
 		for _, serverConfig := range storedConfig.Http.Servers {
-			if strings.EqualFold(*serverConfig.Name, serverName) {
+			if serverConfig.Name != nil && strings.EqualFold(*serverConfig.Name, serverName) {
 				m.configs[i] = newConfig
 				log.Printf("DEBUG: Configuración actualizada en memoria para servidor: %s", serverName)
 				return
@@ -485,6 +1052,277 @@ func (m *Manager) updateStoredConfig(serverName string, newConfig *models.MockSe
 	log.Printf("DEBUG: Nueva configuración agregada en memoria para servidor: %s", serverName)
 }
 
+// LaunchServer creates a server that is not part of the loaded configuration, e.g. a clone, and
+// starts it. Its config is kept in memory so it can be found by name like the others.
+func (m *Manager) LaunchServer(config models.Server) error {
+	if config.Name == nil {
+		return fmt.Errorf("server on port %d has no name", config.Listen)
+	}
+
+	m.mu.Lock()
+	if _, exists := m.servers[config.Listen]; exists {
+		m.mu.Unlock()
+		return fmt.Errorf("port %d is already used by another server", config.Listen)
+	}
+
+	server, err := m.createServer(config)
+	if err != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("error creating server %s: %w", *config.Name, err)
+	}
+	m.configs = append(m.configs, &models.MockServer{Http: models.Http{Servers: []models.Server{config}}})
+	m.mu.Unlock()
+
+	m.runServer(server)
+
+	return nil
+}
+
+// RunningConfig returns the in-memory config that contains the named server, or that has the name itself
+func (m *Manager) RunningConfig(serverName string) (*models.MockServer, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, storedConfig := range m.configs {
+		if strings.EqualFold(storedConfig.Name, serverName) {
+			return storedConfig, true
+		}
+		for _, serverConfig := range storedConfig.Http.Servers {
+			if serverConfig.Name != nil && strings.EqualFold(*serverConfig.Name, serverName) {
+				return storedConfig, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ChaosHistory merges the chaos events of every mock server, newest first
+func (m *Manager) ChaosHistory(path, method string, limit int) []models.ChaosEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := make([]models.ChaosEvent, 0)
+	for _, server := range m.servers {
+		events = append(events, server.handler.ChaosHistory(path, method, limit)...)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp.After(events[j].Timestamp)
+	})
+
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
+
+// CacheStats returns the response cache statistics of every mock server keyed by port
+func (m *Manager) CacheStats() map[int]models.CacheStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[int]models.CacheStats, len(m.servers))
+	for port, server := range m.servers {
+		stats[port] = server.handler.CacheStats()
+	}
+	return stats
+}
+
+// RealtimeStats merges the in-memory request counters of every mock server by method and path
+func (m *Manager) RealtimeStats() []models.RequestCounter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	merged := make(map[string]*models.RequestCounter)
+	for _, server := range m.servers {
+		for _, counter := range server.handler.RequestCounters() {
+			key := counter.Method + " " + counter.Path
+			if existing, ok := merged[key]; ok {
+				existing.Total += counter.Total
+				existing.Errors += counter.Errors
+				continue
+			}
+			counter := counter
+			merged[key] = &counter
+		}
+	}
+
+	counters := make([]models.RequestCounter, 0, len(merged))
+	for _, counter := range merged {
+		counters = append(counters, *counter)
+	}
+	sort.Slice(counters, func(i, j int) bool {
+		if counters[i].Path != counters[j].Path {
+			return counters[i].Path < counters[j].Path
+		}
+		return counters[i].Method < counters[j].Method
+	})
+	return counters
+}
+
+// ResetRealtimeStats resets the in-memory request counters of every mock server
+func (m *Manager) ResetRealtimeStats() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, server := range m.servers {
+		server.handler.ResetRequestCounters()
+	}
+}
+
+// Servers lists every mock server with its status and request totals, sorted by port
+func (m *Manager) Servers() []api.ServerInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	servers := make([]api.ServerInfo, 0, len(m.servers))
+	for _, server := range m.servers {
+		servers = append(servers, server.info())
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Port < servers[j].Port
+	})
+	return servers
+}
+
+// Server returns the status of the mock server listening on port
+func (m *Manager) Server(port int) (api.ServerInfo, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	server, ok := m.servers[port]
+	if !ok {
+		return api.ServerInfo{}, false
+	}
+	return server.info(), true
+}
+
+// Schemas returns the JSON schema of every location of the servers, sorted by server, path and method
+func (m *Manager) Schemas() []api.LocationSchema {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var schemas []api.LocationSchema
+	for _, server := range m.servers {
+		for key, schema := range server.handler.GetSchemas() {
+			// The key is path:method, the path itself may hold : parameters
+			sep := strings.LastIndex(key, ":")
+			schemas = append(schemas, api.LocationSchema{
+				Server: server.name,
+				Path:   key[:sep],
+				Method: key[sep+1:],
+				Schema: schema,
+			})
+		}
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		if schemas[i].Server != schemas[j].Server {
+			return schemas[i].Server < schemas[j].Server
+		}
+		if schemas[i].Path != schemas[j].Path {
+			return schemas[i].Path < schemas[j].Path
+		}
+		return schemas[i].Method < schemas[j].Method
+	})
+	return schemas
+}
+
+// info summarizes the server status and its realtime request counters
+func (s *Server) info() api.ServerInfo {
+	info := api.ServerInfo{
+		Port:          s.Port,
+		Name:          s.name,
+		Status:        api.ServerStatusStopped,
+		LocationCount: len(s.locations),
+	}
+	if s.running.Load() {
+		info.Status = api.ServerStatusRunning
+		if startedAt, ok := s.startedAt.Load().(time.Time); ok {
+			info.StartedAt = &startedAt
+		}
+	}
+	for _, counter := range s.handler.RequestCounters() {
+		info.RequestTotal += counter.Total
+		info.ErrorTotal += counter.Errors
+	}
+	return info
+}
+
+// LogLevel returns the current log level of the named server
+func (m *Manager) LogLevel(serverName string) (string, bool) {
+	server, ok := m.serverByName(serverName)
+	if !ok {
+		return "", false
+	}
+	return server.logLevel.Load().(string), true
+}
+
+// SetLogLevel changes the log level of the named server; it applies from the next request
+func (m *Manager) SetLogLevel(serverName, level string) error {
+	server, ok := m.serverByName(serverName)
+	if !ok {
+		return fmt.Errorf("server %s not found", serverName)
+	}
+	return server.SetLogLevel(level)
+}
+
+// SetLogLevel re-creates the server logger with a new minimum level and swaps it into the handler
+func (s *Server) SetLogLevel(level string) error {
+	if err := logger.ValidateLevel(level); err != nil {
+		return err
+	}
+
+	log, err := logger.GetLoggerContextWithLevel(s.logConfig, level)
+	if err != nil {
+		return fmt.Errorf("error creating logger: %w", err)
+	}
+
+	s.handler.SetLogger(log)
+	s.logLevel.Store(level)
+	return nil
+}
+
+// State returns the state machine of a running server
+func (m *Manager) State(serverName string) (api.ServerState, bool) {
+	server, ok := m.serverByName(serverName)
+	if !ok || server.states == nil {
+		return api.ServerState{}, false
+	}
+
+	current, _ := server.handler.State()
+	return api.ServerState{
+		ServerName:   server.name,
+		State:        current,
+		InitialState: server.states.InitialState,
+		States:       server.states.States,
+	}, true
+}
+
+// SetState moves a running server to state; an empty state resets it to the initial state
+func (m *Manager) SetState(serverName, state string) error {
+	server, ok := m.serverByName(serverName)
+	if !ok || server.states == nil {
+		return fmt.Errorf("server %s not found or without state machine", serverName)
+	}
+	if state != "" && !server.states.Has(state) {
+		return fmt.Errorf("%w: %s", api.ErrUnknownState, state)
+	}
+	return server.handler.SetState(state)
+}
+
+// serverByName finds a running mock server by its configured name
+func (m *Manager) serverByName(serverName string) (*Server, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, server := range m.servers {
+		if strings.EqualFold(server.name, serverName) {
+			return server, true
+		}
+	}
+	return nil, false
+}
+
 func (m *Manager) RestartAPIServer() error {
 	log.Printf("Reiniciando servidor API...")
 
@@ -515,14 +1353,34 @@ func (m *Manager) Stop() {
 		m.metricsServer.Stop()
 	}
 
-	for _, server := range m.servers {
+	servers := m.serverList()
+	for _, server := range servers {
 		server.Stop()
 	}
+
+	// With the HTTP servers down nothing else is queued; persist what is left before exiting
+	for port, server := range servers {
+		if server.handler == nil || server.handler.BatchManager == nil {
+			continue
+		}
+		if err := server.handler.BatchManager.DrainWithTimeout(batchDrainTimeout); err != nil {
+			log.Printf("Error draining batch manager of server on port %d: %v", port, err)
+		}
+		server.handler.BatchManager.Stop()
+	}
 	m.wg.Wait()
 }
 
 // Stop stops the server
 func (s *Server) Stop() {
+	s.shutdown()
+	if s.handler != nil {
+		s.handler.Close()
+	}
+}
+
+// shutdown gracefully stops the HTTP server but keeps the handler, so the server can be started again
+func (s *Server) shutdown() {
 	if s.httpServer != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -531,6 +1389,7 @@ func (s *Server) Stop() {
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
+	s.running.Store(false)
 }
 
 // Wait waits for all servers to stop
@@ -1,13 +1,32 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"catalyst/api"
+	"catalyst/internal/config"
+	"catalyst/internal/logger"
 	"catalyst/internal/models"
+	prom "catalyst/prometheus"
+
+	"github.com/SOLUCIONESSYCOM/scribe"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/net/http2"
 )
 
 func TestCreateServer(t *testing.T) {
@@ -214,3 +233,1580 @@ func TestStartStop(t *testing.T) {
 	// Wait for the server to stop
 	manager.Wait()
 }
+
+func TestProbes(t *testing.T) {
+	// Create a server manager
+	manager := NewManager()
+
+	// One port with a listener and one without
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	listening := ln.Addr().(*net.TCPAddr).Port
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	notListening := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	manager.servers[listening] = &Server{Port: listening}
+	manager.servers[notListening] = &Server{Port: notListening}
+
+	notReady := manager.NotReadyPorts()
+	if len(notReady) != 1 || notReady[0] != notListening {
+		t.Errorf("Expected not ready ports [%d], got %v", notListening, notReady)
+	}
+
+	// Start() was never called
+	if manager.Started() {
+		t.Error("Expected Started() to be false before Start()")
+	}
+
+	manager.startCalled.Store(true)
+	if manager.Started() {
+		t.Error("Expected Started() to be false while a server is not listening")
+	}
+
+	delete(manager.servers, notListening)
+	if !manager.Started() {
+		t.Error("Expected Started() to be true once every server is listening")
+	}
+}
+
+func TestPathPrefixStripping(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "PREFIXED"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:     18095,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		PathPrefix: "/mock-svc/",
+		Location: []models.Location{
+			{
+				Path:       "/api/test",
+				Method:     "GET",
+				Response:   `{"message":"prefixed"}`,
+				StatusCode: 200,
+			},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18095]
+	defer server.handler.BatchManager.Stop()
+
+	// The prefixed path is routed to the location
+	recepcionID := fmt.Sprintf("prefix-%d", time.Now().UnixNano())
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mock-svc/api/test", nil)
+	req.Header.Set("X-Recepcion-ID", recepcionID)
+	server.Router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code 200 for prefixed path, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "prefixed") {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+
+	// Paths without the prefix are still routed
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/test", nil)
+	server.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code 200 for unprefixed path, got %d", w.Code)
+	}
+
+	// A path that only shares the first characters of the prefix is not stripped
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/mock-svcx/api/test", nil)
+	server.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code 404, got %d", w.Code)
+	}
+
+	// The stored endpoint is the stripped path
+	var endpoint string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		err := server.handler.BatchManager.GetDB().QueryRow(
+			"SELECT request_endpoint FROM mock_transactions WHERE recepcion_id = ?", recepcionID).Scan(&endpoint)
+		if err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if endpoint != "/api/test" {
+		t.Errorf("Expected stored endpoint /api/test, got %q", endpoint)
+	}
+}
+
+func TestStartupFailProbability(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	newServer := func(name string, port int, probability float64) models.Server {
+		return models.Server{
+			Listen:                 port,
+			Logger:                 &logger,
+			Name:                   &name,
+			Version:                &version,
+			LoggerPath:             &loggerPath,
+			StartupFailProbability: probability,
+			Location: []models.Location{
+				{
+					Path:       "/api/test",
+					Method:     "GET",
+					Response:   `{"message":"test"}`,
+					StatusCode: 200,
+				},
+			},
+		}
+	}
+
+	err := manager.CreateServers(&models.MockServer{
+		Http: models.Http{
+			Servers: []models.Server{
+				newServer("FAILING", 18096, 1),
+				newServer("HEALTHY", 18097, 0),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Startup chaos must not fail CreateServers: %v", err)
+	}
+
+	if failed := manager.FailedServers(); len(failed) != 1 || failed[0] != 18096 {
+		t.Errorf("Expected failed servers [18096], got %v", failed)
+	}
+	if _, exists := manager.servers[18096]; exists {
+		t.Error("Failing server must not be registered")
+	}
+	server, exists := manager.servers[18097]
+	if !exists {
+		t.Fatal("Healthy server should be created")
+	}
+	defer server.handler.BatchManager.Stop()
+
+	// CreateServer reports the sentinel error
+	err = manager.CreateServer(newServer("FAILING", 18098, 1))
+	if !errors.Is(err, ErrChaosStartupFail) {
+		t.Errorf("Expected ErrChaosStartupFail, got %v", err)
+	}
+}
+
+func TestRequestLoggingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logDir := t.TempDir()
+	log, err := logger.GetLoggerContext(models.LogDescriptor{
+		Name:    "LOGGING",
+		Version: "0.0.1",
+		Path:    logDir,
+		File:    true,
+		Logger:  false,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(RequestLoggingMiddleware(func() *scribe.Scribe { return log }))
+	router.GET("/api/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/test", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("User-Agent", "logging-test")
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Request-ID"); got != "req-123" {
+		t.Errorf("Expected X-Request-ID to be echoed, got %q", got)
+	}
+
+	// Without the header a request ID is generated
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/test", nil)
+	router.ServeHTTP(w, req)
+	if w.Header().Get("X-Request-ID") == "" {
+		t.Error("Expected a generated X-Request-ID")
+	}
+
+	var output strings.Builder
+	filepath.WalkDir(logDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			data, _ := os.ReadFile(path)
+			output.Write(data)
+		}
+		return nil
+	})
+
+	logs := output.String()
+	for _, expected := range []string{
+		`"method":"GET"`,
+		`"path":"/api/test"`,
+		`"status":200`,
+		`"latency_ms"`,
+		`"client_ip"`,
+		`"request_id":"req-123"`,
+		`"user_agent":"logging-test"`,
+		`"response_bytes":5`,
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("Expected log output to contain %s, got %s", expected, logs)
+		}
+	}
+}
+
+func TestDefaultResponseHeaders(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "HEADERS"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:     18099,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		DefaultResponseHeaders: models.Headers{
+			"X-Mock-Server":          "mockingbird",
+			"X-Content-Type-Options": "nosniff",
+		},
+		Location: []models.Location{
+			{
+				Path:       "/api/default",
+				Method:     "GET",
+				Response:   `{"message":"default"}`,
+				StatusCode: 200,
+			},
+			{
+				Path:       "/api/override",
+				Method:     "GET",
+				Response:   `{"message":"override"}`,
+				StatusCode: 200,
+				Headers:    &models.Headers{"X-Mock-Server": "custom"},
+			},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18099]
+	defer server.handler.BatchManager.Stop()
+
+	serve := func(path string) http.Header {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		server.Router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s, got %d", path, w.Code)
+		}
+		return w.Header()
+	}
+
+	headers := serve("/api/default")
+	if headers.Get("X-Mock-Server") != "mockingbird" || headers.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected server default headers, got %v", headers)
+	}
+
+	// The location header wins, the other defaults are kept
+	headers = serve("/api/override")
+	if headers.Get("X-Mock-Server") != "custom" {
+		t.Errorf("Expected location override, got %s", headers.Get("X-Mock-Server"))
+	}
+	if headers.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected server default to be kept, got %v", headers)
+	}
+}
+
+func TestMaxRequestHeaderBytes(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "HEADER_LIMIT"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:                18109,
+		Logger:                &logger,
+		Name:                  &name,
+		Version:               &version,
+		LoggerPath:            &loggerPath,
+		MaxRequestHeaderBytes: 8192,
+		Location: []models.Location{
+			{
+				Path:       "/api/headers",
+				Method:     "GET",
+				Response:   `{"message":"ok"}`,
+				StatusCode: 200,
+			},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18109]
+	defer server.handler.BatchManager.Stop()
+
+	rejected := prom.HandlerHeaderTooLargeTotal.WithLabelValues("/api/headers", "GET")
+	before := testutil.ToFloat64(rejected)
+
+	serve := func(value string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/headers", nil)
+		req.Header.Set("X-Large", value)
+		server.Router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve(strings.Repeat("a", 100)); code != http.StatusOK {
+		t.Errorf("Expected 200 for a small header, got %d", code)
+	}
+	if code := serve(strings.Repeat("a", 10000)); code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("Expected 431 for a 10000 byte header, got %d", code)
+	}
+	if got := testutil.ToFloat64(rejected) - before; got != 1 {
+		t.Errorf("Expected handler_header_too_large_total to increase by 1, got %v", got)
+	}
+}
+
+func TestPathRegex(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "PATH_REGEX"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:     18110,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		Location: []models.Location{
+			{
+				Path:       "/api/v1/users/me",
+				Method:     "GET",
+				Response:   `{"user":"me"}`,
+				StatusCode: 200,
+			},
+			{
+				PathRegex:  "^/api/v[0-9]+/users/[0-9]+$",
+				Method:     "GET",
+				Response:   `{"user":"by-id"}`,
+				StatusCode: 200,
+			},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18110]
+	defer server.handler.BatchManager.Stop()
+
+	tests := []struct {
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"GET", "/api/v1/users/me", http.StatusOK, `{"user":"me"}`},
+		{"GET", "/api/v1/users/42", http.StatusOK, `{"user":"by-id"}`},
+		{"GET", "/api/v12/users/7", http.StatusOK, `{"user":"by-id"}`},
+		{"GET", "/api/v1/users/abc", http.StatusNotFound, ""},
+		{"POST", "/api/v1/users/42", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		server.Router.ServeHTTP(w, req)
+		if w.Code != tt.expectedCode {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.expectedCode, w.Code)
+		}
+		if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.expectedBody, w.Body.String())
+		}
+	}
+
+	// Invalid patterns are rejected when the location is registered
+	invalidName := "PATH_REGEX_INVALID"
+	serverConfig.Listen = 18111
+	serverConfig.Name = &invalidName
+	serverConfig.Location = []models.Location{{PathRegex: "^/api/(", Method: "GET", StatusCode: 200}}
+	if err := manager.CreateServer(serverConfig); err == nil {
+		t.Error("Expected an error for an invalid path_regex")
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	manager := NewManager()
+
+	logEnabled := true
+	name := "LOGLEVEL"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:     18100,
+		Logger:     &logEnabled,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		Location: []models.Location{
+			{
+				Path:       "/api/level",
+				Method:     "GET",
+				Response:   `{"message":"level"}`,
+				StatusCode: 200,
+			},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18100]
+	// Manager.Stop drains the recorded requests before stopping the batch manager
+	defer manager.Stop()
+
+	countDebugLogs := func() int {
+		var output strings.Builder
+		filepath.WalkDir(loggerPath, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				data, _ := os.ReadFile(path)
+				output.Write(data)
+			}
+			return nil
+		})
+		return strings.Count(output.String(), "Handling request")
+	}
+
+	serve := func() {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/level", nil)
+		server.Router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+	}
+
+	if err := manager.SetLogLevel("loglevel", "info"); err != nil {
+		t.Fatalf("Failed to set log level: %v", err)
+	}
+	if level, ok := manager.LogLevel("LOGLEVEL"); !ok || level != "info" {
+		t.Errorf("Expected level info, got %q (found %v)", level, ok)
+	}
+
+	serve()
+	if count := countDebugLogs(); count != 0 {
+		t.Errorf("Expected no debug logs at info level, got %d", count)
+	}
+
+	if err := manager.SetLogLevel("LOGLEVEL", "debug"); err != nil {
+		t.Fatalf("Failed to set log level: %v", err)
+	}
+
+	serve()
+	if count := countDebugLogs(); count != 1 {
+		t.Errorf("Expected 1 debug log after switching to debug, got %d", count)
+	}
+
+	if err := manager.SetLogLevel("LOGLEVEL", "verbose"); !errors.Is(err, logger.ErrInvalidLevel) {
+		t.Errorf("Expected ErrInvalidLevel, got %v", err)
+	}
+	if err := manager.SetLogLevel("MISSING", "debug"); err == nil {
+		t.Error("Expected an error for an unknown server")
+	}
+}
+
+func TestMetricsServerAuth(t *testing.T) {
+	manager := NewManager()
+
+	if err := manager.CreateMetricsServerWithConfig(MetricsConfig{Port: 18101, AuthToken: "secret", ListenAddr: "127.0.0.1"}); err != nil {
+		t.Fatalf("Failed to create metrics server: %v", err)
+	}
+	if manager.metricsServer.ListenAddr != "127.0.0.1" {
+		t.Errorf("Expected listen address 127.0.0.1, got %s", manager.metricsServer.ListenAddr)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer wrong", http.StatusUnauthorized},
+		{"correct token", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			manager.metricsServer.Router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestMetricsServerDefaults(t *testing.T) {
+	manager := NewManager()
+
+	if err := manager.CreateMetricsServer(18102); err != nil {
+		t.Fatalf("Failed to create metrics server: %v", err)
+	}
+	if manager.metricsServer.ListenAddr != DefaultMetricsListenAddr {
+		t.Errorf("Expected listen address %s, got %s", DefaultMetricsListenAddr, manager.metricsServer.ListenAddr)
+	}
+
+	w := httptest.NewRecorder()
+	manager.metricsServer.Router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected unauthenticated metrics to return 200, got %d", w.Code)
+	}
+}
+
+func TestHTTP2(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    models.Server
+		url       string
+		transport *http2.Transport
+	}{
+		{
+			name:   "http2 over tls_auto",
+			config: models.Server{Listen: 18103, HTTP2: true, TLSAuto: true},
+			url:    "https://127.0.0.1:18103/api/h2",
+			transport: &http2.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		{
+			name:   "cleartext h2c",
+			config: models.Server{Listen: 18104, H2C: true},
+			url:    "http://127.0.0.1:18104/api/h2",
+			transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, network, addr)
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+
+			tt.config.Location = []models.Location{
+				{Path: "/api/h2", Method: "GET", Response: `{"message":"h2"}`, StatusCode: 200},
+			}
+			if err := manager.CreateServer(tt.config); err != nil {
+				t.Fatalf("Failed to create server: %v", err)
+			}
+			if err := manager.Start(); err != nil {
+				t.Fatalf("Failed to start server: %v", err)
+			}
+			defer manager.Stop()
+
+			client := &http.Client{Transport: tt.transport, Timeout: 5 * time.Second}
+			var (
+				resp *http.Response
+				err  error
+			)
+			for attempt := 0; attempt < 20; attempt++ {
+				if resp, err = client.Get(tt.url); err == nil {
+					break
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+			if err != nil {
+				t.Fatalf("HTTP/2 request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.ProtoMajor != 2 {
+				t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHTTP2RequiresTLS(t *testing.T) {
+	manager := NewManager()
+
+	err := manager.CreateServer(models.Server{
+		Listen:   18105,
+		HTTP2:    true,
+		Location: []models.Location{{Path: "/api/h2", Method: "GET", StatusCode: 200}},
+	})
+	if err == nil {
+		t.Error("Expected an error enabling http2 without TLS")
+	}
+}
+
+func TestServerGroups(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	loggerPath := ""
+	version := "1.0.0"
+	servers := []struct {
+		name  string
+		port  int
+		group string
+	}{
+		{"payments", 18106, "payment-services"},
+		{"refunds", 18107, "payment-services"},
+		{"users", 18108, ""},
+	}
+	for _, s := range servers {
+		name := s.name
+		err := manager.CreateServer(models.Server{
+			Listen:     s.port,
+			Name:       &name,
+			Version:    &version,
+			Logger:     &logger,
+			LoggerPath: &loggerPath,
+			Group:      s.group,
+			Location:   []models.Location{{Path: "/health", Method: "GET", Response: `{"status":"ok"}`, StatusCode: 200}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create server %s: %v", s.name, err)
+		}
+	}
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Failed to start servers: %v", err)
+	}
+	defer manager.Stop()
+
+	client := &http.Client{Timeout: time.Second}
+	healthy := func(port int) bool {
+		for attempt := 0; attempt < 20; attempt++ {
+			resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/health", port))
+			if err == nil {
+				resp.Body.Close()
+				return resp.StatusCode == http.StatusOK
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		return false
+	}
+	for _, s := range servers {
+		if !healthy(s.port) {
+			t.Fatalf("Server %s not healthy after start", s.name)
+		}
+	}
+
+	groups := manager.Groups()
+	if len(groups) != 1 || groups[0].Name != "payment-services" || groups[0].ServerCount != 2 || groups[0].Status != "running" {
+		t.Fatalf("Unexpected groups: %+v", groups)
+	}
+
+	if err := manager.StopGroup("payment-services"); err != nil {
+		t.Fatalf("StopGroup failed: %v", err)
+	}
+	for _, s := range servers[:2] {
+		if resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/health", s.port)); err == nil {
+			resp.Body.Close()
+			t.Errorf("Expected server %s to stop responding after StopGroup", s.name)
+		}
+	}
+	if !healthy(18108) {
+		t.Error("Expected the ungrouped server to keep responding")
+	}
+	if groups := manager.Groups(); groups[0].Running != 0 || groups[0].Status != "stopped" {
+		t.Errorf("Expected the group to be stopped, got %+v", groups[0])
+	}
+
+	if err := manager.StartGroup("payment-services"); err != nil {
+		t.Fatalf("StartGroup failed: %v", err)
+	}
+	for _, s := range servers[:2] {
+		if !healthy(s.port) {
+			t.Errorf("Expected server %s to respond after StartGroup", s.name)
+		}
+	}
+
+	if err := manager.StopGroup("unknown"); !errors.Is(err, api.ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+func TestWatchDirServers(t *testing.T) {
+	manager := NewManager()
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Failed to start manager: %v", err)
+	}
+	defer manager.Stop()
+
+	tempDir := t.TempDir()
+	watcher, err := config.WatchDir(tempDir, func(cfg *models.MockServer) {
+		if err := manager.CreateServers(cfg); err != nil {
+			t.Errorf("Failed to create servers: %v", err)
+		}
+	}, func(cfg *models.MockServer) {
+		for _, serverConfig := range cfg.Http.Servers {
+			if err := manager.StopServer(serverConfig.Listen); err != nil {
+				t.Errorf("Failed to stop server: %v", err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("WatchDir failed: %v", err)
+	}
+	defer watcher.Close()
+
+	configFile := filepath.Join(tempDir, "dropped.yaml")
+	configData := `http:
+  servers:
+    - listen: 18112
+      logger: false
+      location:
+        - path: /health
+          method: GET
+          response: '{"status":"ok"}'
+          status_code: 200
+`
+	if err := os.WriteFile(configFile, []byte(configData), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	client := &http.Client{Timeout: time.Second}
+	responds := func() bool {
+		resp, err := client.Get("http://127.0.0.1:18112/health")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}
+	waitFor := func(want bool) bool {
+		for attempt := 0; attempt < 100; attempt++ {
+			if responds() == want {
+				return true
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		return false
+	}
+
+	if !waitFor(true) {
+		t.Fatal("Expected the server of the dropped config file to start")
+	}
+	if _, ok := manager.RunningConfig("dropped"); !ok {
+		t.Error("Expected the dropped config to be stored by name")
+	}
+	// The config sets neither name nor logger_path, the server is named after the file
+	if info, ok := manager.Server(18112); !ok || info.Name != "dropped" {
+		t.Errorf("Expected the server to be named after the config file, got %+v", info)
+	}
+
+	if err := os.Remove(configFile); err != nil {
+		t.Fatalf("Failed to remove config file: %v", err)
+	}
+	if !waitFor(false) {
+		t.Fatal("Expected the server of the removed config file to stop")
+	}
+	for attempt := 0; attempt < 20; attempt++ {
+		_, stored := manager.RunningConfig("dropped")
+		_, exists := manager.Server(18112)
+		if !stored && !exists {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if _, ok := manager.RunningConfig("dropped"); ok {
+		t.Error("Expected the removed config to be forgotten")
+	}
+	if _, ok := manager.Server(18112); ok {
+		t.Error("Expected the removed server to be gone")
+	}
+}
+
+func TestStopServerUnknownPort(t *testing.T) {
+	manager := NewManager()
+	if err := manager.StopServer(18113); err == nil {
+		t.Error("Expected error stopping a server that does not exist")
+	}
+}
+
+func TestManagerConcurrentAccess(t *testing.T) {
+	manager := NewManager()
+	manager.SetDatabasePath(filepath.Join(t.TempDir(), "concurrent.db"))
+
+	logger := false
+	loggerPath := t.TempDir()
+	version := "1.0.0"
+	ports := []int{18126, 18127, 18128}
+
+	// Readers run while servers are created and removed, go test -race reports unguarded access
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				manager.Servers()
+				manager.Groups()
+				manager.CacheStats()
+				manager.RunningConfig("concurrent-18126")
+				manager.NotReadyPorts()
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	for _, port := range ports {
+		writers.Add(1)
+		go func(port int) {
+			defer writers.Done()
+			name := fmt.Sprintf("concurrent-%d", port)
+			server := models.Server{Listen: port, Logger: &logger, Name: &name, Version: &version, LoggerPath: &loggerPath}
+			if err := manager.CreateServers(&models.MockServer{Http: models.Http{Servers: []models.Server{server}}}); err != nil {
+				t.Errorf("Failed to create server on port %d: %v", port, err)
+				return
+			}
+			if err := manager.StopServer(port); err != nil {
+				t.Errorf("Failed to stop server on port %d: %v", port, err)
+			}
+		}(port)
+	}
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	if servers := manager.Servers(); len(servers) != 0 {
+		t.Errorf("Expected every server to be removed, got %d", len(servers))
+	}
+}
+
+func TestCreateServersSkipsRunningServer(t *testing.T) {
+	manager := NewManager()
+	manager.SetDatabasePath(filepath.Join(t.TempDir(), "skip.db"))
+
+	logger := false
+	loggerPath := t.TempDir()
+	name := "cloned"
+	version := "1.0.0"
+	server := models.Server{Listen: 18129, Logger: &logger, Name: &name, Version: &version, LoggerPath: &loggerPath}
+	if err := manager.CreateServer(server); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer manager.servers[18129].handler.BatchManager.Stop()
+
+	// The watcher hands over the config file the API persisted for the same server
+	persisted := &models.MockServer{Name: "cloned", Http: models.Http{Servers: []models.Server{server}}}
+	if err := manager.CreateServers(persisted); err != nil {
+		t.Fatalf("Expected an already running server to be skipped, got %v", err)
+	}
+	if len(manager.servers) != 1 {
+		t.Errorf("Expected 1 server, got %d", len(manager.servers))
+	}
+	if len(manager.configs) != 0 {
+		t.Errorf("Expected the config of a running server not to be stored again, got %d configs", len(manager.configs))
+	}
+
+	// Another server on the same port is still an error
+	other := "other"
+	server.Name = &other
+	if err := manager.CreateServers(&models.MockServer{Http: models.Http{Servers: []models.Server{server}}}); err == nil {
+		t.Error("Expected an error creating another server on a used port")
+	}
+}
+
+func TestConfiguredMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logDir := t.TempDir()
+	log, err := logger.GetLoggerContext(models.LogDescriptor{
+		Name:    "MIDDLEWARE",
+		Version: "0.0.1",
+		Path:    logDir,
+		File:    true,
+		Logger:  false,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	newRouter := func(t *testing.T, middleware ...models.Middleware) *gin.Engine {
+		t.Helper()
+		router := gin.New()
+		handlers, err := ConfiguredMiddleware(router, middleware, func() *scribe.Scribe { return log })
+		if err != nil {
+			t.Fatalf("ConfiguredMiddleware failed: %v", err)
+		}
+		router.Use(gin.Recovery())
+		router.Use(handlers...)
+		router.GET("/echo/*path", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"path":   c.Request.URL.Path,
+				"tenant": c.GetHeader("X-Tenant"),
+				"debug":  c.GetHeader("X-Debug"),
+				"calls":  c.GetHeader("X-Calls"),
+			})
+		})
+		return router
+	}
+	get := func(router *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("add_header", func(t *testing.T) {
+		router := newRouter(t, models.Middleware{Type: "add_header", Header: "X-Tenant", Value: "acme"})
+		w := get(router, "/echo/a", map[string]string{"X-Tenant": "other"})
+		if !strings.Contains(w.Body.String(), `"tenant":"acme"`) {
+			t.Errorf("Expected the added header to replace the request one, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("remove_header", func(t *testing.T) {
+		router := newRouter(t, models.Middleware{Type: "remove_header", Header: "X-Debug"})
+		w := get(router, "/echo/a", map[string]string{"X-Debug": "1", "X-Tenant": "acme"})
+		if !strings.Contains(w.Body.String(), `"debug":""`) || !strings.Contains(w.Body.String(), `"tenant":"acme"`) {
+			t.Errorf("Expected only X-Debug to be removed, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("rewrite_path", func(t *testing.T) {
+		// The rewritten path is routed again and later middleware sees it once
+		router := newRouter(t,
+			models.Middleware{Type: "rewrite_path", Pattern: `^/v1/(.*)$`, Replacement: "/echo/$1"},
+			models.Middleware{Type: "add_header", Header: "X-Tenant", Value: "acme"},
+		)
+		w := get(router, "/v1/users/42", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for the rewritten path, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"path":"/echo/users/42"`) || !strings.Contains(w.Body.String(), `"tenant":"acme"`) {
+			t.Errorf("Unexpected body: %s", w.Body.String())
+		}
+
+		// Paths the pattern doesn't match are left alone
+		if w := get(router, "/v2/users", nil); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an unmatched path, got %d", w.Code)
+		}
+	})
+
+	t.Run("rewrite_path matching its own output", func(t *testing.T) {
+		router := newRouter(t, models.Middleware{Type: "rewrite_path", Pattern: `^/echo/`, Replacement: "/echo/v2/"})
+		w := get(router, "/echo/users", nil)
+		if !strings.Contains(w.Body.String(), `"path":"/echo/v2/users"`) {
+			t.Errorf("Expected a single rewrite, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("log_only", func(t *testing.T) {
+		router := newRouter(t, models.Middleware{Type: "log_only", Message: "Adapter call"})
+		if w := get(router, "/echo/logged?x=1", nil); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var output strings.Builder
+		filepath.WalkDir(logDir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				data, _ := os.ReadFile(path)
+				output.Write(data)
+			}
+			return nil
+		})
+		for _, expected := range []string{`"Adapter call"`, `"path":"/echo/logged"`, `"query":"x=1"`} {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("Expected log output to contain %s, got %s", expected, output.String())
+			}
+		}
+	})
+
+	t.Run("rate_limit", func(t *testing.T) {
+		router := newRouter(t, models.Middleware{Type: "rate_limit", RequestsPerSecond: 1, Burst: 2})
+		for i := 0; i < 2; i++ {
+			if w := get(router, "/echo/a", nil); w.Code != http.StatusOK {
+				t.Fatalf("Expected request %d within the burst to pass, got %d", i, w.Code)
+			}
+		}
+		w := get(router, "/echo/a", nil)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status 429 above the burst, got %d", w.Code)
+		}
+		if w.Header().Get("Retry-After") != "1" {
+			t.Errorf("Expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, middleware := range []models.Middleware{
+			{Type: "compress"},
+			{Type: "add_header"},
+			{Type: "rewrite_path", Pattern: "("},
+			{Type: "rate_limit"},
+		} {
+			if _, err := ConfiguredMiddleware(gin.New(), []models.Middleware{middleware}, func() *scribe.Scribe { return log }); err == nil {
+				t.Errorf("Expected error for %+v", middleware)
+			}
+		}
+	})
+}
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(2, 0)
+	now := bucket.last
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := bucket.take(now); !ok {
+			t.Fatalf("Expected token %d of the default burst", i)
+		}
+	}
+	if ok, wait := bucket.take(now); ok || wait != 500*time.Millisecond {
+		t.Errorf("Expected to wait 500ms for the next token, got %v, %v", ok, wait)
+	}
+	if ok, _ := bucket.take(now.Add(500 * time.Millisecond)); !ok {
+		t.Error("Expected a token to be refilled after 500ms")
+	}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "MIDDLEWARE"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:     18114,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		Middleware: []models.Middleware{
+			{Type: "rewrite_path", Pattern: `^/legacy/`, Replacement: "/api/"},
+		},
+		Location: []models.Location{
+			{Path: "/api/test", Method: "GET", Response: `{"message":"rewritten"}`, StatusCode: 200},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18114]
+	defer server.handler.BatchManager.Stop()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/legacy/test", nil)
+	server.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "rewritten") {
+		t.Errorf("Expected the legacy path to reach /api/test, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// An invalid middleware fails the server creation
+	serverConfig.Listen = 18115
+	serverConfig.Middleware = []models.Middleware{{Type: "rewrite_path", Pattern: "("}}
+	if err := manager.CreateServer(serverConfig); err == nil || !strings.Contains(err.Error(), "invalid middleware") {
+		t.Errorf("Expected invalid middleware error, got %v", err)
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	manager := NewManager()
+	manager.SetBuildInfo("1.2.3", "2024-05-01T10:00:00Z")
+
+	own := "0.0.1"
+	tests := []struct {
+		name          string
+		configVersion string
+		serverVersion *string
+		expected      string
+	}{
+		{"build version", "", nil, "1.2.3"},
+		{"config version overrides build version", "2.0.0", nil, "2.0.0"},
+		{"server version wins", "2.0.0", &own, "0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.MockServer{Version: tt.configVersion}
+			server := manager.withVersion(config, models.Server{Version: tt.serverVersion})
+			if server.Version == nil || *server.Version != tt.expected {
+				t.Errorf("Expected version %s, got %v", tt.expected, server.Version)
+			}
+		})
+	}
+
+	// Servers created outside a config, e.g. clones, fall back to the build version
+	logger := false
+	name := "UNVERSIONED"
+	loggerPath := t.TempDir()
+	if err := manager.CreateServer(models.Server{Listen: 18116, Logger: &logger, Name: &name, LoggerPath: &loggerPath}); err != nil {
+		t.Fatalf("Failed to create server without version: %v", err)
+	}
+	manager.servers[18116].handler.BatchManager.Stop()
+}
+
+func TestMockOnlyIfHeader(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "CONDITIONAL"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:     18117,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		Location: []models.Location{
+			{Path: "/api/orders", Method: "GET", Response: `{"source":"mock"}`, StatusCode: 200, MockOnlyIfHeader: "X-Mock-Mode: true"},
+			{PathRegex: "^/api/orders$", Method: "GET", Response: `{"source":"regex"}`, StatusCode: 200},
+			{Path: "/api/users", Method: "GET", Response: `{"source":"mock"}`, StatusCode: 200, MockOnlyIfHeader: "X-Mock-Mode:true"},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18117]
+	defer server.handler.BatchManager.Stop()
+
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		status   int
+		contains string
+	}{
+		{"marked request is mocked", "/api/orders", "true", http.StatusOK, "mock"},
+		{"unmarked request falls through to path_regex", "/api/orders", "", http.StatusOK, "regex"},
+		{"other header value falls through", "/api/orders", "TRUE", http.StatusOK, "regex"},
+		{"marked request without spaces in the condition", "/api/users", "true", http.StatusOK, "mock"},
+		{"unmarked request without fallback", "/api/users", "", http.StatusNotFound, "404 page not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Mock-Mode", tt.header)
+			}
+			server.Router.ServeHTTP(w, req)
+
+			if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("Expected %d with %q, got %d: %s", tt.status, tt.contains, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	for _, condition := range []string{"X-Mock-Mode", ": true"} {
+		if _, err := parseHeaderCondition(condition); err == nil {
+			t.Errorf("Expected error parsing mock_only_if_header %q", condition)
+		}
+	}
+}
+
+func TestCustomMethod(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "VARNISH"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:     18118,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		Location: []models.Location{
+			{Path: "/cache", Method: "PURGE", Response: `{"purged":true}`, StatusCode: 200},
+		},
+	}
+
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18118]
+	defer server.handler.BatchManager.Stop()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PURGE", "/cache", nil)
+	server.Router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"purged":true}` {
+		t.Errorf("Expected 200 with the PURGE response, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Other methods on the same path are not routed to the location
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/cache", nil)
+	server.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for GET /cache, got %d", w.Code)
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "PROXIED"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	serverConfig := models.Server{
+		Listen:         18119,
+		Logger:         &logger,
+		Name:           &name,
+		Version:        &version,
+		LoggerPath:     &loggerPath,
+		TrustedProxies: []string{"10.0.0.0/8"},
+		Location: []models.Location{
+			{Path: "/api/orders", Method: "GET", Response: `{}`, StatusCode: 200},
+		},
+	}
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18119]
+	defer server.handler.BatchManager.Stop()
+
+	server.Router.GET("/client-ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		expected   string
+	}{
+		{"trusted proxy", "10.1.2.3:4000", "203.0.113.7"},
+		{"untrusted peer", "192.168.1.5:4000", "192.168.1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/client-ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			server.Router.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected client IP %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	// Without trusted_proxies no proxy is trusted
+	serverConfig.Listen = 18120
+	serverConfig.TrustedProxies = nil
+	if err := manager.CreateServer(serverConfig); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer manager.servers[18120].handler.BatchManager.Stop()
+	manager.servers[18120].Router.GET("/client-ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/client-ip", nil)
+	req.RemoteAddr = "10.1.2.3:4000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	manager.servers[18120].Router.ServeHTTP(w, req)
+	if w.Body.String() != "10.1.2.3" {
+		t.Errorf("Expected X-Forwarded-For to be ignored without trusted_proxies, got %s", w.Body.String())
+	}
+
+	serverConfig.Listen = 18121
+	serverConfig.TrustedProxies = []string{"not-an-ip"}
+	if err := manager.CreateServer(serverConfig); err == nil {
+		t.Error("Expected an error for an invalid trusted proxy")
+	}
+}
+
+func TestReadHeaderTimeout(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "SLOW_HEADERS"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	err := manager.CreateServer(models.Server{
+		Listen:                   18122,
+		Logger:                   &logger,
+		Name:                     &name,
+		Version:                  &version,
+		LoggerPath:               &loggerPath,
+		ReadHeaderTimeoutSeconds: 1,
+		IdleTimeoutSeconds:       30,
+		Location:                 []models.Location{{Path: "/health", Method: "GET", Response: `{"status":"ok"}`, StatusCode: 200}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer manager.Stop()
+
+	client := &http.Client{Timeout: time.Second}
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Get("http://127.0.0.1:18122/health")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if attempt == 20 {
+			t.Fatalf("Server not healthy after start: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	httpServer := manager.servers[18122].httpServer
+	if httpServer.ReadHeaderTimeout != time.Second || httpServer.IdleTimeout != 30*time.Second {
+		t.Errorf("Expected the configured timeouts, got header %v idle %v", httpServer.ReadHeaderTimeout, httpServer.IdleTimeout)
+	}
+
+	// A client that never finishes its headers is disconnected after the timeout
+	conn, err := net.Dial("tcp", "127.0.0.1:18122")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if _, err := conn.Write([]byte("GET /health HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatalf("Failed to write partial headers: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	for {
+		if _, err = conn.Read(buf); err != nil {
+			break
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatal("Expected the slow client to be disconnected before the 5 second deadline")
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Expected the connection to stay open until the header timeout, closed after %v", elapsed)
+	}
+
+	if timeouts := newHTTPTimeouts(models.Server{}); timeouts.readHeader != defaultReadHeaderTimeout {
+		t.Errorf("Expected the default header timeout %v, got %v", defaultReadHeaderTimeout, timeouts.readHeader)
+	}
+}
+
+func TestWildcardParam(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "WILDCARD"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	err := manager.CreateServer(models.Server{
+		Listen:     18123,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		Location: []models.Location{
+			{Path: "/api/*", WildcardParam: "path", Method: "GET", Response: `{"captured":"{{.Params.path}}"}`, StatusCode: 200},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18123]
+	defer server.handler.BatchManager.Stop()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/v1/users", `{"captured":"/v1/users"}`},
+		{"/api/v2/orders", `{"captured":"/v2/orders"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			server.Router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected body %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := NewManager()
+
+	schema := `{"type":"object","required":["amount"]}`
+	logger := false
+	name := "SCHEMAS"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	err := manager.CreateServer(models.Server{
+		Listen:     18124,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		Location: []models.Location{
+			{Path: "/payments/:id", Method: "POST", Schema: schema, Response: `{}`, StatusCode: 200},
+			{Path: "/health", Method: "GET", Response: `{}`, StatusCode: 200},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer manager.servers[18124].handler.BatchManager.Stop()
+
+	router := gin.New()
+	api.SetupRoutes(router, api.APIDependencies{ConfigDir: t.TempDir(), RestartChan: make(chan string, 1), Schemas: manager})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/schemas", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Data []api.SchemaSummary `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Data) != 1 || list.Data[0].Server != name || list.Data[0].Path != "/payments/:id" || list.Data[0].Method != "POST" || list.Data[0].SchemaHash == "" {
+		t.Fatalf("Expected the registered schema in the list, got %+v", list.Data)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/mock/schemas?path=/payments/:id&method=POST", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var detail struct {
+		Data api.SchemaDetail `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if string(detail.Data.Schema) != schema || detail.Data.SchemaHash != list.Data[0].SchemaHash {
+		t.Errorf("Expected the full schema, got %+v", detail.Data)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	manager := NewManager()
+
+	logger := false
+	name := "SECURE"
+	version := "0.0.1"
+	loggerPath := t.TempDir()
+	err := manager.CreateServer(models.Server{
+		Listen:     18125,
+		Logger:     &logger,
+		Name:       &name,
+		Version:    &version,
+		LoggerPath: &loggerPath,
+		SecurityHeaders: models.SecurityHeaders{
+			"content_security_policy":   "default-src 'self'",
+			"x_frame_options":           "DENY",
+			"strict_transport_security": "max-age=31536000",
+			"referrer_policy":           "no-referrer",
+		},
+		Location: []models.Location{{Path: "/page", Method: "GET", Response: `<html></html>`, StatusCode: 200}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := manager.servers[18125]
+	defer server.handler.BatchManager.Stop()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	server.Router.ServeHTTP(w, req)
+
+	expected := map[string]string{
+		"Content-Security-Policy":   "default-src 'self'",
+		"X-Frame-Options":           "DENY",
+		"X-Content-Type-Options":    "nosniff",
+		"Strict-Transport-Security": "max-age=31536000",
+		"Referrer-Policy":           "no-referrer",
+	}
+	for header, value := range expected {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("Expected %s: %s, got %q", header, value, got)
+		}
+	}
+}
+
+func TestSecurityHeadersDisableDefault(t *testing.T) {
+	handler, err := SecurityHeadersMiddleware(models.SecurityHeaders{"x_content_type_options": ""})
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(handler)
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if _, ok := w.Header()["X-Content-Type-Options"]; ok {
+		t.Errorf("Expected the default header to be disabled, got %v", w.Header())
+	}
+
+	if _, err := SecurityHeadersMiddleware(models.SecurityHeaders{"x_powered_by": "mock"}); err == nil {
+		t.Error("Expected an error for an unknown security header")
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+
+	"catalyst/internal/models"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// tlsSettings holds the TLS and HTTP/2 options of a mock server
+type tlsSettings struct {
+	certFile string
+	keyFile  string
+	config   *tls.Config // Set for tls_auto with a generated certificate
+	http2    bool
+	h2c      bool
+}
+
+// enabled reports whether the server is served over TLS
+func (t tlsSettings) enabled() bool {
+	return t.config != nil || (t.certFile != "" && t.keyFile != "")
+}
+
+// newTLSSettings builds the TLS settings of a server, generating a self-signed certificate for tls_auto
+func newTLSSettings(config models.Server) (tlsSettings, error) {
+	settings := tlsSettings{
+		certFile: config.TLSCertFile,
+		keyFile:  config.TLSKeyFile,
+		http2:    config.HTTP2,
+		h2c:      config.H2C,
+	}
+
+	if config.TLSAuto && settings.certFile == "" {
+		cert, err := selfSignedCertificate()
+		if err != nil {
+			return settings, fmt.Errorf("error generating self-signed certificate: %w", err)
+		}
+		settings.config = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	if settings.http2 && !settings.enabled() {
+		return settings, fmt.Errorf("http2 requires tls_cert_file and tls_key_file or tls_auto")
+	}
+	if settings.h2c && settings.enabled() {
+		return settings, fmt.Errorf("h2c is cleartext HTTP/2 and cannot be combined with TLS")
+	}
+
+	return settings, nil
+}
+
+// configureHTTPServer wraps the handler for h2c and sets up TLS and HTTP/2 on the server
+func (t tlsSettings) configureHTTPServer(server *http.Server) error {
+	if t.h2c {
+		server.Handler = h2c.NewHandler(server.Handler, &http2.Server{})
+		return nil
+	}
+	if !t.enabled() {
+		return nil
+	}
+
+	if t.config != nil {
+		server.TLSConfig = t.config.Clone()
+	}
+	if !t.http2 {
+		// A non-nil empty map keeps net/http from negotiating HTTP/2 over TLS
+		server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		return nil
+	}
+	return http2.ConfigureServer(server, &http2.Server{})
+}
+
+// listenAndServe starts the server over TLS when configured, otherwise in cleartext
+func (t tlsSettings) listenAndServe(server *http.Server) error {
+	if !t.enabled() {
+		return server.ListenAndServe()
+	}
+	if t.config != nil {
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServeTLS(t.certFile, t.keyFile)
+}
+
+// selfSignedCertificate generates a certificate for localhost valid for one year
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"Mockingbird"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -18,25 +18,58 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// version and buildTime are set at build time, e.g.
+// go build -ldflags "-X main.version=1.2.3 -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	buildTime = ""
+)
+
 func main() {
 	// Parse command line flags
 	configDir := flag.String("config", "", "Directory containing YAML configuration files")
 	configFile := flag.String("file", "", "Path to a specific YAML configuration file")
+	configURL := flag.String("config-url", "", "http(s) or file:// URL of a YAML configuration file, used when -file is not set")
+	configURLTimeout := flag.Duration("config-url-timeout", 10*time.Second, "Timeout fetching the -config-url configuration")
+	configGlob := flag.String("glob", "", "Glob pattern matching YAML configuration files (e.g. ./configs/*/server.yaml)")
+	mergeConfigs := flag.String("merge-configs", "", "Colon-separated YAML files merged in order, later files overriding earlier ones (e.g. base.yaml:prod.yaml)")
 	flag.Parse()
 
 	// Determine configuration source
 	var (
 		configs []*models.MockServer
 		err     error
+		// watchDir is the config directory watched for new files, only set when loading a directory
+		watchDir string
 	)
 
-	if *configFile != "" {
+	if *mergeConfigs != "" {
+		// Merge a base configuration with its environment overrides
+		cfg, err := config.MergeConfigFiles(config.ParseMergeList(*mergeConfigs))
+		if err != nil {
+			log.Fatalf("Error merging configuration files: %v", err)
+		}
+		configs = []*models.MockServer{cfg}
+	} else if *configFile != "" {
 		// Load a specific configuration file
 		cfg, err := config.LoadConfig(*configFile)
 		if err != nil {
 			log.Fatalf("Error loading configuration file: %v", err)
 		}
 		configs = []*models.MockServer{cfg}
+	} else if *configURL != "" {
+		// Load the configuration from a central config service
+		cfg, err := config.LoadConfigFromURL(*configURL, *configURLTimeout)
+		if err != nil {
+			log.Fatalf("Error loading configuration from URL: %v", err)
+		}
+		configs = []*models.MockServer{cfg}
+	} else if *configGlob != "" {
+		// Load all configuration files matching the glob pattern
+		configs, err = config.LoadConfigFromGlob(*configGlob)
+		if err != nil {
+			log.Fatalf("Error loading configuration files: %v", err)
+		}
 	} else {
 		// Load all configuration files from directory
 		dir := *configDir
@@ -48,12 +81,29 @@ func main() {
 		if err != nil {
 			log.Fatalf("Error loading configuration files: %v", err)
 		}
+		watchDir = dir
+	}
+
+	// Lint warnings never block startup
+	for _, cfg := range configs {
+		for _, warning := range config.LintConfig(cfg) {
+			log.Printf("WARNING: config lint %s", warning)
+		}
 	}
+
 	prom.InitMetrics()
 
 	// Create server manager
 	manager := server.NewManager()
+	manager.SetBuildInfo(version, buildTime)
+	dbConfig := config.GetDatabaseSettings(configs)
+	manager.SetDatabaseConfig(dbConfig)
 	postgresManager := postgres_server.NewPostgresManager()
+	manager.SetPostgresConnections(postgresManager)
+
+	// The servers and the API share the body search index of the transactions database
+	searchIndex := database.NewBodySearchIndex()
+	manager.SetSearchIndex(searchIndex)
 
 	configDirPath := *configDir
 	if configDirPath == "" {
@@ -70,11 +120,6 @@ func main() {
 	}
 
 	// Create batch manager for API server
-	db, err := database.InitDB("./database.db")
-	if err != nil {
-		log.Fatalf("Error initializing database for API: %v", err)
-	}
-
 	batchConfig := database.BatchConfig{
 		BatchSize:     20,
 		FlushInterval: 2 * time.Second,
@@ -84,19 +129,33 @@ func main() {
 		Timeout:       30 * time.Second,
 		RetryAttempts: 3,
 	}
-	batchManager := database.NewBatchManager(db, batchConfig)
+	batchManager, err := database.OpenBatchManagerFromConfig("./database.db", batchConfig, dbConfig)
+	if err != nil {
+		log.Fatalf("Error initializing database for API: %v", err)
+	}
 
 	// Start batch manager
 	if err := batchManager.Start(); err != nil {
 		log.Fatalf("Error starting batch manager for API: %v", err)
 	}
 
-	if err := manager.CreateAPIServer(batchManager, configDirPath); err != nil {
+	// Index the bodies already stored so body search covers previous runs
+	batchManager.SetSearchIndex(searchIndex)
+	if err := batchManager.LoadSearchIndex(); err != nil {
+		log.Printf("WARNING: Error loading body search index: %v", err)
+	}
+
+	if err := manager.CreateAPIServer(batchManager, configDirPath, config.GetAPISettings(configs)); err != nil {
 		log.Fatalf("Error creating API server: %v", err)
 	}
 
-	// Create metrics server on port 9090 (default Prometheus port)
-	if err := manager.CreateMetricsServer(4894); err != nil {
+	// Create metrics server on port 4894, optionally protected by a bearer token
+	metricsConfig := server.MetricsConfig{
+		Port:       4894,
+		AuthToken:  os.Getenv("METRICS_AUTH_TOKEN"),
+		ListenAddr: os.Getenv("METRICS_LISTEN_ADDR"),
+	}
+	if err := manager.CreateMetricsServerWithConfig(metricsConfig); err != nil {
 		log.Fatalf("Error creating metrics server: %v", err)
 	}
 
@@ -112,6 +171,25 @@ func main() {
 		log.Fatalf("Error starting metrics server: %v", err)
 	}
 
+	// Config files dropped into the directory start their servers, removed ones stop them
+	var watcher *config.DirWatcher
+	if watchDir != "" {
+		watcher, err = config.WatchDir(watchDir, func(cfg *models.MockServer) {
+			if err := manager.CreateServers(cfg); err != nil {
+				log.Printf("ERROR: Error creating http servers of config %s: %v", cfg.Name, err)
+			}
+		}, func(cfg *models.MockServer) {
+			for _, serverConfig := range cfg.Http.Servers {
+				if err := manager.StopServer(serverConfig.Listen); err != nil {
+					log.Printf("ERROR: Error stopping server of removed config %s: %v", cfg.Name, err)
+				}
+			}
+		})
+		if err != nil {
+			log.Printf("WARNING: Config directory not watched for new files: %v", err)
+		}
+	}
+
 	log.Println("All HTTP servers started successfully")
 	log.Println("API server started on port 8282")
 	log.Println("Metrics server started on port 4894")
@@ -127,6 +205,9 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down servers...")
+	if watcher != nil {
+		watcher.Close()
+	}
 	manager.Stop()
 	//postgresManager.Stop()
 	log.Println("Servers stopped")
@@ -0,0 +1,71 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestLabels are the labels every custom label counter has, before the custom ones
+var requestLabels = []string{"path", "method", "status_code"}
+
+// labelNamePattern is the Prometheus label name syntax
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// customLabelCounters holds the registered custom label counters by metric name, so locations
+// with the same labels share a counter and restarts don't register it twice
+var customLabelCounters sync.Map
+
+// ValidateLabelName checks a custom label name: Prometheus syntax, not reserved (__ prefix) and
+// not one of the labels every request counter already has
+func ValidateLabelName(name string) error {
+	if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+		return fmt.Errorf("invalid prometheus label name %q", name)
+	}
+	for _, label := range requestLabels {
+		if name == label {
+			return fmt.Errorf("prometheus label %q is already set on every request", name)
+		}
+	}
+	return nil
+}
+
+// CustomLabelCounter returns the request counter with the given custom labels, registering it the
+// first time. Its labels are path, method, status_code and the custom labels.
+func CustomLabelCounter(labels []string) (*prometheus.CounterVec, error) {
+	sorted := append([]string(nil), labels...)
+	sort.Strings(sorted)
+	for _, label := range sorted {
+		if err := ValidateLabelName(label); err != nil {
+			return nil, err
+		}
+	}
+
+	name := ":handler_request_by_" + strings.Join(sorted, "_") + "_total"
+	if counter, ok := customLabelCounters.Load(name); ok {
+		return counter.(*prometheus.CounterVec), nil
+	}
+
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: name,
+			Help: "Total requests by the prometheus_labels of the location: " + strings.Join(sorted, ", "),
+		},
+		append(append([]string(nil), requestLabels...), sorted...),
+	)
+	if err := prometheus.Register(counter); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			return nil, fmt.Errorf("error registering %s: %w", name, err)
+		}
+		counter = registered.ExistingCollector.(*prometheus.CounterVec)
+	}
+
+	actual, _ := customLabelCounters.LoadOrStore(name, counter)
+	return actual.(*prometheus.CounterVec), nil
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// queueDepthBuckets are the histogram buckets for the database queue depths
+var queueDepthBuckets = []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000}
+
 var (
 	HandlerResquestTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -40,6 +43,151 @@ var (
 		[]string{"path", "method", "async_url"},
 	)
 
+	HandlerDedupHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "handler_dedup_hits_total",
+			Help: "Total requests served from the deduplication cache",
+		},
+		[]string{"path", "method"},
+	)
+	HandlerResponseTooLargeTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "handler_response_too_large_total",
+			Help: "Total responses rejected for exceeding max_response_body_bytes",
+		},
+		[]string{"path", "method"},
+	)
+
+	HandlerResponseBytesSavedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "handler_response_bytes_saved_total",
+			Help: "Total bytes removed from JSON responses by minify_response",
+		},
+		[]string{"path", "method"},
+	)
+
+	HandlerHeaderTooLargeTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "handler_header_too_large_total",
+			Help: "Total requests rejected for exceeding max_request_header_bytes",
+		},
+		[]string{"path", "method"},
+	)
+
+	HandlerTemplateErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "handler_template_errors_total",
+			Help: "Total response template rendering errors",
+		},
+		[]string{"path", "method"},
+	)
+	HandlerTemplateDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "handler_template_duration_seconds",
+			Help:    "Duration of response template rendering in seconds.",
+			Buckets: []float64{0.00001, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.05, 0.1},
+		},
+		[]string{"path", "method"},
+	)
+
+	DatabaseHealthStatus = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "database_health_status",
+			Help: "Database health (1=healthy, 0=unhealthy)",
+		},
+	)
+
+	DatabaseInputQueueDepth = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "database_input_queue_depth",
+			Help:    "Depth of the database input queue sampled every flush interval",
+			Buckets: queueDepthBuckets,
+		},
+	)
+	DatabaseBatchQueueDepth = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "database_batch_queue_depth",
+			Help:    "Depth of the database batch queue sampled every flush interval",
+			Buckets: queueDepthBuckets,
+		},
+	)
+
+	DatabaseInputQueueUtilization = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "database_input_queue_utilization",
+			Help: "Ratio of the database input queue in use to its maximum size",
+		},
+	)
+	DatabaseCurrentBatchSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "database_current_batch_size",
+			Help: "Operations in the batch being aggregated",
+		},
+	)
+	DatabaseAdaptiveBatchSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "database_adaptive_batch_size",
+			Help: "Effective batch size, reduced while the input queue is more than 80% full",
+		},
+	)
+	DatabaseTotalProcessed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "database_total_processed",
+			Help: "Total operations persisted by the batch workers",
+		},
+	)
+	DatabaseTotalBatches = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "database_total_batches",
+			Help: "Total batches persisted",
+		},
+	)
+
+	DatabaseInsertDuration = prometheus.NewSummary(
+		prometheus.SummaryOpts{
+			Name:       "database_insert_duration_seconds",
+			Help:       "Duration of successful batch insert transactions, from begin to commit",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+	)
+	DatabaseBatchSizeRecords = prometheus.NewSummary(
+		prometheus.SummaryOpts{
+			Name:       "database_batch_size_records",
+			Help:       "Operations in each batch inserted into the database",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+	)
+
+	DatabaseSearchIndexSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "database_search_index_size",
+			Help: "Number of distinct words in the transaction body search index",
+		},
+	)
+
+	PostgresCrashRestartsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "postgres_crash_restarts_total",
+			Help: "Total automatic restarts of crashed Postgres containers",
+		},
+		[]string{"server_name"},
+	)
+
+	ServerRestartTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "server_restart_total",
+			Help: "Total server restarts processed by the restart manager, by result (success, failed or timeout)",
+		},
+		[]string{"server_name", "result"},
+	)
+	ServerRestartDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "server_restart_duration_seconds",
+			Help:    "Duration of server restarts, from the dequeued signal to the last attempt, retries included",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+	)
+
 	HandlerActiveRequests = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "handler_active_requests",
@@ -56,6 +204,26 @@ func InitMetrics() {
 		HandlerErrorsTotal,
 		HandlerAsyncCallsTotal,
 		HandlerActiveRequests,
+		HandlerDedupHitsTotal,
+		HandlerResponseTooLargeTotal,
+		HandlerResponseBytesSavedTotal,
+		HandlerHeaderTooLargeTotal,
+		HandlerTemplateErrorsTotal,
+		HandlerTemplateDuration,
+		DatabaseHealthStatus,
+		DatabaseInputQueueDepth,
+		DatabaseBatchQueueDepth,
+		DatabaseInputQueueUtilization,
+		DatabaseCurrentBatchSize,
+		DatabaseAdaptiveBatchSize,
+		DatabaseTotalProcessed,
+		DatabaseTotalBatches,
+		DatabaseInsertDuration,
+		DatabaseBatchSizeRecords,
+		DatabaseSearchIndexSize,
+		PostgresCrashRestartsTotal,
+		ServerRestartTotal,
+		ServerRestartDuration,
 	)
 }
 
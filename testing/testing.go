@@ -0,0 +1,121 @@
+// Package testing starts mock servers in-process for the tests of applications that embed
+// Mockingbird as a library, in the style of httptest.NewServer:
+//
+//	mock := testing.NewTestMockServer(t, []testing.Location{
+//		{Path: "/api/users", Method: "GET", Response: `[{"id":1}]`, StatusCode: 200},
+//	})
+//	resp, err := http.Get(mock.BaseURL() + "/api/users")
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	stdtesting "testing"
+	"time"
+
+	"catalyst/database"
+	"catalyst/internal/models"
+	"catalyst/internal/server"
+)
+
+// Location is a mock endpoint, configured as the location entries of a YAML config
+type Location = models.Location
+
+// startTimeout bounds how long NewTestMockServer waits for the server to accept connections
+const startTimeout = 5 * time.Second
+
+// flushTimeout bounds how long RecordedTransactions waits for the queued transactions to be persisted
+const flushTimeout = 5 * time.Second
+
+// TestMockServer is a mock server listening on a random local port, stopped when the test ends
+type TestMockServer struct {
+	t       *stdtesting.T
+	manager *server.Manager
+	port    int
+	dbPath  string
+}
+
+// NewTestMockServer starts a mock server serving the locations. Its transactions are stored in a
+// database of its own under t.TempDir(), and the server is stopped by t.Cleanup.
+func NewTestMockServer(t *stdtesting.T, locations []Location) *TestMockServer {
+	t.Helper()
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("testing: no free port for the mock server: %v", err)
+	}
+
+	dir := t.TempDir()
+	name := fmt.Sprintf("test-mock-%d", port)
+	logger := false
+
+	manager := server.NewManager()
+	dbPath := filepath.Join(dir, "database.db")
+	manager.SetDatabasePath(dbPath)
+
+	err = manager.CreateServer(models.Server{
+		Listen:     port,
+		Name:       &name,
+		Logger:     &logger,
+		LoggerPath: &dir,
+		Location:   locations,
+	})
+	if err != nil {
+		t.Fatalf("testing: error creating mock server: %v", err)
+	}
+	if err := manager.Start(); err != nil {
+		t.Fatalf("testing: error starting mock server: %v", err)
+	}
+	t.Cleanup(manager.Stop)
+
+	deadline := time.Now().Add(startTimeout)
+	for !manager.Started() {
+		if time.Now().After(deadline) {
+			t.Fatalf("testing: mock server on port %d not accepting connections after %v", port, startTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return &TestMockServer{t: t, manager: manager, port: port, dbPath: dbPath}
+}
+
+// BaseURL returns the URL of the server, e.g. http://127.0.0.1:54321, without a trailing slash
+func (s *TestMockServer) BaseURL() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", s.port)
+}
+
+// RecordedTransactions returns the transactions recorded so far, oldest first, including the
+// async calls made by the locations
+func (s *TestMockServer) RecordedTransactions() []database.Mockdata {
+	s.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := s.manager.FlushTransactions(ctx); err != nil {
+		s.t.Fatalf("testing: error flushing recorded transactions: %v", err)
+	}
+
+	db, err := database.InitDB(s.dbPath)
+	if err != nil {
+		s.t.Fatalf("testing: error opening recorded transactions: %v", err)
+	}
+	defer db.Close()
+
+	transactions, err := database.ListOperations(db)
+	if err != nil {
+		s.t.Fatalf("testing: error reading recorded transactions: %v", err)
+	}
+	return transactions
+}
+
+// freePort asks the kernel for a free local port
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
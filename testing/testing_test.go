@@ -0,0 +1,77 @@
+package testing_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	mocktest "catalyst/testing"
+)
+
+func TestNewTestMockServer(t *testing.T) {
+	mock := mocktest.NewTestMockServer(t, []mocktest.Location{
+		{Path: "/api/users", Method: "GET", Response: `[{"id":1}]`, StatusCode: 200},
+	})
+
+	resp, err := http.Get(mock.BaseURL() + "/api/users")
+	if err != nil {
+		t.Fatalf("Request to the mock server failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `[{"id":1}]` {
+		t.Errorf("Expected 200 with the configured response, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestRecordedTransactions(t *testing.T) {
+	mock := mocktest.NewTestMockServer(t, []mocktest.Location{
+		{Path: "/api/orders", Method: "POST", Response: `{"status":"created"}`, StatusCode: 201},
+	})
+
+	for _, sku := range []string{"A1", "B2"} {
+		resp, err := http.Post(mock.BaseURL()+"/api/orders", "application/json", strings.NewReader(`{"sku":"`+sku+`"}`))
+		if err != nil {
+			t.Fatalf("Request to the mock server failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	transactions := mock.RecordedTransactions()
+	if len(transactions) != 2 {
+		t.Fatalf("Expected 2 recorded transactions, got %d", len(transactions))
+	}
+	for i, sku := range []string{"A1", "B2"} {
+		transaction := transactions[i]
+		if transaction.RequestMethod != "POST" || transaction.RequestEndpoint != "/api/orders" || transaction.ResponseStatusCode != 201 {
+			t.Errorf("Unexpected transaction %d: %+v", i, transaction)
+		}
+		if !strings.Contains(transaction.RequestBody, sku) {
+			t.Errorf("Expected transaction %d to record the %s order, got %s", i, sku, transaction.RequestBody)
+		}
+	}
+}
+
+func TestTestMockServersAreIsolated(t *testing.T) {
+	first := mocktest.NewTestMockServer(t, []mocktest.Location{{Path: "/health", Method: "GET", Response: `{}`, StatusCode: 200}})
+	second := mocktest.NewTestMockServer(t, []mocktest.Location{{Path: "/health", Method: "GET", Response: `{}`, StatusCode: 200}})
+
+	if first.BaseURL() == second.BaseURL() {
+		t.Fatalf("Expected each server on its own port, both on %s", first.BaseURL())
+	}
+
+	resp, err := http.Get(first.BaseURL() + "/health")
+	if err != nil {
+		t.Fatalf("Request to the mock server failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := len(second.RecordedTransactions()); got != 0 {
+		t.Errorf("Expected the second server to record nothing, got %d transactions", got)
+	}
+	if got := len(first.RecordedTransactions()); got != 1 {
+		t.Errorf("Expected the first server to record 1 transaction, got %d", got)
+	}
+}